@@ -9,8 +9,13 @@ import (
 // Set by goreleaser ldflags
 var version = "dev"
 
+// Set by package builds (e.g. -X main.installedBy=homebrew) so 'update'
+// defers to the package manager
+var installedBy = ""
+
 func main() {
 	cmd.SetVersion(version)
+	cmd.SetInstalledBy(installedBy)
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
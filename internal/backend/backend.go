@@ -0,0 +1,234 @@
+// Package backend moves the staged sync repo (~/.claude-sync/repo) to and from
+// remote storage. The engine writes encrypted and plain files into the staging
+// directory; a Backend is only responsible for transport and revisions.
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+)
+
+// Backend names accepted in config.yaml
+const (
+	NameGit      = "git"
+	NameS3       = "s3"
+	NameLocalDir = "localdir"
+)
+
+// Backend publishes and retrieves revisions of the staging directory
+type Backend interface {
+	// Name identifies the backend ("git", "s3", "localdir")
+	Name() string
+	// HasRemote reports whether remote storage is configured
+	HasRemote() bool
+	// Commit records the staging directory as a new local revision.
+	// It returns false when nothing changed since the previous revision.
+	Commit(message string) (bool, error)
+	// Push uploads the latest local revision to remote storage
+	Push() error
+	// Pull downloads the latest remote revision into the staging directory
+	Pull() error
+	// Revisions refreshes remote state and returns the local and remote revision IDs
+	Revisions() (local, remote string, err error)
+	// LastUpdated returns when the local revision was created
+	LastUpdated() (time.Time, error)
+}
+
+// Rebaser is a backend that can replay the local revision on top of remote
+// revisions it hasn't seen, after Push returns git.ErrRejected
+type Rebaser interface {
+	Rebase() error
+}
+
+// Open returns the backend selected in cfg for the given paths
+func Open(cfg *config.Config, paths config.Paths) (Backend, error) {
+	switch cfg.Backend {
+	case "", NameGit:
+		g := &Git{repo: gitpkg.Open(paths.RepoDir, cfg.GitBackend)}
+		if cfg.GitBranches == config.BranchesPerMachine {
+			g.branch = MachineBranch(config.Hostname())
+		}
+		return g, nil
+	case NameS3:
+		store, err := newS3Store(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return newBlobBackend(NameS3, store, paths.RepoDir, statePath(paths)), nil
+	case NameLocalDir:
+		store, err := newDirStore(cfg.LocalDir)
+		if err != nil {
+			return nil, err
+		}
+		return newBlobBackend(NameLocalDir, store, paths.RepoDir, statePath(paths)), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+}
+
+// GitRepo returns the underlying git repo when b is the git backend
+func GitRepo(b Backend) (gitpkg.Repo, bool) {
+	g, ok := b.(*Git)
+	if !ok {
+		return nil, false
+	}
+	return g.repo, true
+}
+
+// statePath is where non-git backends remember what they last synced
+func statePath(paths config.Paths) string {
+	return filepath.Join(paths.SyncDir, "backend-state.json")
+}
+
+// machineBranchPrefix holds one branch per machine in per-machine mode
+const machineBranchPrefix = "sync/"
+
+var unsafeRefChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// reviewBranchPrefix holds one branch per machine for push --pr
+const reviewBranchPrefix = "review/"
+
+// MachineBranch returns the branch a machine pushes to in per-machine mode
+func MachineBranch(hostname string) string {
+	return machineBranchPrefix + refName(hostname)
+}
+
+// ReviewBranch returns the branch a machine pushes to for review
+func ReviewBranch(hostname string) string {
+	return reviewBranchPrefix + refName(hostname)
+}
+
+// refName makes a hostname safe to use in a branch name
+func refName(hostname string) string {
+	name := strings.Trim(unsafeRefChars.ReplaceAllString(hostname, "-"), ".-")
+	if name == "" {
+		name = "unknown"
+	}
+	return name
+}
+
+// Git stores revisions as commits in a git repository
+type Git struct {
+	repo   gitpkg.Repo
+	branch string // Per-machine branch to push to, "" to push the current branch
+}
+
+// Name identifies the backend
+func (g *Git) Name() string {
+	return NameGit
+}
+
+// HasRemote checks if origin remote exists
+func (g *Git) HasRemote() bool {
+	return g.repo.HasRemote()
+}
+
+// Commit stages everything and commits if there are changes
+func (g *Git) Commit(message string) (bool, error) {
+	if err := g.repo.AddAll(); err != nil {
+		return false, fmt.Errorf("git add failed: %w", err)
+	}
+
+	hasChanges, err := g.repo.HasChanges()
+	if err != nil {
+		return false, err
+	}
+	if !hasChanges {
+		return false, nil
+	}
+
+	if err := g.repo.Commit(message); err != nil {
+		return false, fmt.Errorf("git commit failed: %w", err)
+	}
+	return true, nil
+}
+
+// Push pushes to origin. In per-machine mode the machine's own branch is
+// pushed, then the shared branch is fast-forwarded if nobody else moved it;
+// if they did, the next pull merges their branch and a later push catches up.
+func (g *Git) Push() error {
+	if g.branch != "" {
+		if err := g.repo.PushBranch(g.branch); err != nil {
+			return fmt.Errorf("git push failed: %w", err)
+		}
+		g.repo.Push()
+		return nil
+	}
+	if err := g.repo.Push(); err != nil {
+		return fmt.Errorf("git push failed: %w", err)
+	}
+	return nil
+}
+
+// Pull pulls from origin, putting sync commits that were never pushed on
+// top. In per-machine mode every machine branch is merged in, a file both
+// changed taking the version of the branch merged last. If another
+// machine squashed the history with gc, the branch is reset to the remote
+// instead of merging the old history back in, unless that would drop sync
+// commits that were never pushed: those are left for push, which puts them
+// on top of the new history.
+func (g *Git) Pull() error {
+	if g.branch != "" {
+		_, err := g.repo.MergeBranches(machineBranchPrefix, true)
+		return err
+	}
+	// Counted before the fetch moves origin to the new history
+	unpushed, _, _ := g.repo.AheadBehind()
+	g.repo.Fetch()
+	if unpushed > 0 {
+		if rewritten, err := g.repo.Rewritten(); err != nil {
+			return err
+		} else if rewritten {
+			return fmt.Errorf("another machine squashed the repo history with gc, and %d sync commit(s) here were never pushed; run 'claude-code-sync push' to put them on top of the new history, then pull", unpushed)
+		}
+	}
+	if reset, err := g.repo.FollowRewrite(false); err != nil || reset {
+		return err
+	}
+	if unpushed > 0 {
+		// Where both changed a file the remote's version goes in the repo;
+		// this machine's is still in ~/.claude, and the pull strategy
+		// decides which of the two is kept there
+		if _, behind, err := g.repo.AheadBehind(); err == nil && behind > 0 {
+			return g.repo.Rebase(true)
+		}
+	}
+	return g.repo.Pull()
+}
+
+// Rebase puts the local sync commits on top of the remote branch. The
+// manifest is regenerated afterwards, so only conflicts in synced files stop
+// it, with a *git.ConflictError. In per-machine mode the machine branch is
+// merged instead. After a gc on another machine the branch moves to the
+// squashed history and the files are left staged for the caller to commit
+// again.
+func (g *Git) Rebase() error {
+	if g.branch != "" {
+		_, err := g.repo.MergeBranches(machineBranchPrefix, false, ".sync-manifest")
+		return err
+	}
+	g.repo.Fetch()
+	if reset, err := g.repo.FollowRewrite(true); err != nil || reset {
+		return err
+	}
+	return g.repo.Rebase(false, ".sync-manifest")
+}
+
+// Revisions fetches and returns the local and origin/HEAD commit hashes
+func (g *Git) Revisions() (string, string, error) {
+	g.repo.Fetch()
+	local, _ := g.repo.GetLocalCommit()
+	remote, _ := g.repo.GetRemoteCommit()
+	return local, remote, nil
+}
+
+// LastUpdated returns the time of the last commit
+func (g *Git) LastUpdated() (time.Time, error) {
+	return g.repo.LastCommitTime()
+}
@@ -0,0 +1,406 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// indexKey is the object holding the remote index. It is written last on push,
+// so a reader never sees an index that references files not yet uploaded.
+const indexKey = ".sync-index"
+
+// objectsLayout marks an index whose files are stored by content under
+// objectsPrefix. Two machines pushing at once then never overwrite each
+// other's files, and whichever index is written last refers only to
+// objects that exist. Older indexes stored each file under its own path.
+const (
+	objectsLayout = "objects"
+	objectsPrefix = "objects/"
+)
+
+// errNotFound is returned by a blobStore when a key does not exist
+var errNotFound = errors.New("not found")
+
+// blobStore is a flat key/value store addressed by slash-separated paths
+type blobStore interface {
+	Get(key string) ([]byte, error)
+	Put(key string, data []byte) error
+	Delete(key string) error
+}
+
+// blobIndex describes one revision: every file and its SHA256
+type blobIndex struct {
+	Revision string            `json:"revision"`
+	Message  string            `json:"message,omitempty"`
+	Updated  time.Time         `json:"updated"`
+	Files    map[string]string `json:"files"`
+	Layout   string            `json:"layout,omitempty"`
+
+	// Superseded lists the objects the previous revision used and this one
+	// doesn't. The next push deletes them: by then nobody can still be
+	// pushing on top of the previous revision.
+	Superseded []string `json:"superseded,omitempty"`
+}
+
+// key returns where the contents of path are stored
+func (i blobIndex) key(path string) string {
+	if i.Layout == objectsLayout {
+		return objectsPrefix + i.Files[path]
+	}
+	return path
+}
+
+// validate rejects an index naming files outside the staging directory or
+// objects that aren't SHA256 checksums. Whoever can write to the store
+// could otherwise make a pull write or delete any file.
+func (i blobIndex) validate() error {
+	for path, sum := range i.Files {
+		if !safePath(path) {
+			return fmt.Errorf("remote index has an invalid path %q", path)
+		}
+		if !validSum(sum) {
+			return fmt.Errorf("remote index has an invalid checksum for %s", path)
+		}
+	}
+	for _, sum := range i.Superseded {
+		if !validSum(sum) {
+			return fmt.Errorf("remote index has an invalid object %q", sum)
+		}
+	}
+	return nil
+}
+
+// safePath reports whether a slash-separated path from the remote index
+// stays inside the directory it is joined to
+func safePath(path string) bool {
+	return path != "" && !filepath.IsAbs(path) &&
+		!slices.Contains(strings.Split(path, "/"), "..") && filepath.IsLocal(filepath.FromSlash(path))
+}
+
+// validSum reports whether sum is a hex SHA256, as sync.Checksum returns
+func validSum(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil && strings.ToLower(sum) == sum
+}
+
+// objects returns the checksums of the objects the index refers to
+func (i blobIndex) objects() map[string]bool {
+	objects := map[string]bool{}
+	if i.Layout == objectsLayout {
+		for _, sum := range i.Files {
+			objects[sum] = true
+		}
+	}
+	return objects
+}
+
+// blobState is the local view: the committed revision and the remote
+// revision it was based on (used to reject pushes over unseen changes)
+type blobState struct {
+	blobIndex
+	Base string `json:"base"`
+}
+
+// blobBackend implements Backend on top of any blobStore
+type blobBackend struct {
+	name       string
+	store      blobStore
+	stagingDir string
+	statePath  string
+}
+
+func newBlobBackend(name string, store blobStore, stagingDir, statePath string) *blobBackend {
+	return &blobBackend{name: name, store: store, stagingDir: stagingDir, statePath: statePath}
+}
+
+// Name identifies the backend
+func (b *blobBackend) Name() string {
+	return b.name
+}
+
+// HasRemote is always true: the store is configured in config.yaml
+func (b *blobBackend) HasRemote() bool {
+	return true
+}
+
+// Commit snapshots the staging directory as a new local revision
+func (b *blobBackend) Commit(message string) (bool, error) {
+	state, err := b.loadState()
+	if err != nil {
+		return false, err
+	}
+
+	files, err := snapshot(b.stagingDir)
+	if err != nil {
+		return false, err
+	}
+
+	revision := revisionID(files)
+	if revision == state.Revision {
+		return false, nil
+	}
+
+	state.Revision = revision
+	state.Message = message
+	state.Updated = time.Now()
+	state.Files = files
+	return true, b.saveState(state)
+}
+
+// Push uploads the objects the remote doesn't have yet, then writes the
+// index, then deletes objects no revision since the previous one uses
+func (b *blobBackend) Push() error {
+	state, err := b.loadState()
+	if err != nil {
+		return err
+	}
+	if state.Revision == "" {
+		return nil
+	}
+
+	remote, err := b.remoteIndex()
+	if err != nil {
+		return err
+	}
+	if remote.Revision == state.Revision {
+		return nil
+	}
+	if remote.Revision != "" && remote.Revision != state.Base {
+		return fmt.Errorf("push rejected: remote has changed since your last pull. Run 'claude-code-sync pull' first")
+	}
+
+	present := remote.objects()
+	for path, sum := range state.Files {
+		if present[sum] {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(b.stagingDir, filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+		if err := b.store.Put(objectsPrefix+sum, data); err != nil {
+			return fmt.Errorf("upload %s: %w", path, err)
+		}
+		present[sum] = true
+	}
+
+	index := state.blobIndex
+	index.Layout = objectsLayout
+	index.Superseded = nil
+	used := index.objects()
+	for sum := range remote.objects() {
+		if !used[sum] {
+			index.Superseded = append(index.Superseded, sum)
+		}
+	}
+	sort.Strings(index.Superseded)
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := b.store.Put(indexKey, data); err != nil {
+		return fmt.Errorf("upload index: %w", err)
+	}
+	// Stores have no compare-and-swap, so check nobody wrote theirs at the
+	// same time. If they did, their index stands and ours is dropped.
+	if written, err := b.remoteIndex(); err != nil {
+		return err
+	} else if written.Revision != index.Revision {
+		return fmt.Errorf("push rejected: another machine pushed at the same time. Run 'claude-code-sync pull' first")
+	}
+
+	state.blobIndex = index
+	state.Base = state.Revision
+	if err := b.saveState(state); err != nil {
+		return err
+	}
+
+	// Clean up after the revision before the one replaced. A remote from
+	// before objects were used has its files under their own paths instead.
+	var garbage []string
+	if remote.Layout == objectsLayout {
+		for _, sum := range remote.Superseded {
+			if !used[sum] {
+				garbage = append(garbage, objectsPrefix+sum)
+			}
+		}
+	} else {
+		for path := range remote.Files {
+			garbage = append(garbage, path)
+		}
+	}
+	for _, key := range garbage {
+		b.store.Delete(key) // The push is done; a leftover only takes space
+	}
+	return nil
+}
+
+// Pull makes the staging directory match the remote revision
+func (b *blobBackend) Pull() error {
+	state, err := b.loadState()
+	if err != nil {
+		return err
+	}
+
+	remote, err := b.remoteIndex()
+	if err != nil {
+		return err
+	}
+	if remote.Revision == "" || remote.Revision == state.Base {
+		return nil
+	}
+
+	local, err := snapshot(b.stagingDir)
+	if err != nil {
+		return err
+	}
+
+	for path, sum := range remote.Files {
+		if local[path] == sum {
+			continue
+		}
+		data, err := b.store.Get(remote.key(path))
+		if err != nil {
+			return fmt.Errorf("download %s: %w", path, err)
+		}
+		if sync.Checksum(data) != sum {
+			return fmt.Errorf("download %s: checksum mismatch", path)
+		}
+		dest := filepath.Join(b.stagingDir, filepath.FromSlash(path))
+		if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	for path := range local {
+		if _, ok := remote.Files[path]; !ok {
+			os.Remove(filepath.Join(b.stagingDir, filepath.FromSlash(path)))
+		}
+	}
+
+	state.blobIndex = remote
+	state.Base = remote.Revision
+	return b.saveState(state)
+}
+
+// Revisions returns the local revision and the revision in the remote index
+func (b *blobBackend) Revisions() (string, string, error) {
+	state, err := b.loadState()
+	if err != nil {
+		return "", "", err
+	}
+	remote, err := b.remoteIndex()
+	if err != nil {
+		return state.Revision, "", err
+	}
+	return state.Revision, remote.Revision, nil
+}
+
+// LastUpdated returns when the local revision was created
+func (b *blobBackend) LastUpdated() (time.Time, error) {
+	state, err := b.loadState()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state.Revision == "" {
+		return time.Time{}, fmt.Errorf("no revisions")
+	}
+	return state.Updated, nil
+}
+
+// remoteIndex fetches the remote index (empty if nothing has been pushed yet)
+func (b *blobBackend) remoteIndex() (blobIndex, error) {
+	var index blobIndex
+	data, err := b.store.Get(indexKey)
+	if errors.Is(err, errNotFound) {
+		return index, nil
+	}
+	if err != nil {
+		return index, fmt.Errorf("read remote index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("parse remote index: %w", err)
+	}
+	return index, index.validate()
+}
+
+func (b *blobBackend) loadState() (*blobState, error) {
+	state := &blobState{}
+	data, err := os.ReadFile(b.statePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", b.statePath, err)
+	}
+	return state, nil
+}
+
+func (b *blobBackend) saveState(state *blobState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.statePath, data, 0600)
+}
+
+// snapshot hashes every file in dir, keyed by slash-separated relative path
+func snapshot(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	if !sync.FileExists(dir) {
+		return files, nil
+	}
+
+	paths, err := sync.WalkFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		rel := filepath.ToSlash(sync.RelPath(dir, p))
+		if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+			continue
+		}
+		sum, err := sync.FileChecksum(p)
+		if err != nil {
+			return nil, err
+		}
+		files[rel] = sum
+	}
+	return files, nil
+}
+
+// revisionID derives a stable ID from a snapshot
+func revisionID(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s  %s\n", files[p], p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// memStore is a blobStore in memory
+type memStore map[string][]byte
+
+func (m memStore) Get(key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, errNotFound
+	}
+	return data, nil
+}
+
+func (m memStore) Put(key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+// newTestBlob returns a blob backend on store with an empty staging directory
+func newTestBlob(t *testing.T, store blobStore) *blobBackend {
+	t.Helper()
+	dir := t.TempDir()
+	staging := filepath.Join(dir, "repo")
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return newBlobBackend("test", store, staging, filepath.Join(dir, "state.json"))
+}
+
+func writeStaged(t *testing.T, b *blobBackend, path, content string) {
+	t.Helper()
+	dest := filepath.Join(b.stagingDir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readStaged(t *testing.T, b *blobBackend, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(b.stagingDir, filepath.FromSlash(path)))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestBlobPushPull(t *testing.T) {
+	store := memStore{}
+	a, b := newTestBlob(t, store), newTestBlob(t, store)
+
+	writeStaged(t, a, "CLAUDE.md", "one")
+	writeStaged(t, a, "commands/deploy.md", "deploy")
+	if committed, err := a.Commit("first"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	if committed, _ := a.Commit("again"); committed {
+		t.Error("Commit with no changes made a revision")
+	}
+	if err := a.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	if err := b.Pull(); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if got := readStaged(t, b, "commands/deploy.md"); got != "deploy" {
+		t.Errorf("pulled commands/deploy.md = %q", got)
+	}
+
+	// b replaces a file and deletes another
+	writeStaged(t, b, "CLAUDE.md", "two")
+	os.Remove(filepath.Join(b.stagingDir, "commands", "deploy.md"))
+	b.Commit("second")
+	if err := b.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// a can't push over changes it hasn't pulled
+	writeStaged(t, a, "CLAUDE.md", "three")
+	a.Commit("third")
+	if err := a.Push(); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("Push over unpulled changes = %v, want rejected", err)
+	}
+
+	if err := a.Pull(); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if got := readStaged(t, a, "CLAUDE.md"); got != "two" {
+		t.Errorf("pulled CLAUDE.md = %q, want two", got)
+	}
+	if sync.FileExists(filepath.Join(a.stagingDir, "commands", "deploy.md")) {
+		t.Error("file deleted on the remote is still staged")
+	}
+	local, remote, err := a.Revisions()
+	if err != nil || local == "" || local != remote {
+		t.Errorf("Revisions = %q, %q, %v", local, remote, err)
+	}
+}
+
+func TestBlobPullRejectsBadIndex(t *testing.T) {
+	content := []byte("owned")
+	sum := sync.Checksum(content)
+	tests := []struct {
+		name  string
+		path  string
+		sum   string
+		valid bool // Index accepted, but the download fails the checksum
+	}{
+		{"parent", "../outside.txt", sum, false},
+		{"nested parent", "commands/../../outside.txt", sum, false},
+		{"absolute", filepath.ToSlash(filepath.Join(os.TempDir(), "outside.txt")), sum, false},
+		{"empty", "", sum, false},
+		{"bad checksum", "CLAUDE.md", "../../outside", false},
+		{"wrong content", "CLAUDE.md", sync.Checksum([]byte("other")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memStore{}
+			index := blobIndex{Revision: "r1", Layout: objectsLayout, Files: map[string]string{tt.path: tt.sum}}
+			data, _ := json.Marshal(index)
+			store[indexKey] = data
+			store[objectsPrefix+tt.sum] = content
+
+			b := newTestBlob(t, store)
+			err := b.Pull()
+			if err == nil {
+				t.Fatal("Pull accepted a bad index")
+			}
+			if tt.valid != strings.Contains(err.Error(), "checksum mismatch") {
+				t.Errorf("Pull = %v", err)
+			}
+			if sync.FileExists(filepath.Join(filepath.Dir(b.stagingDir), "outside.txt")) {
+				t.Error("Pull wrote outside the staging directory")
+			}
+			if readStaged(t, b, "CLAUDE.md") != "" {
+				t.Error("Pull wrote a file that failed its checksum")
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// dirStore keeps objects as plain files under a directory, e.g. a mounted
+// USB stick or a folder replicated by Syncthing
+type dirStore struct {
+	root string
+}
+
+func newDirStore(cfg config.LocalDirConfig) (*dirStore, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("localdir backend requires localdir.path in config.yaml")
+	}
+	root := config.ExpandHome(cfg.Path)
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("localdir %s does not exist (is the drive mounted?)", root)
+		}
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("localdir %s is not a directory", root)
+	}
+	return &dirStore{root: root}, nil
+}
+
+// Get reads an object
+func (d *dirStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, errNotFound
+	}
+	return data, err
+}
+
+// Put writes an object via a temp file so a yanked drive never leaves a
+// half-written file behind
+func (d *dirStore) Put(key string, data []byte) error {
+	dest := d.path(key)
+	if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// Delete removes an object (missing objects are not an error)
+func (d *dirStore) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (d *dirStore) path(key string) string {
+	return filepath.Join(d.root, filepath.FromSlash(key))
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// offlineMessages are git CLI errors that mean the remote could not be reached
+var offlineMessages = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"failed to connect to",
+	"couldn't connect to server",
+	"connection refused",
+	"connection timed out",
+	"operation timed out",
+	"network is unreachable",
+	"no route to host",
+	"no such host",
+}
+
+// IsOffline reports whether err means the remote could not be reached, as
+// opposed to rejecting the request
+func IsOffline(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range offlineMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
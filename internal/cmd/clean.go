@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var cleanDryRun bool
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove repo files deleted from this machine or excluded",
+	Long: `Remove the files in the sync repo that no longer exist on this machine,
+such as files deleted from ~/.claude after they were pushed, and push the
+removal as one commit. Push only adds and updates files, so without clean
+a deleted file keeps coming back on every pull.
+
+Files exclude_patterns leave out are removed too, such as a directory
+pushed before it was excluded. Patterns added for one platform or machine
+only don't count, since other machines still sync those files.
+
+Only files this machine would restore are checked: other platforms' and
+other machines' variants are left alone. Files pushed by other machines
+since this machine's last pull are not touched either: clean works on the
+repo as this machine last saw it, and its commit is put on top of theirs.
+
+Examples:
+  claude-code-sync clean --dry-run   List the files that would be removed
+  claude-code-sync clean`,
+	Args: cobra.NoArgs,
+	RunE: runClean,
+}
+
+func init() {
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List the files that would be removed")
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	result, err := engine.Clean(syncer.CleanOptions{DryRun: cleanDryRun})
+	if err != nil {
+		return err
+	}
+	if len(result.Removed) == 0 {
+		logInfo("Nothing to clean: every repo file still exists on this machine and none are excluded.")
+		return nil
+	}
+
+	if cleanDryRun {
+		logInfo("[DRY RUN] Would remove the following files from the repo:")
+	}
+	for _, path := range result.Removed {
+		if slices.Contains(result.Excluded, path) {
+			fmt.Printf("  - %s (excluded)\n", path)
+		} else {
+			fmt.Printf("  - %s\n", path)
+		}
+	}
+
+	switch {
+	case cleanDryRun:
+		logInfo(fmt.Sprintf("[DRY RUN] Would remove %d files", len(result.Removed)))
+	case result.PullRequest != "":
+		logSuccess(fmt.Sprintf("Removed %d files. Pull request: %s", len(result.Removed), result.PullRequest))
+	case result.Pushed:
+		logSuccess(fmt.Sprintf("Removed %d files and pushed the cleanup.", len(result.Removed)))
+	case result.Queued:
+		logWarn(fmt.Sprintf("Removed %d files; the cleanup will be pushed by the next command that can reach the remote.", len(result.Removed)))
+	default:
+		logSuccess(fmt.Sprintf("Removed %d files (committed locally).", len(result.Removed)))
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and edit ~/.claude-sync/config.yaml",
+	Long: `View and edit the claude-code-sync configuration.
+
+Keys use dots for nesting, e.g. backup.max_count or s3.bucket.
+Run 'claude-code-sync config keys' to list every key.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a setting, or the whole effective config",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Long: `Change a setting in config.yaml. Comments and other settings are kept.
+
+Lists take comma-separated values or YAML flow syntax:
+  claude-code-sync config set backup.max_count 10
+  claude-code-sync config set exclude_patterns "projects, todos, *.log"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting (revert to the default)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open config.yaml in $EDITOR and validate it afterwards",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEdit,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check config.yaml for unknown keys, bad values and malformed patterns",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigValidate,
+}
+
+var configKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List all configuration keys",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, key := range config.Keys() {
+			fmt.Println(key)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configKeysCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(args) == 0 {
+		return printYAML(cfg)
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case []string:
+		for _, item := range v {
+			fmt.Println(item)
+		}
+	case string, int, bool:
+		fmt.Println(v)
+	default:
+		return printYAML(v)
+	}
+	return nil
+}
+
+// printYAML writes v to stdout with the same indentation config.yaml uses
+func printYAML(v interface{}) error {
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	if err := config.SetValue(paths.ConfigFile, args[0], args[1]); err != nil {
+		return err
+	}
+	logSuccess(fmt.Sprintf("Set %s", args[0]))
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	if err := config.UnsetValue(paths.ConfigFile, args[0]); err != nil {
+		return err
+	}
+	logSuccess(fmt.Sprintf("Removed %s", args[0]))
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	if err := sync.EnsureDir(paths.SyncDir); err != nil {
+		return err
+	}
+	if !sync.FileExists(paths.ConfigFile) {
+		if err := os.WriteFile(paths.ConfigFile, []byte("# claude-code-sync configuration\n# Run 'claude-code-sync config keys' for available settings\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// $EDITOR may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], paths.ConfigFile)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	return runConfigValidate(cmd, nil)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	problems := config.Validate(paths.ConfigFile)
+	if len(problems) == 0 {
+		logSuccess(fmt.Sprintf("%s is valid", toUnixPath(paths.ConfigFile)))
+		return nil
+	}
+
+	for _, p := range problems {
+		logError(p.Error())
+	}
+	return fmt.Errorf("%d problem(s) in %s", len(problems), toUnixPath(paths.ConfigFile))
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Show files that differ between this machine and the remote",
+	Long: `Fetch from the remote and list the files whose content here differs from the
+remote's latest sync, with their sizes and modification times on each side
+and the machine that last changed them on the remote. Encrypted files are
+decrypted to compare them.
+
+Nothing is pulled or changed: use it to decide between 'pull' (take the
+remote's versions), 'pull --ours' (keep these) and 'push'.
+
+States:
+  changed      Different content here and on the remote
+  remote only  Not on this machine yet; pull restores it
+  local only   Not on the remote yet; push adds it`,
+	Args: cobra.NoArgs,
+	RunE: runConflicts,
+}
+
+func runConflicts(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := engine.Conflicts()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		logSuccess("This machine matches the remote.")
+		return nil
+	}
+
+	fmt.Printf("%-40s %-12s %-28s %-28s %s\n", "FILE", "STATE", "LOCAL", "REMOTE", "LAST CHANGED BY")
+	for _, c := range conflicts {
+		path := c.Path
+		if c.Encrypted {
+			path += " [encrypted]"
+		}
+		color.New(color.FgCyan).Printf("%-40s", path)
+		fmt.Printf(" %-12s %-28s %-28s %s\n", conflictState(c.Kind), conflictSide(c.LocalSize, c.LocalTime), conflictSide(c.RemoteSize, c.RemoteTime), orDash(c.Machine))
+	}
+	fmt.Println()
+	logInfo(fmt.Sprintf("%d files differ. Nothing was changed.", len(conflicts)))
+	return nil
+}
+
+// conflictState names a difference as seen from this machine
+func conflictState(kind syncer.ChangeKind) string {
+	switch kind {
+	case syncer.ChangeNew:
+		return "remote only"
+	case syncer.ChangeAdded:
+		return "local only"
+	}
+	return "changed"
+}
+
+// conflictSide renders one side of a difference: size and modification time
+func conflictSide(size int64, t time.Time) string {
+	if size < 0 {
+		return "-"
+	}
+	if t.IsZero() {
+		return formatSize(size)
+	}
+	return fmt.Sprintf("%s, %s", formatSize(size), t.Local().Format("2006-01-02 15:04"))
+}
+
+// orDash renders an unknown value as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
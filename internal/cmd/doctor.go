@@ -2,21 +2,34 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/fatih/color"
+	storage "github.com/felixisaac/claude-code-sync/internal/backend"
 	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
 	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check system health",
-	Long:  `Verify that all dependencies and configurations are correct.`,
-	RunE:  runDoctor,
+	Long: `Verify that all dependencies and configurations are correct.
+
+The git remote is contacted with ls-remote and a dry-run push to check that
+it is reachable and writable with the current credentials.`,
+	RunE: runDoctor,
 }
 
+// remoteTimeout bounds each network check against the git remote
+const remoteTimeout = 15 * time.Second
+
 func runDoctor(cmd *cobra.Command, args []string) error {
 	paths := config.GetPaths()
 	allOk := true
@@ -24,15 +37,29 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	color.Cyan("=== claude-code-sync doctor ===")
 	fmt.Println()
 
+	// Check version
+	fmt.Print("Version: ")
+	if latest := availableUpdate(); latest != "" {
+		color.Yellow("v%s (v%s available - run '%s')", version, latest, updateHint())
+	} else {
+		color.Green("v%s", version)
+	}
+
 	// Check git
+	backend := gitBackend(paths)
 	fmt.Print("Git installed: ")
 	if gitpkg.IsInstalled() {
 		color.Green("OK")
-	} else {
-		color.Red("NOT FOUND")
+	} else if backend == gitpkg.BackendCLI {
+		color.Red("NOT FOUND (git_backend: cli requires git)")
 		allOk = false
+	} else {
+		color.Yellow("NOT FOUND (using built-in go-git)")
 	}
 
+	fmt.Print("Git backend: ")
+	color.Green("%s", gitpkg.ResolveBackend(backend))
+
 	// Check age library (it's built-in, so always OK)
 	fmt.Print("Age encryption: ")
 	color.Green("OK (built-in)")
@@ -47,8 +74,13 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	// Check key file
 	fmt.Print("Private key: ")
-	if sync.FileExists(paths.KeyFile) {
-		color.Green("OK (%s)", paths.KeyFile)
+	if hasKey(paths) {
+		if _, err := loadIdentity(paths); err != nil {
+			color.Red("INVALID (%v)", err)
+			allOk = false
+		} else {
+			color.Green("OK (%s)", keys.Describe(paths, keyCommand(paths)))
+		}
 	} else {
 		color.Yellow("NOT FOUND - run 'init' or 'import-key'")
 	}
@@ -56,7 +88,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	// Check repo
 	fmt.Print("Local repo: ")
 	if sync.FileExists(paths.RepoDir) {
-		g := gitpkg.New(paths.RepoDir)
+		g := openRepo(paths)
 		if g.IsRepo() {
 			color.Green("OK (%s)", paths.RepoDir)
 		} else {
@@ -66,12 +98,82 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		color.Yellow("NOT FOUND - run 'init'")
 	}
 
+	// Check the team's recipients file
+	recipientsFile := filepath.Join(paths.RepoDir, syncer.RecipientsFile)
+	if sync.FileExists(recipientsFile) {
+		fmt.Print("Recipients: ")
+		recipients, err := crypto.LoadRecipients(recipientsFile)
+		if err != nil {
+			color.Red("INVALID (%s: %v)", syncer.RecipientsFile, err)
+			allOk = false
+		} else if n := unaccepted(paths, recipients); n > 0 {
+			color.Yellow("%d key(s) in %s not accepted on this machine yet; the next push asks", n, syncer.RecipientsFile)
+		} else if identity, err := loadIdentity(paths); err == nil && !slices.Contains(recipients, identity.Recipient().String()) {
+			color.Yellow("%d key(s) in %s, not including this machine's", len(recipients), syncer.RecipientsFile)
+		} else {
+			color.Green("%d key(s) in %s", len(recipients), syncer.RecipientsFile)
+		}
+	}
+
+	// Check storage backend
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		fmt.Print("Config file: ")
+		color.Red("INVALID (%v)", err)
+		allOk = false
+	} else if cfg.Backend != storage.NameGit {
+		fmt.Print("Storage backend: ")
+		b, err := storage.Open(cfg, paths)
+		if err == nil {
+			_, _, err = b.Revisions()
+		}
+		if err != nil {
+			color.Red("%s UNREACHABLE (%v)", cfg.Backend, err)
+			allOk = false
+		} else {
+			color.Green("%s OK", cfg.Backend)
+		}
+	}
+
+	// Check Git LFS, when the repo or config stores files with it
+	if gitpkg.UsesLFS(paths.RepoDir) || cfg != nil && cfg.UsesLFS() {
+		fmt.Print("Git LFS: ")
+		switch {
+		case !gitpkg.LFSInstalled():
+			color.Red("NOT FOUND (install git-lfs from https://git-lfs.com)")
+			allOk = false
+		case gitpkg.ResolveBackend(backend) != gitpkg.BackendCLI:
+			color.Red("UNSUPPORTED (Git LFS needs git_backend: cli)")
+			allOk = false
+		case cfg != nil && cfg.Backend != storage.NameGit:
+			color.Yellow("NOT USED (only the git backend stores files with Git LFS)")
+		default:
+			color.Green("OK")
+		}
+	}
+
 	// Check remote
 	fmt.Print("Remote origin: ")
-	if sync.FileExists(paths.RepoDir) {
-		g := gitpkg.New(paths.RepoDir)
+	if cfg != nil && cfg.Backend != storage.NameGit {
+		color.Green("N/A (%s backend)", cfg.Backend)
+	} else if sync.FileExists(paths.RepoDir) {
+		g := openRepo(paths)
 		if g.HasRemote() {
-			color.Green("CONFIGURED")
+			check := gitpkg.ProbeRemote(paths.RepoDir, backend, remoteTimeout)
+			color.Green("CONFIGURED (%s)", check.URL)
+			fmt.Print("Remote auth: ")
+			color.Green("%s", check.Auth)
+			fmt.Print("Remote access: ")
+			switch {
+			case check.Writable:
+				color.Green("OK (read/write)")
+			case check.Reachable:
+				color.Red("READ-ONLY (%v)", check.Err)
+				allOk = false
+			default:
+				color.Red("UNREACHABLE (%v)", check.Err)
+				allOk = false
+			}
 		} else {
 			color.Yellow("NOT CONFIGURED")
 		}
@@ -84,7 +186,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	if sync.FileExists(paths.ClaudeDir) {
 		color.Green("OK (%s)", paths.ClaudeDir)
 	} else {
-		color.Yellow("NOT FOUND")
+		color.Yellow("NOT FOUND (%s)", paths.ClaudeDir)
 	}
 
 	// Check claude.json
@@ -95,6 +197,20 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		color.Yellow("NOT FOUND (optional)")
 	}
 
+	// Check plugins from the synced config are installed
+	if engine, err := newEngine(); err == nil && engine.Config().Plugins.Sync != config.PluginsNone {
+		fmt.Print("Plugins: ")
+		missing, err := engine.MissingPlugins()
+		switch {
+		case err != nil:
+			color.Yellow("UNREADABLE (%v)", err)
+		case len(missing) == 0:
+			color.Green("OK (sync: %s)", engine.Config().Plugins.Sync)
+		default:
+			color.Yellow("%d NOT INSTALLED - e.g. %s", len(missing), missing[0].Command())
+		}
+	}
+
 	fmt.Println()
 	if allOk {
 		logSuccess("All checks passed!")
@@ -104,3 +220,21 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// unaccepted counts the keys in .recipients that push hasn't accepted yet
+func unaccepted(paths config.Paths, recipients []string) int {
+	if !sync.FileExists(paths.AcceptedFile) {
+		return 0
+	}
+	accepted, err := crypto.LoadRecipients(paths.AcceptedFile)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, key := range recipients {
+		if !slices.Contains(accepted, key) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+// passphraseEnv supplies the archive passphrase without a prompt
+const passphraseEnv = "CLAUDE_SYNC_PASSPHRASE"
+
+var (
+	exportOutput     string
+	exportIncludeKey bool
+	exportAll        bool
+	exportRecipient  string
+	exportFormat     string
+	exportDryRun     bool
+
+	importFrom    string
+	importDryRun  bool
+	importPointer bool
+	importPush    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export ~/.claude to a single encrypted archive",
+	Long: `Write ~/.claude and ~/.claude.json to one age-encrypted archive for
+moving to a new machine without setting up a repo. Restore it there with
+'claude-code-sync import'.
+
+The archive is encrypted with a passphrase, prompted for or read from
+$CLAUDE_SYNC_PASSPHRASE. With --recipient it is encrypted to an age public
+key instead. Files matching exclude patterns are skipped unless --all is set.
+
+--include-key adds your private key so the new machine can join the sync
+repo afterwards. Only share such an archive over a channel you trust.
+
+With --format claude-brain, -o is a claude-brain repo instead: the files it
+tracks (CLAUDE.md, agents, commands, skills, rules, ...) are written there
+in plain text for its git hooks to commit. Like share, files matching
+encrypt patterns and files with likely secrets are held back.
+
+Examples:
+  claude-code-sync export -o bundle.age
+  claude-code-sync export -o bundle.age --include-key
+  claude-code-sync export -o bundle.age --recipient age1...
+  claude-code-sync export --format claude-brain -o ~/claude-brain`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive> | --from <tool> <path>",
+	Short: "Restore ~/.claude from an archive made by export, or another tool's repo",
+	Long: `Extract an archive made by 'claude-code-sync export' into ~/.claude and
+~/.claude.json. The current files are backed up first, and files that are not
+in the archive are left alone.
+
+Archives encrypted to a recipient are opened with your key; otherwise the
+passphrase is prompted for or read from $CLAUDE_SYNC_PASSPHRASE. A private
+key in the archive is installed if this machine has none.
+
+With --from, <path> is another tool's repo and its Claude Code files are
+copied into ~/.claude, again after a backup. Push afterwards (or pass
+--push) to sync them; encrypt patterns decide which are encrypted.
+Supported:
+
+  claude-brain  a claude-brain repo
+  chezmoi       a chezmoi source dir (dot_claude/, private_dot_claude.json, ...)
+  stow          a stow dir, or one package in it
+  bare-git      a bare repo whose work tree is $HOME
+
+--pointer then hands the files over: they are removed from the dotfiles
+repo (chezmoi also gets .chezmoiignore entries, a bare repo stops tracking
+them with a commit) and a note says where they went.
+
+Examples:
+  claude-code-sync import bundle.age
+  claude-code-sync import --from claude-brain ~/claude-brain --dry-run
+  claude-code-sync import --from chezmoi ~/.local/share/chezmoi --pointer --push`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Archive to write (required)")
+	exportCmd.Flags().BoolVar(&exportIncludeKey, "include-key", false, "Include your private key in the archive")
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "Include files matching exclude patterns")
+	exportCmd.Flags().StringVar(&exportRecipient, "recipient", "", "Encrypt to an age public key instead of a passphrase")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "archive", "archive, or claude-brain to write into a claude-brain repo")
+	exportCmd.Flags().BoolVar(&exportDryRun, "dry-run", false, "With --format claude-brain, show what would be written")
+	exportCmd.MarkFlagRequired("output")
+
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Import from another tool's repo: "+strings.Join(syncer.ImportSources(), ", "))
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "With --from, show what would be copied")
+	importCmd.Flags().BoolVar(&importPointer, "pointer", false, "With --from, remove the files from the dotfiles repo and leave a note")
+	importCmd.Flags().BoolVar(&importPush, "push", false, "With --from, push right after importing")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	switch exportFormat {
+	case "archive":
+	case syncer.SourceClaudeBrain:
+		return runExportBrain(engine)
+	default:
+		return fmt.Errorf("unknown format %q (use archive or %s)", exportFormat, syncer.SourceClaudeBrain)
+	}
+	paths := engine.Paths()
+
+	if exportIncludeKey && !hasKey(paths) {
+		return fmt.Errorf("no key found at %s", paths.KeyFile)
+	}
+	if sync.FileExists(exportOutput) {
+		return fmt.Errorf("%s already exists", exportOutput)
+	}
+
+	passphrase := ""
+	if exportRecipient == "" {
+		if passphrase, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	out, err := os.OpenFile(exportOutput, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	var enc io.WriteCloser
+	if exportRecipient != "" {
+		enc, err = crypto.EncryptWriter(exportRecipient, out)
+	} else {
+		enc, err = crypto.PassphraseWriter(passphrase, out)
+	}
+	var result *syncer.ExportResult
+	if err == nil {
+		result, err = engine.Export(enc, syncer.ExportOptions{All: exportAll, IncludeKey: exportIncludeKey})
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(exportOutput)
+		return err
+	}
+
+	logSuccess(fmt.Sprintf("Exported %d file(s) to %s", result.Files, exportOutput))
+	if result.IncludeKey {
+		logWarn("The archive contains your private key. Keep it somewhere safe.")
+	}
+	return nil
+}
+
+// runExportBrain writes the files a claude-brain repo tracks into it
+func runExportBrain(engine *syncer.Engine) error {
+	result, err := engine.ExportBrain(config.ExpandHome(exportOutput), exportDryRun)
+	if err != nil {
+		return err
+	}
+	if len(result.Withheld)+len(result.Flagged) > 0 {
+		logWarn(fmt.Sprintf("Held back %d file(s) that are encrypted in sync or may contain secrets.", len(result.Withheld)+len(result.Flagged)))
+	}
+	if exportDryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would write %d and remove %d file(s) in %s", len(result.Files), len(result.Removed), exportOutput))
+		return nil
+	}
+	logSuccess(fmt.Sprintf("Wrote %d and removed %d file(s) in %s", len(result.Files), len(result.Removed), exportOutput))
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	if importFrom != "" {
+		return runImportFrom(args[0])
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	paths := config.GetPaths()
+	plaintext, err := decryptArchive(paths, data)
+	if err != nil {
+		return err
+	}
+	r, err := zip.NewReader(bytes.NewReader(plaintext), int64(len(plaintext)))
+	if err != nil {
+		return fmt.Errorf("%s is not an export archive: %w", args[0], err)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	result, err := engine.Import(r)
+	if err != nil {
+		return err
+	}
+
+	logSuccess(fmt.Sprintf("Imported %d file(s).", len(result.Restored)))
+	if result.KeyImported {
+		logSuccess(fmt.Sprintf("Key installed at %s", paths.KeyFile))
+		fmt.Println("\nTo keep syncing, run: claude-code-sync init <repo-url>")
+	}
+	if result.KeySkipped {
+		logWarn(fmt.Sprintf("The archive has a different key than %s; kept the existing one", paths.KeyFile))
+	}
+	return nil
+}
+
+// decryptArchive opens an export with the local key if it was encrypted to
+// a recipient, otherwise with a passphrase
+func decryptArchive(paths config.Paths, data []byte) ([]byte, error) {
+	if hasKey(paths) {
+		identity, err := loadIdentity(paths)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := crypto.Decrypt(identity, data)
+		if err == nil {
+			return plaintext, nil
+		}
+		var noMatch *age.NoIdentityMatchError
+		if !errors.As(err, &noMatch) {
+			return nil, err
+		}
+	}
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := crypto.DecryptPassphrase(passphrase, data)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, fmt.Errorf("wrong passphrase, or the archive was encrypted to another key")
+	}
+	return plaintext, err
+}
+
+// readPassphrase returns $CLAUDE_SYNC_PASSPHRASE or prompts for the
+// passphrase without echo, twice if confirm is set
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	prompt := func(msg string) string {
+		fmt.Print(msg)
+		setEcho(false)
+		line, _ := reader.ReadString('\n')
+		setEcho(true)
+		fmt.Println()
+		return strings.TrimRight(line, "\r\n")
+	}
+
+	passphrase := prompt("Passphrase: ")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+	if confirm && prompt("Confirm passphrase: ") != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// runImportFrom copies another tool's Claude Code files into ~/.claude
+func runImportFrom(path string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	result, err := engine.ImportFrom(syncer.ImportFromOptions{
+		From:    importFrom,
+		Path:    config.ExpandHome(path),
+		DryRun:  importDryRun,
+		Pointer: importPointer,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Skipped) > 0 {
+		logInfo(fmt.Sprintf("Skipped %d file(s) matching exclude patterns.", len(result.Skipped)))
+	}
+	if importDryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would import %d file(s); %d already match. %d will be encrypted on push.", len(result.Files), len(result.Unchanged), len(result.Encrypted)))
+		return nil
+	}
+	logSuccess(fmt.Sprintf("Imported %d file(s); %d already matched. %d will be encrypted on push.", len(result.Files), len(result.Unchanged), len(result.Encrypted)))
+	if result.BackupPath != "" {
+		logInfo(fmt.Sprintf("Previous files backed up to %s", result.BackupPath))
+	}
+	if result.Pointer != "" {
+		logInfo(fmt.Sprintf("Handed over: see %s", result.Pointer))
+	}
+	if importPush {
+		return runPush(pushCmd, nil)
+	}
+	if len(result.Files) > 0 {
+		fmt.Println("\nRun 'claude-code-sync push' to sync them.")
+	}
+	return nil
+}
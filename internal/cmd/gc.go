@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDays     int
+	gcNoSquash bool
+	gcReport   bool
+	gcYes      bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Shrink the sync repo by squashing old history",
+	Long: `Encrypted files change completely on every edit, so the repo keeps a full
+copy of each version and grows forever. gc squashes the syncs older than
+--days into a single snapshot commit, force-pushes the rewritten history and
+runs git gc to free the space.
+
+Other machines follow the rewritten history on their next pull or push.
+Older syncs can no longer be shown, rolled back to or listed in history.
+
+Examples:
+  claude-code-sync gc --report          Show repo size only
+  claude-code-sync gc                   Squash syncs older than 90 days
+  claude-code-sync gc --days 30 --yes
+  claude-code-sync gc --no-squash       Only run git gc`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	gcCmd.Flags().IntVar(&gcDays, "days", 90, "Squash syncs older than this many days (0 squashes everything)")
+	gcCmd.Flags().BoolVar(&gcNoSquash, "no-squash", false, "Keep history; only prune and repack")
+	gcCmd.Flags().BoolVar(&gcReport, "report", false, "Show the repo size without changing anything")
+	gcCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "Don't ask before rewriting history")
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	if gcDays < 0 {
+		return fmt.Errorf("--days cannot be negative")
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	size, err := engine.RepoSize()
+	if err != nil {
+		return err
+	}
+	printRepoSize(size)
+	if gcReport {
+		return nil
+	}
+
+	opts := syncer.GCOptions{}
+	if !gcNoSquash {
+		before := time.Now().AddDate(0, 0, -gcDays)
+		n, err := engine.SquashCount(before)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			logInfo(fmt.Sprintf("No history older than %d days to squash.", gcDays))
+		} else {
+			fmt.Println()
+			logWarn(fmt.Sprintf("This squashes %d sync(s) from before %s into one commit and force-pushes the result.", n, before.Format("2006-01-02")))
+			if !gcYes && !confirmGC() {
+				return fmt.Errorf("aborted")
+			}
+			opts.Before = before
+		}
+	}
+
+	result, err := engine.GC(opts)
+	if err != nil {
+		return err
+	}
+
+	if result.Squashed > 0 {
+		logSuccess(fmt.Sprintf("Squashed %d commit(s)", result.Squashed))
+		if result.Pushed {
+			logInfo("Other machines will follow the new history on their next pull.")
+		}
+	}
+	freed := result.SizeBefore.GitBytes - result.SizeAfter.GitBytes
+	if freed < 0 {
+		freed = 0
+	}
+	logSuccess(fmt.Sprintf("Repo history is now %s (freed %s)", formatSize(result.SizeAfter.GitBytes), formatSize(freed)))
+	return nil
+}
+
+// printRepoSize shows how much space the sync repo takes
+func printRepoSize(size *syncer.RepoSize) {
+	fmt.Println("Repo size:")
+	fmt.Printf("  History (.git): %s\n", formatSize(size.GitBytes))
+	fmt.Printf("  Files:          %s\n", formatSize(size.FileBytes))
+	fmt.Printf("  Commits:        %d\n", size.Commits)
+	if !size.Oldest.IsZero() {
+		fmt.Printf("  Oldest sync:    %s\n", size.Oldest.Local().Format("2006-01-02"))
+	}
+}
+
+// confirmGC asks before rewriting history
+func confirmGC() bool {
+	fmt.Print("Older syncs can no longer be restored. Continue? (y/N) ")
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	return answer == "y" || answer == "yes"
+}
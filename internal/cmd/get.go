@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var getStdout bool
+
+var getCmd = &cobra.Command{
+	Use:   "get <path>...",
+	Short: "Restore single files from the repo without a full pull",
+	Long: `Decrypt or copy files from the local repo into ~/.claude, leaving every
+other file alone. The remote is not contacted; run 'pull' first if you need
+the latest version.
+
+Paths are relative to ~/.claude ("claude.json" is ~/.claude.json). A
+directory restores everything under it. Local copies that differ are saved
+to ~/.claude-sync/backups/files first.
+
+Examples:
+  claude-code-sync get commands/deploy.md
+  claude-code-sync get skills/review
+  claude-code-sync get settings.json --stdout`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runGet,
+}
+
+func init() {
+	getCmd.Flags().BoolVar(&getStdout, "stdout", false, "Print the file instead of writing it to ~/.claude")
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	if getStdout {
+		if len(args) > 1 {
+			return fmt.Errorf("--stdout takes a single file")
+		}
+		file, err := engine.ReadRepoFile(args[0])
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(file.Content)
+		return nil
+	}
+
+	result, err := engine.Get(args)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Changed) == 0 {
+		logSuccess("Already up to date.")
+		return nil
+	}
+	logSuccess(fmt.Sprintf("Restored %d file(s).", len(result.Changed)))
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepIgnoreCase bool
+	grepFixed      bool
+	grepFilesOnly  bool
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Search plain and encrypted files in the repo",
+	Long: `Search every synced file in the local repo for a regular expression,
+decrypting .age files in memory, and print path:line matches. Plaintext is
+never written to disk. The remote is not contacted.
+
+Examples:
+  claude-code-sync grep ANTHROPIC_
+  claude-code-sync grep -i "mcp.*github"
+  claude-code-sync grep -F -l "api.example.com"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGrep,
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	grepCmd.Flags().BoolVarP(&grepFixed, "fixed-strings", "F", false, "Treat the pattern as a literal string")
+	grepCmd.Flags().BoolVarP(&grepFilesOnly, "files-with-matches", "l", false, "Only print the names of matching files")
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	expr := args[0]
+	if grepFixed {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if grepIgnoreCase {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	matches, err := engine.Grep(pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		logInfo(fmt.Sprintf("No matches for %q", args[0]))
+		return nil
+	}
+
+	printed := map[string]bool{}
+	for _, m := range matches {
+		path := filepath.ToSlash(m.Path)
+		if grepFilesOnly {
+			if !printed[path] {
+				printed[path] = true
+				fmt.Println(path)
+			}
+			continue
+		}
+		fmt.Printf("%s:%s %s\n", color.CyanString(path), color.GreenString("%d:", m.Line), m.Text)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past syncs and the files they changed",
+	Long: `List sync commits with their time, the machine that pushed them, and the
+files they changed. Encrypted files are decrypted to check whether their
+contents really changed, so re-encryption alone is not reported.`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of syncs to show (0 for all)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	entries, err := engine.History(historyLimit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		logInfo("No syncs yet.")
+		return nil
+	}
+
+	for _, entry := range entries {
+		color.New(color.FgYellow).Printf("%s", shortHash(entry.Commit))
+		fmt.Printf("  %s  ", entry.Time.Local().Format("2006-01-02 15:04"))
+		color.New(color.FgCyan).Printf("%s", entry.Machine)
+		fmt.Printf("  %s\n", entry.Message)
+
+		if len(entry.Files) == 0 {
+			fmt.Println("    (no content changes)")
+		}
+		for _, f := range entry.Files {
+			suffix := ""
+			if f.Encrypted {
+				suffix = " [encrypted]"
+			}
+			switch f.Status {
+			case "A":
+				color.Green("    + %s%s", f.Path, suffix)
+			case "D":
+				color.Red("    - %s%s", f.Path, suffix)
+			default:
+				fmt.Printf("    ~ %s%s\n", f.Path, suffix)
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+// defaultHookDelay is how long an automatic push waits for more changes
+const defaultHookDelay = 30 * time.Second
+
+// maxHookLog is the size at which hook.log is started over
+const maxHookLog = 1 << 20
+
+// hookMatchers limits events that fire per tool call to tools that edit files
+var hookMatchers = map[string]string{
+	"PostToolUse": "Write|Edit|MultiEdit",
+}
+
+var (
+	hookEvents []string
+	hookDelay  time.Duration
+	hookWait   string
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Push automatically after Claude Code sessions",
+	Long: `Manage Claude Code hooks that run a debounced push.
+
+'hooks install' adds entries to ~/.claude/settings.json that call
+'claude-code-sync hooks run' when a session ends and after file edits.
+Each call restarts a short timer; the push happens once Claude Code has been
+quiet for the delay. Output of automatic pushes is written to
+~/.claude-sync/hook.log.
+
+settings.json is synced, so other machines get the hooks on their next pull.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Add the auto-push hooks to settings.json",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the auto-push hooks from settings.json",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksUninstall,
+}
+
+var hooksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which auto-push hooks are installed",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksStatus,
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Schedule a debounced push (called by Claude Code)",
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	RunE:   runHooksRun,
+}
+
+func init() {
+	hooksInstallCmd.Flags().StringSliceVar(&hookEvents, "events", []string{"SessionEnd", "PostToolUse"}, "Claude Code hook events that trigger a push")
+	hooksInstallCmd.Flags().DurationVar(&hookDelay, "delay", defaultHookDelay, "Wait this long without further triggers before pushing")
+	hooksRunCmd.Flags().DurationVar(&hookDelay, "delay", defaultHookDelay, "Wait this long without further triggers before pushing")
+	hooksRunCmd.Flags().StringVar(&hookWait, "wait", "", "Internal: token of the trigger to push for")
+
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksStatusCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) error {
+	if engine, err := newEngine(); err == nil {
+		if err := engine.Config().CanPush(); err != nil {
+			return err
+		}
+	}
+	paths := config.GetPaths()
+	settingsPath := filepath.Join(paths.ClaudeDir, "settings.json")
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	command := hookCommand()
+	removeHooks(settings, config.Profile())
+	for _, event := range hookEvents {
+		addHook(settings, event, command)
+	}
+	if err := writeSettings(settingsPath, settings); err != nil {
+		return err
+	}
+
+	logSuccess(fmt.Sprintf("Installed auto-push hooks for %s in %s", strings.Join(hookEvents, ", "), toUnixPath(settingsPath)))
+	logInfo(fmt.Sprintf("Command: %s", command))
+	return nil
+}
+
+func runHooksUninstall(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	settingsPath := filepath.Join(paths.ClaudeDir, "settings.json")
+	if !sync.FileExists(settingsPath) {
+		logInfo("No settings.json, nothing to remove.")
+		return nil
+	}
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	removed := removeHooks(settings, config.Profile())
+	if removed == 0 {
+		logInfo("No auto-push hooks installed.")
+		return nil
+	}
+	if err := writeSettings(settingsPath, settings); err != nil {
+		return err
+	}
+	logSuccess(fmt.Sprintf("Removed %d auto-push hook(s) from %s", removed, toUnixPath(settingsPath)))
+	return nil
+}
+
+func runHooksStatus(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	settings, err := readSettings(filepath.Join(paths.ClaudeDir, "settings.json"))
+	if err != nil {
+		return err
+	}
+
+	color.Cyan("=== Auto-push hooks ===")
+	found := false
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	for _, event := range sortedKeys(hooks) {
+		groups, _ := hooks[event].([]interface{})
+		for _, g := range groups {
+			for _, h := range groupHooks(g) {
+				if command, _ := h["command"].(string); isOurHook(command, config.Profile()) {
+					fmt.Printf("  %-14s %s\n", event, command)
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		fmt.Println("  Not installed. Run 'claude-code-sync hooks install'.")
+		return nil
+	}
+
+	if info, err := os.Stat(hookLogPath(paths)); err == nil {
+		fmt.Printf("\nLast automatic push: %s (log: %s)\n", info.ModTime().Format("2006-01-02 15:04:05"), toUnixPath(hookLogPath(paths)))
+	}
+	return nil
+}
+
+// runHooksRun is called by Claude Code. It records a trigger and starts a
+// detached process that pushes after the delay unless a newer trigger has
+// replaced it by then, so a burst of edits results in one push.
+func runHooksRun(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	if !hasKey(paths) {
+		return nil // Not set up on this machine (settings.json may have been synced here)
+	}
+	if engine, err := newEngine(); err == nil && engine.Config().CanPush() != nil {
+		return nil // Pull-only machine, same reason
+	}
+	pending := filepath.Join(paths.SyncDir, "hook-pending")
+
+	if hookWait == "" {
+		token := strconv.FormatInt(time.Now().UnixNano(), 10)
+		if err := os.WriteFile(pending, []byte(token), 0644); err != nil {
+			return err
+		}
+		return spawnHookPush(paths, token)
+	}
+
+	time.Sleep(hookDelay)
+	if data, err := os.ReadFile(pending); err != nil || string(data) != hookWait {
+		return nil // A newer trigger will push
+	}
+	os.Remove(pending)
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	logInfo(fmt.Sprintf("Automatic push at %s", time.Now().Format("2006-01-02 15:04:05")))
+	result, err := engine.Push(syncer.PushOptions{})
+	notifyPush(engine, true, result, err)
+	if err != nil {
+		logError(err.Error())
+		return err
+	}
+	logSuccess("Push complete!")
+	return nil
+}
+
+// spawnHookPush starts the waiting push in the background, logging to hook.log
+func spawnHookPush(paths config.Paths, token string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := profileArgs()
+	args = append(args, "hooks", "run", "--delay", hookDelay.String(), "--wait", token)
+
+	// Appended to, and started over once it gets large
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if info, err := os.Stat(hookLogPath(paths)); err == nil && info.Size() > maxHookLog {
+		flags |= os.O_TRUNC
+	}
+	logFile, err := os.OpenFile(hookLogPath(paths), flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	c := exec.Command(exe, args...)
+	c.Stdout, c.Stderr = logFile, logFile
+	detach(c)
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start push: %w", err)
+	}
+	return c.Process.Release()
+}
+
+func hookLogPath(paths config.Paths) string {
+	return filepath.Join(paths.SyncDir, "hook.log")
+}
+
+// hookCommand is the command written into settings.json. It calls the
+// binary by name when it is on PATH so the synced settings work on every
+// machine.
+func hookCommand() string {
+	exe := "claude-code-sync"
+	if _, err := exec.LookPath(exe); err != nil {
+		if path, err := os.Executable(); err == nil {
+			exe = path
+			if strings.Contains(exe, " ") {
+				exe = strconv.Quote(exe)
+			}
+			logWarn("claude-code-sync is not on PATH; the hook uses this machine's path to the binary.")
+		}
+	}
+
+	parts := append([]string{exe}, profileArgs()...)
+	parts = append(parts, "hooks", "run")
+	if hookDelay != defaultHookDelay {
+		parts = append(parts, "--delay", hookDelay.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// profileArgs passes the active profile on to commands we start
+func profileArgs() []string {
+	if p := config.Profile(); p != "" {
+		return []string{"--profile", p}
+	}
+	return nil
+}
+
+// isOurHook reports whether a hook command is an auto-push hook for profile
+func isOurHook(command, profile string) bool {
+	if !strings.Contains(command, " hooks run") {
+		return false
+	}
+	fields := strings.Fields(command)
+	got := ""
+	for i, f := range fields {
+		if f == "--profile" && i+1 < len(fields) {
+			got = fields[i+1]
+		}
+	}
+	return got == profile
+}
+
+// addHook appends a command hook for event, with the event's tool matcher
+func addHook(settings map[string]interface{}, event, command string) {
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	if hooks == nil {
+		hooks = map[string]interface{}{}
+		settings["hooks"] = hooks
+	}
+
+	group := map[string]interface{}{
+		"hooks": []interface{}{
+			map[string]interface{}{"type": "command", "command": command},
+		},
+	}
+	if matcher, ok := hookMatchers[event]; ok {
+		group["matcher"] = matcher
+	}
+	groups, _ := hooks[event].([]interface{})
+	hooks[event] = append(groups, group)
+}
+
+// removeHooks deletes the auto-push hooks for profile, dropping groups and
+// events left empty. Returns how many hooks were removed.
+func removeHooks(settings map[string]interface{}, profile string) int {
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	removed := 0
+	for event, v := range hooks {
+		groups, _ := v.([]interface{})
+		var keptGroups []interface{}
+		for _, g := range groups {
+			group, ok := g.(map[string]interface{})
+			if !ok {
+				keptGroups = append(keptGroups, g)
+				continue
+			}
+			entries, _ := group["hooks"].([]interface{})
+			var kept []interface{}
+			for _, h := range entries {
+				if m, ok := h.(map[string]interface{}); ok {
+					if command, _ := m["command"].(string); isOurHook(command, profile) {
+						removed++
+						continue
+					}
+				}
+				kept = append(kept, h)
+			}
+			if len(kept) == 0 && len(entries) > 0 {
+				continue
+			}
+			group["hooks"] = kept
+			keptGroups = append(keptGroups, group)
+		}
+		if len(keptGroups) == 0 {
+			delete(hooks, event)
+		} else {
+			hooks[event] = keptGroups
+		}
+	}
+	if hooks != nil && len(hooks) == 0 {
+		delete(settings, "hooks")
+	}
+	return removed
+}
+
+// groupHooks returns the hook entries of one matcher group
+func groupHooks(g interface{}) []map[string]interface{} {
+	group, _ := g.(map[string]interface{})
+	entries, _ := group["hooks"].([]interface{})
+	var hooks []map[string]interface{}
+	for _, h := range entries {
+		if m, ok := h.(map[string]interface{}); ok {
+			hooks = append(hooks, m)
+		}
+	}
+	return hooks
+}
+
+// readSettings parses settings.json, returning an empty object if it is missing
+func readSettings(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var settings map[string]interface{}
+	if err := dec.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", toUnixPath(path), err)
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	return settings, nil
+}
+
+// writeSettings writes settings.json with the two-space indent Claude Code uses
+func writeSettings(path string, settings map[string]interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(settings); err != nil {
+		return err
+	}
+	if err := sync.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,9 +6,12 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	storage "github.com/felixisaac/claude-code-sync/internal/backend"
 	"github.com/felixisaac/claude-code-sync/internal/config"
 	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/forge"
 	"github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
 	"github.com/spf13/cobra"
 )
@@ -18,17 +21,46 @@ func toUnixPath(path string) string {
 	return strings.ReplaceAll(path, "\\", "/")
 }
 
+var (
+	initCreateRepo string
+	initProvider   string
+	initSSH        bool
+	initForce      bool
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init [repo-url]",
 	Short: "Initialize sync (generate keys, clone/create repo)",
 	Long: `Initialize claude-code-sync for this machine.
 
 If no repo URL is provided, creates a local repo that you can later
-connect to a remote with: git -C ~/.claude-sync/repo remote add origin <url>`,
+connect to a remote with: git -C ~/.claude-sync/repo remote add origin <url>
+
+Use --create-repo <name> to create a private repo, add it as origin and push
+the initial commit. The name can be "repo", "owner/repo", or a URL such as
+https://gitlab.example.com/group/repo for self-hosted servers. The forge is
+detected from the URL or chosen with --provider (default github).
+
+Tokens are read from the environment:
+  github     GITHUB_TOKEN (or 'gh auth login')
+  gitlab     GITLAB_TOKEN
+  gitea      GITEA_TOKEN
+  bitbucket  BITBUCKET_TOKEN, or BITBUCKET_USERNAME + BITBUCKET_APP_PASSWORD
+
+An existing key is always kept. An existing local repo is kept too, unless
+--force is given: then it is deleted (with any unpushed commits) and set up
+again from the URL or --create-repo, so scripts get a known state.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInit,
 }
 
+func init() {
+	initCmd.Flags().StringVar(&initCreateRepo, "create-repo", "", "Create a private repo (name, owner/name, or URL) and use it as origin")
+	initCmd.Flags().StringVar(&initProvider, "provider", "", "Forge for --create-repo: github, gitlab, gitea, or bitbucket")
+	initCmd.Flags().BoolVar(&initSSH, "ssh", false, "Use the SSH URL of the created repo instead of HTTPS")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Replace an existing local repo")
+}
+
 func runInit(cmd *cobra.Command, args []string) error {
 	paths := config.GetPaths()
 	repoURL := ""
@@ -36,11 +68,19 @@ func runInit(cmd *cobra.Command, args []string) error {
 		repoURL = args[0]
 	}
 
+	if initCreateRepo != "" && repoURL != "" {
+		return fmt.Errorf("use either a repo URL or --create-repo, not both")
+	}
+	if initProvider != "" && initCreateRepo == "" {
+		return fmt.Errorf("--provider only applies with --create-repo")
+	}
+
 	logInfo("Initializing claude-code-sync...")
 
 	// Check dependencies
-	if !git.IsInstalled() {
-		return fmt.Errorf("git is not installed")
+	backend := gitBackend(paths)
+	if backend == git.BackendCLI && !git.IsInstalled() {
+		return fmt.Errorf("git is not installed (set git_backend: go-git to use the built-in implementation)")
 	}
 
 	// Create directories
@@ -52,9 +92,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Generate or show existing key
-	if sync.FileExists(paths.KeyFile) {
-		logWarn(fmt.Sprintf("Key already exists at %s", paths.KeyFile))
-		pubKey, err := crypto.GetPublicKey(paths.KeyFile)
+	if hasKey(paths) {
+		logWarn(fmt.Sprintf("Key already exists at %s", keys.Describe(paths, keyCommand(paths))))
+		pubKey, err := keys.PublicKey(paths, keyCommand(paths))
 		if err != nil {
 			return err
 		}
@@ -97,8 +137,25 @@ func runInit(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	// Non-git storage backends use the repo dir as a plain staging directory
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Backend != storage.NameGit {
+		if initCreateRepo != "" {
+			return fmt.Errorf("--create-repo requires the git backend (current backend: %s)", cfg.Backend)
+		}
+		return initStorageBackend(cfg, paths)
+	}
+
 	// Setup repo
-	g := git.New(paths.RepoDir)
+	if initForce && sync.FileExists(paths.RepoDir) {
+		if err := removeRepo(paths); err != nil {
+			return err
+		}
+	}
+	g := git.Open(paths.RepoDir, backend)
 
 	if repoURL != "" {
 		// Validate URL format
@@ -108,7 +165,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 		// Check if URL is reachable
 		logInfo("Verifying repo URL...")
-		if err := git.CheckRemote(repoURL); err != nil {
+		if err := git.CheckRemote(repoURL, backend); err != nil {
 			return fmt.Errorf("cannot access repo: %w\nCheck the URL and your permissions", err)
 		}
 
@@ -116,10 +173,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 			logWarn(fmt.Sprintf("Repo already exists at %s", toUnixPath(paths.RepoDir)))
 		} else {
 			logInfo("Cloning repo...")
-			if err := git.Clone(repoURL, paths.RepoDir); err != nil {
+			if err := git.Clone(repoURL, paths.RepoDir, backend); err != nil {
 				return fmt.Errorf("failed to clone: %w", err)
 			}
 		}
+	} else if initCreateRepo != "" {
+		if err := initCreatedRepo(g, paths); err != nil {
+			return err
+		}
 	} else {
 		if !g.IsRepo() {
 			logInfo("Creating local repo (you'll need to add a remote later)...")
@@ -139,3 +200,83 @@ func runInit(cmd *cobra.Command, args []string) error {
 	logSuccess("Initialization complete!")
 	return nil
 }
+
+// removeRepo deletes the local repo and the checksum cache that describes it
+func removeRepo(paths config.Paths) error {
+	l, err := acquireLock(paths)
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+
+	logWarn(fmt.Sprintf("Removing existing repo at %s (--force)", toUnixPath(paths.RepoDir)))
+	if err := os.RemoveAll(paths.RepoDir); err != nil {
+		return fmt.Errorf("failed to remove repo: %w", err)
+	}
+	os.Remove(paths.IndexFile)
+	return nil
+}
+
+// initCreatedRepo creates a private repo on a forge, adds it as origin of the
+// local repo and pushes the initial commit
+func initCreatedRepo(g git.Repo, paths config.Paths) error {
+	if g.IsRepo() && g.HasRemote() {
+		return fmt.Errorf("repo at %s already has a remote. Run 'claude-code-sync unlink' first", toUnixPath(paths.RepoDir))
+	}
+
+	provider, name, err := forge.Resolve(initCreateRepo, initProvider)
+	if err != nil {
+		return err
+	}
+
+	logInfo(fmt.Sprintf("Creating private %s repo %s...", provider.Name(), name))
+	created, err := provider.CreateRepo(name)
+	if err != nil {
+		return fmt.Errorf("failed to create repo: %w", err)
+	}
+	logSuccess(fmt.Sprintf("Created %s", created.FullName))
+
+	remoteURL := created.HTTPSURL
+	if initSSH {
+		remoteURL = created.SSHURL
+	}
+
+	if !g.IsRepo() {
+		if err := g.Init(); err != nil {
+			return fmt.Errorf("failed to init repo: %w", err)
+		}
+		if err := g.CreateInitialCommit(); err != nil {
+			return fmt.Errorf("failed to create initial commit: %w", err)
+		}
+	}
+	if err := g.AddRemote("origin", remoteURL); err != nil {
+		return fmt.Errorf("failed to add remote: %w", err)
+	}
+
+	logInfo("Pushing initial commit...")
+	if err := g.Push(); err != nil {
+		return fmt.Errorf("repo created but initial push failed: %w\nFix your git credentials, then run 'claude-code-sync push'", err)
+	}
+	return nil
+}
+
+// initStorageBackend prepares the staging directory for a non-git backend
+// and checks that the remote storage is reachable
+func initStorageBackend(cfg *config.Config, paths config.Paths) error {
+	if err := sync.EnsureDir(paths.RepoDir); err != nil {
+		return fmt.Errorf("failed to create staging dir: %w", err)
+	}
+
+	b, err := storage.Open(cfg, paths)
+	if err != nil {
+		return err
+	}
+
+	logInfo(fmt.Sprintf("Verifying %s storage...", b.Name()))
+	if _, _, err := b.Revisions(); err != nil {
+		return fmt.Errorf("cannot access %s storage: %w", b.Name(), err)
+	}
+
+	logSuccess("Initialization complete!")
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// installedBy names the package manager that built this binary, set by
+// packagers with -ldflags "-X main.installedBy=<name>"; see SetInstalledBy
+var installedBy string
+
+// SetInstalledBy records the package manager named in the build metadata
+func SetInstalledBy(name string) {
+	installedBy = name
+}
+
+// packageManager describes how this binary was installed when something
+// other than 'update' owns it
+type packageManager struct {
+	Name    string // homebrew, scoop, apt, ...
+	Upgrade string // Command that updates it
+}
+
+// upgradeCommands maps package managers to their upgrade command
+var upgradeCommands = map[string]string{
+	"homebrew": "brew upgrade claude-code-sync",
+	"scoop":    "scoop update claude-code-sync",
+	"winget":   "winget upgrade claude-code-sync",
+	"apt":      "sudo apt update && sudo apt install --only-upgrade claude-code-sync",
+	"dnf":      "sudo dnf upgrade claude-code-sync",
+	"pacman":   "sudo pacman -Syu claude-code-sync",
+	"apk":      "sudo apk upgrade claude-code-sync",
+	"nix":      "nix profile upgrade claude-code-sync",
+}
+
+// detectPackageManager reports the package manager that owns the binary at
+// path, from the build metadata or else from where it is installed. nil
+// means update may replace the binary itself.
+func detectPackageManager(path string) *packageManager {
+	if installedBy != "" {
+		return newPackageManager(installedBy)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	slashed := filepath.ToSlash(path)
+
+	switch {
+	case strings.Contains(slashed, "/Cellar/") || strings.Contains(slashed, "/homebrew/") || strings.Contains(slashed, "/linuxbrew/"):
+		return newPackageManager("homebrew")
+	case strings.Contains(strings.ToLower(slashed), "/scoop/apps/"):
+		return newPackageManager("scoop")
+	case strings.Contains(slashed, "/WinGet/Packages/"):
+		return newPackageManager("winget")
+	case strings.HasPrefix(slashed, "/nix/store/"):
+		return newPackageManager("nix")
+	}
+
+	if runtime.GOOS == "linux" {
+		return distroPackage(path)
+	}
+	return nil
+}
+
+// distroPackage asks the system package database whether it owns path
+func distroPackage(path string) *packageManager {
+	// Only system directories hold packaged files; /usr/local and home are ours
+	candidates := []string{path}
+	switch dir, base := filepath.Split(path); dir {
+	case "/usr/bin/":
+		candidates = append(candidates, "/bin/"+base) // Merged /usr: dpkg may list the /bin path
+	case "/bin/":
+		candidates = append(candidates, "/usr/bin/"+base)
+	case "/usr/sbin/":
+	default:
+		return nil
+	}
+	queries := []struct {
+		name string
+		args []string // The path is appended
+	}{
+		{"apt", []string{"dpkg", "-S"}},
+		{"dnf", []string{"rpm", "-qf"}},
+		{"pacman", []string{"pacman", "-Qo"}},
+		{"apk", []string{"apk", "info", "--who-owns"}},
+	}
+	for _, q := range queries {
+		if _, err := exec.LookPath(q.args[0]); err != nil {
+			continue
+		}
+		for _, p := range candidates {
+			if exec.Command(q.args[0], append(q.args[1:], p)...).Run() == nil {
+				return newPackageManager(q.name)
+			}
+		}
+	}
+	return nil
+}
+
+func newPackageManager(name string) *packageManager {
+	upgrade, ok := upgradeCommands[name]
+	if !ok {
+		upgrade = "your package manager's upgrade command"
+	}
+	return &packageManager{Name: name, Upgrade: upgrade}
+}
+
+// currentPackageManager checks the running binary
+func currentPackageManager() *packageManager {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	return detectPackageManager(exe)
+}
@@ -3,12 +3,17 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/fatih/color"
 	"github.com/felixisaac/claude-code-sync/internal/config"
 	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
+	"github.com/felixisaac/claude-code-sync/internal/qr"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
 	"github.com/spf13/cobra"
 )
@@ -16,25 +21,158 @@ import (
 var importKeyCmd = &cobra.Command{
 	Use:   "import-key",
 	Short: "Import private key on new machine",
-	Long:  `Import your age private key to set up sync on a new machine.`,
-	RunE:  runImportKey,
+	Long: `Import your age private key to set up sync on a new machine.
+
+Without flags the key is pasted at a prompt. For provisioning scripts, read
+it from a file with --file or from standard input with --stdin; these never
+prompt, and an existing different key is only replaced with --force.
+
+--scan takes the key as one line, as typed by a QR scanner or copied from a
+phone that scanned 'export-key --qr' on the other machine, so no Ctrl+D is
+needed; compare the fingerprints both machines print.
+
+Examples:
+  claude-code-sync import-key --scan
+  claude-code-sync import-key --file ~/key.txt
+  pass show claude-sync | claude-code-sync import-key --stdin`,
+	Args: cobra.NoArgs,
+	RunE: runImportKey,
 }
 
 var exportKeyCmd = &cobra.Command{
 	Use:   "export-key",
 	Short: "Display private key for backup",
-	Long:  `Display your private key so you can save it securely.`,
-	RunE:  runExportKey,
+	Long: `Display your private key so you can save it securely.
+
+With --qr it is shown as a QR code instead, to scan with a phone (or a
+scanner attached to the new machine) and enter with 'import-key --scan'.
+Anyone who can see the screen can take the key.`,
+	RunE: runExportKey,
+}
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Inspect private keys",
+	Long: `Inspect this machine's private key, or a key file exported elsewhere.
+
+Examples:
+  claude-code-sync key fingerprint            Short hash of this machine's public key
+  claude-code-sync key fingerprint old.key    ...of an exported key (or an age1... public key)
+  claude-code-sync key verify                 Check this key decrypts the repo's files
+  claude-code-sync key verify old.key         Check an exported key instead
+  claude-code-sync key split --shares 3 --threshold 2
+                                              Split the key into 3 shares, any 2 recover it
+  claude-code-sync key recover share-1.txt share-3.txt
+                                              Rebuild the key from its shares`,
+}
+
+var keyFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [key-file | public-key]",
+	Short: "Print a short hash of the public key",
+	Long: `Print a short hash of the public key, followed by the key itself, so keys
+on different machines or in exported files can be compared at a glance.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runKeyFingerprint,
+}
+
+var keyVerifyCmd = &cobra.Command{
+	Use:   "verify [key-file]",
+	Short: "Check that a key decrypts the repo's encrypted files",
+	Long: `Decrypt every encrypted file in the local repo (as of the last pull) with
+this machine's key, or with the key in key-file, and report any it cannot
+open.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runKeyVerify,
+}
+
+var keySplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split the private key into recovery shares",
+	Long: `Split this machine's private key into share files using Shamir's secret
+sharing. Any --threshold of the --shares files recover the key with 'key
+recover'; fewer reveal nothing about it. Keep the shares in different places
+(a password manager, a USB stick, a trusted friend) so no single one is a
+full plaintext key.
+
+The share files are written to --out (default: the current directory) as
+claude-sync-key-share-N.txt.`,
+	Args: cobra.NoArgs,
+	RunE: runKeySplit,
+}
+
+var keyRecoverCmd = &cobra.Command{
+	Use:   "recover <share-file>...",
+	Short: "Rebuild the private key from recovery shares",
+	Long: `Combine share files written by 'key split' back into the private key and
+install it as this machine's key, as 'import-key' would. An existing
+different key is only replaced with --force. With --print the key is shown
+instead of installed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runKeyRecover,
+}
+
+var (
+	importKeyFile  string
+	importKeyStdin bool
+	importKeyForce bool
+	importKeyScan  bool
+	exportKeyQR    bool
+
+	keySplitShares    int
+	keySplitThreshold int
+	keySplitOut       string
+	keyRecoverForce   bool
+	keyRecoverPrint   bool
+)
+
+func init() {
+	importKeyCmd.Flags().StringVar(&importKeyFile, "file", "", "Read the key from this file instead of prompting")
+	importKeyCmd.Flags().BoolVar(&importKeyStdin, "stdin", false, "Read the key from standard input without prompting")
+	importKeyCmd.Flags().BoolVarP(&importKeyForce, "force", "f", false, "Replace an existing key without asking")
+	importKeyCmd.Flags().BoolVar(&importKeyScan, "scan", false, "Read the key as a single line from a QR scan")
+	importKeyCmd.MarkFlagsMutuallyExclusive("file", "stdin", "scan")
+	exportKeyCmd.Flags().BoolVar(&exportKeyQR, "qr", false, "Show the key as a QR code")
+
+	keyCmd.AddCommand(keyFingerprintCmd)
+	keySplitCmd.Flags().IntVar(&keySplitShares, "shares", 3, "Number of share files to write")
+	keySplitCmd.Flags().IntVar(&keySplitThreshold, "threshold", 2, "Number of shares needed to recover the key")
+	keySplitCmd.Flags().StringVar(&keySplitOut, "out", ".", "Directory to write the share files to")
+	keyRecoverCmd.Flags().BoolVarP(&keyRecoverForce, "force", "f", false, "Replace an existing different key")
+	keyRecoverCmd.Flags().BoolVar(&keyRecoverPrint, "print", false, "Print the recovered key instead of installing it")
+
+	keyCmd.AddCommand(keyVerifyCmd)
+	keyCmd.AddCommand(keySplitCmd)
+	keyCmd.AddCommand(keyRecoverCmd)
 }
 
 func runImportKey(cmd *cobra.Command, args []string) error {
 	paths := config.GetPaths()
+	interactive := importKeyFile == "" && !importKeyStdin
 
 	if err := sync.EnsureDir(paths.SyncDir); err != nil {
 		return err
 	}
 
-	if sync.FileExists(paths.KeyFile) {
+	// Non-interactive imports read the key before deciding about an
+	// existing one, so re-running a provisioning script is a no-op
+	var keyContent string
+	if !interactive {
+		content, err := readKeyInput()
+		if err != nil {
+			return err
+		}
+		keyContent = content
+	}
+
+	if sync.FileExists(paths.KeyFile) && !importKeyForce {
+		if !interactive {
+			if sameKey(paths.KeyFile, keyContent) {
+				logSuccess(fmt.Sprintf("Key already imported at %s", paths.KeyFile))
+				return nil
+			}
+			return fmt.Errorf("a different key already exists at %s (use --force to replace it)", paths.KeyFile)
+		}
+
 		logWarn(fmt.Sprintf("Key already exists at %s", paths.KeyFile))
 		fmt.Print("Overwrite? (y/N) ")
 
@@ -46,16 +184,25 @@ func runImportKey(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	fmt.Println("Paste your age private key (starts with AGE-SECRET-KEY-):")
-	fmt.Println("Press Ctrl+D (Unix) or Ctrl+Z then Enter (Windows) when done.")
-	fmt.Println()
+	if importKeyScan {
+		fmt.Println("Scan the QR code shown by 'claude-code-sync export-key --qr', or type the key, then press Enter:")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("no key entered")
+		}
+		keyContent = scannedKey(line)
+	} else if interactive {
+		fmt.Println("Paste your age private key (starts with AGE-SECRET-KEY-):")
+		fmt.Println("Press Ctrl+D (Unix) or Ctrl+Z then Enter (Windows) when done.")
+		fmt.Println()
 
-	var lines []string
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		var lines []string
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		keyContent = strings.Join(lines, "\n")
 	}
-	keyContent := strings.Join(lines, "\n")
 
 	// Validate key format
 	if err := crypto.ValidateKeyContent(keyContent); err != nil {
@@ -71,22 +218,93 @@ func runImportKey(cmd *cobra.Command, args []string) error {
 
 	pubKey, err := crypto.GetPublicKeyFromContent(keyContent)
 	if err == nil {
-		logInfo(fmt.Sprintf("Public key: %s", pubKey))
+		logInfo(fmt.Sprintf("Public key: %s (fingerprint %s)", pubKey, crypto.Fingerprint(pubKey)))
 	}
 
 	return nil
 }
 
+// scannedKey cleans up a key typed by a scanner or phone keyboard: spaces
+// and line breaks removed, and upper-cased, since bech32 keys are
+// case-insensitive but age only accepts them in upper case
+func scannedKey(line string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(line), ""))
+}
+
+// readKeyInput reads the key given by --file or --stdin
+func readKeyInput() (string, error) {
+	var data []byte
+	var err error
+	if importKeyStdin {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(config.ExpandHome(importKeyFile))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read key: %w", err)
+	}
+	content := strings.TrimSpace(string(data))
+	if err := crypto.ValidateKeyContent(content); err != nil {
+		return "", fmt.Errorf("invalid key format: %w", err)
+	}
+	return content, nil
+}
+
+// sameKey reports whether the key file holds the same identity as content
+func sameKey(keyFile, content string) bool {
+	existing, err := os.ReadFile(keyFile)
+	if err != nil {
+		return false
+	}
+	a, errA := crypto.ParseKey(string(existing))
+	b, errB := crypto.ParseKey(content)
+	return errA == nil && errB == nil && a.String() == b.String()
+}
+
+// keyCommand returns key_command from config.yaml, or "" if it can't be read
+func keyCommand(paths config.Paths) string {
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return ""
+	}
+	return cfg.KeyCommand
+}
+
+// hasKey reports whether a key is configured: in $CLAUDE_SYNC_KEY, as
+// key_command, or in identity.key
+func hasKey(paths config.Paths) bool {
+	return keys.Available(paths, keyCommand(paths))
+}
+
+// loadIdentity loads the key from wherever it is configured
+func loadIdentity(paths config.Paths) (*age.X25519Identity, error) {
+	return keys.Load(paths, keyCommand(paths))
+}
+
 func runExportKey(cmd *cobra.Command, args []string) error {
 	paths := config.GetPaths()
 
-	if !sync.FileExists(paths.KeyFile) {
+	if !hasKey(paths) {
 		return fmt.Errorf("no key found. Run 'claude-code-sync init' first")
 	}
 
-	content, err := os.ReadFile(paths.KeyFile)
-	if err != nil {
-		return err
+	var content []byte
+	if keys.Source(paths, keyCommand(paths)) == keys.SourceFile {
+		data, err := os.ReadFile(paths.KeyFile)
+		if err != nil {
+			return err
+		}
+		content = data
+	} else {
+		identity, err := loadIdentity(paths)
+		if err != nil {
+			return err
+		}
+		content = []byte(fmt.Sprintf("# public key: %s\n%s\n", identity.Recipient(), identity))
+	}
+
+	if exportKeyQR {
+		return printKeyQR(content)
 	}
 
 	fmt.Println()
@@ -98,3 +316,175 @@ func runExportKey(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// printKeyQR shows the secret key line of content as a QR code. Only the
+// key itself is encoded, which fits in a small alphanumeric-mode symbol.
+func printKeyQR(content []byte) error {
+	identity, err := crypto.ParseKey(string(content))
+	if err != nil {
+		return err
+	}
+	code, err := qr.Encode(identity.String())
+	if err != nil {
+		return err
+	}
+	pubKey := identity.Recipient().String()
+
+	fmt.Println()
+	color.Yellow("=== Your Private Key ===")
+	fmt.Println()
+	// Drawn light-on-dark; force the colors so it scans on light terminals too
+	color.New(color.FgWhite, color.BgBlack).Print(code.Terminal())
+	fmt.Println()
+	fmt.Printf("Fingerprint: %s\n", crypto.Fingerprint(pubKey))
+	fmt.Println("On the new machine run 'claude-code-sync import-key --scan' and check it shows the same fingerprint.")
+	color.Yellow("Anyone who can see this screen can copy your key. Clear it when done.")
+	return nil
+}
+
+func runKeyFingerprint(cmd *cobra.Command, args []string) error {
+	var pubKey string
+	switch {
+	case len(args) == 0:
+		identity, err := loadIdentity(config.GetPaths())
+		if err != nil {
+			return err
+		}
+		pubKey = identity.Recipient().String()
+	case strings.HasPrefix(args[0], "age1"):
+		if _, err := age.ParseX25519Recipient(args[0]); err != nil {
+			return fmt.Errorf("invalid public key: %w", err)
+		}
+		pubKey = args[0]
+	default:
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		if identity, err := crypto.ParseKey(string(data)); err == nil {
+			pubKey = identity.Recipient().String()
+		} else if pubKey, err = crypto.GetPublicKeyFromContent(string(data)); err != nil {
+			return fmt.Errorf("no age key found in %s", args[0])
+		}
+	}
+
+	fmt.Printf("%s  %s\n", crypto.Fingerprint(pubKey), pubKey)
+	return nil
+}
+
+func runKeyVerify(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	var identity *age.X25519Identity
+	if len(args) > 0 {
+		if identity, err = crypto.LoadKey(args[0]); err != nil {
+			return fmt.Errorf("failed to load %s: %w", args[0], err)
+		}
+	} else if identity, err = loadIdentity(engine.Paths()); err != nil {
+		return err
+	}
+	pubKey := identity.Recipient().String()
+	logInfo(fmt.Sprintf("Checking key %s (%s)...", crypto.Fingerprint(pubKey), pubKey))
+
+	check, err := engine.VerifyKey(identity)
+	if err != nil {
+		return err
+	}
+	for _, path := range check.Mismatch {
+		logError(fmt.Sprintf("Encrypted to a different key: %s", path))
+	}
+	for _, failure := range check.Failed {
+		logError(fmt.Sprintf("Cannot decrypt: %s", failure))
+	}
+
+	switch {
+	case check.Checked == 0:
+		logWarn("The repo has no encrypted files to check this key against.")
+	case check.OK():
+		logSuccess(fmt.Sprintf("This key decrypts all %d encrypted file(s) in the repo.", check.Checked))
+	default:
+		return fmt.Errorf("this key cannot decrypt %d of %d encrypted file(s)", len(check.Mismatch)+len(check.Failed), check.Checked)
+	}
+	return nil
+}
+
+func runKeySplit(cmd *cobra.Command, args []string) error {
+	identity, err := loadIdentity(config.GetPaths())
+	if err != nil {
+		return err
+	}
+	shares, err := keys.SplitKey(identity, keySplitShares, keySplitThreshold)
+	if err != nil {
+		return err
+	}
+
+	dir := config.ExpandHome(keySplitOut)
+	if err := sync.EnsureDir(dir); err != nil {
+		return err
+	}
+	// Check every name first so a rerun doesn't leave a mix of two splits
+	files := make([]string, len(shares))
+	for i, share := range shares {
+		files[i] = filepath.Join(dir, fmt.Sprintf("claude-sync-key-share-%d.txt", share.Index))
+		if sync.FileExists(files[i]) {
+			return fmt.Errorf("%s already exists (move the old shares away or use --out)", files[i])
+		}
+	}
+	for i, share := range shares {
+		if err := keys.WriteShareFile(files[i], share); err != nil {
+			return fmt.Errorf("failed to write share: %w", err)
+		}
+		logSuccess(fmt.Sprintf("Wrote share %d of %d: %s", share.Index, share.Shares, files[i]))
+	}
+
+	pubKey := identity.Recipient().String()
+	fmt.Println()
+	fmt.Printf("Any %d of these %d shares recover key %s with:\n", keySplitThreshold, keySplitShares, crypto.Fingerprint(pubKey))
+	fmt.Println("  claude-code-sync key recover <share-file>...")
+	color.Yellow("Store each share in a different place, and delete any full copy of the key you no longer need.")
+	return nil
+}
+
+func runKeyRecover(cmd *cobra.Command, args []string) error {
+	var shares []keys.ShareFile
+	for _, arg := range args {
+		share, err := keys.ReadShareFile(config.ExpandHome(arg))
+		if err != nil {
+			return err
+		}
+		shares = append(shares, share)
+	}
+	identity, err := keys.RecoverKey(shares)
+	if err != nil {
+		return err
+	}
+	keyContent := identity.String()
+	pubKey := identity.Recipient().String()
+
+	if keyRecoverPrint {
+		fmt.Println(keyContent)
+		return nil
+	}
+
+	paths := config.GetPaths()
+	if err := sync.EnsureDir(paths.SyncDir); err != nil {
+		return err
+	}
+	if sync.FileExists(paths.KeyFile) && !keyRecoverForce {
+		if sameKey(paths.KeyFile, keyContent) {
+			logSuccess(fmt.Sprintf("Key already installed at %s (fingerprint %s)", paths.KeyFile, crypto.Fingerprint(pubKey)))
+			return nil
+		}
+		return fmt.Errorf("a different key already exists at %s (use --force to replace it)", paths.KeyFile)
+	}
+	if err := crypto.SaveKey(identity, paths.KeyFile); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	logSuccess(fmt.Sprintf("Key recovered from %d share(s)", len(shares)))
+	logInfo(fmt.Sprintf("Public key: %s (fingerprint %s)", pubKey, crypto.Fingerprint(pubKey)))
+	return nil
+}
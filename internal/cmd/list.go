@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listEncrypted bool
+	listPlain     bool
+	listExcluded  bool
+	listGlob      string
+)
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List local files and how they are synced",
+	Long: `List the files in ~/.claude (plus ~/.claude.json and extra_paths) and
+whether a push encrypts, copies or excludes them.
+
+Filter by class with --encrypted, --plain and --excluded (combine them to
+show several), and by path with --glob. A glob without "/" matches file
+names anywhere; one with "/" matches the whole path (* doesn't cross "/").
+
+Examples:
+  claude-code-sync list --encrypted
+  claude-code-sync list --excluded --glob "*.json"
+  claude-code-sync list --glob "skills/*/*"`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listEncrypted, "encrypted", false, "Only files encrypted before push")
+	listCmd.Flags().BoolVar(&listPlain, "plain", false, "Only files pushed as plain text")
+	listCmd.Flags().BoolVar(&listExcluded, "excluded", false, "Only files that are never synced")
+	listCmd.Flags().StringVar(&listGlob, "glob", "", "Only paths matching this pattern")
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	if listGlob != "" {
+		if _, err := path.Match(listGlob, ""); err != nil {
+			return fmt.Errorf("invalid --glob pattern: %w", err)
+		}
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	files, err := engine.List()
+	if err != nil {
+		return err
+	}
+
+	classes := map[syncer.FileClass]bool{
+		syncer.ClassEncrypted: listEncrypted,
+		syncer.ClassPlain:     listPlain,
+		syncer.ClassExcluded:  listExcluded,
+	}
+	all := !listEncrypted && !listPlain && !listExcluded
+
+	for _, f := range files {
+		if !all && !classes[f.Class] {
+			continue
+		}
+		if listGlob != "" && !matchGlob(listGlob, filepath.ToSlash(f.Path)) {
+			continue
+		}
+		printFileStatus(f)
+	}
+	return nil
+}
+
+// matchGlob matches a slash-separated path against a glob; patterns
+// without "/" are matched against the file name only
+func matchGlob(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		p = path.Base(p)
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var machinesCmd = &cobra.Command{
+	Use:   "machines",
+	Short: "List machines that sync with the repo",
+	Long: `List every machine that has pushed to the sync repo with its platform and
+when it last pushed and pulled. Each push records the machine in .machines/
+in the repo; pull times are published with the machine's next push.`,
+	Args: cobra.NoArgs,
+	RunE: runMachines,
+}
+
+func runMachines(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	machines, err := engine.Machines()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  %-24s %-15s %-17s %s\n", "MACHINE", "PLATFORM", "LAST PUSH", "LAST PULL")
+	for _, m := range machines {
+		marker := " "
+		if m.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s ", marker)
+		color.New(color.FgCyan).Printf("%-24s", m.Hostname)
+		fmt.Printf(" %-15s %-17s %s\n", m.Platform, formatSyncTime(m.LastPush), formatSyncTime(m.LastPull))
+	}
+	fmt.Println()
+	fmt.Println("* this machine")
+	return nil
+}
+
+// formatSyncTime renders a sync time, or "never" if it is unset
+func formatSyncTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
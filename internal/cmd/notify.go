@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/notify"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+)
+
+// wantDesktop reports whether notifications.desktop covers this run.
+// background is set for automatic pushes started by Claude Code hooks.
+func wantDesktop(engine *syncer.Engine, background bool) bool {
+	switch engine.Config().Notifications.Desktop {
+	case config.DesktopNotifyAlways:
+		return true
+	case config.DesktopNotifyAuto:
+		return background
+	}
+	return false
+}
+
+// notifyPush reports the outcome of a push as a desktop notification: a
+// failure, a push that had to be rebased over another machine's, or the
+// files pushed. Pushes that changed nothing stay quiet. The same events are
+// posted to the webhook, if one is configured.
+func notifyPush(engine *syncer.Engine, background bool, result *syncer.PushResult, err error) {
+	switch {
+	case err != nil:
+		webhook(engine, notify.EventFailure, "push failed: "+firstLine(err.Error()), nil)
+	case result.Rebased:
+		webhook(engine, notify.EventConflict, "pushed on top of another machine's push", result.Changed)
+	case result.Pushed && len(result.Changed) > 0:
+		webhook(engine, notify.EventPush, fmt.Sprintf("pushed %d file(s)", len(result.Changed)), result.Changed)
+	}
+
+	if !wantDesktop(engine, background) {
+		return
+	}
+	switch {
+	case err != nil:
+		desktop("Claude Code sync failed", err.Error())
+	case result.Rebased:
+		desktop("Claude Code sync: another machine pushed first", "Your changes were pushed on top. Run 'claude-code-sync pull' to get theirs.")
+	case result.Queued:
+		desktop("Claude Code sync queued", "The remote is unreachable; the push will be retried.")
+	case result.Pushed && len(result.Changed) > 0:
+		desktop("Claude Code config pushed", fmt.Sprintf("%d file(s) synced", len(result.Changed)))
+	}
+}
+
+// notifyPull reports the outcome of a pull as a desktop notification and
+// to the webhook
+func notifyPull(engine *syncer.Engine, result *syncer.PullResult, err error) {
+	switch {
+	case err != nil:
+		webhook(engine, notify.EventFailure, "pull failed: "+firstLine(err.Error()), nil)
+	case len(result.Conflicts) > 0:
+		webhook(engine, notify.EventConflict, fmt.Sprintf("pull replaced %d locally edited file(s); the local copies are in backups", len(result.Conflicts)), result.Conflicts)
+	case len(result.Changed) > 0:
+		webhook(engine, notify.EventPull, fmt.Sprintf("pulled %d file(s)", len(result.Changed)), result.Changed)
+	}
+
+	if !wantDesktop(engine, false) {
+		return
+	}
+	switch {
+	case err != nil:
+		desktop("Claude Code sync failed", err.Error())
+	case len(result.Changed) > 0:
+		desktop("Claude Code config pulled", fmt.Sprintf("%d file(s) updated", len(result.Changed)))
+	}
+}
+
+// desktop shows a notification, logging rather than failing if the
+// platform notifier is missing
+func desktop(title, body string) {
+	if err := notify.Desktop(title, firstLine(body)); err != nil {
+		logDebug(fmt.Sprintf("Desktop notification failed: %v", err))
+	}
+}
+
+// webhook posts an event to notifications.webhook if the event is enabled.
+// A failed post is a warning; the sync itself already succeeded or failed.
+func webhook(engine *syncer.Engine, kind, summary string, files []string) {
+	n := engine.Config().Notifications
+	if !n.Wants(kind) {
+		return
+	}
+	ev := notify.Event{
+		Kind:    kind,
+		Machine: config.Hostname(),
+		Profile: config.Profile(),
+		Summary: summary,
+		Files:   files,
+		Time:    time.Now().UTC(),
+	}
+	if err := notify.Post(n.Webhook, n.WebhookFormat, ev); err != nil {
+		logWarn(fmt.Sprintf("Webhook notification failed: %v", err))
+		return
+	}
+	logDebug(fmt.Sprintf("Posted %s event to webhook", kind))
+}
+
+// firstLine drops the hints that follow the first line of an error
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// patternKind describes one of the pattern lists in config.yaml
+type patternKind struct {
+	name    string // "exclude" or "encrypt"
+	key     string // config key
+	list    func(cfg *config.Config) []string
+	matches func(pattern, relPath string) bool
+}
+
+var (
+	excludeKind = patternKind{
+		name:    "exclude",
+		key:     "exclude_patterns",
+		list:    func(cfg *config.Config) []string { return cfg.ExcludePatterns },
+		matches: config.ExcludePatternMatches,
+	}
+	encryptKind = patternKind{
+		name:    "encrypt",
+		key:     "encrypt_patterns",
+		list:    func(cfg *config.Config) []string { return cfg.EncryptPatterns },
+		matches: config.EncryptPatternMatches,
+	}
+)
+
+var (
+	patternListFiles bool
+	patternPlatform  string
+)
+
+// forPlatform returns kind for the list under platforms.<platform>, or kind
+// itself if platform is ""
+func (kind patternKind) forPlatform(platform string) (patternKind, error) {
+	if platform == "" {
+		return kind, nil
+	}
+	if !slices.Contains(config.PlatformKeys, platform) {
+		return kind, fmt.Errorf("unknown platform %q (use %s)", platform, strings.Join(config.PlatformKeys, ", "))
+	}
+	name := kind.name
+	kind.key = "platforms." + platform + "." + kind.key
+	kind.list = func(cfg *config.Config) []string {
+		if name == excludeKind.name {
+			return cfg.Platforms[platform].ExcludePatterns
+		}
+		return cfg.Platforms[platform].EncryptPatterns
+	}
+	return kind, nil
+}
+
+var excludeCmd = newPatternCmd(excludeKind, "Manage patterns for files that are never synced")
+var encryptCmd = newPatternCmd(encryptKind, "Manage patterns for files that are encrypted before syncing")
+
+// newPatternCmd builds the add/remove/list command tree for a pattern list
+func newPatternCmd(kind patternKind, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   kind.name,
+		Short: short,
+		Long: fmt.Sprintf(`%s
+
+Patterns are matched against paths relative to ~/.claude, as in a
+.gitignore file. A plain name matches a file or directory with that name at
+any depth; a pattern with a slash is anchored to ~/.claude. * and ? stop at
+a slash, ** matches any number of directories, and a pattern matching a
+directory covers everything in it.
+
+The first add copies the built-in defaults into config.yaml, so they keep
+applying alongside your own patterns. With --platform, patterns go in a
+list that only applies on that OS (windows, unix, darwin or linux), on top
+of the shared one.`, short),
+	}
+	cmd.PersistentFlags().StringVar(&patternPlatform, "platform", "", "Only apply on this OS: windows, unix, darwin or linux")
+
+	add := &cobra.Command{
+		Use:   "add <pattern>...",
+		Short: "Add patterns",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind, err := kind.forPlatform(patternPlatform)
+			if err != nil {
+				return err
+			}
+			return runPatternAdd(kind, args)
+		},
+	}
+	remove := &cobra.Command{
+		Use:     "remove <pattern>...",
+		Aliases: []string{"rm"},
+		Short:   "Remove patterns",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind, err := kind.forPlatform(patternPlatform)
+			if err != nil {
+				return err
+			}
+			return runPatternRemove(kind, args)
+		},
+	}
+	list := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List patterns and how many files each matches",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind, err := kind.forPlatform(patternPlatform)
+			if err != nil {
+				return err
+			}
+			return runPatternList(kind)
+		},
+	}
+	list.Flags().BoolVar(&patternListFiles, "files", false, "Show every matched file")
+
+	cmd.AddCommand(add, remove, list)
+	return cmd
+}
+
+func runPatternAdd(kind patternKind, patterns []string) error {
+	paths := config.GetPaths()
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	current := kind.list(cfg)
+	updated := append([]string{}, current...)
+	for _, p := range patterns {
+		if err := config.CheckPattern(p); err != nil {
+			return err
+		}
+		if containsString(updated, p) {
+			logWarn(fmt.Sprintf("%s is already an %s pattern", p, kind.name))
+			continue
+		}
+		updated = append(updated, p)
+	}
+	if len(updated) == len(current) {
+		return nil
+	}
+
+	if err := config.SetList(paths.ConfigFile, kind.key, updated); err != nil {
+		return err
+	}
+
+	localFiles := claudeFiles(paths, kind, cfg)
+	for _, p := range updated[len(current):] {
+		matched := matchPattern(kind, p, localFiles)
+		logSuccess(fmt.Sprintf("Added %s pattern %s (matches %d files)", kind.name, p, len(matched)))
+		printMatches(matched, 10)
+
+		if kind.name == encryptKind.name {
+			removePlainCopies(paths, matched)
+		}
+	}
+	if kind.name == excludeKind.name {
+		logInfo("Pull skips copies already pushed; run 'claude-code-sync clean' to remove them from the repo.")
+	}
+	return nil
+}
+
+func runPatternRemove(kind patternKind, patterns []string) error {
+	paths := config.GetPaths()
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var updated []string
+	removed := map[string]bool{}
+	for _, p := range kind.list(cfg) {
+		if containsString(patterns, p) {
+			removed[p] = true
+			continue
+		}
+		updated = append(updated, p)
+	}
+	for _, p := range patterns {
+		if !removed[p] {
+			return fmt.Errorf("%s is not an %s pattern (see 'claude-code-sync %s list')", p, kind.name, kind.name)
+		}
+	}
+
+	if len(updated) == 0 {
+		if !strings.HasPrefix(kind.key, "platforms.") {
+			logWarn(fmt.Sprintf("That removes every %s pattern; the built-in defaults will apply again.", kind.name))
+		}
+		if err := config.UnsetValue(paths.ConfigFile, kind.key); err != nil {
+			return err
+		}
+	} else if err := config.SetList(paths.ConfigFile, kind.key, updated); err != nil {
+		return err
+	}
+
+	for _, p := range patterns {
+		logSuccess(fmt.Sprintf("Removed %s pattern %s", kind.name, p))
+	}
+	return nil
+}
+
+func runPatternList(kind patternKind) error {
+	paths := config.GetPaths()
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if patternPlatform != "" {
+		color.Cyan("=== %s patterns on %s ===", kind.name, patternPlatform)
+	} else {
+		color.Cyan("=== %s patterns ===", kind.name)
+	}
+	localFiles := claudeFiles(paths, kind, cfg)
+	for _, p := range kind.list(cfg) {
+		matched := matchPattern(kind, p, localFiles)
+		fmt.Printf("  %-30s %d files\n", p, len(matched))
+		if patternListFiles {
+			printMatches(matched, 0)
+		}
+	}
+	return nil
+}
+
+// claudeFiles lists files in ~/.claude relative to it. Encrypt patterns only
+// apply to files that are synced, so excluded files are left out for them.
+func claudeFiles(paths config.Paths, kind patternKind, cfg *config.Config) []string {
+	files, err := sync.WalkFiles(paths.ClaudeDir)
+	if err != nil {
+		return nil
+	}
+	cfg.UseIgnoreFiles(paths.ClaudeDir)
+	rel := make([]string, 0, len(files))
+	for _, f := range files {
+		if f == paths.ClaudeJSON {
+			continue
+		}
+		relPath := sync.RelPath(paths.ClaudeDir, f)
+		if kind.name == encryptKind.name && cfg.ShouldExclude(relPath) {
+			continue
+		}
+		rel = append(rel, relPath)
+	}
+	return rel
+}
+
+// matchPattern returns the files a single pattern matches
+func matchPattern(kind patternKind, pattern string, files []string) []string {
+	var matched []string
+	for _, f := range files {
+		if kind.matches(pattern, f) {
+			matched = append(matched, filepath.ToSlash(f))
+		}
+	}
+	return matched
+}
+
+// printMatches prints up to limit matched files (0 = all)
+func printMatches(files []string, limit int) {
+	for i, f := range files {
+		if limit > 0 && i == limit {
+			fmt.Printf("      ... and %d more\n", len(files)-limit)
+			return
+		}
+		fmt.Printf("      %s\n", f)
+	}
+}
+
+// removePlainCopies deletes plain-text copies of newly encrypted files from
+// the repo so the next push removes them (git history still has them)
+func removePlainCopies(paths config.Paths, files []string) {
+	removed := 0
+	for _, f := range files {
+		plain := filepath.Join(paths.RepoDir, filepath.FromSlash(f))
+		if sync.FileExists(plain) && os.Remove(plain) == nil {
+			removed++
+		}
+	}
+	if removed > 0 {
+		logWarn(fmt.Sprintf("Removed %d plain-text copies from the repo; they are deleted remotely on the next push.", removed))
+		logWarn("They remain in git history. Rotate any secrets they contained.")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
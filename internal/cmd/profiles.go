@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List sync profiles",
+	Long: `List sync profiles.
+
+Each profile has its own repo, key and config under
+~/.claude-sync/profiles/<name>, while ~/.claude is shared. Create one by
+running init with --profile:
+
+  claude-code-sync --profile work init git@github.example.com:you/claude-config.git
+  claude-code-sync --profile work push
+
+Set CLAUDE_SYNC_PROFILE to use a profile without passing --profile.`,
+	Args: cobra.NoArgs,
+	RunE: runProfiles,
+}
+
+func runProfiles(cmd *cobra.Command, args []string) error {
+	names, err := config.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	active := config.Profile()
+	color.Cyan("=== Profiles ===")
+	printProfile("default", active == "")
+	for _, name := range names {
+		printProfile(name, active == name)
+	}
+	return nil
+}
+
+// printProfile prints one profile line with its backend, marking the active one
+func printProfile(name string, active bool) {
+	profile := name
+	if name == "default" {
+		profile = ""
+	}
+	paths := config.ProfilePaths(profile)
+
+	marker := " "
+	if active {
+		marker = "*"
+	}
+
+	state := "not initialized"
+	if hasKey(paths) {
+		state = "git, no remote"
+		if cfg, err := config.Load(paths.ConfigFile); err == nil && cfg.Backend != "git" {
+			state = cfg.Backend
+		} else if openRepo(paths).HasRemote() {
+			state = "git"
+		}
+	}
+	fmt.Printf("%s %-15s %-16s %s\n", marker, name, state, toUnixPath(paths.SyncDir))
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setEcho turns terminal echo on or off so passphrases aren't shown
+func setEcho(on bool) {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	c := exec.Command("stty", arg)
+	c.Stdin = os.Stdin
+	c.Run()
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// setEcho turns console echo on or off so passphrases aren't shown
+func setEcho(on bool) {
+	h := windows.Handle(os.Stdin.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return
+	}
+	if on {
+		mode |= windows.ENABLE_ECHO_INPUT
+	} else {
+		mode &^= windows.ENABLE_ECHO_INPUT
+	}
+	windows.SetConsoleMode(h, mode)
+}
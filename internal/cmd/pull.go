@@ -1,19 +1,13 @@
 package cmd
 
 import (
-	"archive/zip"
+	"bufio"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/felixisaac/claude-code-sync/internal/config"
-	"github.com/felixisaac/claude-code-sync/internal/crypto"
-	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
-	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +16,10 @@ var (
 	pullOurs     bool
 	pullTheirs   bool
 	pullShowDiff bool
+	pullJobs     int
+	pullPeer     string
+	pullForce    bool
+	pullAllPlats bool
 )
 
 var pullCmd = &cobra.Command{
@@ -32,7 +30,22 @@ var pullCmd = &cobra.Command{
 Conflict handling:
   By default, remote changes overwrite local (with backup).
   Use --ours to keep local versions when they differ from remote.
-  Use --diff to preview differences without applying changes.`,
+  Use --diff to preview differences without applying changes.
+
+Platform variants:
+  Variants for other platforms, such as deploy.windows.md on macOS, are left
+  out. Use --all-platforms to restore them too, to edit them here; later
+  pulls keep them up to date and push sends edits back under the same name.
+
+LAN sync:
+  Use --peer <host[:port]> to pull straight from another machine running
+  'claude-code-sync serve --lan', without a round trip to the remote.
+
+Running sessions:
+  A running Claude Code session keeps the settings it started with and may
+  write to ~/.claude while the pull replaces files. What pull does then is
+  set by claude_running in config.yaml: warn (default, and asks first in a
+  terminal), wait, refuse, or off. Use --force to pull regardless.`,
 	RunE: runPull,
 }
 
@@ -41,6 +54,10 @@ func init() {
 	pullCmd.Flags().BoolVar(&pullOurs, "ours", false, "Keep local files when they differ from remote")
 	pullCmd.Flags().BoolVar(&pullTheirs, "theirs", false, "Apply remote files, backup local (default behavior)")
 	pullCmd.Flags().BoolVar(&pullShowDiff, "diff", false, "Show differences between local and remote without applying")
+	pullCmd.Flags().IntVarP(&pullJobs, "jobs", "j", 0, "Files to decrypt/copy in parallel (default: one per CPU)")
+	pullCmd.Flags().StringVar(&pullPeer, "peer", "", "Pull from a machine on the LAN running 'serve --lan' instead of the remote")
+	pullCmd.Flags().BoolVar(&pullForce, "force", false, "Pull even while Claude Code is running")
+	pullCmd.Flags().BoolVar(&pullAllPlats, "all-platforms", false, "Also restore other platforms' variants (e.g. deploy.windows.md) to edit them here")
 }
 
 func runPull(cmd *cobra.Command, args []string) error {
@@ -59,221 +76,95 @@ func runPull(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--ours, --theirs, and --diff are mutually exclusive")
 	}
 
-	// Determine strategy (default: theirs)
-	strategy := "theirs"
-	if pullOurs {
-		strategy = "ours"
-	} else if pullShowDiff {
-		strategy = "diff"
-	}
-
-	paths := config.GetPaths()
-
-	// Check prerequisites
-	if !sync.FileExists(paths.KeyFile) {
-		return fmt.Errorf("not initialized. Run 'claude-code-sync init' or 'claude-code-sync import-key' first")
-	}
-	if !sync.FileExists(paths.RepoDir) {
-		return fmt.Errorf("no repo found. Run 'claude-code-sync init <repo-url>' first")
-	}
-
-	// Load identity for decryption
-	identity, err := crypto.LoadKey(paths.KeyFile)
+	engine, err := newEngine()
 	if err != nil {
-		return fmt.Errorf("failed to load key: %w", err)
+		return err
 	}
 
-	// Load config
-	cfg, err := config.Load(paths.ConfigFile)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	if pullShowDiff {
+		return runPullDiff(engine)
 	}
 
-	g := gitpkg.New(paths.RepoDir)
-
-	// Pull from remote
-	if g.HasRemote() && !pullDryRun {
-		logInfo("Pulling from remote...")
-		if err := g.Pull(); err != nil {
-			logWarn(fmt.Sprintf("Pull failed: %v", err))
-			logWarn("You may need to resolve conflicts manually.")
-
-			// Show age of local repo when pull fails
-			if age := getRepoAge(paths.RepoDir); age != "" {
-				logWarn(fmt.Sprintf("Using cached files from: %s", age))
-			}
-		}
+	// Determine strategy (default: theirs)
+	strategy := syncer.StrategyTheirs
+	if pullOurs {
+		strategy = syncer.StrategyOurs
 	}
 
-	// Backup current config
-	if sync.FileExists(paths.ClaudeDir) && !pullDryRun {
-		backupPath := filepath.Join(paths.BackupDir, fmt.Sprintf("backup-%s.zip", sync.Timestamp()))
-		logInfo(fmt.Sprintf("Backing up current config to %s...", backupPath))
-		if err := createBackupZip(paths.ClaudeDir, paths.ClaudeJSON, backupPath); err != nil {
-			logWarn(fmt.Sprintf("Backup failed: %v", err))
-		}
-
-		// Keep only last N backups
-		if err := pruneBackups(paths.BackupDir, cfg.Backup.MaxCount); err != nil {
-			logWarn(fmt.Sprintf("Failed to prune backups: %v", err))
+	force := pullForce
+	if !force && !pullDryRun && engine.Config().ClaudeRunning == config.ClaudeRunningWarn && stdinIsTerminal() {
+		if pids := syncer.ClaudeSessions(); len(pids) > 0 {
+			if !confirmPullWhileRunning(len(pids)) {
+				return fmt.Errorf("pull cancelled")
+			}
+			force = true
 		}
 	}
 
+	result, err := engine.Pull(syncer.PullOptions{
+		DryRun:       pullDryRun,
+		Strategy:     strategy,
+		Jobs:         pullJobs,
+		Peer:         pullPeer,
+		Force:        force,
+		AllPlatforms: pullAllPlats,
+	})
 	if !pullDryRun {
-		if err := sync.EnsureDir(paths.ClaudeDir); err != nil {
-			return err
-		}
+		notifyPull(engine, result, err)
 	}
-
-	if pullDryRun {
-		logInfo("[DRY RUN] Would restore the following files:")
-	} else if strategy == "diff" {
-		logInfo("Comparing local vs remote (no changes will be applied):")
-	} else if strategy == "ours" {
-		logInfo("Pulling with --ours: keeping local files where they differ")
-	} else {
-		logInfo("Restoring files...")
-	}
-
-	// Process files from repo
-	files, err := sync.WalkFiles(paths.RepoDir)
 	if err != nil {
-		return fmt.Errorf("failed to walk repo: %w", err)
+		return err
 	}
 
-	count := 0
-	for _, file := range files {
-		relPath := sync.RelPath(paths.RepoDir, file)
-
-		// Skip git and manifest
-		if strings.HasPrefix(relPath, ".git") || relPath == ".sync-manifest" || relPath == "README.md" {
-			continue
-		}
-
-		// Check base name (without .age) against exclude patterns
-		basePath := strings.TrimSuffix(relPath, ".age")
-		if cfg.ShouldExclude(basePath) {
-			continue
+	count := len(result.Files)
+	if pullDryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would restore %d files", count))
+		if result.Impact != nil {
+			logInfo(fmt.Sprintf("[DRY RUN] Impact: %s", result.Impact.Summary()))
 		}
+	} else if strategy == syncer.StrategyOurs {
+		logSuccess(fmt.Sprintf("Pull complete (--ours)! Kept local versions, %d files checked.", count))
+	} else {
+		logSuccess(fmt.Sprintf("Pull complete! Restored %d files.", count))
+	}
 
-		// Skip platform variants for other platforms
-		// e.g., on Windows, skip .unix.md files; on Unix, skip .windows.md files
-		if sync.ShouldSkipForPlatform(basePath) {
-			continue
+	if len(result.MissingPlugins) > 0 {
+		logWarn(fmt.Sprintf("%d plugins/marketplaces from the synced config are not installed here:", len(result.MissingPlugins)))
+		for _, m := range result.MissingPlugins {
+			fmt.Printf("  %s\n", m.Command())
 		}
+		logInfo("Run these, or set plugins.reinstall: true to install them on pull.")
+	}
 
-		var dest string
-		actualRelPath := relPath
-
-		// Handle encrypted files
-		if strings.HasSuffix(relPath, ".age") {
-			actualRelPath = strings.TrimSuffix(relPath, ".age")
+	return nil
+}
 
-			// Special case for claude.json
-			if actualRelPath == "claude.json" {
-				dest = paths.ClaudeJSON
-			} else {
-				dest = filepath.Join(paths.ClaudeDir, actualRelPath)
-			}
+// runPullDiff previews differences between local and remote without applying them
+func runPullDiff(engine *syncer.Engine) error {
+	changes, err := engine.Diff()
+	if err != nil {
+		return err
+	}
 
-			if pullDryRun {
-				logInfo(fmt.Sprintf("  [decrypt] %s", actualRelPath))
-			} else if strategy == "diff" {
-				// Show diff for encrypted files (decrypt to temp, compare)
-				if sync.FileExists(dest) {
-					logInfo(fmt.Sprintf("  [encrypted] %s (local exists, remote differs)", actualRelPath))
-				} else {
-					logInfo(fmt.Sprintf("  [encrypted] %s (new file)", actualRelPath))
-				}
+	logInfo("Comparing local vs remote (no changes will be applied):")
+	for _, c := range changes {
+		if c.Encrypted {
+			if c.Kind == syncer.ChangeNew {
+				logInfo(fmt.Sprintf("  [encrypted] %s (new file)", c.Path))
 			} else {
-				// Check if local exists and differs
-				localExists := sync.FileExists(dest)
-
-				if localExists && strategy == "ours" {
-					// Keep local, skip remote
-					logInfo(fmt.Sprintf("Keeping local: %s", actualRelPath))
-				} else {
-					// theirs strategy: backup and apply
-					if localExists {
-						backupPath, _ := sync.BackupFile(dest)
-						if backupPath != "" {
-							logWarn(fmt.Sprintf("Conflict: backing up %s", actualRelPath))
-						}
-					}
-
-					logInfo(fmt.Sprintf("Decrypting: %s", actualRelPath))
-					if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
-						return err
-					}
-					if err := crypto.DecryptFile(identity, file, dest); err != nil {
-						return fmt.Errorf("failed to decrypt %s: %w", actualRelPath, err)
-					}
-				}
-			}
-		} else {
-			dest = filepath.Join(paths.ClaudeDir, relPath)
-
-			if pullDryRun {
-				logInfo(fmt.Sprintf("  [copy] %s", relPath))
-			} else {
-				// Check if local exists and differs
-				localExists := sync.FileExists(dest)
-				var differs bool
-				if localExists {
-					srcHash, _ := sync.FileChecksum(file)
-					dstHash, _ := sync.FileChecksum(dest)
-					differs = srcHash != dstHash
-				}
-
-				if strategy == "diff" {
-					// Show diff
-					if !localExists {
-						logInfo(fmt.Sprintf("  [new] %s", relPath))
-					} else if differs {
-						logInfo(fmt.Sprintf("  [changed] %s", relPath))
-						showFileDiff(dest, file, relPath)
-					} else {
-						// Same content, skip
-						continue
-					}
-				} else if localExists && differs && strategy == "ours" {
-					// Keep local, skip remote
-					logInfo(fmt.Sprintf("Keeping local: %s", relPath))
-				} else if !localExists || differs {
-					// theirs strategy: backup and apply
-					if localExists && differs {
-						backupPath, _ := sync.BackupFile(dest)
-						if backupPath != "" {
-							logWarn(fmt.Sprintf("Conflict: backing up %s", relPath))
-						}
-					}
-
-					logInfo(fmt.Sprintf("Copying: %s", relPath))
-					if err := sync.CopyFile(file, dest); err != nil {
-						return fmt.Errorf("failed to copy %s: %w", relPath, err)
-					}
-				}
+				logInfo(fmt.Sprintf("  [encrypted] %s (local exists, remote differs)", c.Path))
 			}
+			continue
 		}
-		count++
-	}
 
-	if pullDryRun {
-		logInfo(fmt.Sprintf("[DRY RUN] Would restore %d files", count))
-	} else if strategy == "diff" {
-		logInfo(fmt.Sprintf("Diff complete. %d files would be affected.", count))
-		logInfo("Run 'sync pull' to apply changes, or 'sync pull --ours' to keep local.")
-	} else if strategy == "ours" {
-		logSuccess(fmt.Sprintf("Pull complete (--ours)! Kept local versions, %d files checked.", count))
-	} else {
-		// Expand cross-platform path placeholders to local paths
-		if err := expandPluginPaths(paths.ClaudeDir); err != nil {
-			logWarn(fmt.Sprintf("Failed to expand plugin paths: %v", err))
+		logInfo(fmt.Sprintf("  [%s] %s", c.Kind, c.Path))
+		if c.Kind == syncer.ChangeChanged {
+			showFileDiff(c.LocalPath, c.RepoPath, c.Path)
 		}
-
-		logSuccess(fmt.Sprintf("Pull complete! Restored %d files.", count))
 	}
 
+	logInfo(fmt.Sprintf("Diff complete. %d files would be affected.", len(changes)))
+	logInfo("Run 'sync pull' to apply changes, or 'sync pull --ours' to keep local.")
 	return nil
 }
 
@@ -328,187 +219,22 @@ func showFileDiff(localPath, remotePath, relPath string) {
 	}
 }
 
-// expandPluginPaths converts cross-platform placeholders to local platform paths
-// in plugin configuration files after pulling from the repo.
-func expandPluginPaths(claudeDir string) error {
-	// Find all JSON files in plugins directory that may contain path placeholders
-	pluginsDir := filepath.Join(claudeDir, "plugins")
-	logInfo(fmt.Sprintf("Checking for plugin paths to expand in: %s", pluginsDir))
-	if !sync.FileExists(pluginsDir) {
-		logInfo("Plugins directory does not exist, skipping expansion")
-		return nil
-	}
-
-	files, err := sync.WalkFiles(pluginsDir)
-	if err != nil {
-		return err
-	}
-
-	logInfo(fmt.Sprintf("Found %d files in plugins directory", len(files)))
-
-	for _, file := range files {
-		if !strings.HasSuffix(file, ".json") {
-			continue
-		}
-
-		data, err := os.ReadFile(file)
-		if err != nil {
-			continue
-		}
-
-		// Only process if file contains the placeholder
-		if !strings.Contains(string(data), sync.ClaudeDirPlaceholder) {
-			continue
-		}
-
-		logInfo(fmt.Sprintf("Found placeholder in: %s", file))
-
-		expanded := sync.ExpandPathsInJSON(data, claudeDir)
-		if err := os.WriteFile(file, expanded, 0644); err != nil {
-			return fmt.Errorf("failed to write expanded %s: %w", file, err)
-		}
-
-		relPath := sync.RelPath(claudeDir, file)
-		logInfo(fmt.Sprintf("Expanded paths: %s", relPath))
-	}
-
-	return nil
-}
-
-// createBackupZip creates a zip backup of the claude directory
-func createBackupZip(claudeDir, claudeJSON, dest string) error {
-	if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
-		return err
-	}
-
-	zipFile, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer zipFile.Close()
-
-	w := zip.NewWriter(zipFile)
-	defer w.Close()
-
-	// Add claude directory
-	if sync.FileExists(claudeDir) {
-		err := filepath.Walk(claudeDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-
-			relPath, _ := filepath.Rel(filepath.Dir(claudeDir), path)
-			f, err := w.Create(relPath)
-			if err != nil {
-				return err
-			}
-
-			src, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer src.Close()
-
-			_, err = io.Copy(f, src)
-			return err
-		})
-		if err != nil {
-			return err
-		}
-	}
-
-	// Add claude.json
-	if sync.FileExists(claudeJSON) {
-		f, err := w.Create(".claude.json")
-		if err != nil {
-			return err
-		}
-		src, err := os.Open(claudeJSON)
-		if err != nil {
-			return err
-		}
-		defer src.Close()
-		_, err = io.Copy(f, src)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// pruneBackups keeps only the last N backups
-func pruneBackups(backupDir string, maxCount int) error {
-	entries, err := os.ReadDir(backupDir)
-	if err != nil {
-		return err
-	}
-
-	var backups []string
-	for _, e := range entries {
-		if strings.HasPrefix(e.Name(), "backup-") && strings.HasSuffix(e.Name(), ".zip") {
-			backups = append(backups, filepath.Join(backupDir, e.Name()))
-		}
-	}
-
-	if len(backups) <= maxCount {
-		return nil
-	}
-
-	// Sort by name (which includes timestamp) - oldest first
-	// Actually we want newest first, so we remove from the end
-	// The names are like backup-20251219-120000.zip so alphabetical = chronological
-
-	// Remove oldest
-	for i := 0; i < len(backups)-maxCount; i++ {
-		if err := os.Remove(backups[i]); err != nil {
-			return err
-		}
-	}
-
-	return nil
+// confirmPullWhileRunning asks before pulling under running Claude Code
+// sessions
+func confirmPullWhileRunning(sessions int) bool {
+	what := "Claude Code is running"
+	if sessions > 1 {
+		what = fmt.Sprintf("%d Claude Code sessions are running", sessions)
+	}
+	fmt.Printf("%s; restart it to load the pulled settings. Pull anyway? (y/N) ", what)
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	return answer == "y" || answer == "yes"
 }
 
-// getRepoAge returns a human-readable string showing when the repo was last updated
-func getRepoAge(repoDir string) string {
-	// Get last commit timestamp using git log
-	cmd := exec.Command("git", "-C", repoDir, "log", "-1", "--format=%ai")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-
-	timestampStr := strings.TrimSpace(string(output))
-	if timestampStr == "" {
-		return ""
-	}
-
-	// Parse timestamp (format: "2025-12-19 21:22:01 +0800")
-	// Use only the date+time part, ignore timezone
-	parts := strings.Fields(timestampStr)
-	if len(parts) < 2 {
-		return ""
-	}
-	dateTimeStr := parts[0] + " " + parts[1]
-
-	lastCommit, err := time.Parse("2006-01-02 15:04:05", dateTimeStr)
-	if err != nil {
-		return ""
-	}
-
-	age := time.Since(lastCommit)
-
-	// Format based on age
-	if age < time.Hour {
-		return fmt.Sprintf("minutes ago (%s)", lastCommit.Format("2006-01-02 15:04"))
-	} else if age < 24*time.Hour {
-		hours := int(age.Hours())
-		return fmt.Sprintf("%d hour(s) ago (%s)", hours, lastCommit.Format("2006-01-02 15:04"))
-	} else {
-		days := int(age.Hours() / 24)
-		return fmt.Sprintf("%d day(s) ago (%s)", days, lastCommit.Format("2006-01-02"))
-	}
+// stdinIsTerminal reports whether someone can answer a prompt
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
 }
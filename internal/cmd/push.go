@@ -1,21 +1,24 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/felixisaac/claude-code-sync/internal/config"
-	"github.com/felixisaac/claude-code-sync/internal/crypto"
-	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
 var (
 	pushDryRun          bool
 	pushNoPlatformCheck bool
+	pushAllowSecrets    bool
+	pushJobs            int
+	pushPR              bool
+	pushAcceptKeys      bool
 )
 
 var pushCmd = &cobra.Command{
@@ -25,217 +28,127 @@ var pushCmd = &cobra.Command{
 
 Platform detection:
   By default, warns if files contain platform-specific content without variants.
-  Use --no-platform-check to skip this detection.`,
+  Use --no-platform-check to skip this detection.
+
+Secret scanning:
+  Files pushed as plain text are scanned for API keys, tokens and private
+  keys first. Findings block the push (see secrets.scan in config.yaml).
+  Use --allow-secrets to push anyway.
+
+Review:
+  With --pr (or push_mode: review in config.yaml) the sync commit is pushed
+  to review/<hostname> and a pull request is opened against the shared
+  branch, so other machines only get the changes once it is merged. The
+  forge is detected from the remote URL; set forge in config.yaml for
+  self-hosted servers. Tokens are read as for 'init --create-repo'.
+
+Recipients:
+  Keys added to the repo's .recipients since the last push need to be
+  accepted before anything is encrypted to them: push asks, or takes
+  --accept-recipients when nobody can answer.`,
 	RunE: runPush,
 }
 
 func init() {
 	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "Show what would be synced without doing it")
 	pushCmd.Flags().BoolVar(&pushNoPlatformCheck, "no-platform-check", false, "Skip platform-specific content detection")
+	pushCmd.Flags().BoolVar(&pushAllowSecrets, "allow-secrets", false, "Push even if plain-text files appear to contain secrets")
+	pushCmd.Flags().IntVarP(&pushJobs, "jobs", "j", 0, "Files to encrypt/copy in parallel (default: one per CPU)")
+	pushCmd.Flags().BoolVar(&pushPR, "pr", false, "Push to a review branch and open a pull request")
+	pushCmd.Flags().BoolVar(&pushAcceptKeys, "accept-recipients", false, "Encrypt to keys newly added to the repo's .recipients")
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
-	paths := config.GetPaths()
-
-	// Check prerequisites
-	if !sync.FileExists(paths.KeyFile) {
-		return fmt.Errorf("not initialized. Run 'claude-code-sync init' first")
-	}
-	if !sync.FileExists(paths.ClaudeDir) {
-		return fmt.Errorf("no ~/.claude directory found. Nothing to sync")
-	}
-
-	// Load config
-	cfg, err := config.Load(paths.ConfigFile)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// Get public key
-	pubKey, err := crypto.GetPublicKey(paths.KeyFile)
+	engine, err := newEngine()
 	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
+		return err
 	}
 
-	if pushDryRun {
-		logInfo("[DRY RUN] Would sync the following files:")
-	} else {
-		logInfo("Syncing files to repo...")
+	opts := syncer.PushOptions{
+		DryRun:          pushDryRun,
+		NoPlatformCheck: pushNoPlatformCheck,
+		AllowSecrets:    pushAllowSecrets,
+		Jobs:            pushJobs,
+		PR:              pushPR,
 	}
-
-	// Process ~/.claude directory
-	files, err := sync.WalkFiles(paths.ClaudeDir)
-	if err != nil {
-		return fmt.Errorf("failed to walk claude dir: %w", err)
+	result, err := engine.Push(opts)
+	if acceptRecipients(engine, err, pushAcceptKeys) {
+		result, err = engine.Push(opts)
 	}
-
-	count := 0
-	for _, file := range files {
-		relPath := sync.RelPath(paths.ClaudeDir, file)
-
-		// Skip excluded files
-		if cfg.ShouldExclude(relPath) {
-			continue
-		}
-
-		dest := filepath.Join(paths.RepoDir, relPath)
-
-		if cfg.ShouldEncrypt(relPath) {
-			if pushDryRun {
-				logInfo(fmt.Sprintf("  [encrypt] %s", relPath))
-			} else {
-				logInfo(fmt.Sprintf("Encrypting: %s", relPath))
-				if err := sync.EnsureDir(filepath.Dir(dest + ".age")); err != nil {
-					return err
-				}
-				if err := crypto.EncryptFile(pubKey, file, dest+".age"); err != nil {
-					return fmt.Errorf("failed to encrypt %s: %w", relPath, err)
-				}
-			}
-		} else {
-			if pushDryRun {
-				logInfo(fmt.Sprintf("  [copy] %s", relPath))
-			} else {
-				logInfo(fmt.Sprintf("Copying: %s", relPath))
-				if err := sync.CopyFile(file, dest); err != nil {
-					return fmt.Errorf("failed to copy %s: %w", relPath, err)
-				}
-			}
-		}
-		count++
+	if !pushDryRun {
+		notifyPush(engine, false, result, err)
 	}
-
-	// Also sync ~/.claude.json if it exists
-	if sync.FileExists(paths.ClaudeJSON) {
-		dest := filepath.Join(paths.RepoDir, "claude.json.age")
-		if pushDryRun {
-			logInfo("  [encrypt] ~/.claude.json")
-		} else {
-			logInfo("Encrypting: claude.json")
-			if err := crypto.EncryptFile(pubKey, paths.ClaudeJSON, dest); err != nil {
-				return fmt.Errorf("failed to encrypt claude.json: %w", err)
-			}
-		}
-		count++
+	if err != nil {
+		return err
 	}
 
 	if pushDryRun {
-		logInfo(fmt.Sprintf("[DRY RUN] Would sync %d files", count))
+		logInfo(fmt.Sprintf("[DRY RUN] Would sync %d files", len(result.Files)))
 		return nil
 	}
 
-	// Normalize paths in plugin config files for cross-platform compatibility
-	if err := normalizePluginPaths(paths.RepoDir, paths.ClaudeDir); err != nil {
-		logWarn(fmt.Sprintf("Failed to normalize plugin paths: %v", err))
-	}
-
-	// Check for platform-specific content without variants
-	if !pushNoPlatformCheck {
-		repoFiles, err := sync.WalkFiles(paths.RepoDir)
-		if err == nil {
-			warnings := sync.CheckPlatformVariants(paths.RepoDir, repoFiles)
-			if len(warnings) > 0 {
-				logWarn("Platform-specific content detected without variants:")
-				for _, w := range warnings {
-					logWarn(fmt.Sprintf("  %s contains %s syntax (%s)", w.File, w.Platform, w.Pattern))
-					otherPlatform := "windows"
-					if w.Platform == "windows" {
-						otherPlatform = "unix"
-					}
-					variantName := sync.GetPlatformVariantName(w.File, otherPlatform)
-					logInfo(fmt.Sprintf("    Consider creating: %s", variantName))
-				}
-				logInfo("Use --no-platform-check to skip this warning")
-			}
-		}
-	}
-
-	// Generate manifest
-	logInfo("Generating manifest...")
-	entries, err := sync.GenerateManifest(paths.RepoDir)
-	if err != nil {
-		return fmt.Errorf("failed to generate manifest: %w", err)
-	}
-	manifestPath := filepath.Join(paths.RepoDir, ".sync-manifest")
-	if err := sync.WriteManifest(manifestPath, entries); err != nil {
-		return fmt.Errorf("failed to write manifest: %w", err)
-	}
+	printPlatformWarnings(result.PlatformWarnings)
 
-	// Git commit and push
-	g := gitpkg.New(paths.RepoDir)
-
-	logInfo("Committing changes...")
-	if err := g.AddAll(); err != nil {
-		return fmt.Errorf("git add failed: %w", err)
-	}
-
-	hasChanges, err := g.HasChanges()
-	if err != nil {
-		return err
+	if result.Queued {
+		logSuccess("Committed locally. Push queued until the remote is reachable.")
+		return nil
 	}
-
-	if !hasChanges {
-		logInfo("No changes to commit.")
-	} else {
-		if err := g.Commit(fmt.Sprintf("Sync %s", sync.Timestamp())); err != nil {
-			return fmt.Errorf("git commit failed: %w", err)
-		}
-
-		if g.HasRemote() {
-			logInfo("Pushing to remote...")
-			if err := g.Push(); err != nil {
-				return fmt.Errorf("git push failed: %w", err)
-			}
-			logSuccess(fmt.Sprintf("Pushed %d files to remote.", count))
-		} else {
-			logWarn("No remote configured. Changes committed locally only.")
-			logInfo(fmt.Sprintf("Add a remote with: git -C %s remote add origin <url>", paths.RepoDir))
-		}
+	if result.PullRequest != "" {
+		logSuccess(fmt.Sprintf("Pushed for review: %s", result.PullRequest))
+		return nil
 	}
-
 	logSuccess("Push complete!")
 	return nil
 }
 
-// normalizePluginPaths converts platform-specific paths to cross-platform placeholders
-// in plugin configuration files for seamless syncing across Windows/macOS/Linux.
-func normalizePluginPaths(repoDir, claudeDir string) error {
-	// Find all JSON files in plugins directory that may contain paths
-	pluginsDir := filepath.Join(repoDir, "plugins")
-	if !sync.FileExists(pluginsDir) {
-		return nil
+// acceptRecipients handles syncer.ErrNewRecipients: with accept set, or once
+// the user confirms, the new keys are recorded and the command can be run
+// again. Reports whether they were accepted.
+func acceptRecipients(engine *syncer.Engine, err error, accept bool) bool {
+	if !errors.Is(err, syncer.ErrNewRecipients) {
+		return false
 	}
-
-	files, err := sync.WalkFiles(pluginsDir)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range files {
-		if !strings.HasSuffix(file, ".json") {
-			continue
+	if !accept {
+		if !stdinIsTerminal() {
+			return false
 		}
-
-		data, err := os.ReadFile(file)
-		if err != nil {
-			continue
+		keys, err := engine.UnacceptedRecipients()
+		if err != nil || len(keys) == 0 {
+			return false
 		}
-
-		// Only process if file contains the claude dir path
-		if !strings.Contains(string(data), claudeDir) &&
-			!strings.Contains(string(data), filepath.ToSlash(claudeDir)) &&
-			!strings.Contains(string(data), strings.ReplaceAll(claudeDir, `\`, `\\`)) {
-			continue
+		fmt.Printf("The repo's %s lists keys not accepted on this machine:\n", syncer.RecipientsFile)
+		for _, key := range keys {
+			fmt.Printf("  %s\n", key)
 		}
-
-		normalized := sync.NormalizePathsInJSON(data, claudeDir)
-		if err := os.WriteFile(file, normalized, 0644); err != nil {
-			return fmt.Errorf("failed to write normalized %s: %w", file, err)
+		fmt.Print("Whoever holds them can read everything pushed from now on. Encrypt to them? (y/N) ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.TrimSpace(strings.ToLower(answer))
+		if answer != "y" && answer != "yes" {
+			return false
 		}
+	}
+	if err := engine.AcceptRecipients(); err != nil {
+		logWarn(err.Error())
+		return false
+	}
+	return true
+}
 
-		relPath := sync.RelPath(repoDir, file)
-		logInfo(fmt.Sprintf("Normalized paths: %s", relPath))
+// printPlatformWarnings lists files with platform-specific content but no variant
+func printPlatformWarnings(warnings []syncer.PlatformWarning) {
+	if len(warnings) == 0 {
+		return
 	}
 
-	return nil
+	logWarn("Platform-specific content detected without variants:")
+	for _, w := range warnings {
+		logWarn(fmt.Sprintf("  %s contains %s syntax (%s)", w.File, w.Platform, w.Pattern))
+		otherPlatform := sync.PlatformWindows
+		if w.Platform == sync.PlatformWindows {
+			otherPlatform = sync.PlatformUnix
+		}
+		variantName := sync.GetPlatformVariantName(w.File, otherPlatform)
+		logInfo(fmt.Sprintf("    Consider creating: %s, or wrapping it in {{#%s}}...{{/%s}}", variantName, w.Platform, w.Platform))
+	}
+	logInfo("Use --no-platform-check to skip this warning")
 }
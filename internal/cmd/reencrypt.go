@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reencryptDryRun     bool
+	reencryptAcceptKeys bool
+)
+
+var reencryptCmd = &cobra.Command{
+	Use:   "reencrypt",
+	Short: "Re-encrypt the repo for the current recipients",
+	Long: `Rewrite every encrypted file in the repo for the current recipients (this
+machine's key, the recipients rules in config.yaml and the repo's .recipients
+file) and push the result as one commit.
+
+Run it after adding or removing a recipient: otherwise a new teammate can only
+read files as they change, and a removed one can still read files that
+haven't changed since. Removing a recipient does not take back what they
+could already read; rotate any secrets they had access to.
+
+Files this machine's key cannot decrypt are left as they are and listed.
+Keys added to .recipients by someone else need to be accepted first, as
+for push.`,
+	Args: cobra.NoArgs,
+	RunE: runReencrypt,
+}
+
+func init() {
+	reencryptCmd.Flags().BoolVar(&reencryptDryRun, "dry-run", false, "List the files that would be re-encrypted")
+	reencryptCmd.Flags().BoolVar(&reencryptAcceptKeys, "accept-recipients", false, "Encrypt to keys newly added to the repo's .recipients")
+}
+
+func runReencrypt(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	if reencryptDryRun {
+		logInfo("[DRY RUN] Would re-encrypt the following files:")
+	}
+	result, err := engine.Reencrypt(reencryptDryRun)
+	if acceptRecipients(engine, err, reencryptAcceptKeys) {
+		result, err = engine.Reencrypt(reencryptDryRun)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case reencryptDryRun:
+		logInfo(fmt.Sprintf("[DRY RUN] Would re-encrypt %d files", len(result.Files)))
+	case len(result.Files) == 0:
+		logInfo("No encrypted files to re-encrypt.")
+	case result.PullRequest != "":
+		logSuccess(fmt.Sprintf("Re-encrypted %d files. Pull request: %s", len(result.Files), result.PullRequest))
+	case result.Pushed:
+		logSuccess(fmt.Sprintf("Re-encrypted and pushed %d files.", len(result.Files)))
+	case result.Queued:
+		logWarn(fmt.Sprintf("Re-encrypted %d files; they will be pushed by the next command that can reach the remote.", len(result.Files)))
+	default:
+		logSuccess(fmt.Sprintf("Re-encrypted %d files (committed locally).", len(result.Files)))
+	}
+	if len(result.Skipped) > 0 {
+		logWarn(fmt.Sprintf("%d file(s) are not encrypted to this key and were left as they are. Run reencrypt on a machine that can read them.", len(result.Skipped)))
+	}
+	return nil
+}
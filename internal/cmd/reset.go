@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
@@ -14,24 +15,28 @@ import (
 
 var (
 	resetKeepKey bool
+	resetYes     bool
 )
 
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Delete all sync data",
-	Long:  `Delete all claude-code-sync data. Use --keep-key to preserve your private key.`,
-	RunE:  runReset,
+	Long: `Delete all claude-code-sync data. Use --keep-key to preserve your private key.
+
+Asks you to type 'yes' first; --yes skips that for scripts.`,
+	RunE: runReset,
 }
 
 func init() {
 	resetCmd.Flags().BoolVarP(&resetKeepKey, "keep-key", "k", false, "Preserve your private key")
+	resetCmd.Flags().BoolVarP(&resetYes, "yes", "y", false, "Don't ask for confirmation")
 }
 
 func runReset(cmd *cobra.Command, args []string) error {
 	paths := config.GetPaths()
 
 	if !sync.FileExists(paths.SyncDir) {
-		logInfo("Nothing to reset - ~/.claude-sync does not exist.")
+		logInfo(fmt.Sprintf("Nothing to reset - %s does not exist.", toUnixPath(paths.SyncDir)))
 		return nil
 	}
 
@@ -51,16 +56,25 @@ func runReset(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
-	fmt.Print("Type 'yes' to confirm: ")
-	reader := bufio.NewReader(os.Stdin)
-	confirm, _ := reader.ReadString('\n')
-	confirm = strings.TrimSpace(confirm)
+	if !resetYes {
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		confirm, _ := reader.ReadString('\n')
+		confirm = strings.TrimSpace(confirm)
 
-	if confirm != "yes" {
-		logInfo("Aborted.")
-		return nil
+		if confirm != "yes" {
+			logInfo("Aborted.")
+			return nil
+		}
 	}
 
+	// Don't pull the repo out from under a running push or pull
+	l, err := acquireLock(paths)
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+
 	if resetKeepKey {
 		// Remove everything except key
 		if sync.FileExists(paths.RepoDir) {
@@ -72,14 +86,30 @@ func runReset(cmd *cobra.Command, args []string) error {
 		if sync.FileExists(paths.BackupDir) {
 			os.RemoveAll(paths.BackupDir)
 		}
-		if sync.FileExists(paths.LockFile) {
-			os.Remove(paths.LockFile)
-		}
 		logSuccess("Reset complete. Key preserved. Run 'claude-code-sync init <repo-url>' to reconnect.")
 	} else {
-		os.RemoveAll(paths.SyncDir)
+		removeSyncDir(paths)
 		logSuccess("Reset complete. All sync data removed.")
 	}
 
 	return nil
 }
+
+// removeSyncDir deletes the sync directory. Resetting the default profile
+// leaves the named profiles in ~/.claude-sync/profiles alone.
+func removeSyncDir(paths config.Paths) {
+	if config.Profile() != "" {
+		os.RemoveAll(paths.SyncDir)
+		return
+	}
+	entries, err := os.ReadDir(paths.SyncDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() && config.IsProfilesDir(e.Name()) {
+			continue
+		}
+		os.RemoveAll(filepath.Join(paths.SyncDir, e.Name()))
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreList bool
+	restoreFile string
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [backup-name]",
+	Short: "List and restore backups taken before pull",
+	Long: `Restore ~/.claude from a backup zip in ~/.claude-sync/backups.
+
+Without arguments, lists available backups. With a backup name, restores
+that snapshot (the current state is backed up first). Files that are not in
+the backup are left alone.
+
+Examples:
+  claude-code-sync restore --list                          List backups
+  claude-code-sync restore --list 20251219-120000          List files in a backup
+  claude-code-sync restore 20251219-120000                 Restore a whole snapshot
+  claude-code-sync restore --file CLAUDE.md                Restore one file from the newest backup
+  claude-code-sync restore --file claude.json 20251219-120000`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().BoolVarP(&restoreList, "list", "l", false, "List backups, or the files in a backup")
+	restoreCmd.Flags().StringVarP(&restoreFile, "file", "f", "", "Restore a single file (relative to ~/.claude, or claude.json)")
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	if restoreList && restoreFile != "" {
+		return fmt.Errorf("--list and --file are mutually exclusive")
+	}
+
+	if restoreList || (name == "" && restoreFile == "") {
+		if name != "" {
+			return listBackupFiles(paths, name)
+		}
+		return listBackups(paths)
+	}
+
+	backup, err := sync.FindBackup(paths.BackupDir, name)
+	if err != nil {
+		return err
+	}
+
+	l, err := acquireLock(paths)
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+
+	if restoreFile != "" {
+		return restoreSingleFile(paths, backup)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	// Read the backup before taking a new one, which could replace it if
+	// both were made in the same second
+	identity := backupIdentity(paths)
+	if _, err := sync.BackupFiles(backup.Path, identity); err != nil {
+		return fmt.Errorf("failed to read %s: %w", backup.Name, err)
+	}
+
+	// Back up current state first so the restore itself can be undone
+	if !strings.Contains(backup.Name, sync.Timestamp()) {
+		if _, err := engine.Backup(); err != nil {
+			return fmt.Errorf("backup failed: %w", err)
+		}
+	}
+
+	logInfo(fmt.Sprintf("Restoring %s...", backup.Name))
+	restored, err := sync.RestoreBackup(backup.Path, identity, paths.ClaudeDir, paths.ClaudeJSON, "")
+	if err != nil {
+		return err
+	}
+
+	if err := sync.PruneBackups(paths.BackupDir, engine.Config().Backup.MaxCount); err != nil {
+		logWarn(fmt.Sprintf("Failed to prune backups: %v", err))
+	}
+
+	logSuccess(fmt.Sprintf("Restored %d files from %s", len(restored), backup.Name))
+	return nil
+}
+
+// restoreSingleFile extracts one file, keeping a copy of the current one in
+// backups/files
+func restoreSingleFile(paths config.Paths, backup *sync.Backup) error {
+	dest := filepath.Join(paths.ClaudeDir, filepath.FromSlash(restoreFile))
+	if restoreFile == "claude.json" || restoreFile == ".claude.json" {
+		dest = paths.ClaudeJSON
+	}
+
+	localBackup, err := sync.BackupFile(dest, paths.BackupDir, sync.Timestamp(), filepath.FromSlash(restoreFile))
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", restoreFile, err)
+	}
+
+	if _, err := sync.RestoreBackup(backup.Path, backupIdentity(paths), paths.ClaudeDir, paths.ClaudeJSON, restoreFile); err != nil {
+		return err
+	}
+
+	if localBackup != "" {
+		logInfo(fmt.Sprintf("Previous version saved to %s", toUnixPath(localBackup)))
+		if cfg, err := config.Load(paths.ConfigFile); err == nil {
+			sync.PruneFileBackups(paths.BackupDir, cfg.Backup.MaxCount)
+		}
+	}
+	logSuccess(fmt.Sprintf("Restored %s from %s", restoreFile, backup.Name))
+	return nil
+}
+
+// listBackups prints all backups, newest first
+func listBackups(paths config.Paths) error {
+	backups, err := sync.ListBackups(paths.BackupDir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		logInfo("No backups yet. A backup is taken before every pull.")
+		return nil
+	}
+
+	color.Cyan("=== Backups (%s) ===", toUnixPath(paths.BackupDir))
+	fmt.Println()
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		files := fmt.Sprintf("%5d files", b.Entries)
+		if b.Encrypted {
+			files = "  encrypted"
+		}
+		fmt.Printf("  %s  %s  %s  %8s\n", b.Name, b.Time.Format("2006-01-02 15:04:05"), files, formatSize(b.Size))
+	}
+	fmt.Println()
+	fmt.Println("Restore with: claude-code-sync restore <backup-name>")
+	return nil
+}
+
+// listBackupFiles prints the files inside one backup
+func listBackupFiles(paths config.Paths, name string) error {
+	backup, err := sync.FindBackup(paths.BackupDir, name)
+	if err != nil {
+		return err
+	}
+	files, err := sync.BackupFiles(backup.Path, backupIdentity(paths))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", backup.Name, err)
+	}
+
+	color.Cyan("=== %s (%d files) ===", backup.Name, len(files))
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+	return nil
+}
+
+// backupIdentity loads the key for reading encrypted backups, or nil if
+// there is none
+func backupIdentity(paths config.Paths) *age.X25519Identity {
+	identity, err := loadIdentity(paths)
+	if err != nil {
+		return nil
+	}
+	return identity
+}
+
+// formatSize renders a byte count for humans
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
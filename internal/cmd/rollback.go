@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackLast   bool
+	rollbackDryRun bool
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <commit|--last>",
+	Short: "Restore ~/.claude to an earlier sync",
+	Long: `Restore ~/.claude to the state of an earlier sync commit.
+
+The commit can be a hash (see 'git -C ~/.claude-sync/repo log') or anything
+git understands, like HEAD~3. --last rolls back to the sync before the latest.
+
+Your current config is backed up first (see 'claude-code-sync restore').
+The repo is not changed: run 'claude-code-sync push' afterwards to make the
+rolled-back state the latest sync on all machines.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRollback,
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVar(&rollbackLast, "last", false, "Roll back to the sync before the latest one")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "Show what would be restored without doing it")
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	rev := ""
+	switch {
+	case rollbackLast && len(args) > 0:
+		return fmt.Errorf("use either a commit or --last, not both")
+	case rollbackLast:
+		rev = "HEAD~1"
+	case len(args) > 0:
+		rev = args[0]
+	default:
+		return fmt.Errorf("specify a commit to roll back to, or --last")
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	result, err := engine.Rollback(rev, rollbackDryRun)
+	if err != nil {
+		return err
+	}
+
+	if rollbackDryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would restore %d files from %s", len(result.Files), shortHash(result.Commit)))
+		return nil
+	}
+
+	logSuccess(fmt.Sprintf("Rolled back to %s (%d files restored)", shortHash(result.Commit), len(result.Files)))
+	if result.BackupPath != "" {
+		logInfo(fmt.Sprintf("Undo with: claude-code-sync restore %s", filepath.Base(result.BackupPath)))
+	}
+	logInfo("Run 'claude-code-sync push' to sync this state to your other machines.")
+	return nil
+}
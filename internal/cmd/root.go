@@ -1,41 +1,143 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/lock"
+	"github.com/felixisaac/claude-code-sync/internal/logfile"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
 var (
-	version = "dev"
-	rootCmd = &cobra.Command{
+	version     = "dev"
+	profileFlag string
+	verbose     bool
+	quiet       bool
+	events      bool
+	rootCmd     = &cobra.Command{
 		Use:   "claude-code-sync",
 		Short: "Sync Claude Code configs across machines",
 		Long: `claude-code-sync - Secure Claude Code config sync across machines
 
 Sync your ~/.claude/ configs via GitHub with age encryption.
-Sensitive files (API keys, OAuth tokens) are encrypted before pushing.`,
+Sensitive files (API keys, OAuth tokens) are encrypted before pushing.
+
+Use --profile (or CLAUDE_SYNC_PROFILE) to keep separate repos, keys and
+configs, e.g. one for work and one for personal use.
+
+Every run is logged with timestamps and levels to ~/.claude-sync/logs.
+--verbose also prints debug messages; --quiet prints only warnings, errors
+and command output.
+
+--events writes newline-delimited JSON events (file_encrypted,
+file_conflict, push_complete, ...) to stdout for other tools, and moves
+all human-readable output to stderr.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if verbose && quiet {
+				return fmt.Errorf("--verbose and --quiet are mutually exclusive")
+			}
+			name := profileFlag
+			if name == "" {
+				name = os.Getenv(config.ProfileEnv)
+			}
+			if err := config.SetProfile(name); err != nil {
+				return err
+			}
+			if events {
+				startEvents()
+			}
+			openLog(cmd)
+			flushPendingPush(cmd)
+			return nil
+		},
 	}
 )
 
+// flushPendingPush pushes sync commits queued while offline before running
+// cmd. Push and pull do this themselves; commands that don't touch the repo
+// skip it.
+func flushPendingPush(cmd *cobra.Command) {
+	switch cmd.Name() {
+	case "push", "pull", "init", "version", "help", "completion", "update", "check-update", "run":
+		return
+	}
+	engine, err := newEngine()
+	if err != nil || engine.PendingPush() == nil {
+		return
+	}
+	pushed, err := engine.FlushPending()
+	if err != nil {
+		logWarn(fmt.Sprintf("Failed to push commits queued while offline: %v", err))
+	} else if pushed {
+		logSuccess("Pushed commits queued while offline.")
+	}
+}
+
 func SetVersion(v string) {
 	version = v
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	removeOldBinary()
+	err := rootCmd.Execute()
+	if err != nil {
+		writeLog(logfile.Error, err.Error())
+		writeEvent(syncer.Event{Type: syncer.EventError, Time: time.Now().UTC(), Message: err.Error()})
+	}
+	closeLog()
+	return err
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use the named profile in ~/.claude-sync/profiles/<name>")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Also print debug messages")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print warnings, errors and command output")
+	rootCmd.PersistentFlags().BoolVar(&events, "events", false, "Write NDJSON events to stdout and everything else to stderr")
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(conflictsCmd)
+	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(importKeyCmd)
 	rootCmd.AddCommand(exportKeyCmd)
+	rootCmd.AddCommand(keyCmd)
+	rootCmd.AddCommand(reencryptCmd)
 	rootCmd.AddCommand(verifyCmd)
 	rootCmd.AddCommand(resetCmd)
 	rootCmd.AddCommand(unlinkCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(machinesCmd)
+	rootCmd.AddCommand(wslCmd)
+	rootCmd.AddCommand(variantCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(grepCmd)
+	rootCmd.AddCommand(shareCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(cleanCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(excludeCmd)
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(profilesCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(checkUpdateCmd)
 	rootCmd.AddCommand(updateCmd)
@@ -49,18 +151,117 @@ var (
 	errorColor   = color.New(color.FgRed)
 )
 
+func logDebug(msg string) {
+	writeLog(logfile.Debug, msg)
+	if verbose {
+		fmt.Printf("[DEBUG] %s\n", msg)
+	}
+}
+
 func logInfo(msg string) {
-	infoColor.Printf("[INFO] %s\n", msg)
+	writeLog(logfile.Info, msg)
+	if !quiet {
+		infoColor.Printf("[INFO] %s\n", msg)
+	}
 }
 
 func logSuccess(msg string) {
-	successColor.Printf("[OK] %s\n", msg)
+	writeLog(logfile.Info, msg)
+	if !quiet {
+		successColor.Printf("[OK] %s\n", msg)
+	}
 }
 
 func logWarn(msg string) {
+	writeLog(logfile.Warn, msg)
 	warnColor.Printf("[WARN] %s\n", msg)
 }
 
 func logError(msg string) {
+	writeLog(logfile.Error, msg)
 	errorColor.Printf("[ERROR] %s\n", msg)
 }
+
+// opLog is this run's log file, nil until opened or if it can't be
+var opLog *logfile.Log
+
+// openLog starts logging to ~/.claude-sync/logs once the sync dir exists,
+// so commands run before init leave nothing behind
+func openLog(cmd *cobra.Command) {
+	paths := config.GetPaths()
+	if _, err := os.Stat(paths.SyncDir); err != nil {
+		return
+	}
+	l, err := logfile.Open(paths.LogDir, cmd.Name())
+	if err != nil {
+		return
+	}
+	opLog = l
+	logDebug(fmt.Sprintf("claude-code-sync %s (%s)", strings.Join(os.Args[1:], " "), version))
+}
+
+// writeLog appends a message to the log file, if one is open
+func writeLog(level, msg string) {
+	opLog.Write(level, msg)
+}
+
+func closeLog() {
+	opLog.Close()
+	opLog = nil
+}
+
+// eventOut receives NDJSON events with --events, nil otherwise
+var eventOut io.Writer
+
+// startEvents keeps stdout for events and sends all other output,
+// including command output and colored log lines, to stderr
+func startEvents() {
+	eventOut = os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = color.Error
+}
+
+// writeEvent writes an event as one JSON line, if --events is set
+func writeEvent(ev syncer.Event) {
+	if eventOut == nil {
+		return
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	eventOut.Write(append(line, '\n'))
+}
+
+// cliLogger routes engine progress messages through the CLI log helpers
+type cliLogger struct{}
+
+func (cliLogger) Debug(msg string)      { logDebug(msg) }
+func (cliLogger) Info(msg string)       { logInfo(msg) }
+func (cliLogger) Warn(msg string)       { logWarn(msg) }
+func (cliLogger) Success(msg string)    { logSuccess(msg) }
+func (cliLogger) Event(ev syncer.Event) { writeEvent(ev) }
+
+// newEngine creates a sync engine for the current user that logs to the terminal
+func newEngine() (*syncer.Engine, error) {
+	return syncer.Default(cliLogger{})
+}
+
+// gitBackend returns the git backend selected in config.yaml (auto if unset or unreadable)
+func gitBackend(paths config.Paths) string {
+	if cfg, err := config.Load(paths.ConfigFile); err == nil {
+		return cfg.GitBackend
+	}
+	return gitpkg.BackendAuto
+}
+
+// openRepo opens the sync repo with the configured git backend
+func openRepo(paths config.Paths) gitpkg.Repo {
+	return gitpkg.Open(paths.RepoDir, gitBackend(paths))
+}
+
+// acquireLock takes the sync lock for commands that change ~/.claude or the
+// repo outside the engine
+func acquireLock(paths config.Paths) (*lock.Lock, error) {
+	return lock.Acquire(paths.LockFile, 30*time.Second)
+}
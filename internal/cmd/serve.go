@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/control"
+	"github.com/felixisaac/claude-code-sync/internal/peer"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveSocket string
+	serveLAN    string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run as a daemon serving a local JSON-RPC control API",
+	Long: `Run in the foreground and serve a JSON-RPC 2.0 API on a unix socket
+(~/.claude-sync/control.sock, readable only by you) so editors, tray apps and
+other tools can drive sync without spawning CLI processes.
+
+Send one JSON object per line; each response is one line. Methods:
+  status                                  Drift, conflicts and remote state
+  push     {"dry_run": bool, "allow_secrets": bool, "pr": bool}
+  pull     {"dry_run": bool, "strategy": "theirs" | "ours"}
+  resolve  {"keep": "local" | "remote"}   Settle conflicts: "local" pulls
+                                          keeping local files, then pushes;
+                                          "remote" pulls replacing them
+
+While a call runs, progress is sent to the caller as "log" notifications
+({"level", "message"}) and "event" notifications (the --events objects).
+
+With --lan, the repo is also served over HTTP to other machines on the
+network that hold the same key; they pull from it with 'pull --peer <host>'.
+Requests and responses are signed with the key and the repo is sent
+age-encrypted. Requires the git CLI backend.
+
+Examples:
+  echo '{"jsonrpc":"2.0","id":1,"method":"status"}' | nc -U ~/.claude-sync/control.sock
+  claude-code-sync serve --lan              Also serve peers on port 8731
+  claude-code-sync serve --lan 192.168.1.5:9000`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Socket path (default: ~/.claude-sync/control.sock)")
+	serveCmd.Flags().StringVar(&serveLAN, "lan", "", "Serve the repo to LAN peers on this address")
+	serveCmd.Flags().Lookup("lan").NoOptDefVal = fmt.Sprintf(":%d", peer.DefaultPort)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	paths := config.GetPaths()
+	if !hasKey(paths) {
+		return fmt.Errorf("not initialized. Run 'claude-code-sync init' first")
+	}
+	socket := serveSocket
+	if socket == "" {
+		socket = paths.Socket
+	}
+
+	l, err := control.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	var lan *http.Server
+	if serveLAN != "" {
+		if lan, err = servePeers(serveLAN); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		logInfo("Shutting down...")
+		if lan != nil {
+			lan.Close()
+		}
+		l.Close()
+	}()
+
+	logSuccess(fmt.Sprintf("Listening on %s", socket))
+	return control.Serve(l, handleRPC)
+}
+
+// servePeers starts serving the repo to LAN peers on addr
+func servePeers(addr string) (*http.Server, error) {
+	engine, err := newEngine()
+	if err != nil {
+		return nil, err
+	}
+	handler, err := engine.PeerHandler()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logError(fmt.Sprintf("LAN server stopped: %v", err))
+		}
+	}()
+	logSuccess(fmt.Sprintf("Serving the repo to LAN peers on %s", ln.Addr()))
+	return srv, nil
+}
+
+// rpcLogger sends engine messages to the daemon's own output and log file,
+// and to the client whose call produced them
+type rpcLogger struct {
+	notify control.Notifier
+}
+
+// rpcLog is the params of a "log" notification
+type rpcLog struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func (l rpcLogger) Debug(msg string) {
+	logDebug(msg)
+	l.notify("log", rpcLog{"debug", msg})
+}
+
+func (l rpcLogger) Info(msg string) {
+	logInfo(msg)
+	l.notify("log", rpcLog{"info", msg})
+}
+
+func (l rpcLogger) Warn(msg string) {
+	logWarn(msg)
+	l.notify("log", rpcLog{"warn", msg})
+}
+
+func (l rpcLogger) Success(msg string) {
+	logSuccess(msg)
+	l.notify("log", rpcLog{"success", msg})
+}
+
+func (l rpcLogger) Event(ev syncer.Event) {
+	l.notify("event", ev)
+}
+
+// Method params and results
+type (
+	rpcPushParams struct {
+		DryRun       bool `json:"dry_run"`
+		AllowSecrets bool `json:"allow_secrets"`
+		PR           bool `json:"pr"`
+	}
+	rpcPullParams struct {
+		DryRun   bool            `json:"dry_run"`
+		Strategy syncer.Strategy `json:"strategy"`
+		Force    bool            `json:"force"`
+	}
+	rpcResolveParams struct {
+		Keep string `json:"keep"`
+	}
+
+	rpcPushResult struct {
+		Changed   []string `json:"changed"`
+		Committed bool     `json:"committed"`
+		Pushed    bool     `json:"pushed"`
+		Queued    bool     `json:"queued"`
+		Rebased   bool     `json:"rebased"`
+		Backup    string   `json:"backup,omitempty"`
+		PR        string   `json:"pull_request,omitempty"`
+	}
+	rpcPullResult struct {
+		Changed   []string `json:"changed"`
+		Conflicts []string `json:"conflicts"`
+		Backup    string   `json:"backup,omitempty"`
+	}
+	rpcResolveResult struct {
+		Pull *rpcPullResult `json:"pull"`
+		Push *rpcPushResult `json:"push,omitempty"`
+	}
+	rpcStatusResult struct {
+		Remote       syncer.RemoteState  `json:"remote"`
+		LocalCommit  string              `json:"local_commit,omitempty"`
+		RemoteCommit string              `json:"remote_commit,omitempty"`
+		Ahead        int                 `json:"ahead"`
+		Behind       int                 `json:"behind"`
+		Pending      *syncer.PendingPush `json:"pending,omitempty"`
+		Drift        []rpcChange         `json:"drift"`
+		Conflicts    []string            `json:"conflicts"`
+	}
+	rpcChange struct {
+		Path string            `json:"path"`
+		Kind syncer.ChangeKind `json:"kind"`
+	}
+)
+
+// handleRPC runs one control API call on a fresh engine, so config edits
+// apply without restarting the daemon
+func handleRPC(method string, params json.RawMessage, notify control.Notifier) (any, error) {
+	logDebug(fmt.Sprintf("RPC %s", method))
+	engine, err := syncer.Default(rpcLogger{notify})
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "status":
+		return rpcStatus(engine)
+	case "push":
+		var p rpcPushParams
+		if err := control.Decode(params, &p); err != nil {
+			return nil, err
+		}
+		return rpcPush(engine, syncer.PushOptions{DryRun: p.DryRun, AllowSecrets: p.AllowSecrets, PR: p.PR})
+	case "pull":
+		var p rpcPullParams
+		if err := control.Decode(params, &p); err != nil {
+			return nil, err
+		}
+		switch p.Strategy {
+		case "", syncer.StrategyTheirs, syncer.StrategyOurs:
+		default:
+			return nil, control.InvalidParams(fmt.Errorf("strategy must be theirs or ours"))
+		}
+		return rpcPull(engine, syncer.PullOptions{DryRun: p.DryRun, Strategy: p.Strategy, Force: p.Force})
+	case "resolve":
+		var p rpcResolveParams
+		if err := control.Decode(params, &p); err != nil {
+			return nil, err
+		}
+		return rpcResolve(engine, p.Keep)
+	}
+	return nil, control.ErrMethodNotFound
+}
+
+func rpcStatus(engine *syncer.Engine) (*rpcStatusResult, error) {
+	status, err := engine.Status()
+	if err != nil {
+		return nil, err
+	}
+	result := &rpcStatusResult{
+		Remote:       status.Remote,
+		LocalCommit:  status.LocalCommit,
+		RemoteCommit: status.RemoteCommit,
+		Ahead:        status.Ahead,
+		Behind:       status.Behind,
+		Pending:      status.Pending,
+		Drift:        []rpcChange{},
+		Conflicts:    []string{},
+	}
+	for _, c := range status.Drift {
+		result.Drift = append(result.Drift, rpcChange{Path: c.Path, Kind: c.Kind})
+	}
+	for _, c := range status.Conflicts {
+		result.Conflicts = append(result.Conflicts, c.Path)
+	}
+	return result, nil
+}
+
+func rpcPush(engine *syncer.Engine, opts syncer.PushOptions) (*rpcPushResult, error) {
+	result, err := engine.Push(opts)
+	if !opts.DryRun {
+		notifyPush(engine, false, result, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	changed := result.Changed
+	if opts.DryRun {
+		changed = nil
+		for _, f := range result.Files {
+			changed = append(changed, f.Path)
+		}
+	}
+	return &rpcPushResult{
+		Changed:   nonNil(changed),
+		Committed: result.Committed,
+		Pushed:    result.Pushed,
+		Queued:    result.Queued,
+		Rebased:   result.Rebased,
+		Backup:    result.BackupPath,
+		PR:        result.PullRequest,
+	}, nil
+}
+
+func rpcPull(engine *syncer.Engine, opts syncer.PullOptions) (*rpcPullResult, error) {
+	result, err := engine.Pull(opts)
+	if !opts.DryRun {
+		notifyPull(engine, result, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	changed := result.Changed
+	if opts.DryRun {
+		changed = nil
+		for _, f := range result.Files {
+			changed = append(changed, f.Path)
+		}
+	}
+	return &rpcPullResult{
+		Changed:   nonNil(changed),
+		Conflicts: nonNil(result.Conflicts),
+		Backup:    result.BackupPath,
+	}, nil
+}
+
+// rpcResolve settles files changed both locally and on the remote, the way
+// 'pull --ours && push' or 'pull --theirs' would
+func rpcResolve(engine *syncer.Engine, keep string) (*rpcResolveResult, error) {
+	switch keep {
+	case "remote":
+		pull, err := rpcPull(engine, syncer.PullOptions{Strategy: syncer.StrategyTheirs})
+		if err != nil {
+			return nil, err
+		}
+		return &rpcResolveResult{Pull: pull}, nil
+	case "local":
+		pull, err := rpcPull(engine, syncer.PullOptions{Strategy: syncer.StrategyOurs})
+		if err != nil {
+			return nil, err
+		}
+		push, err := rpcPush(engine, syncer.PushOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &rpcResolveResult{Pull: pull, Push: push}, nil
+	}
+	return nil, control.InvalidParams(fmt.Errorf("keep must be local or remote"))
+}
+
+// nonNil makes empty lists encode as [] rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareTo      string
+	shareMessage string
+	shareDryRun  bool
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <path>... --to <repo-url>",
+	Short: "Publish selected configs in plain text to another repo",
+	Long: `Copy chosen files or directories from ~/.claude, such as commands, agents or
+CLAUDE.md, in plain text to a separate repo (e.g. a public one) and push them
+as one commit. Paths are relative to ~/.claude and keep that layout in the
+target repo.
+
+Nothing secret is published: excluded files are skipped, files matching
+encrypt patterns are never shared, files with likely secrets (see
+secrets.allow for false positives) are held back, and redact rules are
+applied. Check with --dry-run first.
+
+Examples:
+  claude-code-sync share commands/review.md --to git@github.com:you/prompts.git
+  claude-code-sync share commands agents CLAUDE.md --to https://github.com/you/prompts --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runShare,
+}
+
+func init() {
+	shareCmd.Flags().StringVar(&shareTo, "to", "", "URL of the repo to publish to (required)")
+	shareCmd.Flags().StringVarP(&shareMessage, "message", "m", "", "Commit message")
+	shareCmd.Flags().BoolVar(&shareDryRun, "dry-run", false, "List what would be shared without pushing")
+	shareCmd.MarkFlagRequired("to")
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	if shareDryRun {
+		logInfo("[DRY RUN] Would share the following files:")
+	}
+	result, err := engine.Share(syncer.ShareOptions{
+		Paths:   args,
+		To:      shareTo,
+		Message: shareMessage,
+		DryRun:  shareDryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	held := len(result.Withheld) + len(result.Flagged)
+	if len(result.Flagged) > 0 {
+		logWarn("Remove the secrets, or allow false positives with secrets.allow in config.yaml, then share again.")
+	}
+	switch {
+	case len(result.Files) == 0:
+		return fmt.Errorf("nothing to share (%d file(s) held back)", held)
+	case shareDryRun:
+		logInfo(fmt.Sprintf("[DRY RUN] Would share %d file(s) to %s", len(result.Files), shareTo))
+	case !result.Committed:
+		logInfo(fmt.Sprintf("Nothing to commit: %s already has the same content.", shareTo))
+	default:
+		logSuccess(fmt.Sprintf("Shared %d file(s) to %s", len(result.Files), shareTo))
+	}
+	if held > 0 {
+		logWarn(fmt.Sprintf("%d file(s) were held back.", held))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var showAt string
+
+var showCmd = &cobra.Command{
+	Use:     "show <path>[@<commit>]",
+	Aliases: []string{"cat"},
+	Short:   "Print a decrypted file from the repo, now or at an earlier sync",
+	Long: `Decrypt and print a synced file from the repo history. Plaintext is never
+written to disk.
+
+The path is relative to ~/.claude ("claude.json" is ~/.claude.json). Pick the
+version with @<commit> (hash or HEAD~N) or with --at <date>, which uses the
+last sync at or before that time. Without either, the latest sync is shown;
+that is the only version available with the s3 and localdir backends.
+
+Dates: 2025-12-16, "2025-12-16 14:30", yesterday, tuesday, "last tuesday",
+"3 days ago", "2 weeks ago".
+
+Examples:
+  claude-code-sync show settings.json
+  claude-code-sync show CLAUDE.md@HEAD~3
+  claude-code-sync show settings.json --at "last tuesday"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShow,
+}
+
+func init() {
+	showCmd.Flags().StringVar(&showAt, "at", "", "Show the version from the last sync at or before this date")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	file, rev := args[0], ""
+	if i := strings.LastIndex(file, "@"); i > 0 && !strings.ContainsAny(file[i+1:], `/\`) {
+		file, rev = file[:i], file[i+1:]
+	}
+	if rev != "" && showAt != "" {
+		return fmt.Errorf("use either <path>@<commit> or --at, not both")
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	if showAt != "" {
+		when, err := parseWhen(showAt, time.Now())
+		if err != nil {
+			return err
+		}
+		rev, err = engine.CommitAt(when)
+		if err != nil {
+			return err
+		}
+	}
+
+	shown, err := engine.Show(file, rev)
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout clean for redirection; the revision goes to stderr
+	if shown.Commit != "" {
+		fmt.Fprintf(os.Stderr, "# %s @ %s\n", shown.Path, shortHash(shown.Commit))
+	} else {
+		fmt.Fprintf(os.Stderr, "# %s (latest sync)\n", shown.Path)
+	}
+	os.Stdout.Write(shown.Content)
+	return nil
+}
+
+// parseWhen parses an absolute or relative date. A bare day means the end of
+// that day, so "--at tuesday" includes syncs made on Tuesday.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	endOfDay := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 23, 59, 59, 0, t.Location())
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return endOfDay(t), nil
+	}
+
+	switch s {
+	case "now":
+		return now, nil
+	case "today":
+		return endOfDay(now), nil
+	case "yesterday":
+		return endOfDay(now.AddDate(0, 0, -1)), nil
+	}
+
+	// "tuesday" / "last tuesday": the most recent such day before today
+	day := strings.TrimPrefix(s, "last ")
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if day == strings.ToLower(wd.String()) {
+			back := (int(now.Weekday()) - int(wd) + 7) % 7
+			if back == 0 {
+				back = 7
+			}
+			return endOfDay(now.AddDate(0, 0, -back)), nil
+		}
+	}
+
+	// "3 days ago", "2 weeks ago", "5 hours ago"
+	if fields := strings.Fields(s); len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		if err == nil {
+			switch strings.TrimSuffix(fields[1], "s") {
+			case "minute":
+				return now.Add(-time.Duration(n) * time.Minute), nil
+			case "hour":
+				return now.Add(-time.Duration(n) * time.Hour), nil
+			case "day":
+				return now.AddDate(0, 0, -n), nil
+			case "week":
+				return now.AddDate(0, 0, -7*n), nil
+			case "month":
+				return now.AddDate(0, -n, 0), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse date %q (try 2025-12-16, yesterday, tuesday, or \"3 days ago\")", s)
+}
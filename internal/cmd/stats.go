@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show repo size, file counts, backup usage and sync times",
+	Long: `Report on the sync repo and this machine's sync data: repo size, synced
+files by class (encrypted or plain), disk used by backups, and when each
+machine last pushed and pulled with its average push and pull duration.
+
+Only the local repo is read. Other machines' times are as of the last pull,
+and are published with each machine's next push.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	stats, err := engine.Stats()
+	if err != nil {
+		return err
+	}
+
+	if stats.Repo != nil {
+		printRepoSize(stats.Repo)
+	} else {
+		fmt.Printf("Backend: %s\n", stats.Backend)
+	}
+	fmt.Println()
+
+	fmt.Println("Synced files:")
+	fmt.Printf("  Encrypted: %d (%s)\n", stats.EncryptedFiles, formatSize(stats.EncryptedBytes))
+	fmt.Printf("  Plain:     %d (%s)\n", stats.PlainFiles, formatSize(stats.PlainBytes))
+	fmt.Println()
+
+	fmt.Printf("Backups: %d snapshot(s), %s on disk\n", stats.Backups, formatSize(stats.BackupBytes))
+	fmt.Println()
+
+	fmt.Printf("  %-24s %-17s %-17s %-9s %s\n", "MACHINE", "LAST PUSH", "LAST PULL", "AVG PUSH", "AVG PULL")
+	for _, m := range stats.Machines {
+		marker := " "
+		if m.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s ", marker)
+		color.New(color.FgCyan).Printf("%-24s", m.Hostname)
+		fmt.Printf(" %-17s %-17s %-9s %s\n", formatSyncTime(m.LastPush), formatSyncTime(m.LastPull),
+			formatSyncDuration(m.Pushes.Count, m.Pushes.Average()), formatSyncDuration(m.Pulls.Count, m.Pulls.Average()))
+	}
+	fmt.Println()
+	fmt.Println("* this machine")
+	return nil
+}
+
+// formatSyncDuration renders an average sync time, or "-" if none were recorded
+func formatSyncDuration(count int, avg time.Duration) string {
+	if count == 0 {
+		return "-"
+	}
+	if avg < time.Second {
+		return fmt.Sprintf("%dms", avg.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", avg.Seconds())
+}
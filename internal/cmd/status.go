@@ -2,120 +2,163 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/fatih/color"
 	"github.com/felixisaac/claude-code-sync/internal/config"
-	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
-	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show sync status",
-	Long:  `Show the current sync status, including local and remote state.`,
-	RunE:  runStatus,
+	Long: `Show the current sync status: how the local repo compares with the remote,
+and how many files in ~/.claude are synced, excluded, changed since the last
+push or pull, or also changed on the remote (conflicts).
+
+Use --all to list every local and repo file.`,
+	RunE: runStatus,
 }
 
-func runStatus(cmd *cobra.Command, args []string) error {
-	paths := config.GetPaths()
+var statusAll bool
 
-	if !sync.FileExists(paths.RepoDir) {
-		return fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+func init() {
+	statusCmd.Flags().BoolVarP(&statusAll, "all", "a", false, "List every local and repo file")
+	statusCmd.Flags().BoolVar(&statusAll, "files", false, "Alias for --all")
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
 	}
 
-	// Load config
-	cfg, err := config.Load(paths.ConfigFile)
+	status, err := engine.Status()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
 	color.Cyan("=== claude-code-sync status ===")
 	fmt.Println()
 
-	g := gitpkg.New(paths.RepoDir)
-
-	// Check remote status
-	if g.HasRemote() {
-		g.Fetch()
-		localCommit, _ := g.GetLocalCommit()
-		remoteCommit, _ := g.GetRemoteCommit()
-
-		if localCommit == remoteCommit && localCommit != "" {
-			fmt.Print("Remote: ")
-			color.Green("Up to date")
-		} else if localCommit != "" && remoteCommit != "" {
-			fmt.Print("Remote: ")
-			local := localCommit
-			remote := remoteCommit
-			if len(local) > 7 {
-				local = local[:7]
-			}
-			if len(remote) > 7 {
-				remote = remote[:7]
-			}
-			color.Yellow("Out of sync (local: %s, remote: %s)", local, remote)
+	fmt.Print("Remote: ")
+	switch status.Remote {
+	case syncer.RemoteUpToDate:
+		color.Green("Up to date")
+	case syncer.RemoteOutOfSync:
+		if status.Ahead > 0 || status.Behind > 0 {
+			color.Yellow("Local repo is %d commit(s) ahead, %d behind origin", status.Ahead, status.Behind)
 		} else {
-			fmt.Print("Remote: ")
-			color.Yellow("Unknown state")
+			color.Yellow("Out of sync (local: %s, remote: %s)", shortHash(status.LocalCommit), shortHash(status.RemoteCommit))
 		}
-	} else {
-		fmt.Print("Remote: ")
+	case syncer.RemoteUnknown:
+		color.Yellow("Unknown state")
+	default:
 		color.Yellow("Not configured")
 	}
+	if p := status.Pending; p != nil {
+		color.Yellow("Push pending since %s (remote unreachable)", p.Since.Local().Format("2006-01-02 15:04"))
+	}
 
-	fmt.Println()
-	fmt.Println("Local files in ~/.claude:")
+	if latest := availableUpdate(); latest != "" {
+		color.Yellow("v%s available (run '%s')", latest, updateHint())
+	}
 
-	if sync.FileExists(paths.ClaudeDir) {
-		files, err := sync.WalkFiles(paths.ClaudeDir)
-		if err != nil {
-			return err
+	if engine.Config().MachineRole() == config.RolePullOnly {
+		color.Yellow("Pull-only machine: push is disabled")
+	}
+	if name, m := engine.Config().Machine(); m != nil {
+		fmt.Printf("Machine overrides: machines.%s\n", name)
+		if m.Push != nil && !*m.Push {
+			color.Yellow("  push disabled")
 		}
+		if engine.Config().CanPull() != nil {
+			color.Yellow("  pull disabled")
+		}
+	}
 
-		for _, file := range files {
-			relPath := sync.RelPath(paths.ClaudeDir, file)
-
-			if cfg.ShouldExclude(relPath) {
-				color.Yellow("  [excluded] %s", relPath)
-			} else if cfg.ShouldEncrypt(relPath) {
-				color.Cyan("  [encrypted] %s", relPath)
-			} else {
-				color.Green("  [plain] %s", relPath)
-			}
+	if engine.Config().WSL.Mode == "" {
+		if side := syncer.DetectWSL(); side != nil {
+			color.Yellow("Claude Code is also set up in %s (see 'claude-code-sync wsl')", side.Dir)
 		}
-	} else {
+	}
+
+	fmt.Println()
+	sum := status.Summary()
+	fmt.Printf("%d synced, %d excluded, %d changed, %d conflicts\n", sum.Synced, sum.Excluded, sum.Changed, sum.Conflicts)
+	printDrift(status.Drift, status.Conflicts)
+
+	if !statusAll {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("Local files in ~/.claude:")
+
+	if len(status.LocalFiles) == 0 {
 		fmt.Println("  (none)")
 	}
+	for _, f := range status.LocalFiles {
+		printFileStatus(f)
+	}
 
-	if sync.FileExists(paths.ClaudeJSON) {
+	if status.ClaudeJSON {
 		color.Cyan("  [encrypted] ~/.claude.json")
 	}
 
 	fmt.Println()
-	fmt.Printf("Repo files in %s:\n", paths.RepoDir)
+	fmt.Printf("Repo files in %s:\n", engine.Paths().RepoDir)
 
-	if sync.FileExists(paths.RepoDir) {
-		files, err := sync.WalkFiles(paths.RepoDir)
-		if err != nil {
-			return err
-		}
+	for _, f := range status.RepoFiles {
+		printFileStatus(f)
+	}
+
+	return nil
+}
 
-		for _, file := range files {
-			relPath := sync.RelPath(paths.RepoDir, file)
+// printFileStatus prints one file with a color matching its sync class
+func printFileStatus(f syncer.FileStatus) {
+	switch f.Class {
+	case syncer.ClassExcluded:
+		color.Yellow("  [excluded] %s", f.Path)
+	case syncer.ClassEncrypted:
+		color.Cyan("  [encrypted] %s", f.Path)
+	default:
+		color.Green("  [plain] %s", f.Path)
+	}
+}
 
-			if strings.HasPrefix(relPath, ".git") {
-				continue
-			}
+// printDrift lists local files that differ from the repo
+func printDrift(drift, conflicts []syncer.Change) {
+	if len(drift) == 0 {
+		color.Green("No local changes since last push or pull")
+		return
+	}
+	conflicted := map[string]bool{}
+	for _, c := range conflicts {
+		conflicted[c.Path] = true
+	}
 
-			if strings.HasSuffix(relPath, ".age") {
-				color.Cyan("  [encrypted] %s", relPath)
-			} else {
-				color.Green("  [plain] %s", relPath)
-			}
+	fmt.Println()
+	color.Yellow("%d file(s) in ~/.claude changed since last push or pull:", len(drift))
+	for _, c := range drift {
+		switch {
+		case conflicted[c.Path]:
+			color.Red("  [conflict] %s (also changed on the remote)", c.Path)
+		case c.Kind == syncer.ChangeAdded:
+			color.Green("  [added] %s", c.Path)
+		case c.Kind == syncer.ChangeNew:
+			color.Red("  [missing] %s", c.Path)
+		default:
+			color.Yellow("  [modified] %s", c.Path)
 		}
 	}
+}
 
-	return nil
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
 }
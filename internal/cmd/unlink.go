@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/felixisaac/claude-code-sync/internal/config"
-	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
 	"github.com/spf13/cobra"
 )
@@ -25,7 +24,11 @@ func runUnlink(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	g := gitpkg.New(paths.RepoDir)
+	if cfg, err := config.Load(paths.ConfigFile); err == nil && cfg.Backend != "git" {
+		return fmt.Errorf("unlink only applies to the git backend (current backend: %s)", cfg.Backend)
+	}
+
+	g := openRepo(paths)
 
 	if g.HasRemote() {
 		if err := g.RemoveRemote("origin"); err != nil {
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+)
+
+// updateCache is the result of the last update check, kept so status and
+// doctor can report a new release without waiting on the network
+type updateCache struct {
+	Checked time.Time `json:"checked"`
+	Source  string    `json:"source"`           // Where and for which channel/pin it looked
+	Latest  string    `json:"latest,omitempty"` // Newest matching version, without "v"
+	Error   string    `json:"error,omitempty"`
+}
+
+// source identifies the releases these settings select, so a cached result
+// is dropped when the channel, pin or repo changes
+func (s *updateSettings) source() string {
+	return fmt.Sprintf("%s/repos/%s (%s)", s.APIURL, s.Repo, s)
+}
+
+func readUpdateCache(paths config.Paths) *updateCache {
+	data, err := os.ReadFile(paths.UpdateCheck)
+	if err != nil {
+		return nil
+	}
+	c := &updateCache{}
+	if json.Unmarshal(data, c) != nil {
+		return nil
+	}
+	return c
+}
+
+func writeUpdateCache(paths config.Paths, c *updateCache) {
+	if data, err := json.MarshalIndent(c, "", "  "); err == nil {
+		_ = os.WriteFile(paths.UpdateCheck, data, 0644)
+	}
+}
+
+// recordUpdateCheck caches the outcome of looking for a release
+func recordUpdateCheck(settings *updateSettings, latest *githubRelease, err error) {
+	c := &updateCache{Checked: time.Now().UTC(), Source: settings.source()}
+	if err != nil {
+		c.Error = err.Error()
+		// Keep what the last successful check found
+		if old := readUpdateCache(config.GetPaths()); old != nil && old.Source == c.Source {
+			c.Latest = old.Latest
+		}
+	} else {
+		c.Latest = strings.TrimPrefix(latest.TagName, "v")
+	}
+	writeUpdateCache(config.GetPaths(), c)
+}
+
+// availableUpdate returns the newer version found by the last background
+// check, or "" if there is none or update.check is off. Once that check is
+// older than update.check_interval, a new one is started in the background
+// for next time.
+func availableUpdate() string {
+	paths := config.GetPaths()
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil || !cfg.Update.Check || version == "dev" {
+		return ""
+	}
+	settings, err := loadUpdateSettings()
+	if err != nil {
+		return ""
+	}
+
+	c := readUpdateCache(paths)
+	if c == nil || c.Source != settings.source() {
+		c = &updateCache{Source: settings.source()}
+	}
+	if time.Since(c.Checked) >= cfg.Update.CheckEvery() {
+		// Stamp the attempt first so commands run meanwhile don't start more
+		c.Checked = time.Now().UTC()
+		writeUpdateCache(paths, c)
+		if err := spawnUpdateCheck(); err != nil {
+			logDebug(fmt.Sprintf("Failed to start background update check: %v", err))
+		}
+	}
+
+	if c.Latest != "" && compareVersions(c.Latest, version) > 0 {
+		return c.Latest
+	}
+	return ""
+}
+
+// spawnUpdateCheck runs 'check-update --background' detached, so the
+// command that started it doesn't wait on GitHub
+func spawnUpdateCheck() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := profileArgs()
+	args = append(args, "check-update", "--background")
+
+	c := exec.Command(exe, args...)
+	detach(c)
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Process.Release()
+}
+
+// updateHint says how to install an available update
+func updateHint() string {
+	if pm := currentPackageManager(); pm != nil {
+		return pm.Upgrade
+	}
+	return "claude-code-sync update"
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var variantCmd = &cobra.Command{
+	Use:   "variant",
+	Short: "Keep this machine's own copy of a file",
+	Long: `Manage this machine's variants of synced files.
+
+A machine variant such as settings.workmac.json holds one machine's copy of
+settings.json, named after its hostname. On that machine it is restored as
+settings.json, taking precedence over the shared file and its platform
+variants, and push writes settings.json back to it. Other machines keep
+using the shared file, so the two no longer conflict.`,
+}
+
+var variantAddCmd = &cobra.Command{
+	Use:   "add <path>...",
+	Short: "Start a variant of files for this machine",
+	Long: `Start a variant of each file for this machine, as a copy of the shared one
+in the repo. Push afterwards to sync it; from then on, this machine's edits
+go to the variant.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVariantAdd,
+}
+
+var variantRemoveCmd = &cobra.Command{
+	Use:   "remove <path>...",
+	Short: "Go back to the shared files",
+	Long: `Delete this machine's variant of each file from the repo and restore the
+shared file in its place. The local copy is saved to backups/files first.
+Push afterwards to remove the variant from the remote.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVariantRemove,
+}
+
+var variantListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the files this machine has a variant of",
+	Args:  cobra.NoArgs,
+	RunE:  runVariantList,
+}
+
+func init() {
+	variantCmd.AddCommand(variantAddCmd)
+	variantCmd.AddCommand(variantRemoveCmd)
+	variantCmd.AddCommand(variantListCmd)
+}
+
+func runVariantAdd(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	added, err := engine.AddMachineVariant(args)
+	for _, variant := range added {
+		logSuccess(fmt.Sprintf("Added %s", variant))
+	}
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		logInfo("Nothing to add: this machine already has those variants.")
+		return nil
+	}
+	logInfo("Run 'claude-code-sync push' to sync it.")
+	return nil
+}
+
+func runVariantRemove(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	result, err := engine.RemoveMachineVariant(args)
+	if err != nil {
+		return err
+	}
+	for _, path := range result.Changed {
+		logInfo(fmt.Sprintf("Restored the shared %s", path))
+	}
+	logSuccess("Variants removed. Run 'claude-code-sync push' to sync the change.")
+	return nil
+}
+
+func runVariantList(cmd *cobra.Command, args []string) error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	files, err := engine.MachineVariants()
+	if err != nil {
+		return err
+	}
+	tag := sync.MachineTag(config.Hostname())
+	if len(files) == 0 {
+		fmt.Printf("No variants for this machine (%s)\n", tag)
+		return nil
+	}
+	for _, file := range files {
+		fmt.Printf("%s (%s)\n", file, sync.GetMachineVariantName(file, tag))
+	}
+	return nil
+}
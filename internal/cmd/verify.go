@@ -3,20 +3,45 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
+	"filippo.io/age"
 	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
 	"github.com/felixisaac/claude-code-sync/internal/sync"
+	"github.com/felixisaac/claude-code-sync/pkg/syncer"
 	"github.com/spf13/cobra"
 )
 
 var verifyCmd = &cobra.Command{
 	Use:   "verify",
 	Short: "Verify file integrity",
-	Long:  `Verify file integrity using SHA256 checksums from the manifest.`,
-	RunE:  runVerify,
+	Long: `Verify file integrity using SHA256 checksums from the manifest.
+
+With --deep, every encrypted (.age) file is also decrypted with your key, so a
+wrong or missing key shows up now rather than when you need to restore.
+
+With --local, the files in ~/.claude are compared with what the repo says
+should be there (after decryption), reporting modified, missing and extra
+files.`,
+	RunE: runVerify,
+}
+
+var (
+	verifyDeep  bool
+	verifyLocal bool
+)
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyDeep, "deep", false, "Also decrypt every .age file with the local key")
+	verifyCmd.Flags().BoolVar(&verifyLocal, "local", false, "Compare ~/.claude with the repo instead of checking the repo")
 }
 
 func runVerify(cmd *cobra.Command, args []string) error {
+	if verifyLocal {
+		return runVerifyLocal()
+	}
+
 	paths := config.GetPaths()
 	manifestPath := filepath.Join(paths.RepoDir, ".sync-manifest")
 
@@ -24,6 +49,18 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no manifest found. Run 'claude-code-sync push' first")
 	}
 
+	var identity *age.X25519Identity
+	if verifyDeep {
+		if !hasKey(paths) {
+			return fmt.Errorf("--deep needs a key. Run 'claude-code-sync import-key' first")
+		}
+		var err error
+		identity, err = loadIdentity(paths)
+		if err != nil {
+			return fmt.Errorf("failed to load key: %w", err)
+		}
+	}
+
 	logInfo("Verifying file integrity...")
 
 	entries, err := sync.ReadManifest(manifestPath)
@@ -51,6 +88,13 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		if actualChecksum != entry.Checksum {
 			logError(fmt.Sprintf("Checksum mismatch: %s", entry.Path))
 			errors++
+		} else if identity != nil && strings.HasSuffix(entry.Path, ".age") {
+			if err := crypto.CheckFile(identity, fullPath); err != nil {
+				logError(fmt.Sprintf("Cannot decrypt: %s (%v)", entry.Path, err))
+				errors++
+			} else {
+				logSuccess(fmt.Sprintf("OK (decrypted): %s", entry.Path))
+			}
 		} else {
 			logSuccess(fmt.Sprintf("OK: %s", entry.Path))
 		}
@@ -65,3 +109,35 @@ func runVerify(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runVerifyLocal reports files in ~/.claude that differ from the repo
+func runVerifyLocal() error {
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+
+	logInfo("Comparing ~/.claude with the repo...")
+	changes, err := engine.VerifyLocal()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		switch c.Kind {
+		case syncer.ChangeNew:
+			logError(fmt.Sprintf("Missing: %s", c.Path))
+		case syncer.ChangeAdded:
+			logWarn(fmt.Sprintf("Extra (not in repo): %s", c.Path))
+		default:
+			logError(fmt.Sprintf("Modified: %s", c.Path))
+		}
+	}
+
+	fmt.Println()
+	if len(changes) == 0 {
+		logSuccess("~/.claude matches the repo!")
+		return nil
+	}
+	return fmt.Errorf("%d file(s) differ from the repo", len(changes))
+}
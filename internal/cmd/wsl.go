@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var wslCmd = &cobra.Command{
+	Use:   "wsl [mirror|variants|off]",
+	Short: "Sync the Windows and WSL sides of this machine",
+	Long: `Show the Claude dir on the other side of WSL, or choose how it syncs.
+
+Inside WSL, the other side is the Windows user's ~/.claude under /mnt/c/Users;
+on Windows, it is the ~/.claude of a running WSL distro. Set wsl.path if it
+isn't found or the wrong one is.
+
+  mirror    keep both sides the same: push copies the other side's changes
+            over first, pull passes what it restored on. Paths in files
+            holding placeholders are translated.
+  variants  push the other side's files that differ as its platform's
+            variants (e.g. commands/deploy.windows.md); pull restores the
+            other side from them.
+  off       sync this side only`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{config.WSLMirror, config.WSLVariants, config.WSLOff},
+	RunE:      runWSL,
+}
+
+func runWSL(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		switch args[0] {
+		case config.WSLMirror, config.WSLVariants, config.WSLOff:
+		default:
+			return fmt.Errorf("unknown mode %q (use mirror, variants, or off)", args[0])
+		}
+		if err := config.SetValue(config.GetPaths().ConfigFile, "wsl.mode", args[0]); err != nil {
+			return err
+		}
+		logSuccess(fmt.Sprintf("Set wsl.mode to %s", args[0]))
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return err
+	}
+	mode := engine.Config().WSL.Mode
+	if mode == "" {
+		mode = config.WSLOff
+	}
+	fmt.Printf("Mode: %s\n", mode)
+	fmt.Print("Other side: ")
+	side, err := engine.WSLSide()
+	if err != nil {
+		color.Yellow("%v", err)
+		return nil
+	}
+	color.Green("%s (%s, %s)", side.Dir, side.Native, side.Platform)
+	if len(args) == 0 && mode == config.WSLOff {
+		fmt.Println("Run 'claude-code-sync wsl mirror' or 'claude-code-sync wsl variants' to sync it too.")
+	}
+	return nil
+}
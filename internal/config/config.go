@@ -1,49 +1,757 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"filippo.io/age"
 	"gopkg.in/yaml.v3"
 )
 
 // Paths returns all the standard paths used by claude-code-sync
 type Paths struct {
-	ClaudeDir  string // ~/.claude
-	ClaudeJSON string // ~/.claude.json
-	SyncDir    string // ~/.claude-sync
-	ConfigFile string // ~/.claude-sync/config.yaml
-	KeyFile    string // ~/.claude-sync/identity.key
-	RepoDir    string // ~/.claude-sync/repo
-	BackupDir  string // ~/.claude-sync/backups
-	LockFile   string // ~/.claude-sync/.lock
+	ClaudeDir    string // ~/.claude
+	ClaudeJSON   string // ~/.claude.json
+	SyncDir      string // ~/.claude-sync
+	ConfigFile   string // ~/.claude-sync/config.yaml
+	KeyFile      string // ~/.claude-sync/identity.key
+	RepoDir      string // ~/.claude-sync/repo
+	BackupDir    string // ~/.claude-sync/backups
+	LockFile     string // ~/.claude-sync/.lock
+	IndexFile    string // ~/.claude-sync/index.json
+	LogDir       string // ~/.claude-sync/logs
+	Socket       string // ~/.claude-sync/control.sock, served by 'serve'
+	UpdateCheck  string // ~/.claude-sync/update-check.json, the last background update check
+	SubsDir      string // ~/.claude-sync/subscriptions, one clone per subscription
+	OverlayFile  string // ~/.claude-sync/overlays.json, files installed from subscriptions
+	TeamDir      string // ~/.claude-sync/team, clone of the team repo
+	AcceptedFile string // ~/.claude-sync/accepted-recipients, the .recipients keys this machine encrypts to
 }
 
-// GetPaths returns the standard paths for the current user
+// Environment variables that change where things live
+const (
+	ProfileEnv         = "CLAUDE_SYNC_PROFILE" // Profile to use when --profile is not given
+	SyncDirEnv         = "CLAUDE_SYNC_DIR"     // Replaces ~/.claude-sync
+	ClaudeConfigDirEnv = "CLAUDE_CONFIG_DIR"   // Replaces ~/.claude, as in Claude Code
+)
+
+// profilesDir is the directory under ~/.claude-sync holding named profiles
+const profilesDir = "profiles"
+
+var (
+	activeProfile string
+	profileName   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// SetProfile selects the profile GetPaths resolves to. An empty name (or
+// "default") selects ~/.claude-sync itself.
+func SetProfile(name string) error {
+	if name == "default" {
+		name = ""
+	}
+	if name != "" && !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	activeProfile = name
+	return nil
+}
+
+// Profile returns the active profile name ("" for the default profile)
+func Profile() string {
+	return activeProfile
+}
+
+// ListProfiles returns the named profiles under ~/.claude-sync/profiles
+func ListProfiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(baseSyncDir(), profilesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profileName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IsProfilesDir reports whether name is the entry in ~/.claude-sync that
+// holds the named profiles
+func IsProfilesDir(name string) bool {
+	return name == profilesDir
+}
+
+// GetPaths returns the standard paths for the current user and active profile
 func GetPaths() Paths {
+	return ProfilePaths(activeProfile)
+}
+
+// ProfilePaths returns the paths for a profile. A named profile keeps its
+// sync directory in ~/.claude-sync/profiles/<name>; ~/.claude is shared.
+func ProfilePaths(profile string) Paths {
 	home, _ := os.UserHomeDir()
-	syncDir := filepath.Join(home, ".claude-sync")
+	syncDir := baseSyncDir()
+	if profile != "" {
+		syncDir = filepath.Join(syncDir, profilesDir, profile)
+	}
 
-	return Paths{
-		ClaudeDir:  filepath.Join(home, ".claude"),
-		ClaudeJSON: filepath.Join(home, ".claude.json"),
-		SyncDir:    syncDir,
-		ConfigFile: filepath.Join(syncDir, "config.yaml"),
-		KeyFile:    filepath.Join(syncDir, "identity.key"),
-		RepoDir:    filepath.Join(syncDir, "repo"),
-		BackupDir:  filepath.Join(syncDir, "backups"),
-		LockFile:   filepath.Join(syncDir, ".lock"),
+	paths := Paths{
+		ClaudeDir:    filepath.Join(home, ".claude"),
+		ClaudeJSON:   filepath.Join(home, ".claude.json"),
+		SyncDir:      syncDir,
+		ConfigFile:   filepath.Join(syncDir, "config.yaml"),
+		KeyFile:      filepath.Join(syncDir, "identity.key"),
+		RepoDir:      filepath.Join(syncDir, "repo"),
+		BackupDir:    filepath.Join(syncDir, "backups"),
+		LockFile:     filepath.Join(syncDir, ".lock"),
+		IndexFile:    filepath.Join(syncDir, "index.json"),
+		LogDir:       filepath.Join(syncDir, "logs"),
+		Socket:       filepath.Join(syncDir, "control.sock"),
+		UpdateCheck:  filepath.Join(syncDir, "update-check.json"),
+		SubsDir:      filepath.Join(syncDir, "subscriptions"),
+		OverlayFile:  filepath.Join(syncDir, "overlays.json"),
+		TeamDir:      filepath.Join(syncDir, "team"),
+		AcceptedFile: filepath.Join(syncDir, "accepted-recipients"),
 	}
+	paths.applyOverrides()
+	return paths
+}
+
+// baseSyncDir returns ~/.claude-sync, or $CLAUDE_SYNC_DIR if set
+func baseSyncDir() string {
+	if dir := os.Getenv(SyncDirEnv); dir != "" {
+		return absPath(ExpandHome(dir))
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude-sync")
+}
+
+// applyOverrides relocates the Claude directory and claude.json using the
+// paths: section of config.yaml, then CLAUDE_CONFIG_DIR (which wins, like it
+// does for Claude Code itself). A relocated Claude directory holds its own
+// .claude.json unless paths.claude_json says otherwise.
+func (p *Paths) applyOverrides() {
+	var file struct {
+		Paths PathsConfig `yaml:"paths"`
+	}
+	if data, err := os.ReadFile(p.ConfigFile); err == nil {
+		_ = yaml.Unmarshal(data, &file)
+	}
+
+	claudeDir := file.Paths.ClaudeDir
+	if dir := os.Getenv(ClaudeConfigDirEnv); dir != "" {
+		claudeDir = dir
+	}
+	if claudeDir != "" {
+		p.ClaudeDir = absPath(ExpandHome(claudeDir))
+		p.ClaudeJSON = filepath.Join(p.ClaudeDir, ".claude.json")
+	}
+	if file.Paths.ClaudeJSON != "" {
+		p.ClaudeJSON = absPath(ExpandHome(file.Paths.ClaudeJSON))
+	}
+}
+
+// absPath returns path made absolute and cleaned. Go only gives paths past
+// MAX_PATH the \\?\ extended-length prefix on Windows when they are
+// absolute, and deep skill trees under a relative CLAUDE_CONFIG_DIR would
+// otherwise fail to copy.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// ExpandHome replaces a leading ~ with the home directory
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// Hostname returns the machine name recorded in sync commits
+func Hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "unknown"
+	}
+	return name
 }
 
 // Config represents the user configuration file
 type Config struct {
 	EncryptPatterns []string `yaml:"encrypt_patterns,omitempty"`
 	ExcludePatterns []string `yaml:"exclude_patterns,omitempty"`
+	// IncludePatterns, if set, limits sync to the files they match; exclude
+	// patterns still apply within them
+	IncludePatterns []string `yaml:"include_patterns,omitempty"`
 	Backup          struct {
-		MaxCount int `yaml:"max_count,omitempty"`
+		MaxCount int    `yaml:"max_count,omitempty"`
+		OnPush   bool   `yaml:"on_push,omitempty"` // Also back up ~/.claude after each push that commits
+		Encrypt  string `yaml:"encrypt,omitempty"` // auto (default), always, or never
 	} `yaml:"backup,omitempty"`
+	GitBackend    string         `yaml:"git_backend,omitempty"`    // auto, cli, or go-git
+	GitBranches   string         `yaml:"git_branches,omitempty"`   // shared (default) or per-machine
+	PushMode      string         `yaml:"push_mode,omitempty"`      // direct (default) or review
+	Role          string         `yaml:"role,omitempty"`           // full (default) or pull-only
+	Validate      string         `yaml:"validate,omitempty"`       // json (default), schema, or off: checks on JSON files before pull installs them
+	ClaudeRunning string         `yaml:"claude_running,omitempty"` // warn (default), wait, refuse, or off: what pull does while Claude Code is running
+	EOL           string         `yaml:"eol,omitempty"`            // lf, crlf, or native: line endings of text files here, LF in the repo; default: files synced as they are
+	MaxFileSize   string         `yaml:"max_file_size,omitempty"`  // e.g. 50MB: larger files aren't pushed; default: no limit
+	BinaryFiles   string         `yaml:"binary_files,omitempty"`   // sync (default), warn, skip, or lfs: what push does with binary files
+	Forge         string         `yaml:"forge,omitempty"`          // github, gitlab, gitea, or bitbucket; default: from the remote's host
+	Backend       string         `yaml:"backend,omitempty"`        // git (default), s3, or localdir
+	S3            S3Config       `yaml:"s3,omitempty"`
+	LocalDir      LocalDirConfig `yaml:"localdir,omitempty"`
+	LFS           LFSConfig      `yaml:"lfs,omitempty"`
+
+	Paths      PathsConfig      `yaml:"paths,omitempty"`
+	ExtraPaths []ExtraPath      `yaml:"extra_paths,omitempty"`
+	Plugins    PluginsConfig    `yaml:"plugins,omitempty"`
+	ClaudeJSON ClaudeJSONConfig `yaml:"claude_json,omitempty"`
+	Secrets    SecretsConfig    `yaml:"secrets,omitempty"`
+	Hooks      HooksConfig      `yaml:"hooks,omitempty"`
+
+	Notifications NotificationsConfig `yaml:"notifications,omitempty"`
+	Update        UpdateConfig        `yaml:"update,omitempty"`
+
+	// KeyCommand prints the age private key, e.g. "op read op://Private/claude-sync/key",
+	// so identity.key doesn't have to exist. CLAUDE_SYNC_KEY takes precedence.
+	KeyCommand string `yaml:"key_command,omitempty"`
+
+	// Redact lists JSON keys stripped before push, as dotted paths
+	// ("oauthAccount.emailAddress"), optionally limited to one file
+	// ("settings.json:env.MY_TOKEN")
+	Redact []string `yaml:"redact,omitempty"`
+
+	// Placeholders picks which of this machine's values synced files store
+	// as placeholders, besides $CLAUDE_DIR
+	Placeholders PlaceholdersConfig `yaml:"placeholders,omitempty"`
+
+	// CanonicalJSON lists patterns of JSON files pushed in canonical form
+	// (sorted keys, two-space indent), so reformatting one isn't a change
+	CanonicalJSON []string `yaml:"canonical_json,omitempty"`
+
+	// Recipients encrypts matching files to more age public keys, e.g. a
+	// team's, as well as this machine's. The first matching rule applies.
+	Recipients []RecipientRule `yaml:"recipients,omitempty"`
+
+	// Subscriptions are repos whose files pull installs into ~/.claude as
+	// read-only overlays, below this machine's own files
+	Subscriptions []Subscription `yaml:"subscriptions,omitempty"`
+
+	// Team is a shared repo that files matching its patterns are pushed to
+	// and pulled from, instead of this machine's own repo
+	Team TeamConfig `yaml:"team,omitempty"`
+
+	// Machines overrides settings per hostname (exact name or glob, e.g. "ci-*")
+	Machines map[string]MachineConfig `yaml:"machines,omitempty"`
+
+	// WSL also syncs the other side's Claude dir on a machine running WSL:
+	// the Windows one from inside WSL, or the WSL one from Windows
+	WSL WSLConfig `yaml:"wsl,omitempty"`
+
+	// Platforms adds patterns per OS: windows, unix (macOS and Linux),
+	// darwin or linux
+	Platforms map[string]PlatformConfig `yaml:"platforms,omitempty"`
+
+	machine     *MachineConfig // Entry matching this machine, applied by Load
+	machineName string
+	ignore      *Ignore // .claudesyncignore files, set by UseIgnoreFiles
+}
+
+// RecipientRule lists the extra keys files matching Pattern are encrypted to.
+// Pattern works like an encrypt pattern, and matching files are encrypted
+// even if no encrypt pattern selects them.
+type RecipientRule struct {
+	Pattern string   `yaml:"pattern"`
+	Keys    []string `yaml:"keys"`
+}
+
+// Subscription is an external config pack, e.g. a team's shared commands
+type Subscription struct {
+	URL   string   `yaml:"url"`
+	Name  string   `yaml:"name,omitempty"`  // Default: the repo name from the URL
+	Paths []string `yaml:"paths,omitempty"` // Only these files or directories, e.g. commands
+}
+
+// RepoName returns the name a subscription is stored and reported
+// under
+func (s Subscription) RepoName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	name := strings.TrimSuffix(strings.TrimRight(s.URL, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// TeamConfig routes shared files to a team repo
+type TeamConfig struct {
+	URL      string   `yaml:"url,omitempty"`
+	Patterns []string `yaml:"patterns,omitempty"` // Like exclude patterns; default DefaultTeamPatterns
+}
+
+// DefaultTeamPatterns are the files a team repo holds when team.patterns is
+// not set
+var DefaultTeamPatterns = []string{"commands", "agents", "skills"}
+
+// PathsConfig relocates the files Claude Code uses. ~ expands to the home
+// directory; CLAUDE_CONFIG_DIR takes precedence over claude_dir.
+type PathsConfig struct {
+	ClaudeDir  string `yaml:"claude_dir,omitempty"`  // Default ~/.claude
+	ClaudeJSON string `yaml:"claude_json,omitempty"` // Default ~/.claude.json, or <claude_dir>/.claude.json
+}
+
+// Plugin sync modes
+const (
+	PluginsNone       = "none"   // Nothing under plugins/ is synced
+	PluginsOnlyConfig = "config" // Only the plugin and marketplace lists (default)
+	PluginsAll        = "all"    // All of plugins/, including installed plugin code
+)
+
+// pluginConfigFiles are the files under plugins/ synced in "config" mode
+var pluginConfigFiles = []string{
+	"plugins/installed_plugins.json",
+	"plugins/known_marketplaces.json",
+}
+
+// PluginsConfig controls how ~/.claude/plugins is synced
+type PluginsConfig struct {
+	Sync      string `yaml:"sync,omitempty"`      // none, config (default), or all
+	Reinstall bool   `yaml:"reinstall,omitempty"` // Run 'claude plugin install' for missing plugins after pull
+}
+
+// PlaceholdersConfig picks the values replaced with placeholders on push
+// and put back on pull, so paths written on one machine work on another
+type PlaceholdersConfig struct {
+	Home     *bool `yaml:"home,omitempty"`     // $HOME for the home dir; default true
+	User     bool  `yaml:"user,omitempty"`     // $USER for the username
+	Hostname bool  `yaml:"hostname,omitempty"` // $HOSTNAME for the machine name
+	Markdown bool  `yaml:"markdown,omitempty"` // Markdown files too, not just JSON
+	YAML     bool  `yaml:"yaml,omitempty"`     // YAML files too
+}
+
+// HomeEnabled reports whether the home dir is stored as $HOME
+func (c PlaceholdersConfig) HomeEnabled() bool {
+	return c.Home == nil || *c.Home
+}
+
+// Git branch modes
+const (
+	BranchesShared     = "shared"      // Every machine pushes to the same branch (default)
+	BranchesPerMachine = "per-machine" // Each machine pushes to sync/<hostname>; pull merges them all
+)
+
+// Machine roles
+const (
+	RoleFull     = "full"      // Push and pull (default)
+	RolePullOnly = "pull-only" // Never push: CI runners, shared servers
+)
+
+// Pull validation levels
+const (
+	ValidateJSON   = "json"   // JSON files must parse (default)
+	ValidateSchema = "schema" // settings.json and claude.json must also have the expected key types
+	ValidateOff    = "off"
+)
+
+// What pull does while Claude Code is running
+const (
+	ClaudeRunningWarn   = "warn"   // Pull, warning that the session has the old settings loaded (default)
+	ClaudeRunningWait   = "wait"   // Wait for every session to exit first
+	ClaudeRunningRefuse = "refuse" // Fail; pull --force overrides
+	ClaudeRunningOff    = "off"    // Don't check
+)
+
+// Line ending policies for text files on this machine
+const (
+	EOLLF     = "lf"
+	EOLCRLF   = "crlf"
+	EOLNative = "native" // CRLF on Windows, LF elsewhere
+)
+
+// LineEnding returns the line ending text files get on this machine, or ""
+// if eol is unset and files are synced as they are
+func (c *Config) LineEnding() string {
+	switch c.EOL {
+	case EOLLF:
+		return "\n"
+	case EOLCRLF:
+		return "\r\n"
+	case EOLNative:
+		if runtime.GOOS == "windows" {
+			return "\r\n"
+		}
+		return "\n"
+	}
+	return ""
+}
+
+// Binary file policies for push
+const (
+	BinarySync = "sync" // Push them like any other file (default)
+	BinaryWarn = "warn" // Push them, warning about each one
+	BinarySkip = "skip" // Leave them out
+	BinaryLFS  = "lfs"  // Store them with Git LFS
+)
+
+// LFSConfig stores large encrypted files with Git LFS, keeping them out of
+// the repo's git history
+type LFSConfig struct {
+	Threshold string `yaml:"threshold,omitempty"` // e.g. 10MB: .age files larger than this go through LFS; default: none do
+}
+
+// ThresholdBytes returns lfs.threshold in bytes, or 0 if unset
+func (l LFSConfig) ThresholdBytes() int64 {
+	n, _ := ParseSize(l.Threshold)
+	return n
+}
+
+// UsesLFS reports whether push stores any files with Git LFS
+func (c *Config) UsesLFS() bool {
+	return c.BinaryFiles == BinaryLFS || c.LFS.ThresholdBytes() > 0
+}
+
+// MaxFileBytes returns max_file_size in bytes, or 0 for no limit
+func (c *Config) MaxFileBytes() int64 {
+	n, _ := ParseSize(c.MaxFileSize)
+	return n
+}
+
+// ParseSize reads a size such as 500KB, 50MB or 2GB (powers of 1024), or a
+// plain number of bytes. An empty size is 0.
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	unit := int64(1)
+	number := strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+	if i := len(number) - 1; i > 0 && strings.IndexByte("KMGT", number[i]) >= 0 {
+		unit = int64(1) << (10 * (strings.IndexByte("KMGT", number[i]) + 1))
+		number = number[:i]
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 50MB)", s)
+	}
+	return int64(value * float64(unit)), nil
+}
+
+// Push modes
+const (
+	PushModeDirect = "direct" // Push sync commits to the shared branch (default)
+	PushModeReview = "review" // Push to review/<hostname> and open a pull request
+)
+
+// Backup encryption modes
+const (
+	BackupEncryptAuto   = "auto" // Encrypt when a backed-up file matches an encrypt pattern (default)
+	BackupEncryptAlways = "always"
+	BackupEncryptNever  = "never"
+)
+
+// Secret scan modes
+const (
+	SecretScanBlock = "block" // Refuse to push (default)
+	SecretScanWarn  = "warn"  // Push, but report findings
+	SecretScanOff   = "off"
+)
+
+// Desktop notification modes
+const (
+	DesktopNotifyAuto   = "auto"   // Only for automatic pushes started by Claude Code hooks (default)
+	DesktopNotifyAlways = "always" // Also for push and pull run by hand
+	DesktopNotifyOff    = "off"
+)
+
+// Update channels
+const (
+	ChannelStable = "stable" // Full releases only (default)
+	ChannelBeta   = "beta"   // Pre-releases too
+)
+
+// Background update checks (update.check)
+const (
+	DefaultUpdateCheckInterval = 24 * time.Hour
+	MinUpdateCheckInterval     = time.Hour // Keeps well under GitHub's unauthenticated rate limit
+)
+
+// pinPattern matches pin_version: a major, major.minor or exact version
+var pinPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}$`)
+
+// UpdateConfig controls which releases 'update' and 'check-update' offer
+// and where they come from
+type UpdateConfig struct {
+	Channel    string `yaml:"channel,omitempty"`     // stable (default) or beta
+	PinVersion string `yaml:"pin_version,omitempty"` // Only take versions within this, e.g. "1", "1.4" or "1.4.2"
+	APIURL     string `yaml:"api_url,omitempty"`     // GitHub API base, e.g. https://github.example.com/api/v3 (default: api.github.com)
+	Repo       string `yaml:"repo,omitempty"`        // owner/name of the repo or mirror publishing releases
+
+	Check         bool   `yaml:"check,omitempty"`          // Look for releases in the background and report them in status and doctor
+	CheckInterval string `yaml:"check_interval,omitempty"` // How often to look, e.g. "12h" (default: 24h)
+}
+
+// CheckEvery returns how often background update checks run
+func (u UpdateConfig) CheckEvery() time.Duration {
+	if d, err := time.ParseDuration(u.CheckInterval); err == nil {
+		return d
+	}
+	return DefaultUpdateCheckInterval
+}
+
+// NotifyEvents are the sync events webhooks can be sent for
+var NotifyEvents = []string{"push", "pull", "conflict", "failure"}
+
+// NotificationsConfig controls how sync results are reported outside the
+// terminal
+type NotificationsConfig struct {
+	Desktop       string   `yaml:"desktop,omitempty"`        // auto (default), always, or off
+	Webhook       string   `yaml:"webhook,omitempty"`        // URL that sync events are posted to
+	WebhookFormat string   `yaml:"webhook_format,omitempty"` // slack, discord, ntfy or json; guessed from the URL if unset
+	Events        []string `yaml:"events,omitempty"`         // Events to post (default: all of NotifyEvents)
+}
+
+// Wants reports whether event should be posted to the webhook
+func (c NotificationsConfig) Wants(event string) bool {
+	if c.Webhook == "" {
+		return false
+	}
+	return len(c.Events) == 0 || slices.Contains(c.Events, event)
+}
+
+// HooksConfig holds shell commands run around push and pull. A failing pre
+// hook aborts the operation; a failing post hook is only reported.
+type HooksConfig struct {
+	PrePush  string `yaml:"pre_push,omitempty"`
+	PostPush string `yaml:"post_push,omitempty"`
+	PrePull  string `yaml:"pre_pull,omitempty"`
+	PostPull string `yaml:"post_pull,omitempty"`
+}
+
+// SecretsConfig controls the scan for credentials in plain-text files before push
+type SecretsConfig struct {
+	Scan  string   `yaml:"scan,omitempty"`  // block (default), warn, or off
+	Allow []string `yaml:"allow,omitempty"` // Files never scanned (exclude pattern syntax)
+}
+
+// ScanAllowed reports whether a file is exempt from secret scanning
+func (c SecretsConfig) ScanAllowed(relPath string) bool {
+	for _, pattern := range c.Allow {
+		if ExcludePatternMatches(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaudeJSONConfig limits which top-level keys of ~/.claude.json are synced.
+// With neither list set, the whole file is synced and replaced on pull;
+// otherwise only the selected keys are pushed and merged into the local file.
+//
+// Machine-local state (project history, startup counters, caches) is
+// stripped on push and kept as it is on pull, like redacted keys.
+//
+// With MCPServers, mcpServers is synced on its own in plain text with its
+// secrets encrypted value by value, and Skip leaves the rest of the file
+// out of sync entirely.
+type ClaudeJSONConfig struct {
+	Include    []string `yaml:"include,omitempty"`     // Only sync these keys
+	Exclude    []string `yaml:"exclude,omitempty"`     // Never sync these keys
+	Sanitize   *bool    `yaml:"sanitize,omitempty"`    // false: sync machine-local state too
+	LocalKeys  []string `yaml:"local_keys,omitempty"`  // Machine-local key paths; default DefaultClaudeJSONLocalKeys
+	MCPServers bool     `yaml:"mcp_servers,omitempty"` // Sync mcpServers separately, encrypting env and header values
+	Skip       bool     `yaml:"skip,omitempty"`        // Don't sync claude.json itself (mcp_servers still applies)
+}
+
+// DefaultClaudeJSONLocalKeys are the claude.json keys Claude Code rewrites
+// on its own and that differ on every machine
+var DefaultClaudeJSONLocalKeys = []string{
+	"projects",
+	"numStartups",
+	"firstStartTime",
+	"installMethod",
+	"tipsHistory",
+	"promptQueueUseCount",
+	"memoryUsageCount",
+	"cachedChangelog",
+	"changelogLastFetched",
+	"lastReleaseNotesSeen",
+	"lastOnboardingVersion",
+	"cachedStatsigGates",
+	"cachedDynamicConfigs",
+	"cachedGrowthBookFeatures",
+	"subscriptionNoticeCount",
+	"hasAvailableSubscription",
+	"fallbackAvailableWarningThreshold",
+	"feedbackSurveyState",
+	"s1mAccessCache",
+}
+
+// MachineLocal returns the key paths of machine-local state, as dotted paths
+func (c ClaudeJSONConfig) MachineLocal() []string {
+	if c.Sanitize != nil && !*c.Sanitize {
+		return nil
+	}
+	if len(c.LocalKeys) > 0 {
+		return c.LocalKeys
+	}
+	return DefaultClaudeJSONLocalKeys
+}
+
+// Filtered reports whether claude.json is synced by key
+func (c ClaudeJSONConfig) Filtered() bool {
+	return len(c.Include) > 0 || len(c.Exclude) > 0
+}
+
+// Synced reports whether a top-level claude.json key is synced
+func (c ClaudeJSONConfig) Synced(key string) bool {
+	for _, k := range c.Exclude {
+		if k == key {
+			return false
+		}
+	}
+	if len(c.Include) == 0 {
+		return true
+	}
+	for _, k := range c.Include {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtraPath is a file or directory outside ~/.claude that is synced too.
+// Paths may start with ~ or use the $HOME, $CONFIG_DIR (~/.config,
+// ~/Library/Application Support or %AppData%) and $CLAUDE_DIR placeholders;
+// windows/darwin/linux replace path on that OS.
+type ExtraPath struct {
+	Name    string `yaml:"name,omitempty"`    // Folder in the repo; derived from path if empty
+	Path    string `yaml:"path,omitempty"`    // Required
+	Windows string `yaml:"windows,omitempty"` // Path on Windows
+	Darwin  string `yaml:"darwin,omitempty"`  // Path on macOS
+	Linux   string `yaml:"linux,omitempty"`   // Path on Linux
+	Encrypt bool   `yaml:"encrypt,omitempty"` // Encrypt even if no encrypt pattern matches
+}
+
+// RepoName returns the folder under extra-paths/ in the repo holding this
+// entry, which is the same on every OS
+func (x ExtraPath) RepoName() string {
+	if x.Name != "" {
+		return x.Name
+	}
+	name := filepath.ToSlash(x.Path)
+	for _, prefix := range []string{"~/", "$HOME/"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return strings.Trim(strings.ReplaceAll(name, "$", ""), "/")
+}
+
+// LocalPath returns where this entry lives on the current OS
+func (x ExtraPath) LocalPath(claudeDir string) string {
+	path := x.Path
+	switch runtime.GOOS {
+	case "windows":
+		path = firstNonEmpty(x.Windows, path)
+	case "darwin":
+		path = firstNonEmpty(x.Darwin, path)
+	case "linux":
+		path = firstNonEmpty(x.Linux, path)
+	}
+
+	home, _ := os.UserHomeDir()
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = filepath.Join(home, ".config")
+	}
+	path = strings.NewReplacer("$HOME", home, "$CONFIG_DIR", configDir, "$CLAUDE_DIR", claudeDir).Replace(path)
+	return filepath.Clean(ExpandHome(path))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// WSLConfig sets up syncing the Windows and WSL sides of one machine
+type WSLConfig struct {
+	Mode string `yaml:"mode,omitempty"` // off, mirror, or variants; unset: off, with a hint when the other side is found
+	Path string `yaml:"path,omitempty"` // The other side's Claude dir; default: detected
+}
+
+// WSL dual sync modes
+const (
+	WSLOff      = "off"      // Only this side is synced (default)
+	WSLMirror   = "mirror"   // The two sides are kept identical, paths aside
+	WSLVariants = "variants" // The other side is synced as its platform's variants
+)
+
+// PlatformConfig holds the patterns that only apply on one OS
+type PlatformConfig struct {
+	EncryptPatterns []string `yaml:"encrypt_patterns,omitempty"` // Added to the global list
+	ExcludePatterns []string `yaml:"exclude_patterns,omitempty"` // Added to the global list
+}
+
+// PlatformKeys are the keys allowed under platforms:
+var PlatformKeys = []string{"windows", "unix", "darwin", "linux"}
+
+// CurrentPlatforms returns the platforms: keys that apply on this OS
+func CurrentPlatforms() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"windows"}
+	}
+	return []string{"unix", runtime.GOOS}
+}
+
+// MachineConfig holds the settings that can differ between machines
+type MachineConfig struct {
+	EncryptPatterns []string `yaml:"encrypt_patterns,omitempty"` // Added to the global list
+	ExcludePatterns []string `yaml:"exclude_patterns,omitempty"` // Added to the global list
+	Role            string   `yaml:"role,omitempty"`             // Replaces the top-level role
+	Push            *bool    `yaml:"push,omitempty"`             // false: never push from this machine
+	Pull            *bool    `yaml:"pull,omitempty"`             // false: never pull onto this machine
+}
+
+// S3Config configures the S3-compatible storage backend.
+// Credentials are read from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint,omitempty"`   // Defaults to AWS for the region
+	Region    string `yaml:"region,omitempty"`     // Defaults to us-east-1
+	Bucket    string `yaml:"bucket,omitempty"`     // Required
+	Prefix    string `yaml:"prefix,omitempty"`     // Key prefix inside the bucket
+	PathStyle bool   `yaml:"path_style,omitempty"` // Use bucket-in-path URLs (MinIO, some self-hosted stores)
+}
+
+// LocalDirConfig configures the plain directory backend (USB drive, network
+// share, Syncthing folder, ...)
+type LocalDirConfig struct {
+	Path string `yaml:"path,omitempty"` // Required; ~ expands to the home directory
 }
 
 // DefaultEncryptPatterns are files that should be encrypted
@@ -91,6 +799,15 @@ func Load(path string) (*Config, error) {
 			cfg.EncryptPatterns = DefaultEncryptPatterns
 			cfg.ExcludePatterns = DefaultExcludePatterns
 			cfg.Backup.MaxCount = 5
+			cfg.Backup.Encrypt = BackupEncryptAuto
+			cfg.GitBackend = "auto"
+			cfg.GitBranches = BranchesShared
+			cfg.Backend = "git"
+			cfg.Plugins.Sync = PluginsOnlyConfig
+			cfg.Secrets.Scan = SecretScanBlock
+			cfg.Notifications.Desktop = DesktopNotifyAuto
+			cfg.Update.Channel = ChannelStable
+			cfg.BinaryFiles = BinarySync
 			return cfg, nil
 		}
 		return nil, err
@@ -110,72 +827,461 @@ func Load(path string) (*Config, error) {
 	if cfg.Backup.MaxCount == 0 {
 		cfg.Backup.MaxCount = 5
 	}
+	switch cfg.Backup.Encrypt {
+	case "":
+		cfg.Backup.Encrypt = BackupEncryptAuto
+	case BackupEncryptAuto, BackupEncryptAlways, BackupEncryptNever:
+	default:
+		return nil, fmt.Errorf("invalid backup.encrypt %q (expected auto, always, or never)", cfg.Backup.Encrypt)
+	}
+	switch cfg.GitBackend {
+	case "":
+		cfg.GitBackend = "auto"
+	case "auto", "cli", "go-git":
+	default:
+		return nil, fmt.Errorf("invalid git_backend %q (expected auto, cli, or go-git)", cfg.GitBackend)
+	}
+	switch cfg.GitBranches {
+	case "":
+		cfg.GitBranches = BranchesShared
+	case BranchesShared, BranchesPerMachine:
+	default:
+		return nil, fmt.Errorf("invalid git_branches %q (expected shared or per-machine)", cfg.GitBranches)
+	}
+	switch cfg.PushMode {
+	case "":
+		cfg.PushMode = PushModeDirect
+	case PushModeDirect, PushModeReview:
+	default:
+		return nil, fmt.Errorf("invalid push_mode %q (expected direct or review)", cfg.PushMode)
+	}
+	switch cfg.Validate {
+	case "":
+		cfg.Validate = ValidateJSON
+	case ValidateJSON, ValidateSchema, ValidateOff:
+	default:
+		return nil, fmt.Errorf("invalid validate %q (expected json, schema, or off)", cfg.Validate)
+	}
+	switch cfg.EOL {
+	case "", EOLLF, EOLCRLF, EOLNative:
+	default:
+		return nil, fmt.Errorf("invalid eol %q (expected lf, crlf, or native)", cfg.EOL)
+	}
+	if _, err := ParseSize(cfg.MaxFileSize); err != nil {
+		return nil, fmt.Errorf("invalid max_file_size: %w", err)
+	}
+	if _, err := ParseSize(cfg.LFS.Threshold); err != nil {
+		return nil, fmt.Errorf("invalid lfs.threshold: %w", err)
+	}
+	switch cfg.BinaryFiles {
+	case "":
+		cfg.BinaryFiles = BinarySync
+	case BinarySync, BinaryWarn, BinarySkip, BinaryLFS:
+	default:
+		return nil, fmt.Errorf("invalid binary_files %q (expected sync, warn, skip, or lfs)", cfg.BinaryFiles)
+	}
+	switch cfg.WSL.Mode {
+	case "", WSLOff, WSLMirror, WSLVariants:
+	default:
+		return nil, fmt.Errorf("invalid wsl.mode %q (expected off, mirror, or variants)", cfg.WSL.Mode)
+	}
+	switch cfg.ClaudeRunning {
+	case "":
+		cfg.ClaudeRunning = ClaudeRunningWarn
+	case ClaudeRunningWarn, ClaudeRunningWait, ClaudeRunningRefuse, ClaudeRunningOff:
+	default:
+		return nil, fmt.Errorf("invalid claude_running %q (expected warn, wait, refuse, or off)", cfg.ClaudeRunning)
+	}
+	if err := validRole(cfg.Role, "role"); err != nil {
+		return nil, err
+	}
+	for name, m := range cfg.Machines {
+		if err := validRole(m.Role, "machines."+name+".role"); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.PushMode == PushModeReview && cfg.GitBranches == BranchesPerMachine {
+		return nil, fmt.Errorf("push_mode: review cannot be combined with git_branches: per-machine")
+	}
+	switch cfg.Forge {
+	case "", "github", "gitlab", "gitea", "bitbucket":
+	default:
+		return nil, fmt.Errorf("invalid forge %q (expected github, gitlab, gitea, or bitbucket)", cfg.Forge)
+	}
+	switch cfg.Backend {
+	case "":
+		cfg.Backend = "git"
+	case "git":
+	case "s3":
+		if cfg.S3.Bucket == "" {
+			return nil, fmt.Errorf("backend: s3 requires s3.bucket")
+		}
+	case "localdir":
+		if cfg.LocalDir.Path == "" {
+			return nil, fmt.Errorf("backend: localdir requires localdir.path")
+		}
+	default:
+		return nil, fmt.Errorf("invalid backend %q (expected git, s3, or localdir)", cfg.Backend)
+	}
+
+	switch cfg.Plugins.Sync {
+	case "":
+		cfg.Plugins.Sync = PluginsOnlyConfig
+	case PluginsNone, PluginsOnlyConfig, PluginsAll:
+	default:
+		return nil, fmt.Errorf("invalid plugins.sync %q (expected none, config, or all)", cfg.Plugins.Sync)
+	}
+
+	switch cfg.Secrets.Scan {
+	case "":
+		cfg.Secrets.Scan = SecretScanBlock
+	case SecretScanBlock, SecretScanWarn, SecretScanOff:
+	default:
+		return nil, fmt.Errorf("invalid secrets.scan %q (expected block, warn, or off)", cfg.Secrets.Scan)
+	}
+
+	switch cfg.Notifications.Desktop {
+	case "":
+		cfg.Notifications.Desktop = DesktopNotifyAuto
+	case DesktopNotifyAuto, DesktopNotifyAlways, DesktopNotifyOff:
+	default:
+		return nil, fmt.Errorf("invalid notifications.desktop %q (expected auto, always, or off)", cfg.Notifications.Desktop)
+	}
+	switch cfg.Notifications.WebhookFormat {
+	case "", "slack", "discord", "ntfy", "json":
+	default:
+		return nil, fmt.Errorf("invalid notifications.webhook_format %q (expected slack, discord, ntfy, or json)", cfg.Notifications.WebhookFormat)
+	}
+	if w := cfg.Notifications.Webhook; w != "" && !strings.HasPrefix(w, "https://") && !strings.HasPrefix(w, "http://") {
+		return nil, fmt.Errorf("notifications.webhook must be an http(s) URL")
+	}
+	switch cfg.Update.Channel {
+	case "":
+		cfg.Update.Channel = ChannelStable
+	case ChannelStable, ChannelBeta:
+	default:
+		return nil, fmt.Errorf("invalid update.channel %q (expected stable or beta)", cfg.Update.Channel)
+	}
+	if p := cfg.Update.PinVersion; p != "" && !pinPattern.MatchString(p) {
+		return nil, fmt.Errorf("invalid update.pin_version %q (expected a version such as 1, 1.4 or 1.4.2)", p)
+	}
+	if u := cfg.Update.APIURL; u != "" && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		return nil, fmt.Errorf("update.api_url must be an http(s) URL")
+	}
+	if r := cfg.Update.Repo; r != "" && strings.Count(r, "/") != 1 {
+		return nil, fmt.Errorf("invalid update.repo %q (expected owner/name)", r)
+	}
+	if i := cfg.Update.CheckInterval; i != "" {
+		if d, err := time.ParseDuration(i); err != nil || d < MinUpdateCheckInterval {
+			return nil, fmt.Errorf("invalid update.check_interval %q (expected a duration of at least 1h, e.g. 24h)", i)
+		}
+	}
+	for _, event := range cfg.Notifications.Events {
+		if !slices.Contains(NotifyEvents, event) {
+			return nil, fmt.Errorf("invalid notifications.events entry %q (expected %s)", event, strings.Join(NotifyEvents, ", "))
+		}
+	}
+
+	for _, rule := range cfg.Redact {
+		key := rule
+		if _, k, ok := strings.Cut(rule, ":"); ok {
+			key = k
+		}
+		if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+			return nil, fmt.Errorf("redact: invalid key path %q", rule)
+		}
+	}
+	for _, key := range cfg.ClaudeJSON.LocalKeys {
+		if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+			return nil, fmt.Errorf("claude_json.local_keys: invalid key path %q", key)
+		}
+	}
+
+	for i, rule := range cfg.Recipients {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("recipients[%d]: pattern is required", i)
+		}
+		if len(rule.Keys) == 0 {
+			return nil, fmt.Errorf("recipients[%d]: keys is required", i)
+		}
+		for _, key := range rule.Keys {
+			if _, err := age.ParseX25519Recipient(key); err != nil {
+				return nil, fmt.Errorf("recipients[%d]: invalid public key %q", i, key)
+			}
+		}
+	}
+
+	subs := map[string]bool{}
+	for i, sub := range cfg.Subscriptions {
+		if sub.URL == "" {
+			return nil, fmt.Errorf("subscriptions[%d]: url is required", i)
+		}
+		name := sub.RepoName()
+		if !profileName.MatchString(name) {
+			return nil, fmt.Errorf("subscriptions[%d]: cannot use %q as a name; set name (letters, digits, '.', '-' and '_')", i, name)
+		}
+		if subs[name] {
+			return nil, fmt.Errorf("subscriptions: name %q is used twice", name)
+		}
+		subs[name] = true
+	}
+
+	if cfg.Team.URL == "" && len(cfg.Team.Patterns) > 0 {
+		return nil, fmt.Errorf("team.patterns requires team.url")
+	}
+	if cfg.Team.URL != "" && len(cfg.Team.Patterns) == 0 {
+		cfg.Team.Patterns = DefaultTeamPatterns
+	}
+
+	names := map[string]bool{}
+	for i, x := range cfg.ExtraPaths {
+		if x.Path == "" {
+			return nil, fmt.Errorf("extra_paths[%d]: path is required", i)
+		}
+		name := x.RepoName()
+		if name == "" || name == "." || strings.HasPrefix(name, "../") || strings.Contains(name, "/../") {
+			return nil, fmt.Errorf("extra_paths[%d]: cannot derive a name from %q; set name", i, x.Path)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("extra_paths: name %q is used twice", name)
+		}
+		names[name] = true
+	}
+
+	cfg.machineName, cfg.machine = cfg.MatchMachine(Hostname())
 
 	return cfg, nil
 }
 
+// MatchMachine returns the machines entry for hostname. An exact
+// (case-insensitive) name wins over globs; among globs the first in sorted
+// order is used.
+func (c *Config) MatchMachine(hostname string) (string, *MachineConfig) {
+	hostname = strings.ToLower(hostname)
+	names := make([]string, 0, len(c.Machines))
+	for name := range c.Machines {
+		if strings.ToLower(name) == hostname {
+			m := c.Machines[name]
+			return name, &m
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ok, _ := filepath.Match(strings.ToLower(name), hostname); ok {
+			m := c.Machines[name]
+			return name, &m
+		}
+	}
+	return "", nil
+}
+
+// Machine returns the machines entry applied to this machine, if any
+func (c *Config) Machine() (string, *MachineConfig) {
+	return c.machineName, c.machine
+}
+
+// validRole checks a role setting; key names it in the error
+func validRole(role, key string) error {
+	switch role {
+	case "", RoleFull, RolePullOnly:
+		return nil
+	}
+	return fmt.Errorf("invalid %s %q (expected full or pull-only)", key, role)
+}
+
+// MachineRole returns this machine's role: its machines entry's, else the
+// top-level one, else full
+func (c *Config) MachineRole() string {
+	if c.machine != nil && c.machine.Role != "" {
+		return c.machine.Role
+	}
+	if c.Role != "" {
+		return c.Role
+	}
+	return RoleFull
+}
+
+// CanPush reports an error if this machine is configured never to push
+func (c *Config) CanPush() error {
+	if c.MachineRole() == RolePullOnly {
+		if c.machine != nil && c.machine.Role != "" {
+			return fmt.Errorf("this machine is pull-only (machines.%s.role in config.yaml); it never pushes", c.machineName)
+		}
+		return fmt.Errorf("this machine is pull-only (role in config.yaml); it never pushes")
+	}
+	if c.machine != nil && c.machine.Push != nil && !*c.machine.Push {
+		return fmt.Errorf("push is disabled on this machine (machines.%s.push: false in config.yaml)", c.machineName)
+	}
+	return nil
+}
+
+// CanPull reports an error if this machine is configured never to pull
+func (c *Config) CanPull() error {
+	if c.machine != nil && c.machine.Pull != nil && !*c.machine.Pull {
+		return fmt.Errorf("pull is disabled on this machine (machines.%s.pull: false in config.yaml)", c.machineName)
+	}
+	return nil
+}
+
+// EffectiveEncryptPatterns returns the encrypt patterns including this
+// platform's and this machine's additions
+func (c *Config) EffectiveEncryptPatterns() []string {
+	patterns := c.EncryptPatterns
+	for _, p := range CurrentPlatforms() {
+		patterns = append(slices.Clip(patterns), c.Platforms[p].EncryptPatterns...)
+	}
+	if c.machine != nil {
+		patterns = append(slices.Clip(patterns), c.machine.EncryptPatterns...)
+	}
+	return patterns
+}
+
+// EffectiveExcludePatterns returns the exclude patterns including this
+// platform's and this machine's additions
+func (c *Config) EffectiveExcludePatterns() []string {
+	patterns := c.ExcludePatterns
+	for _, p := range CurrentPlatforms() {
+		patterns = append(slices.Clip(patterns), c.Platforms[p].ExcludePatterns...)
+	}
+	if c.machine != nil {
+		patterns = append(slices.Clip(patterns), c.machine.ExcludePatterns...)
+	}
+	return patterns
+}
+
 // ShouldEncrypt checks if a file should be encrypted
 func (c *Config) ShouldEncrypt(relPath string) bool {
-	filename := filepath.Base(relPath)
-	relPathNorm := filepath.ToSlash(relPath)
-
-	for _, pattern := range c.EncryptPatterns {
-		if strings.Contains(pattern, "*") {
-			// Wildcard pattern
-			if matchWildcard(filename, pattern) || matchWildcard(relPathNorm, pattern) {
-				return true
-			}
-		} else {
-			// Exact match
-			if filename == pattern {
-				return true
-			}
+	for _, pattern := range c.EffectiveEncryptPatterns() {
+		if EncryptPatternMatches(pattern, relPath) {
+			return true
+		}
+	}
+	return c.RecipientsFor(relPath) != nil
+}
+
+// RecipientsFor returns the extra public keys a file is encrypted to, from
+// the first matching recipients rule, or nil if none matches
+func (c *Config) RecipientsFor(relPath string) []string {
+	for _, rule := range c.Recipients {
+		if EncryptPatternMatches(rule.Pattern, relPath) {
+			return rule.Keys
+		}
+	}
+	return nil
+}
+
+// IsTeamFile reports whether a file is synced through the team repo. Files
+// that are encrypted always stay in this machine's own repo.
+func (c *Config) IsTeamFile(relPath string) bool {
+	if c.Team.URL == "" || c.ShouldEncrypt(relPath) {
+		return false
+	}
+	for _, pattern := range c.Team.Patterns {
+		if ExcludePatternMatches(pattern, relPath) {
+			return true
 		}
 	}
 	return false
 }
 
-// ShouldExclude checks if a file should be excluded from sync
+// UseIgnoreFiles makes ShouldExclude apply the .claudesyncignore files under
+// claudeDir
+func (c *Config) UseIgnoreFiles(claudeDir string) {
+	c.ignore = NewIgnore(claudeDir)
+}
+
+// Included reports whether include_patterns lets a file be synced: it is
+// unset, or one of them matches
+func (c *Config) Included(relPath string) bool {
+	if len(c.IncludePatterns) == 0 {
+		return true
+	}
+	for _, pattern := range c.IncludePatterns {
+		if ExcludePatternMatches(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldExclude checks if a file should be excluded from sync. Files
+// include_patterns leaves out always are; otherwise a .claudesyncignore line
+// matching the file decides over exclude_patterns, so "!pattern" there can
+// sync a file they exclude.
 func (c *Config) ShouldExclude(relPath string) bool {
-	filename := filepath.Base(relPath)
-	relPathNorm := strings.ToLower(filepath.ToSlash(relPath))
+	return c.excluded(relPath, c.EffectiveExcludePatterns())
+}
 
-	for _, pattern := range c.ExcludePatterns {
-		patternLower := strings.ToLower(pattern)
+// ExcludedEverywhere is ShouldExclude without the exclude patterns this
+// platform or machine adds, which other machines may still sync the file
+// under
+func (c *Config) ExcludedEverywhere(relPath string) bool {
+	return c.excluded(relPath, c.ExcludePatterns)
+}
 
-		if strings.Contains(pattern, "*") {
-			// Wildcard pattern - match against filename
-			if matchWildcard(strings.ToLower(filename), patternLower) {
-				return true
-			}
-		} else {
-			// Directory/file name - match if relPath starts with pattern/ or equals pattern
-			if relPathNorm == patternLower || strings.HasPrefix(relPathNorm, patternLower+"/") {
-				return true
-			}
-			// Exact filename match
-			if strings.ToLower(filename) == patternLower {
-				return true
-			}
+func (c *Config) excluded(relPath string, excludePatterns []string) bool {
+	if c.pluginExcluded(relPath) || !c.Included(relPath) {
+		return true
+	}
+	if c.ignore != nil {
+		if ignored, decided := c.ignore.Match(relPath); decided {
+			return ignored
+		}
+	}
+	for _, pattern := range excludePatterns {
+		if ExcludePatternMatches(pattern, relPath) {
+			return true
 		}
 	}
 	return false
 }
 
-// matchWildcard performs simple glob matching (* matches any characters)
-func matchWildcard(s, pattern string) bool {
-	// Simple glob matching - could use filepath.Match but it's stricter
-	if !strings.Contains(pattern, "*") {
-		return s == pattern
+// ShouldCanonicalize reports whether a JSON file is pushed in canonical form
+func (c *Config) ShouldCanonicalize(relPath string) bool {
+	if !strings.HasSuffix(strings.ToLower(relPath), ".json") {
+		return false
 	}
+	for _, pattern := range c.CanonicalJSON {
+		if ExcludePatternMatches(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
 
-	parts := strings.Split(pattern, "*")
-	if len(parts) == 2 {
-		// Simple prefix/suffix match
-		return strings.HasPrefix(s, parts[0]) && strings.HasSuffix(s, parts[1])
+// pluginExcluded applies the plugins.sync mode to a path
+func (c *Config) pluginExcluded(relPath string) bool {
+	rel := filepath.ToSlash(relPath)
+	if !strings.HasPrefix(strings.ToLower(rel), "plugins/") {
+		return false
+	}
+	switch c.Plugins.Sync {
+	case PluginsNone:
+		return true
+	case PluginsAll:
+		// Marketplaces are git clones; never sync their internals
+		return strings.Contains(rel, "/.git/")
+	default:
+		for _, f := range pluginConfigFiles {
+			if strings.EqualFold(rel, f) {
+				return false
+			}
+		}
+		return true
 	}
+}
+
+// EncryptPatternMatches checks a single encrypt pattern against a file.
+// Patterns are gitignore-style (see GlobRegexp), so "skills/*/resources/"
+// covers everything under each skill's resources.
+func EncryptPatternMatches(pattern, relPath string) bool {
+	return patternMatches(pattern, relPath, false)
+}
 
-	// Use filepath.Match for complex patterns
-	matched, _ := filepath.Match(pattern, s)
-	return matched
+// ExcludePatternMatches checks a single exclude pattern against a file,
+// ignoring case: "projects" excludes the directory and everything in it,
+// "*.log" any log file at any depth
+func ExcludePatternMatches(pattern, relPath string) bool {
+	return patternMatches(pattern, relPath, true)
 }
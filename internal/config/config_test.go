@@ -0,0 +1,51 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"", 0, true},
+		{"1024", 1024, true},
+		{"500KB", 500 << 10, true},
+		{"50mb", 50 << 20, true},
+		{"1.5GiB", 3 << 29, true},
+		{"2G", 2 << 30, true},
+		{"10B", 10, true},
+		{"MB", 0, false},
+		{"-1MB", 0, false},
+		{"lots", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d (ok %v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExcludedEverywhere(t *testing.T) {
+	c := &Config{
+		ExcludePatterns: []string{"todos"},
+		machine:         &MachineConfig{ExcludePatterns: []string{"local.md"}},
+	}
+	tests := []struct {
+		path      string
+		here, all bool
+	}{
+		{"todos/a.json", true, true},
+		{"local.md", true, false},
+		{"CLAUDE.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := c.ShouldExclude(tt.path); got != tt.here {
+			t.Errorf("ShouldExclude(%q) = %v, want %v", tt.path, got, tt.here)
+		}
+		if got := c.ExcludedEverywhere(tt.path); got != tt.all {
+			t.Errorf("ExcludedEverywhere(%q) = %v, want %v", tt.path, got, tt.all)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys returns every settable dotted key (e.g. "backup.max_count").
+// Map sections are listed with a "<name>" placeholder.
+func Keys() []string {
+	var keys []string
+	collectKeys(reflect.TypeOf(Config{}), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			name := yamlName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			if t.Field(i).Anonymous {
+				collectKeys(t.Field(i).Type, prefix, keys)
+				continue
+			}
+			collectKeys(t.Field(i).Type, joinKey(prefix, name), keys)
+		}
+	case reflect.Map:
+		collectKeys(t.Elem(), joinKey(prefix, "<name>"), keys)
+	case reflect.Ptr:
+		collectKeys(t.Elem(), prefix, keys)
+	default:
+		*keys = append(*keys, prefix)
+	}
+}
+
+// Get returns the effective value of a dotted key
+func (c *Config) Get(key string) (interface{}, error) {
+	v := reflect.ValueOf(*c)
+	for _, part := range strings.Split(key, ".") {
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByYAML(v.Type(), part)
+			if !ok {
+				return nil, unknownKeyError(key)
+			}
+			v = v.FieldByIndex(field.Index)
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(part))
+			if !v.IsValid() {
+				return nil, fmt.Errorf("%s is not set", key)
+			}
+		default:
+			return nil, unknownKeyError(key)
+		}
+	}
+	return v.Interface(), nil
+}
+
+// SetValue sets a dotted key in the config file at path, keeping comments
+// and the other settings intact. value is converted to the key's type; lists
+// accept YAML flow syntax ("[a, b]") or comma-separated values. The result is
+// validated before it is written.
+func SetValue(path, key, value string) error {
+	t, err := keyType(key)
+	if err != nil {
+		return err
+	}
+
+	node, err := typedNode(t, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return editFile(path, func(root *yaml.Node) error {
+		setNode(root, strings.Split(key, "."), node)
+		return nil
+	})
+}
+
+// SetList replaces a list key (e.g. exclude_patterns) in the config file at path
+func SetList(path, key string, items []string) error {
+	t, err := keyType(key)
+	if err != nil {
+		return err
+	}
+	if t.Kind() != reflect.Slice {
+		return fmt.Errorf("%s is not a list", key)
+	}
+
+	return editFile(path, func(root *yaml.Node) error {
+		setNode(root, strings.Split(key, "."), listNode(items))
+		return nil
+	})
+}
+
+// UnsetValue removes a dotted key from the config file at path
+func UnsetValue(path, key string) error {
+	if _, err := keyType(key); err != nil {
+		return err
+	}
+	return editFile(path, func(root *yaml.Node) error {
+		if !deleteNode(root, strings.Split(key, ".")) {
+			return fmt.Errorf("%s is not set in %s", key, path)
+		}
+		return nil
+	})
+}
+
+// Validate checks the config file at path for unknown keys, bad values and
+// malformed patterns. It returns every problem found.
+func Validate(path string) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []error{err}
+	}
+
+	var problems []error
+
+	// Unknown keys (typos)
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []error{err}
+	}
+	if len(root.Content) > 0 {
+		checkKeys(root.Content[0], reflect.TypeOf(Config{}), "", &problems)
+	}
+
+	// Values
+	cfg, err := Load(path)
+	if err != nil {
+		return append(problems, err)
+	}
+
+	// Patterns
+	type patternList struct {
+		key      string
+		patterns []string
+	}
+	lists := []patternList{
+		{"encrypt_patterns", cfg.EncryptPatterns},
+		{"exclude_patterns", cfg.ExcludePatterns},
+		{"include_patterns", cfg.IncludePatterns},
+	}
+	machines := make([]string, 0, len(cfg.Machines))
+	for name := range cfg.Machines {
+		machines = append(machines, name)
+	}
+	sort.Strings(machines)
+	for _, name := range machines {
+		if _, err := filepath.Match(name, ""); err != nil {
+			problems = append(problems, fmt.Errorf("machines: %q is not a valid hostname pattern: %w", name, err))
+		}
+		m := cfg.Machines[name]
+		lists = append(lists,
+			patternList{"machines." + name + ".encrypt_patterns", m.EncryptPatterns},
+			patternList{"machines." + name + ".exclude_patterns", m.ExcludePatterns})
+	}
+	platforms := make([]string, 0, len(cfg.Platforms))
+	for name := range cfg.Platforms {
+		platforms = append(platforms, name)
+	}
+	sort.Strings(platforms)
+	for _, name := range platforms {
+		if !slices.Contains(PlatformKeys, name) {
+			problems = append(problems, fmt.Errorf("platforms: %q is not a platform (use %s)", name, strings.Join(PlatformKeys, ", ")))
+		}
+		p := cfg.Platforms[name]
+		lists = append(lists,
+			patternList{"platforms." + name + ".encrypt_patterns", p.EncryptPatterns},
+			patternList{"platforms." + name + ".exclude_patterns", p.ExcludePatterns})
+	}
+	for _, list := range lists {
+		for _, p := range list.patterns {
+			if err := CheckPattern(p); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", list.key, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+// CheckPattern reports why a sync pattern can never match, if it can't
+func CheckPattern(p string) error {
+	switch {
+	case strings.TrimSpace(p) == "":
+		return fmt.Errorf("empty pattern")
+	case p != strings.TrimSpace(p):
+		return fmt.Errorf("pattern %q has leading or trailing spaces", p)
+	case filepath.IsAbs(p) || strings.HasPrefix(p, "~"):
+		return fmt.Errorf("pattern %q must be relative to ~/.claude", p)
+	case strings.Contains(p, `\`):
+		return fmt.Errorf("pattern %q uses backslashes; use / as the separator", p)
+	}
+	if _, err := GlobRegexp(strings.TrimSuffix(p, "/"), false); err != nil {
+		return fmt.Errorf("pattern %q is malformed: %w", p, err)
+	}
+	return nil
+}
+
+// checkKeys walks a mapping node and reports keys that don't exist in t
+func checkKeys(node *yaml.Node, t reflect.Type, prefix string, problems *[]error) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+		key := joinKey(prefix, name)
+
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByYAML(t, name)
+			if !ok {
+				*problems = append(*problems, fmt.Errorf("line %d: %w", node.Content[i].Line, unknownKeyError(key)))
+				continue
+			}
+			checkKeys(value, field.Type, key, problems)
+		case reflect.Map:
+			checkKeys(value, t.Elem(), key, problems)
+		}
+	}
+}
+
+// keyType returns the Go type behind a dotted key
+func keyType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByYAML(t, part)
+			if !ok {
+				return nil, unknownKeyError(key)
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, unknownKeyError(key)
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+		return nil, fmt.Errorf("%s is a section; set one of its keys instead (see 'claude-code-sync config keys')", key)
+	}
+	return t, nil
+}
+
+// typedNode converts a command-line value into a YAML node of type t
+func typedNode(t reflect.Type, value string) (*yaml.Node, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	case reflect.Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case reflect.Slice:
+		var items []string
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			if err := yaml.Unmarshal([]byte(value), &items); err != nil {
+				return nil, fmt.Errorf("invalid list %q: %w", value, err)
+			}
+		} else if value != "" {
+			for _, item := range strings.Split(value, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		}
+		return listNode(items), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// listNode builds a YAML sequence of strings
+func listNode(items []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, item := range items {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+	}
+	return seq
+}
+
+// editFile loads the config file as a YAML document, applies edit, validates
+// the result and writes it back
+func editFile(path string, edit func(root *yaml.Node) error) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	if err := edit(doc.Content[0]); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	enc.Close()
+
+	// Validate via a temp file so an edit never introduces a new problem.
+	// Problems that were already there are left for 'config validate'.
+	existing := map[string]bool{}
+	for _, p := range Validate(path) {
+		existing[stripLine(p.Error())] = true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	for _, p := range Validate(tmp) {
+		if !existing[stripLine(p.Error())] {
+			os.Remove(tmp)
+			return p
+		}
+	}
+	return os.Rename(tmp, path)
+}
+
+// stripLine drops the "line N: " prefix so problems compare across edits
+func stripLine(msg string) string {
+	if strings.HasPrefix(msg, "line ") {
+		if _, rest, ok := strings.Cut(msg, ": "); ok {
+			return rest
+		}
+	}
+	return msg
+}
+
+// setNode sets a value at a key path, creating mappings as needed
+func setNode(m *yaml.Node, parts []string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			old := m.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			m.Content[i+1] = value
+			return
+		}
+		child := m.Content[i+1]
+		if child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			m.Content[i+1] = child
+		}
+		setNode(child, parts[1:], value)
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[0]}
+	if len(parts) == 1 {
+		m.Content = append(m.Content, key, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, key, child)
+	setNode(child, parts[1:], value)
+}
+
+// deleteNode removes a key path, reporting whether it existed
+func deleteNode(m *yaml.Node, parts []string) bool {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return true
+		}
+		if m.Content[i+1].Kind != yaml.MappingNode {
+			return false
+		}
+		return deleteNode(m.Content[i+1], parts[1:])
+	}
+	return false
+}
+
+// unknownKeyError suggests the closest known key
+func unknownKeyError(key string) error {
+	// Compare against keys and section names ("backup" for "bakup")
+	candidates := map[string]bool{}
+	for _, k := range Keys() {
+		parts := strings.Split(k, ".")
+		for i := range parts {
+			candidates[strings.Join(parts[:i+1], ".")] = true
+		}
+	}
+
+	best, bestDist := "", 4
+	for k := range candidates {
+		if d := editDistance(key, k); d < bestDist || (d == bestDist && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown key %q (did you mean %q?)", key, best)
+	}
+	return fmt.Errorf("unknown key %q (see 'claude-code-sync config keys')", key)
+}
+
+// fieldByYAML finds a struct field by its yaml tag name, looking into
+// inlined (anonymous) fields
+func fieldByYAML(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			if inner, ok := fieldByYAML(f.Type, name); ok {
+				inner.Index = append([]int{i}, inner.Index...)
+				return inner, true
+			}
+			continue
+		}
+		if yamlName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlName returns the yaml key of a struct field ("" if not serialized)
+func yamlName(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	name := strings.Split(tag, ",")[0]
+	if name == "-" || (name == "" && !f.Anonymous) {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	gosync "sync"
+)
+
+// globs caches compiled config patterns, keyed by globKey
+var globs gosync.Map
+
+type globKey struct {
+	pattern string
+	fold    bool
+}
+
+// patternMatches reports whether a config pattern matches relPath or a
+// directory it is in, as a .gitignore line would. A pattern ending in a
+// slash only matches directories. Patterns that don't compile match
+// nothing; CheckPattern rejects them.
+func patternMatches(pattern, relPath string, fold bool) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	key := globKey{strings.TrimSuffix(pattern, "/"), fold}
+	cached, ok := globs.Load(key)
+	if !ok {
+		re, _ := GlobRegexp(key.pattern, fold)
+		cached, _ = globs.LoadOrStore(key, re)
+	}
+	re := cached.(*regexp.Regexp)
+	if re == nil {
+		return false
+	}
+
+	path := filepath.ToSlash(relPath)
+	if !dirOnly && re.MatchString(path) {
+		return true
+	}
+	for i := strings.LastIndex(path, "/"); i > 0; i = strings.LastIndex(path[:i], "/") {
+		if re.MatchString(path[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobRegexp compiles a gitignore pattern, without a trailing slash, to a
+// regular expression matching the paths (with "/") it selects. A pattern
+// with a slash is anchored to the start of the path; one without matches a
+// name at any depth. "*" and "?" stop at a slash, "[a-z]" and "[!a-z]" match a
+// class, "**/", "/**/" and "/**" match any number of directories, and a
+// backslash escapes the next character.
+func GlobRegexp(pattern string, fold bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if fold {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+	if strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern) && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+			for i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+			}
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			class, n, ok := globClass(pattern[i:])
+			if !ok {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i += n - 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// globClass translates the bracket expression at the start of s to a
+// regular expression class, returning it and how much of s it used
+func globClass(s string) (string, int, bool) {
+	var b strings.Builder
+	b.WriteString("[")
+	i := 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		b.WriteString("^/")
+		i++
+	}
+	// A ] right after the opening bracket is part of the class
+	for start := i; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ']' && i > start:
+			b.WriteString("]")
+			return b.String(), i + 1, true
+		case strings.HasPrefix(s[i:], "[:") && strings.Contains(s[i+2:], ":]"):
+			// A character class name such as [:alpha:]
+			end := i + 2 + strings.Index(s[i+2:], ":]") + 2
+			b.WriteString(s[i:end])
+			i = end - 1
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		case c == '[' || c == ']' || c == '^':
+			b.WriteString(`\` + string(c))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,62 @@
+package config
+
+import "testing"
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.log", "debug.log", true},
+		{"*.log", "logs/debug.log", true},
+		{"/*.log", "logs/debug.log", false},
+		{"skills/*/resources", "skills/pdf/resources", true},
+		{"skills/*/resources", "skills/pdf/extra/resources", false},
+		{"**/cache", "a/b/cache", true},
+		{"**/cache", "cache", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**", "a/x/y", true},
+		{"file[0-9].md", "file7.md", true},
+		{"file[!0-9].md", "file7.md", false},
+		{"file?.md", "file/.md", false},
+		{`\#notes`, "#notes", true},
+	}
+	for _, tt := range tests {
+		re, err := GlobRegexp(tt.pattern, false)
+		if err != nil {
+			t.Fatalf("GlobRegexp(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		exclude bool
+		encrypt bool
+	}{
+		{"projects", "projects/a/b.jsonl", true, true},
+		{"projects", "Projects/a.md", true, false},
+		{"*.log", "debug/x/out.log", true, true},
+		{"settings.json", "plugins/p/settings.json", true, true},
+		{"skills/*/resources/*", "skills/pdf/resources/fonts/a.ttf", true, true},
+		{"skills/*/resources/", "skills/pdf/resources", false, false},
+		{"skills/**/secret.md", "skills/a/b/secret.md", true, true},
+		{"client_secret_*.json", "client_secret_1.json", true, true},
+		{"commands/*.md", "agents/commands/x.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := ExcludePatternMatches(tt.pattern, tt.path); got != tt.exclude {
+			t.Errorf("ExcludePatternMatches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.exclude)
+		}
+		if got := EncryptPatternMatches(tt.pattern, tt.path); got != tt.encrypt {
+			t.Errorf("EncryptPatternMatches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.encrypt)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	gosync "sync"
+)
+
+// IgnoreFile is the name of the gitignore-style files that exclude paths
+// under the Claude directory from sync, in it or any directory below it
+const IgnoreFile = ".claudesyncignore"
+
+// ignoreRule is one line of an ignore file
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // !pattern: include again
+	dirOnly bool // pattern/: matches directories only
+}
+
+// Ignore applies the .claudesyncignore files under a directory, read as
+// paths are checked and cached
+type Ignore struct {
+	root  string
+	mu    gosync.Mutex
+	rules map[string][]ignoreRule // By directory, relative to root with "/"
+}
+
+// NewIgnore returns the ignore files under root
+func NewIgnore(root string) *Ignore {
+	return &Ignore{root: root, rules: map[string][]ignoreRule{}}
+}
+
+// Match reports whether the ignore files decide relPath, a file, and if so
+// whether it is ignored. As in git, the last matching line wins, lines in
+// a deeper directory's file win over those above it, and a file inside an
+// ignored directory can't be included again.
+func (ig *Ignore) Match(relPath string) (ignored, decided bool) {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range parts {
+		path := strings.Join(parts[:i+1], "/")
+		isDir := i < len(parts)-1
+		ignored, decided = ig.decide(parts[:i], path, isDir)
+		if isDir && ignored {
+			return true, true
+		}
+	}
+	return ignored, decided
+}
+
+// decide applies the rules of the files in dirs and above to path
+func (ig *Ignore) decide(dirs []string, path string, isDir bool) (ignored, decided bool) {
+	for i := 0; i <= len(dirs); i++ {
+		dir := strings.Join(dirs[:i], "/")
+		rel := path
+		if dir != "" {
+			rel = strings.TrimPrefix(path, dir+"/")
+		}
+		for _, rule := range ig.load(dir) {
+			if (!rule.dirOnly || isDir) && rule.re.MatchString(rel) {
+				ignored, decided = !rule.negate, true
+			}
+		}
+	}
+	return ignored, decided
+}
+
+// load returns the rules of dir's ignore file, if it has one
+func (ig *Ignore) load(dir string) []ignoreRule {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if rules, ok := ig.rules[dir]; ok {
+		return rules
+	}
+	data, _ := os.ReadFile(filepath.Join(ig.root, filepath.FromSlash(dir), IgnoreFile))
+	rules := parseIgnore(data)
+	ig.rules[dir] = rules
+	return rules
+}
+
+// parseIgnore reads the rules of an ignore file, skipping blank lines,
+// comments and patterns that don't compile
+func parseIgnore(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreLine reads one line of an ignore file
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces don't count unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") && !strings.HasSuffix(line, `\/`) {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Like git, which sets core.ignorecase there
+	re, err := GlobRegexp(line, runtime.GOOS == "windows" || runtime.GOOS == "darwin")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
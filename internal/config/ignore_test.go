@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, data string) {
+		t.Helper()
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(IgnoreFile, "# drafts\n*.draft.md\nscratch/\nnotes/*\n!notes/keep.md\n")
+	write("commands/"+IgnoreFile, "!wip.draft.md\nlocal-*\n")
+
+	tests := []struct {
+		path    string
+		ignored bool
+		decided bool
+	}{
+		{"CLAUDE.md", false, false},
+		{"agents/x.draft.md", true, true},
+		{"commands/wip.draft.md", false, true},
+		{"commands/local-deploy.md", true, true},
+		{"scratch/a.md", true, true},
+		{"notes/a.md", true, true},
+		{"notes/keep.md", false, true},
+	}
+	ig := NewIgnore(root)
+	for _, tt := range tests {
+		ignored, decided := ig.Match(tt.path)
+		if ignored != tt.ignored || decided != tt.decided {
+			t.Errorf("Match(%q) = %v, %v, want %v, %v", tt.path, ignored, decided, tt.ignored, tt.decided)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package control serves a JSON-RPC 2.0 API on a local socket so editors,
+// tray apps and other tools can drive sync without spawning CLI processes.
+// Messages are newline-delimited JSON objects in both directions.
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Version is the JSON-RPC version spoken
+const Version = "2.0"
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeFailed         = -32000 // The method ran and returned an error
+)
+
+// maxMessage caps a single request line
+const maxMessage = 1 << 20
+
+// Request is a JSON-RPC request; ID is absent for notifications
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response to a request
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a server-to-client message without an ID, such as progress
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrMethodNotFound is returned by a Handler for unknown methods
+var ErrMethodNotFound = &Error{Code: CodeMethodNotFound, Message: "method not found"}
+
+// InvalidParams wraps a params decoding error
+func InvalidParams(err error) error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+}
+
+// Notifier sends a notification to the client that made the current request
+type Notifier func(method string, params any)
+
+// Handler runs one method call. Returning an *Error sets its code; any other
+// error is reported as CodeFailed.
+type Handler func(method string, params json.RawMessage, notify Notifier) (any, error)
+
+// Listen listens on the unix socket at path, readable only by this user. A
+// socket left behind by a server that exited is replaced; one that still
+// answers means another server is running.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts connections until l is closed. Requests on one connection
+// are handled in order; connections are handled concurrently.
+func Serve(l net.Listener, h Handler) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, h)
+	}
+}
+
+// serveConn reads requests from conn until it is closed
+func serveConn(conn net.Conn, h Handler) {
+	defer conn.Close()
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(conn)
+	send := func(v any) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(v)
+	}
+	notify := func(method string, params any) {
+		send(Notification{JSONRPC: Version, Method: method, Params: params})
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxMessage)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			send(Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+		resp := call(h, req, notify)
+		if req.ID != nil {
+			send(resp)
+		}
+	}
+}
+
+// call runs a request through the handler and builds its response
+func call(h Handler, req Request, notify Notifier) Response {
+	resp := Response{JSONRPC: Version, ID: req.ID}
+	if req.JSONRPC != Version || req.Method == "" {
+		resp.Error = &Error{Code: CodeInvalidRequest, Message: `expected "jsonrpc": "2.0" and a method`}
+		return resp
+	}
+	result, err := h(req.Method, req.Params, notify)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeFailed, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	if result == nil {
+		result = struct{}{}
+	}
+	resp.Result = result
+	return resp
+}
+
+// Decode unmarshals params into v; absent params leave v unchanged
+func Decode(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return InvalidParams(err)
+	}
+	return nil
+}
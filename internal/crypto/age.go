@@ -2,6 +2,8 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -57,6 +59,14 @@ func GetPublicKey(path string) (string, error) {
 	return identity.Recipient().String(), nil
 }
 
+// Fingerprint returns a short hash of an age public key for comparing keys
+// by eye, e.g. "3F2A 91C0 7D4E B812"
+func Fingerprint(publicKey string) string {
+	sum := sha256.Sum256([]byte(publicKey))
+	h := strings.ToUpper(hex.EncodeToString(sum[:8]))
+	return strings.Join([]string{h[0:4], h[4:8], h[8:12], h[12:16]}, " ")
+}
+
 // GetPublicKeyFromContent extracts public key from key content
 func GetPublicKeyFromContent(content string) (string, error) {
 	// Try to find public key comment
@@ -74,15 +84,48 @@ func GetPublicKeyFromContent(content string) (string, error) {
 	return identity.Recipient().String(), nil
 }
 
+// LoadRecipients reads an age recipients file: one public key per line, with
+// blank lines and # comments ignored. A missing file gives no keys.
+func LoadRecipients(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := age.ParseX25519Recipient(line); err != nil {
+			return nil, fmt.Errorf("line %d: not an age public key (age1...)", i+1)
+		}
+		keys = append(keys, line)
+	}
+	return keys, nil
+}
+
 // Encrypt encrypts data with the given public key
 func Encrypt(publicKey string, plaintext []byte) ([]byte, error) {
-	recipient, err := age.ParseX25519Recipient(publicKey)
-	if err != nil {
-		return nil, fmt.Errorf("invalid public key: %w", err)
+	return EncryptTo([]string{publicKey}, plaintext)
+}
+
+// EncryptTo encrypts data so that any of the given public keys can decrypt it
+func EncryptTo(publicKeys []string, plaintext []byte) ([]byte, error) {
+	recipients := make([]age.Recipient, len(publicKeys))
+	for i, key := range publicKeys {
+		recipient, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		recipients[i] = recipient
 	}
 
 	buf := &bytes.Buffer{}
-	w, err := age.Encrypt(buf, recipient)
+	w, err := age.Encrypt(buf, recipients...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create encryptor: %w", err)
 	}
@@ -110,12 +153,17 @@ func Decrypt(identity *age.X25519Identity, ciphertext []byte) ([]byte, error) {
 
 // EncryptFile encrypts a file and writes to destination
 func EncryptFile(publicKey, srcPath, dstPath string) error {
+	return EncryptFileTo([]string{publicKey}, srcPath, dstPath)
+}
+
+// EncryptFileTo encrypts a file to several public keys and writes to destination
+func EncryptFileTo(publicKeys []string, srcPath, dstPath string) error {
 	plaintext, err := os.ReadFile(srcPath)
 	if err != nil {
 		return err
 	}
 
-	ciphertext, err := Encrypt(publicKey, plaintext)
+	ciphertext, err := EncryptTo(publicKeys, plaintext)
 	if err != nil {
 		return err
 	}
@@ -123,6 +171,47 @@ func EncryptFile(publicKey, srcPath, dstPath string) error {
 	return os.WriteFile(dstPath, ciphertext, 0644)
 }
 
+// EncryptWriter returns a writer that encrypts to publicKey and writes the
+// ciphertext to w. Close it to flush the last chunk.
+func EncryptWriter(publicKey string, w io.Writer) (io.WriteCloser, error) {
+	recipient, err := age.ParseX25519Recipient(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	enc, err := age.Encrypt(w, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryptor: %w", err)
+	}
+	return enc, nil
+}
+
+// PassphraseWriter returns a writer that encrypts with a passphrase (age
+// scrypt) and writes the ciphertext to w. Close it to flush the last chunk.
+func PassphraseWriter(passphrase string, w io.Writer) (io.WriteCloser, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := age.Encrypt(w, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryptor: %w", err)
+	}
+	return enc, nil
+}
+
+// DecryptPassphrase decrypts data encrypted by PassphraseWriter
+func DecryptPassphrase(passphrase string, ciphertext []byte) ([]byte, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
 // DecryptFile decrypts a file and writes to destination
 func DecryptFile(identity *age.X25519Identity, srcPath, dstPath string) error {
 	ciphertext, err := os.ReadFile(srcPath)
@@ -138,6 +227,25 @@ func DecryptFile(identity *age.X25519Identity, srcPath, dstPath string) error {
 	return os.WriteFile(dstPath, plaintext, 0644)
 }
 
+// CheckFile decrypts a file and discards the plaintext, to confirm the
+// identity can read it and the ciphertext is intact
+func CheckFile(identity *age.X25519Identity, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := age.Decrypt(f, identity)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return nil
+}
+
 // ValidateKeyContent checks if content contains a valid age key
 func ValidateKeyContent(content string) error {
 	_, err := ParseKey(content)
@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const bitbucketAPI = "https://api.bitbucket.org/2.0"
+
+// Bitbucket creates repositories and pull requests through the Bitbucket Cloud API
+type Bitbucket struct {
+	token    string // Access token (Bearer)
+	username string // App password auth (Basic)
+	password string
+}
+
+// NewBitbucket returns a Bitbucket Cloud provider. Credentials are either an
+// access token in BITBUCKET_TOKEN or an app password in BITBUCKET_USERNAME and
+// BITBUCKET_APP_PASSWORD (needs repository:admin).
+func NewBitbucket() (*Bitbucket, error) {
+	b := &Bitbucket{
+		token:    envToken("BITBUCKET_TOKEN", "CLAUDE_SYNC_GIT_TOKEN"),
+		username: strings.TrimSpace(os.Getenv("BITBUCKET_USERNAME")),
+		password: strings.TrimSpace(os.Getenv("BITBUCKET_APP_PASSWORD")),
+	}
+	if b.token == "" && (b.username == "" || b.password == "") {
+		return nil, fmt.Errorf("no Bitbucket credentials found. Set BITBUCKET_TOKEN, or BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD")
+	}
+	return b, nil
+}
+
+// Name identifies the provider
+func (b *Bitbucket) Name() string {
+	return ProviderBitbucket
+}
+
+// CreateRepo creates a private repository. owner is the workspace; without
+// one the repo is created in the authenticated user's personal workspace.
+func (b *Bitbucket) CreateRepo(name string) (*Repo, error) {
+	workspace, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(workspace, "/") {
+		return nil, fmt.Errorf("invalid Bitbucket repo name %q (expected workspace/name)", name)
+	}
+
+	if workspace == "" {
+		var user struct {
+			Username string `json:"username"`
+		}
+		if err := doJSON(http.MethodGet, bitbucketAPI+"/user", b.auth, nil, &user); err != nil {
+			return nil, createError("Bitbucket", err)
+		}
+		workspace = user.Username
+	}
+
+	slug := strings.ToLower(repo)
+	body := map[string]interface{}{
+		"scm":         "git",
+		"is_private":  true,
+		"description": "Claude Code config synced by claude-code-sync",
+	}
+	var created struct {
+		FullName  string `json:"full_name"`
+		IsPrivate bool   `json:"is_private"`
+		Links     struct {
+			Clone []struct {
+				Name string `json:"name"`
+				Href string `json:"href"`
+			} `json:"clone"`
+		} `json:"links"`
+	}
+	endpoint := fmt.Sprintf("%s/repositories/%s/%s", bitbucketAPI, workspace, slug)
+	if err := doJSON(http.MethodPost, endpoint, b.auth, body, &created); err != nil {
+		return nil, createError("Bitbucket", err)
+	}
+	if !created.IsPrivate {
+		return nil, fmt.Errorf("Bitbucket created %s as a public repo; delete it and check your workspace settings", created.FullName)
+	}
+
+	result := &Repo{FullName: created.FullName}
+	for _, link := range created.Links.Clone {
+		switch link.Name {
+		case "https":
+			result.HTTPSURL = link.Href
+		case "ssh":
+			result.SSHURL = link.Href
+		}
+	}
+	return result, nil
+}
+
+// OpenPullRequest opens a pull request from head into base
+func (b *Bitbucket) OpenPullRequest(name, head, base, title, body string) (*PullRequest, error) {
+	workspace, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+	request := map[string]interface{}{
+		"title":       title,
+		"description": body,
+		"source":      map[string]interface{}{"branch": map[string]string{"name": head}},
+		"destination": map[string]interface{}{"branch": map[string]string{"name": base}},
+	}
+	var created struct {
+		ID    int `json:"id"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	endpoint := fmt.Sprintf("%s/repositories/%s/%s/pullrequests", bitbucketAPI, workspace, strings.ToLower(repo))
+	if err := doJSON(http.MethodPost, endpoint, b.auth, request, &created); err != nil {
+		return nil, pullRequestError("Bitbucket", err)
+	}
+	return &PullRequest{Number: created.ID, URL: created.Links.HTML.Href}, nil
+}
+
+func (b *Bitbucket) auth(req *http.Request) {
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+		return
+	}
+	req.SetBasicAuth(b.username, b.password)
+}
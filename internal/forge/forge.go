@@ -0,0 +1,305 @@
+// Package forge creates sync repositories on hosted git services so users
+// don't have to create an empty private repo by hand before init, and opens
+// pull requests for push --pr.
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Provider names accepted by --provider
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderGitea     = "gitea"
+	ProviderBitbucket = "bitbucket"
+)
+
+// Repo is a repository created on a forge
+type Repo struct {
+	FullName string // owner/name
+	HTTPSURL string
+	SSHURL   string
+}
+
+// PullRequest is a pull (or merge) request opened on a forge
+type PullRequest struct {
+	Number int
+	URL    string // Web page of the pull request
+}
+
+// ErrPullRequestExists is returned by OpenPullRequest when head already has
+// an open pull request; pushing to head has updated it
+var ErrPullRequestExists = errors.New("a pull request for this branch is already open")
+
+// Provider creates private repositories and opens pull requests on a hosted
+// git service
+type Provider interface {
+	// Name identifies the provider ("github", "gitlab", "gitea", "bitbucket")
+	Name() string
+	// CreateRepo creates a private repository. name is either "repo" (created
+	// under the authenticated user) or "owner/repo" (created under an org/group).
+	CreateRepo(name string) (*Repo, error)
+	// OpenPullRequest asks to merge branch head into base in the repo
+	// "owner/repo"
+	OpenPullRequest(name, head, base, title, body string) (*PullRequest, error)
+}
+
+// Resolve picks the provider and repo name for a --create-repo target.
+// target is either a name ("repo", "owner/repo") or a URL such as
+// https://gitlab.example.com/group/repo, in which case the host selects the
+// server and, unless provider is set, the provider.
+func Resolve(target, provider string) (Provider, string, error) {
+	host := ""
+	name := target
+
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return nil, "", fmt.Errorf("invalid repo URL %q", target)
+		}
+		host = u.Host
+		name = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+		if provider == "" {
+			provider = detectProvider(host)
+		}
+		if provider == "" {
+			return nil, "", fmt.Errorf("cannot tell which forge %s runs. Use --provider gitlab|gitea|github|bitbucket", host)
+		}
+	}
+
+	switch provider {
+	case "", ProviderGitHub:
+		p, err := NewGitHub(host)
+		return p, name, err
+	case ProviderGitLab:
+		p, err := NewGitLab(host)
+		return p, name, err
+	case ProviderGitea:
+		if host == "" {
+			return nil, "", fmt.Errorf("gitea needs the server in the name, e.g. --create-repo https://gitea.example.com/you/claude-config")
+		}
+		p, err := NewGitea(host)
+		return p, name, err
+	case ProviderBitbucket:
+		p, err := NewBitbucket()
+		return p, name, err
+	default:
+		return nil, "", fmt.Errorf("unknown provider %q (expected github, gitlab, gitea, or bitbucket)", provider)
+	}
+}
+
+// ForRemote picks the provider and repo name for a git remote URL: https://,
+// ssh:// or scp-like (git@host:owner/repo.git). provider overrides the one
+// detected from the host.
+func ForRemote(remoteURL, provider string) (Provider, string, error) {
+	target := remoteURL
+	if !strings.Contains(target, "://") {
+		// git@github.com:owner/repo.git
+		userHost, path, ok := strings.Cut(target, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("cannot tell the forge from remote %q", remoteURL)
+		}
+		_, host, found := strings.Cut(userHost, "@")
+		if !found {
+			host = userHost
+		}
+		target = "https://" + host + "/" + path
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, "", fmt.Errorf("cannot tell the forge from remote %q", remoteURL)
+	}
+	u.Scheme, u.User = "https", nil
+	u.Host = u.Hostname() // SSH ports are not the API's
+	return Resolve(u.String(), provider)
+}
+
+// detectProvider maps well-known hosts to a provider
+func detectProvider(host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case host == "github.com":
+		return ProviderGitHub
+	case host == "gitlab.com" || strings.HasPrefix(host, "gitlab."):
+		return ProviderGitLab
+	case host == "codeberg.org" || strings.HasPrefix(host, "gitea.") || strings.HasPrefix(host, "forgejo."):
+		return ProviderGitea
+	case host == "bitbucket.org":
+		return ProviderBitbucket
+	}
+	return ""
+}
+
+// httpClient is shared by all providers
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// apiError is a non-2xx API response
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API returned %d", e.Status)
+	}
+	return fmt.Sprintf("API returned %d: %s", e.Status, e.Message)
+}
+
+// doJSON sends a JSON request and decodes the JSON response into out.
+// auth adds the provider's credentials to the request.
+func doJSON(method, endpoint string, auth func(*http.Request), body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	auth(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &apiError{Status: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// errorMessage extracts a human-readable message from the error bodies used
+// by GitHub ({"message", "errors"}), GitLab ({"message": string|object|array}),
+// Gitea ({"message"}) and Bitbucket ({"error": {"message"}})
+func errorMessage(data []byte) string {
+	var body map[string]interface{}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+
+	var parts []string
+	switch msg := body["message"].(type) {
+	case string:
+		parts = append(parts, msg)
+	case map[string]interface{}:
+		for field, v := range msg {
+			parts = append(parts, fmt.Sprintf("%s %v", field, v))
+		}
+	case []interface{}:
+		for _, v := range msg {
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	if errs, ok := body["errors"].([]interface{}); ok {
+		for _, e := range errs {
+			if m, ok := e.(map[string]interface{}); ok {
+				if s, ok := m["message"].(string); ok && s != "" {
+					parts = append(parts, s)
+				}
+			}
+		}
+	}
+	if e, ok := body["error"].(map[string]interface{}); ok {
+		if s, ok := e["message"].(string); ok {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// createError explains common failures when creating a repo
+func createError(forge string, err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%s rejected the token (%s)", forge, apiErr.Message)
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%s could not create the repo (%s). If it already exists, run 'claude-code-sync init <url>' instead", forge, apiErr.Message)
+	}
+	return fmt.Errorf("%s %w", forge, err)
+}
+
+// pullRequestError explains common failures when opening a pull request.
+// Forges answer 409 or 422 when the branch already has one.
+func pullRequestError(forge string, err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%s rejected the token (%s)", forge, apiErr.Message)
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		if strings.Contains(strings.ToLower(apiErr.Message), "exist") {
+			return ErrPullRequestExists
+		}
+	}
+	return fmt.Errorf("%s could not open the pull request: %w", forge, err)
+}
+
+// splitName splits "owner/repo" into its parts; owner is empty for a bare name.
+// GitLab subgroups are allowed: everything before the last slash is the owner.
+func splitName(name string) (owner, repo string, err error) {
+	name = strings.Trim(name, "/")
+	i := strings.LastIndex(name, "/")
+	owner, repo = "", name
+	if i >= 0 {
+		owner, repo = name[:i], name[i+1:]
+	}
+	if repo == "" || (i >= 0 && owner == "") {
+		return "", "", fmt.Errorf("invalid repo name %q (expected name or owner/name)", name)
+	}
+	return owner, repo, nil
+}
+
+// envToken returns the first non-empty environment variable
+func envToken(names ...string) string {
+	for _, name := range names {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// commandToken runs a CLI that prints a token (e.g. "gh auth token")
+func commandToken(name string, args ...string) string {
+	if _, err := exec.LookPath(name); err != nil {
+		return ""
+	}
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
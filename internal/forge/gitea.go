@@ -0,0 +1,96 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Gitea creates repositories and pull requests through the Gitea API (also Forgejo and Codeberg)
+type Gitea struct {
+	apiBase string
+	token   string
+}
+
+// NewGitea returns a Gitea provider for host. The token is read from
+// CLAUDE_SYNC_GIT_TOKEN or GITEA_TOKEN and needs repository write access.
+func NewGitea(host string) (*Gitea, error) {
+	token := envToken("CLAUDE_SYNC_GIT_TOKEN", "GITEA_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("no Gitea token found. Set GITEA_TOKEN")
+	}
+	return &Gitea{apiBase: fmt.Sprintf("https://%s/api/v1", host), token: token}, nil
+}
+
+// Name identifies the provider
+func (g *Gitea) Name() string {
+	return ProviderGitea
+}
+
+// CreateRepo creates a private repository
+func (g *Gitea) CreateRepo(name string) (*Repo, error) {
+	owner, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := g.apiBase + "/user/repos"
+	if owner != "" {
+		var user struct {
+			Login string `json:"login"`
+		}
+		if err := doJSON(http.MethodGet, g.apiBase+"/user", g.auth, nil, &user); err != nil {
+			return nil, createError("Gitea", err)
+		}
+		if !strings.EqualFold(owner, user.Login) {
+			endpoint = fmt.Sprintf("%s/orgs/%s/repos", g.apiBase, owner)
+		}
+	}
+
+	body := map[string]interface{}{
+		"name":        repo,
+		"private":     true,
+		"description": "Claude Code config synced by claude-code-sync",
+	}
+	var created struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		Private  bool   `json:"private"`
+	}
+	if err := doJSON(http.MethodPost, endpoint, g.auth, body, &created); err != nil {
+		return nil, createError("Gitea", err)
+	}
+	if !created.Private {
+		return nil, fmt.Errorf("Gitea created %s as a public repo; delete it and check the server's visibility settings", created.FullName)
+	}
+
+	return &Repo{FullName: created.FullName, HTTPSURL: created.CloneURL, SSHURL: created.SSHURL}, nil
+}
+
+// OpenPullRequest opens a pull request from head into base
+func (g *Gitea) OpenPullRequest(name, head, base, title, body string) (*PullRequest, error) {
+	owner, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+	request := map[string]interface{}{
+		"title": title,
+		"head":  head,
+		"base":  base,
+		"body":  body,
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls", g.apiBase, owner, repo)
+	if err := doJSON(http.MethodPost, endpoint, g.auth, request, &created); err != nil {
+		return nil, pullRequestError("Gitea", err)
+	}
+	return &PullRequest{Number: created.Number, URL: created.HTMLURL}, nil
+}
+
+func (g *Gitea) auth(req *http.Request) {
+	req.Header.Set("Authorization", "token "+g.token)
+}
@@ -0,0 +1,110 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GitHub creates repositories and pull requests through the GitHub REST API
+type GitHub struct {
+	apiBase string
+	token   string
+}
+
+// NewGitHub returns a GitHub provider for host (empty for github.com; any
+// other host is treated as GitHub Enterprise Server). The token is read from
+// CLAUDE_SYNC_GIT_TOKEN, GITHUB_TOKEN or GH_TOKEN, falling back to the
+// GitHub CLI ("gh auth token") when it is installed and logged in.
+func NewGitHub(host string) (*GitHub, error) {
+	apiBase := "https://api.github.com"
+	ghArgs := []string{"auth", "token"}
+	if host != "" && !strings.EqualFold(host, "github.com") {
+		apiBase = fmt.Sprintf("https://%s/api/v3", host)
+		ghArgs = append(ghArgs, "--hostname", host)
+	}
+
+	token := envToken("CLAUDE_SYNC_GIT_TOKEN", "GITHUB_TOKEN", "GH_TOKEN")
+	if token == "" {
+		token = commandToken("gh", ghArgs...)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no GitHub token found. Set GITHUB_TOKEN (needs the 'repo' scope) or run 'gh auth login'")
+	}
+	return &GitHub{apiBase: apiBase, token: token}, nil
+}
+
+// Name identifies the provider
+func (g *GitHub) Name() string {
+	return ProviderGitHub
+}
+
+// CreateRepo creates a private repository
+func (g *GitHub) CreateRepo(name string) (*Repo, error) {
+	owner, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := g.apiBase + "/user/repos"
+	if owner != "" {
+		var user struct {
+			Login string `json:"login"`
+		}
+		if err := doJSON(http.MethodGet, g.apiBase+"/user", g.auth, nil, &user); err != nil {
+			return nil, createError("GitHub", err)
+		}
+		if !strings.EqualFold(owner, user.Login) {
+			endpoint = fmt.Sprintf("%s/orgs/%s/repos", g.apiBase, owner)
+		}
+	}
+
+	body := map[string]interface{}{
+		"name":        repo,
+		"private":     true,
+		"description": "Claude Code config synced by claude-code-sync",
+	}
+	var created struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		Private  bool   `json:"private"`
+	}
+	if err := doJSON(http.MethodPost, endpoint, g.auth, body, &created); err != nil {
+		return nil, createError("GitHub", err)
+	}
+	if !created.Private {
+		return nil, fmt.Errorf("GitHub created %s as a public repo; delete it and check your org's visibility settings", created.FullName)
+	}
+
+	return &Repo{FullName: created.FullName, HTTPSURL: created.CloneURL, SSHURL: created.SSHURL}, nil
+}
+
+// OpenPullRequest opens a pull request from head into base
+func (g *GitHub) OpenPullRequest(name, head, base, title, body string) (*PullRequest, error) {
+	owner, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+	request := map[string]interface{}{
+		"title": title,
+		"head":  head,
+		"base":  base,
+		"body":  body,
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls", g.apiBase, owner, repo)
+	if err := doJSON(http.MethodPost, endpoint, g.auth, request, &created); err != nil {
+		return nil, pullRequestError("GitHub", err)
+	}
+	return &PullRequest{Number: created.Number, URL: created.HTMLURL}, nil
+}
+
+func (g *GitHub) auth(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+}
@@ -0,0 +1,99 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLab creates projects and merge requests through the GitLab REST API (gitlab.com or self-hosted)
+type GitLab struct {
+	apiBase string
+	token   string
+}
+
+// NewGitLab returns a GitLab provider for host (empty for gitlab.com).
+// The token is read from CLAUDE_SYNC_GIT_TOKEN or GITLAB_TOKEN and needs the
+// 'api' scope.
+func NewGitLab(host string) (*GitLab, error) {
+	if host == "" {
+		host = "gitlab.com"
+	}
+	token := envToken("CLAUDE_SYNC_GIT_TOKEN", "GITLAB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("no GitLab token found. Set GITLAB_TOKEN (needs the 'api' scope)")
+	}
+	return &GitLab{apiBase: fmt.Sprintf("https://%s/api/v4", host), token: token}, nil
+}
+
+// Name identifies the provider
+func (g *GitLab) Name() string {
+	return ProviderGitLab
+}
+
+// CreateRepo creates a private project. owner may be a group or subgroup path.
+func (g *GitLab) CreateRepo(name string) (*Repo, error) {
+	owner, repo, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"name":        repo,
+		"path":        repo,
+		"visibility":  "private",
+		"description": "Claude Code config synced by claude-code-sync",
+	}
+	if owner != "" {
+		var namespace struct {
+			ID   int    `json:"id"`
+			Kind string `json:"kind"`
+		}
+		endpoint := g.apiBase + "/namespaces/" + url.PathEscape(owner)
+		if err := doJSON(http.MethodGet, endpoint, g.auth, nil, &namespace); err != nil {
+			return nil, createError("GitLab", err)
+		}
+		if namespace.Kind != "user" {
+			body["namespace_id"] = namespace.ID
+		}
+	}
+
+	var created struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		HTTPURL           string `json:"http_url_to_repo"`
+		SSHURL            string `json:"ssh_url_to_repo"`
+		Visibility        string `json:"visibility"`
+	}
+	if err := doJSON(http.MethodPost, g.apiBase+"/projects", g.auth, body, &created); err != nil {
+		return nil, createError("GitLab", err)
+	}
+	if created.Visibility != "private" {
+		return nil, fmt.Errorf("GitLab created %s with %s visibility; delete it and check your group's visibility settings", created.PathWithNamespace, created.Visibility)
+	}
+
+	return &Repo{FullName: created.PathWithNamespace, HTTPSURL: created.HTTPURL, SSHURL: created.SSHURL}, nil
+}
+
+// OpenPullRequest opens a merge request from head into base
+func (g *GitLab) OpenPullRequest(name, head, base, title, body string) (*PullRequest, error) {
+	request := map[string]interface{}{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}
+	var created struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests", g.apiBase, url.PathEscape(strings.Trim(name, "/")))
+	if err := doJSON(http.MethodPost, endpoint, g.auth, request, &created); err != nil {
+		return nil, pullRequestError("GitLab", err)
+	}
+	return &PullRequest{Number: created.IID, URL: created.WebURL}, nil
+}
+
+func (g *GitLab) auth(req *http.Request) {
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+}
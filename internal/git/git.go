@@ -1,14 +1,118 @@
 package git
 
 import (
+	"archive/tar"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
+// Backend names for the git implementation
+const (
+	BackendAuto  = "auto"   // git CLI if installed, otherwise go-git
+	BackendCLI   = "cli"    // Shell out to the git binary
+	BackendGoGit = "go-git" // Pure-Go implementation, no git binary required
+)
+
+// SquashMarker ends the message of the snapshot commit written by Squash,
+// so other machines can tell a rewritten remote from diverged history
+const SquashMarker = "(claude-code-sync gc)"
+
+// ErrRejected is returned by Push when the remote has commits that are not
+// in the local branch
+var ErrRejected = errors.New("push rejected: the remote has newer commits")
+
+// ConflictError is returned by Rebase when local and remote commits changed
+// the same files. The rebase is aborted, so the branch is as it was.
+type ConflictError struct {
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("changed both here and on the remote: %s", strings.Join(e.Paths, ", "))
+}
+
+// Repo is the set of git operations claude-code-sync performs on the sync repo
+type Repo interface {
+	Init() error
+	AddAll() error
+	Commit(message string) error
+	HasChanges() (bool, error)
+	Push() error
+	PushBranch(branch string) error
+	Pull() error
+	Rebase(remoteWins bool, keepLocal ...string) error
+	MergeBranches(prefix string, remoteWins bool, keepLocal ...string) ([]string, error)
+	Fetch() error
+	HasRemote() bool
+	AddRemote(name, url string) error
+	RemoveRemote(name string) error
+	RemoteURL() (string, error)
+	CurrentBranch() (string, error)
+	GetLocalCommit() (string, error)
+	GetRemoteCommit() (string, error)
+	AheadBehind() (ahead, behind int, err error)
+	LastCommitTime() (time.Time, error)
+	IsRepo() bool
+	CreateInitialCommit() error
+	ResolveRevision(rev string) (string, error)
+	ExportTree(rev, dest string) error
+	Log(limit int) ([]Commit, error)
+	LogFrom(rev string, limit int) ([]Commit, error)
+	ShowFile(rev, path string) ([]byte, error)
+	Squash(before time.Time, message string) (int, error)
+	Rewritten() (bool, error)
+	FollowRewrite(keepFiles bool) (bool, error)
+	ForcePush() error
+	GC() error
+	Bundle(path string) error
+	PullBundle(path string) error
+}
+
+// Commit is one entry of the repo history, newest first in Log
+type Commit struct {
+	Hash    string
+	Parent  string // First parent, empty for the root commit
+	Time    time.Time
+	Author  string
+	Message string
+	Files   []FileChange
+}
+
+// FileChange is a file touched by a commit
+type FileChange struct {
+	Path   string // Slash-separated path in the repo
+	Status string // "A" (added), "M" (modified) or "D" (deleted)
+}
+
+// Open returns a Repo for repoDir using the requested backend
+func Open(repoDir, backend string) Repo {
+	if ResolveBackend(backend) == BackendGoGit {
+		return NewGoGit(repoDir)
+	}
+	return New(repoDir)
+}
+
+// ResolveBackend maps "auto" (or empty) to the concrete backend for this machine
+func ResolveBackend(backend string) string {
+	switch backend {
+	case BackendCLI, BackendGoGit:
+		return backend
+	}
+	if IsInstalled() {
+		return BackendCLI
+	}
+	return BackendGoGit
+}
+
 // Git wraps git CLI commands
 type Git struct {
 	repoDir string
@@ -19,9 +123,19 @@ func New(repoDir string) *Git {
 	return &Git{repoDir: repoDir}
 }
 
+// longPaths is passed to every git command: Git for Windows refuses paths
+// longer than MAX_PATH (260 characters), which deeply nested skill
+// resources reach, unless core.longpaths is set. Other platforms ignore it.
+var longPaths = []string{"-c", "core.longpaths=true"}
+
+// command returns a git command run with longPaths
+func command(args ...string) *exec.Cmd {
+	return exec.Command("git", slices.Concat(longPaths, args)...)
+}
+
 // run executes a git command and returns stdout
 func (g *Git) run(args ...string) (string, error) {
-	cmd := exec.Command("git", append([]string{"-C", g.repoDir}, args...)...)
+	cmd := command(append([]string{"-C", g.repoDir}, args...)...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -33,9 +147,22 @@ func (g *Git) run(args ...string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// runBytes executes a git command and returns raw stdout (for file contents)
+func (g *Git) runBytes(args ...string) ([]byte, error) {
+	cmd := command(append([]string{"-C", g.repoDir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
 // runSilent executes a git command, ignoring stderr
 func (g *Git) runSilent(args ...string) (string, error) {
-	cmd := exec.Command("git", append([]string{"-C", g.repoDir}, args...)...)
+	cmd := command(append([]string{"-C", g.repoDir}, args...)...)
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = nil
@@ -53,9 +180,13 @@ func (g *Git) Init() error {
 	return err
 }
 
-// Clone clones a remote repository
-func Clone(url, dest string) error {
-	cmd := exec.Command("git", "clone", url, dest)
+// Clone clones a remote repository using the requested backend
+func Clone(url, dest, backend string) error {
+	if ResolveBackend(backend) == BackendGoGit {
+		return cloneGoGit(url, dest)
+	}
+
+	cmd := command("clone", url, dest)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -86,9 +217,172 @@ func (g *Git) HasChanges() (bool, error) {
 // Push pushes to remote
 func (g *Git) Push() error {
 	_, err := g.run("push", "origin", "HEAD")
+	return pushError(err)
+}
+
+// PushBranch pushes HEAD to branch on origin
+func (g *Git) PushBranch(branch string) error {
+	_, err := g.run("push", "origin", "HEAD:refs/heads/"+branch)
+	return pushError(err)
+}
+
+// pushError marks non-fast-forward rejections with ErrRejected
+func pushError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := err.Error(); strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "[rejected]") {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
+
+// Rebase fetches origin and replays local commits on top of it. Conflicts
+// in keepLocal are resolved with the local version, and if remoteWins all
+// others with the remote version. Otherwise a conflict aborts the rebase,
+// leaving the branch as it was, and is returned as a *ConflictError.
+func (g *Git) Rebase(remoteWins bool, keepLocal ...string) error {
+	_, err := g.run("pull", "--rebase", "origin", "HEAD")
+	for err != nil {
+		conflicts := g.conflicts()
+		if len(conflicts) == 0 {
+			g.runSilent("rebase", "--abort")
+			return err
+		}
+		var others []string
+		for _, path := range conflicts {
+			if !remoteWins && !slices.Contains(keepLocal, path) {
+				others = append(others, path)
+			}
+		}
+		if len(others) > 0 {
+			g.runSilent("rebase", "--abort")
+			return &ConflictError{Paths: others}
+		}
+		for _, path := range conflicts {
+			// While rebasing, "ours" is the remote and "theirs" the local
+			// commit being replayed
+			side := "--ours"
+			if slices.Contains(keepLocal, path) {
+				side = "--theirs"
+			}
+			if err := g.resolve(path, side); err != nil {
+				g.runSilent("rebase", "--abort")
+				return err
+			}
+		}
+		err = g.continueRebase()
+	}
+	return nil
+}
+
+// conflicts lists the files left with conflicts by a rebase or merge
+func (g *Git) conflicts() []string {
+	out, _ := g.runBytes("-c", "core.quotepath=off", "diff", "--name-only", "-z", "--diff-filter=U")
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// resolve settles a conflict in path with one side's version, which may be
+// a deletion
+func (g *Git) resolve(path, side string) error {
+	if _, err := g.runSilent("checkout", side, "--", path); err != nil {
+		_, err = g.run("rm", "-q", "--", path)
+		return err
+	}
+	_, err := g.run("add", "--", path)
+	return err
+}
+
+// continueRebase commits the resolved conflicts and replays the remaining
+// commits, dropping a commit the resolution left empty
+func (g *Git) continueRebase() error {
+	if _, err := g.runSilent("diff", "--cached", "--quiet"); err == nil {
+		_, err := g.run("rebase", "--skip")
+		return err
+	}
+	_, err := g.run("-c", "core.editor=true", "rebase", "--continue")
 	return err
 }
 
+// MergeBranches fetches origin and merges its copy of the current branch and
+// every branch under prefix (e.g. "sync/") into HEAD, oldest first.
+// Conflicts are settled a whole file at a time as in Rebase: files in
+// keepLocal keep HEAD's version, and if remoteWins all others take the
+// merged branch's, so the most recently updated branch has the last word.
+// Otherwise a conflict aborts that merge and is returned as a
+// *ConflictError. Returns the branches that were merged.
+func (g *Git) MergeBranches(prefix string, remoteWins bool, keepLocal ...string) ([]string, error) {
+	if _, err := g.run("fetch", "--prune", "origin"); err != nil {
+		return nil, err
+	}
+
+	refs := []string{"refs/remotes/origin/" + strings.TrimSuffix(prefix, "/") + "/"}
+	if branch, err := g.runSilent("symbolic-ref", "--short", "HEAD"); err == nil {
+		refs = append(refs, "refs/remotes/origin/"+branch)
+	}
+	out, err := g.run(append([]string{"for-each-ref", "--sort=committerdate", "--format=%(refname:short)"}, refs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged []string
+	for _, ref := range strings.Fields(out) {
+		if _, err := g.runSilent("merge-base", "--is-ancestor", ref, "HEAD"); err == nil {
+			continue // Already merged
+		}
+		if err := g.merge(ref, remoteWins, keepLocal); err != nil {
+			return merged, err
+		}
+		merged = append(merged, strings.TrimPrefix(ref, "origin/"))
+	}
+	return merged, nil
+}
+
+// merge merges ref into HEAD, settling conflicts as MergeBranches describes
+func (g *Git) merge(ref string, remoteWins bool, keepLocal []string) error {
+	_, err := g.run("merge", "--no-edit", "--allow-unrelated-histories", "-m", "Merge "+ref, ref)
+	if err == nil {
+		return nil
+	}
+	conflicts := g.conflicts()
+	if len(conflicts) == 0 {
+		g.runSilent("merge", "--abort")
+		return err
+	}
+	var others []string
+	for _, path := range conflicts {
+		if !remoteWins && !slices.Contains(keepLocal, path) {
+			others = append(others, path)
+		}
+	}
+	if len(others) > 0 {
+		g.runSilent("merge", "--abort")
+		return &ConflictError{Paths: others}
+	}
+	for _, path := range conflicts {
+		// While merging, "ours" is HEAD and "theirs" the branch merged in
+		side := "--theirs"
+		if slices.Contains(keepLocal, path) {
+			side = "--ours"
+		}
+		if err := g.resolve(path, side); err != nil {
+			g.runSilent("merge", "--abort")
+			return err
+		}
+	}
+	if _, err := g.run("-c", "core.editor=true", "commit", "--no-edit"); err != nil {
+		g.runSilent("merge", "--abort")
+		return err
+	}
+	return nil
+}
+
 // Pull pulls from remote
 func (g *Git) Pull() error {
 	_, err := g.run("pull", "origin", "HEAD")
@@ -123,14 +417,58 @@ func (g *Git) RemoveRemote(name string) error {
 	return err
 }
 
+// RemoteURL returns the URL of origin
+func (g *Git) RemoteURL() (string, error) {
+	return g.runSilent("remote", "get-url", "origin")
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (g *Git) CurrentBranch() (string, error) {
+	return g.runSilent("symbolic-ref", "--short", "HEAD")
+}
+
 // GetLocalCommit returns the current HEAD commit hash
 func (g *Git) GetLocalCommit() (string, error) {
 	return g.runSilent("rev-parse", "HEAD")
 }
 
-// GetRemoteCommit returns the origin/HEAD commit hash
+// GetRemoteCommit returns the origin/HEAD commit hash, falling back to
+// the remote-tracking ref of the current branch
 func (g *Git) GetRemoteCommit() (string, error) {
-	return g.runSilent("rev-parse", "origin/HEAD")
+	return g.runSilent("rev-parse", "--verify", "--quiet", g.remoteRef())
+}
+
+// remoteRef is origin/HEAD if the clone knows it, otherwise the
+// remote-tracking ref of the current branch
+func (g *Git) remoteRef() string {
+	if _, err := g.runSilent("rev-parse", "--verify", "--quiet", "origin/HEAD"); err == nil {
+		return "origin/HEAD"
+	}
+	branch, _ := g.runSilent("symbolic-ref", "--short", "HEAD")
+	return "origin/" + branch
+}
+
+// AheadBehind counts the commits HEAD has that the remote doesn't, and the
+// other way round, as of the last fetch
+func (g *Git) AheadBehind() (int, int, error) {
+	out, err := g.run("rev-list", "--left-right", "--count", "HEAD..."+g.remoteRef())
+	if err != nil {
+		return 0, 0, err
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscan(out, &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return ahead, behind, nil
+}
+
+// LastCommitTime returns the committer date of HEAD
+func (g *Git) LastCommitTime() (time.Time, error) {
+	out, err := g.runSilent("log", "-1", "--format=%cI")
+	if err != nil || out == "" {
+		return time.Time{}, fmt.Errorf("no commits")
+	}
+	return time.Parse(time.RFC3339, out)
 }
 
 // IsRepo checks if the directory is a git repository
@@ -145,6 +483,33 @@ func IsInstalled() bool {
 	return err == nil
 }
 
+// LFSInstalled checks if the git-lfs extension is available
+func LFSInstalled() bool {
+	return command("lfs", "version").Run() == nil
+}
+
+// UsesLFS reports whether the .gitattributes of the repo in repoDir stores
+// any path with Git LFS
+func UsesLFS(repoDir string) bool {
+	data, err := os.ReadFile(filepath.Join(repoDir, ".gitattributes"))
+	return err == nil && bytes.Contains(data, []byte("filter=lfs"))
+}
+
+// InstallLFS sets up the Git LFS filters in the repo, so files its
+// .gitattributes route through LFS are stored there on commit and fetched
+// on checkout
+func (g *Git) InstallLFS() error {
+	_, err := g.run("lfs", "install", "--local")
+	return err
+}
+
+// PullLFS downloads the LFS files of the current commit and checks them out
+// in place of their pointers
+func (g *Git) PullLFS() error {
+	_, err := g.run("lfs", "pull")
+	return err
+}
+
 // IsValidRepoURL checks if a string looks like a valid git repo URL
 func IsValidRepoURL(url string) bool {
 	// HTTPS URLs
@@ -163,8 +528,12 @@ func IsValidRepoURL(url string) bool {
 }
 
 // CheckRemote verifies a remote URL is accessible
-func CheckRemote(url string) error {
-	cmd := exec.Command("git", "ls-remote", "--exit-code", url)
+func CheckRemote(url, backend string) error {
+	if ResolveBackend(backend) == BackendGoGit {
+		return checkRemoteGoGit(url)
+	}
+
+	cmd := command("ls-remote", "--exit-code", url)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -179,6 +548,108 @@ func CheckRemote(url string) error {
 	return nil
 }
 
+// RemoteCheck is the result of contacting the origin remote
+type RemoteCheck struct {
+	URL       string // Remote URL with any credentials removed
+	Auth      string // How credentials are supplied, e.g. "SSH key (ssh-agent or ~/.ssh)"
+	Reachable bool   // The remote could be listed
+	Writable  bool   // A dry-run push was accepted
+	Err       error  // Why the remote is unreachable or read-only
+}
+
+// ProbeRemote checks that origin can be read (ls-remote) and written (a
+// dry-run push to an unused branch, so being behind doesn't matter), giving
+// up on each after timeout. Prompts for credentials
+// are disabled so an expired login fails instead of hanging.
+func ProbeRemote(repoDir, backend string, timeout time.Duration) RemoteCheck {
+	if ResolveBackend(backend) == BackendGoGit {
+		return probeRemoteGoGit(repoDir, timeout)
+	}
+
+	g := New(repoDir)
+	url, err := g.runSilent("remote", "get-url", "origin")
+	if err != nil {
+		return RemoteCheck{Err: fmt.Errorf("no origin remote")}
+	}
+	check := RemoteCheck{URL: redactURL(url), Auth: g.authMethod(url)}
+
+	if _, err := g.runTimeout(timeout, "ls-remote", "origin"); err != nil {
+		check.Err = err
+		return check
+	}
+	check.Reachable = true
+
+	if _, err := g.runTimeout(timeout, "push", "--dry-run", "origin", "HEAD:refs/heads/claude-code-sync-doctor"); err != nil {
+		check.Err = err
+		return check
+	}
+	check.Writable = true
+	return check
+}
+
+// runTimeout executes a git command that may contact the remote, killing it
+// after timeout and never prompting for credentials
+func (g *Git) runTimeout(timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", slices.Concat(longPaths, []string{"-C", g.repoDir}, args)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: timed out after %s", args[0], timeout)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// authMethod describes how the git CLI authenticates to url
+func (g *Git) authMethod(url string) string {
+	switch {
+	case isLocalURL(url):
+		return "none (local path)"
+	case strings.HasPrefix(url, "git@") || strings.HasPrefix(url, "ssh://"):
+		if cmd := os.Getenv("GIT_SSH_COMMAND"); cmd != "" {
+			return fmt.Sprintf("SSH via GIT_SSH_COMMAND (%s)", cmd)
+		}
+		return "SSH key (ssh-agent or ~/.ssh)"
+	case redactURL(url) != url:
+		return "credentials embedded in the remote URL"
+	}
+	if helper, _ := g.runSilent("config", "--get", "credential.helper"); helper != "" {
+		return fmt.Sprintf("credential helper (%s)", helper)
+	}
+	return "none (anonymous HTTPS)"
+}
+
+// isLocalURL reports whether url is a path or file:// URL rather than a
+// network remote
+func isLocalURL(url string) bool {
+	return strings.HasPrefix(url, "file://") || filepath.IsAbs(url) || strings.HasPrefix(url, ".")
+}
+
+// redactURL removes a user or token from an HTTPS URL so it can be displayed
+func redactURL(url string) string {
+	for _, scheme := range []string{"https://", "http://"} {
+		rest, ok := strings.CutPrefix(url, scheme)
+		if !ok {
+			continue
+		}
+		if at := strings.Index(rest, "@"); at >= 0 && at < strings.Index(rest+"/", "/") {
+			return scheme + rest[at+1:]
+		}
+	}
+	return url
+}
+
 // CreateInitialCommit creates a README and initial commit
 func (g *Git) CreateInitialCommit() error {
 	readme := filepath.Join(g.repoDir, "README.md")
@@ -192,3 +663,241 @@ func (g *Git) CreateInitialCommit() error {
 
 	return g.Commit("Initial commit")
 }
+
+// ResolveRevision turns a revision (hash, HEAD~1, tag, ...) into a full commit hash
+func (g *Git) ResolveRevision(rev string) (string, error) {
+	hash, err := g.run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil || hash == "" {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return hash, nil
+}
+
+// ExportTree writes the files of a commit into dest without touching the worktree
+func (g *Git) ExportTree(rev, dest string) error {
+	data, err := g.runBytes("archive", "--format=tar", rev)
+	if err != nil {
+		return err
+	}
+	return extractTar(bytes.NewReader(data), dest)
+}
+
+// extractTar unpacks regular files from a tar stream into dest
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// Log returns up to limit commits reachable from HEAD (0 = all), newest first
+func (g *Git) Log(limit int) ([]Commit, error) {
+	return g.LogFrom("HEAD", limit)
+}
+
+// LogFrom returns up to limit commits reachable from rev (0 = all), newest
+// first
+func (g *Git) LogFrom(rev string, limit int) ([]Commit, error) {
+	args := []string{"-c", "core.quotepath=off", "log", "--no-renames", "--name-status",
+		"--format=%x1e%H%x1f%P%x1f%cI%x1f%an%x1f%B%x1f"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	args = append(args, rev, "--")
+	out, err := g.runBytes(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 6 {
+			continue
+		}
+
+		c := Commit{
+			Hash:    fields[0],
+			Author:  fields[3],
+			Message: strings.TrimSpace(fields[4]),
+		}
+		if parents := strings.Fields(fields[1]); len(parents) > 0 {
+			c.Parent = parents[0]
+		}
+		c.Time, _ = time.Parse(time.RFC3339, fields[2])
+
+		for _, line := range strings.Split(fields[5], "\n") {
+			status, path, ok := strings.Cut(strings.TrimSpace(line), "\t")
+			if !ok {
+				continue
+			}
+			c.Files = append(c.Files, FileChange{Path: path, Status: status[:1]})
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// ShowFile returns the contents of path at rev
+func (g *Git) ShowFile(rev, path string) ([]byte, error) {
+	return g.runBytes("show", rev+":"+path)
+}
+
+// Squash replaces the first-parent commits up to the newest one made before
+// the given time with a single root commit holding its tree, then replays
+// the later commits on top with their original authors, dates and
+// messages. Returns the number of commits squashed; 0 means there was
+// nothing to do. The worktree is not touched.
+func (g *Git) Squash(before time.Time, message string) (int, error) {
+	out, err := g.run("log", "--first-parent", "--reverse", "--format=%x1e%H%x1f%T%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%B")
+	if err != nil {
+		return 0, err
+	}
+
+	var commits [][]string
+	for _, record := range strings.Split(out, "\x1e") {
+		if fields := strings.Split(record, "\x1f"); len(fields) == 9 {
+			commits = append(commits, fields)
+		}
+	}
+	old := 0
+	for i, c := range commits {
+		if t, _ := time.Parse(time.RFC3339, c[7]); t.Before(before) {
+			old = i + 1
+		}
+	}
+	if old < 2 {
+		return 0, nil
+	}
+
+	last := commits[old-1]
+	parent, err := g.commitTree(last[1], "", last[2], last[3], last[4], last[5], last[6], last[7], message)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range commits[old:] {
+		if parent, err = g.commitTree(c[1], parent, c[2], c[3], c[4], c[5], c[6], c[7], strings.TrimSpace(c[8])); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := g.run("update-ref", "-m", "claude-code-sync gc", "HEAD", parent); err != nil {
+		return 0, err
+	}
+	return old, nil
+}
+
+// commitTree writes a commit object for tree with the given parent (none if
+// empty), author and committer
+func (g *Git) commitTree(tree, parent, an, ae, ad, cn, ce, cd, message string) (string, error) {
+	args := []string{"-C", g.repoDir, "commit-tree", tree, "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	cmd := command(args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+an, "GIT_AUTHOR_EMAIL="+ae, "GIT_AUTHOR_DATE="+ad,
+		"GIT_COMMITTER_NAME="+cn, "GIT_COMMITTER_EMAIL="+ce, "GIT_COMMITTER_DATE="+cd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git commit-tree: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Rewritten reports whether another machine has squashed the history (see
+// Squash) since HEAD last caught up with the remote. Call after Fetch.
+func (g *Git) Rewritten() (bool, error) {
+	root, err := g.runSilent("rev-list", "--max-parents=0", "--first-parent", g.remoteRef())
+	if err != nil || root == "" {
+		return false, nil
+	}
+	if msg, _ := g.runSilent("log", "-1", "--format=%B", root); !strings.Contains(msg, SquashMarker) {
+		return false, nil
+	}
+	_, err = g.runSilent("merge-base", "--is-ancestor", root, "HEAD")
+	return err != nil, nil
+}
+
+// FollowRewrite resets the branch to the remote when another machine has
+// squashed the history (see Squash) since the last pull, instead of merging
+// the old history back in. With keepFiles the index and worktree are left
+// as they are, so they can be committed on top; otherwise they are reset to
+// the remote too. Call after Fetch. Reports whether it reset.
+func (g *Git) FollowRewrite(keepFiles bool) (bool, error) {
+	if rewritten, err := g.Rewritten(); err != nil || !rewritten {
+		return false, err
+	}
+
+	mode := "--hard"
+	if keepFiles {
+		mode = "--soft"
+	}
+	if _, err := g.run("reset", mode, g.remoteRef()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ForcePush overwrites the current branch on origin, as long as nobody
+// pushed to it since the last fetch
+func (g *Git) ForcePush() error {
+	_, err := g.run("push", "--force-with-lease", "origin", "HEAD")
+	return pushError(err)
+}
+
+// GC drops unreachable objects, including the commits a Squash replaced,
+// and repacks the repo
+func (g *Git) GC() error {
+	if _, err := g.run("reflog", "expire", "--expire=now", "--all"); err != nil {
+		return err
+	}
+	_, err := g.run("gc", "--prune=now", "--quiet")
+	return err
+}
+
+// Bundle writes HEAD and its history to a bundle file, for peers to pull from
+func (g *Git) Bundle(path string) error {
+	_, err := g.run("bundle", "create", path, "HEAD")
+	return err
+}
+
+// PullBundle merges the HEAD of a bundle written by Bundle, like Pull does
+// for the remote
+func (g *Git) PullBundle(path string) error {
+	_, err := g.run("pull", "--no-edit", path, "HEAD")
+	if err != nil && strings.Contains(err.Error(), "unrelated histories") {
+		_, err = g.run("pull", "--no-edit", path, "HEAD", "--allow-unrelated-histories")
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testClones returns two clones of a new remote holding one commit with
+// path
+func testClones(t *testing.T, path string) (*Git, *Git) {
+	t.Helper()
+	if !IsInstalled() {
+		t.Skip("git is not installed")
+	}
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "test")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	gitCmd(t, dir, "init", "--bare", "-q", "-b", "main", remote)
+
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	gitCmd(t, dir, "clone", "-q", remote, a)
+	writeAndCommit(t, a, path, "base\n")
+	gitCmd(t, a, "push", "-q", "origin", "HEAD:main")
+	gitCmd(t, dir, "clone", "-q", remote, b)
+	return New(a), New(b)
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeAndCommit(t *testing.T, dir, path, content string) {
+	t.Helper()
+	dest := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "add", "-A")
+	gitCmd(t, dir, "commit", "-q", "-m", "change "+path)
+}
+
+func TestRebaseConflictPaths(t *testing.T) {
+	const path = "my notes/ü.md"
+	a, b := testClones(t, path)
+
+	writeAndCommit(t, a.repoDir, path, "from a\n")
+	gitCmd(t, a.repoDir, "push", "-q", "origin", "HEAD:main")
+	writeAndCommit(t, b.repoDir, path, "from b\n")
+
+	err := b.Rebase(false)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Rebase = %v, want a ConflictError", err)
+	}
+	if !slices.Equal(conflict.Paths, []string{path}) {
+		t.Errorf("conflict paths = %q, want %q", conflict.Paths, []string{path})
+	}
+
+	// The remote's version wins, as pull takes it
+	if err := b.Rebase(true); err != nil {
+		t.Fatalf("Rebase(remoteWins) = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(b.repoDir, filepath.FromSlash(path)))
+	if string(data) != "from a\n" {
+		t.Errorf("%s = %q after the remote won", path, data)
+	}
+}
+
+func TestMergeBranchesConflicts(t *testing.T) {
+	const path = "settings.json"
+	a, b := testClones(t, path)
+	writeAndCommit(t, a.repoDir, path, "{\n  \"theme\": \"dark\",\n  \"model\": \"opus\"\n}\n")
+	gitCmd(t, a.repoDir, "push", "-q", "origin", "HEAD:sync/a")
+	writeAndCommit(t, b.repoDir, path, "{\n  \"theme\": \"light\",\n  \"model\": \"sonnet\"\n}\n")
+
+	_, err := b.MergeBranches("sync/", false)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !slices.Equal(conflict.Paths, []string{path}) {
+		t.Fatalf("MergeBranches = %v, want a ConflictError for %s", err, path)
+	}
+
+	// Kept local, the file is settled without mixing the two versions
+	merged, err := b.MergeBranches("sync/", false, path)
+	if err != nil || !slices.Equal(merged, []string{"sync/a"}) {
+		t.Fatalf("MergeBranches keeping %s = %q, %v", path, merged, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(b.repoDir, path))
+	if want := "{\n  \"theme\": \"light\",\n  \"model\": \"sonnet\"\n}\n"; string(data) != want {
+		t.Errorf("%s = %q, want this machine's version", path, data)
+	}
+}
+
+func TestMergeBranchesRemoteWins(t *testing.T) {
+	const path = "my notes/ü.md"
+	a, b := testClones(t, path)
+	writeAndCommit(t, a.repoDir, path, "one\nfrom a\nthree\n")
+	gitCmd(t, a.repoDir, "push", "-q", "origin", "HEAD:sync/a")
+	writeAndCommit(t, b.repoDir, path, "one\nfrom b\nthree\n")
+
+	if _, err := b.MergeBranches("sync/", true); err != nil {
+		t.Fatalf("MergeBranches = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(b.repoDir, filepath.FromSlash(path)))
+	if string(data) != "one\nfrom a\nthree\n" {
+		t.Errorf("%s = %q, want the merged branch's version", path, data)
+	}
+}
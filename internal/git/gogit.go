@@ -0,0 +1,849 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// GoGit implements Repo with go-git, for machines without a git binary.
+//
+// Authentication: SSH URLs use the running ssh-agent. HTTPS URLs use a token
+// from CLAUDE_SYNC_GIT_TOKEN or GITHUB_TOKEN if set (git credential helpers
+// are not consulted).
+type GoGit struct {
+	repoDir string
+}
+
+// NewGoGit creates a go-git backed Repo for the given directory
+func NewGoGit(repoDir string) *GoGit {
+	return &GoGit{repoDir: repoDir}
+}
+
+// open opens the repository on disk
+func (g *GoGit) open() (*gogit.Repository, error) {
+	return gogit.PlainOpen(g.repoDir)
+}
+
+// worktree opens the repository and its worktree
+func (g *GoGit) worktree() (*gogit.Repository, *gogit.Worktree, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, wt, nil
+}
+
+// Init initializes a new git repository
+func (g *GoGit) Init() error {
+	if err := os.MkdirAll(g.repoDir, 0755); err != nil {
+		return err
+	}
+	_, err := gogit.PlainInit(g.repoDir, false)
+	return err
+}
+
+// AddAll stages all changes, including deletions
+func (g *GoGit) AddAll() error {
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.AddWithOptions(&gogit.AddOptions{All: true}); err != nil {
+		return fmt.Errorf("git add -A: %w", err)
+	}
+	return nil
+}
+
+// Commit creates a commit with the given message
+func (g *GoGit) Commit(message string) error {
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	_, err = wt.Commit(message, &gogit.CommitOptions{Author: signature()})
+	if err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+	return nil
+}
+
+// HasChanges checks if there are staged changes to commit
+func (g *GoGit) HasChanges() (bool, error) {
+	_, wt, err := g.worktree()
+	if err != nil {
+		return false, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range status {
+		if s.Staging != gogit.Unmodified && s.Staging != gogit.Untracked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Push pushes the current branch to origin
+func (g *GoGit) Push() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name()))
+	err = repo.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{refSpec},
+		Auth:       g.auth(repo),
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return pushError(fmt.Errorf("git push origin HEAD: %w", err))
+	}
+	return nil
+}
+
+// PushBranch pushes HEAD to branch on origin
+func (g *GoGit) PushBranch(branch string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("%s:%s", head.Name(), plumbing.NewBranchReferenceName(branch)))
+	err = repo.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{refSpec},
+		Auth:       g.auth(repo),
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return pushError(fmt.Errorf("git push origin HEAD:%s: %w", branch, err))
+	}
+	return nil
+}
+
+// Rebase is not supported: go-git cannot rebase
+func (g *GoGit) Rebase(remoteWins bool, keepLocal ...string) error {
+	return fmt.Errorf("go-git cannot rebase; install git and set git_backend: cli")
+}
+
+// MergeBranches is not supported: go-git cannot merge
+func (g *GoGit) MergeBranches(prefix string, remoteWins bool, keepLocal ...string) ([]string, error) {
+	return nil, fmt.Errorf("git_branches: per-machine needs to merge branches, which go-git cannot do; install git and set git_backend: cli")
+}
+
+// Pull fast-forwards the current branch from origin.
+// go-git cannot merge diverged histories; install git for that.
+func (g *GoGit) Pull() error {
+	repo, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+
+	opts := &gogit.PullOptions{
+		RemoteName: "origin",
+		Auth:       g.auth(repo),
+	}
+	if head, err := repo.Head(); err == nil {
+		opts.ReferenceName = head.Name()
+	}
+
+	err = wt.Pull(opts)
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	if errors.Is(err, gogit.ErrNonFastForwardUpdate) {
+		return fmt.Errorf("git pull origin HEAD: histories have diverged and go-git cannot merge them; install git and set git_backend: cli")
+	}
+	if err != nil {
+		return fmt.Errorf("git pull origin HEAD: %w", err)
+	}
+	return nil
+}
+
+// Fetch fetches from remote
+func (g *GoGit) Fetch() error {
+	repo, err := g.open()
+	if err != nil {
+		return nil
+	}
+	_ = repo.Fetch(&gogit.FetchOptions{RemoteName: "origin", Auth: g.auth(repo)})
+	return nil // Ignore errors, fetch is best-effort
+}
+
+// HasRemote checks if origin remote exists
+func (g *GoGit) HasRemote() bool {
+	repo, err := g.open()
+	if err != nil {
+		return false
+	}
+	_, err = repo.Remote("origin")
+	return err == nil
+}
+
+// AddRemote adds a remote
+func (g *GoGit) AddRemote(name, url string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}})
+	return err
+}
+
+// RemoveRemote removes a remote
+func (g *GoGit) RemoveRemote(name string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	return repo.DeleteRemote(name)
+}
+
+// RemoteURL returns the URL of origin
+func (g *GoGit) RemoteURL() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", err
+	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return urls[0], nil
+	}
+	return "", fmt.Errorf("origin has no URL")
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (g *GoGit) CurrentBranch() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached")
+	}
+	return head.Name().Short(), nil
+}
+
+// GetLocalCommit returns the current HEAD commit hash
+func (g *GoGit) GetLocalCommit() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// GetRemoteCommit returns the origin/HEAD commit hash, falling back to
+// the remote-tracking ref of the current branch
+func (g *GoGit) GetRemoteCommit() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	if ref, err := repo.Reference(plumbing.ReferenceName("refs/remotes/origin/HEAD"), true); err == nil {
+		return ref.Hash().String(), nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+	if err != nil {
+		return "", err
+	}
+	return ref.Hash().String(), nil
+}
+
+// AheadBehind counts the commits HEAD has that the remote doesn't, and the
+// other way round, as of the last fetch
+func (g *GoGit) AheadBehind() (int, int, error) {
+	repo, err := g.open()
+	if err != nil {
+		return 0, 0, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return 0, 0, err
+	}
+	remote, err := g.GetRemoteCommit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	local, err := ancestors(repo, head.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	theirs, err := ancestors(repo, plumbing.NewHash(remote))
+	if err != nil {
+		return 0, 0, err
+	}
+	var ahead, behind int
+	for h := range local {
+		if !theirs[h] {
+			ahead++
+		}
+	}
+	for h := range theirs {
+		if !local[h] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// ancestors returns every commit reachable from hash, including itself
+func ancestors(repo *gogit.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	iter, err := repo.Log(&gogit.LogOptions{From: hash})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	seen := map[plumbing.Hash]bool{}
+	err = iter.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+// LastCommitTime returns the committer date of HEAD
+func (g *GoGit) LastCommitTime() (time.Time, error) {
+	repo, err := g.open()
+	if err != nil {
+		return time.Time{}, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return time.Time{}, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return commit.Committer.When, nil
+}
+
+// IsRepo checks if the directory is a git repository
+func (g *GoGit) IsRepo() bool {
+	_, err := os.Stat(filepath.Join(g.repoDir, ".git"))
+	return err == nil
+}
+
+// CreateInitialCommit creates a README and initial commit
+func (g *GoGit) CreateInitialCommit() error {
+	readme := filepath.Join(g.repoDir, "README.md")
+	if err := os.WriteFile(readme, []byte("# Claude Code Sync\n"), 0644); err != nil {
+		return err
+	}
+
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Add("README.md"); err != nil {
+		return err
+	}
+
+	return g.Commit("Initial commit")
+}
+
+// ResolveRevision turns a revision (hash, HEAD~1, tag, ...) into a full commit hash
+func (g *GoGit) ResolveRevision(rev string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return hash.String(), nil
+}
+
+// ExportTree writes the files of a commit into dest without touching the worktree
+func (g *GoGit) ExportTree(rev, dest string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("unknown revision %q", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return err
+	}
+	files, err := commit.Files()
+	if err != nil {
+		return err
+	}
+
+	return files.ForEach(func(f *object.File) error {
+		if !f.Mode.IsFile() {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, []byte(contents), 0644)
+	})
+}
+
+// Log returns up to limit commits reachable from HEAD (0 = all), newest first
+func (g *GoGit) Log(limit int) ([]Commit, error) {
+	return g.LogFrom("HEAD", limit)
+}
+
+// LogFrom returns up to limit commits reachable from rev (0 = all), newest
+// first
+func (g *GoGit) LogFrom(rev string, limit int) ([]Commit, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	from, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	iter, err := repo.Log(&gogit.LogOptions{From: *from})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var commits []Commit
+	for limit <= 0 || len(commits) < limit {
+		commit, err := iter.Next()
+		if err != nil {
+			break
+		}
+
+		c := Commit{
+			Hash:    commit.Hash.String(),
+			Time:    commit.Committer.When,
+			Author:  commit.Author.Name,
+			Message: strings.TrimSpace(commit.Message),
+		}
+
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		var parentTree *object.Tree
+		if commit.NumParents() > 0 {
+			parent, err := commit.Parent(0)
+			if err != nil {
+				return nil, err
+			}
+			c.Parent = parent.Hash.String()
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			action, err := change.Action()
+			if err != nil {
+				return nil, err
+			}
+			switch action {
+			case merkletrie.Insert:
+				c.Files = append(c.Files, FileChange{Path: change.To.Name, Status: "A"})
+			case merkletrie.Delete:
+				c.Files = append(c.Files, FileChange{Path: change.From.Name, Status: "D"})
+			default:
+				c.Files = append(c.Files, FileChange{Path: change.To.Name, Status: "M"})
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// ShowFile returns the contents of path at rev
+func (g *GoGit) ShowFile(rev, path string) ([]byte, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not exist in %s", path, hash.String()[:7])
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
+
+// auth picks credentials for the origin remote of repo
+func (g *GoGit) auth(repo *gogit.Repository) transport.AuthMethod {
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return nil
+	}
+	return goGitAuth(remote.Config().URLs[0])
+}
+
+// goGitAuth returns token auth for HTTPS URLs when a token is available.
+// SSH URLs return nil so go-git falls back to ssh-agent.
+func goGitAuth(url string) transport.AuthMethod {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil
+	}
+	token := os.Getenv("CLAUDE_SYNC_GIT_TOKEN")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: "x-access-token", Password: token}
+}
+
+// signature builds a commit author from the global git config, if any
+func signature() *object.Signature {
+	sig := &object.Signature{
+		Name:  "claude-code-sync",
+		Email: "claude-code-sync@localhost",
+		When:  time.Now(),
+	}
+	if cfg, err := gitconfig.LoadConfig(gitconfig.GlobalScope); err == nil {
+		if cfg.User.Name != "" {
+			sig.Name = cfg.User.Name
+		}
+		if cfg.User.Email != "" {
+			sig.Email = cfg.User.Email
+		}
+	}
+	return sig
+}
+
+// cloneGoGit clones a remote repository with go-git
+func cloneGoGit(url, dest string) error {
+	_, err := gogit.PlainClone(dest, false, &gogit.CloneOptions{
+		URL:      url,
+		Auth:     goGitAuth(url),
+		Progress: os.Stdout,
+	})
+	return err
+}
+
+// checkRemoteGoGit verifies a remote URL is accessible with go-git
+func checkRemoteGoGit(url string) error {
+	remote := gogit.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	_, err := remote.List(&gogit.ListOptions{Auth: goGitAuth(url)})
+	if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return err
+	}
+	return nil
+}
+
+// probeRemoteGoGit checks origin the way ProbeRemote does, using an
+// upload-pack listing for read access and a receive-pack handshake for write
+// access
+func probeRemoteGoGit(repoDir string, timeout time.Duration) RemoteCheck {
+	g := NewGoGit(repoDir)
+	repo, err := g.open()
+	if err != nil {
+		return RemoteCheck{Err: err}
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return RemoteCheck{Err: fmt.Errorf("no origin remote")}
+	}
+	url := remote.Config().URLs[0]
+	auth := goGitAuth(url)
+	check := RemoteCheck{URL: redactURL(url), Auth: goGitAuthMethod(url)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err = remote.ListContext(ctx, &gogit.ListOptions{Auth: auth})
+	if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		check.Err = timeoutError(ctx, "list", timeout, err)
+		return check
+	}
+	check.Reachable = true
+
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	client, err := gitclient.NewClient(endpoint)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	session, err := client.NewReceivePackSession(endpoint, auth)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	defer session.Close()
+	_, err = session.AdvertisedReferencesContext(ctx)
+	if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		check.Err = timeoutError(ctx, "push", timeout, err)
+		return check
+	}
+	check.Writable = true
+	return check
+}
+
+// timeoutError reports err, or a timeout if ctx expired
+func timeoutError(ctx context.Context, op string, timeout time.Duration, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: timed out after %s", op, timeout)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// goGitAuthMethod describes the credentials goGitAuth picks for url
+func goGitAuthMethod(url string) string {
+	switch {
+	case isLocalURL(url):
+		return "none (local path)"
+	case !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://"):
+		return "SSH key (ssh-agent)"
+	case os.Getenv("CLAUDE_SYNC_GIT_TOKEN") != "":
+		return "token (CLAUDE_SYNC_GIT_TOKEN)"
+	case os.Getenv("GITHUB_TOKEN") != "":
+		return "token (GITHUB_TOKEN)"
+	case redactURL(url) != url:
+		return "credentials embedded in the remote URL"
+	}
+	return "none (anonymous HTTPS)"
+}
+
+// Squash replaces the first-parent commits up to the newest one made before
+// the given time with a single root commit and replays the later ones on top (see Git.Squash)
+func (g *GoGit) Squash(before time.Time, message string) (int, error) {
+	repo, err := g.open()
+	if err != nil {
+		return 0, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return 0, err
+	}
+
+	// First-parent chain, oldest first
+	var commits []*object.Commit
+	for c, err := repo.CommitObject(head.Hash()); ; c, err = c.Parent(0) {
+		if err != nil {
+			return 0, err
+		}
+		commits = append([]*object.Commit{c}, commits...)
+		if c.NumParents() == 0 {
+			break
+		}
+	}
+
+	old := 0
+	for i, c := range commits {
+		if c.Committer.When.Before(before) {
+			old = i + 1
+		}
+	}
+	if old < 2 {
+		return 0, nil
+	}
+
+	last := commits[old-1]
+	parent, err := writeCommit(repo, &object.Commit{Author: last.Author, Committer: last.Committer, Message: message, TreeHash: last.TreeHash})
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range commits[old:] {
+		parent, err = writeCommit(repo, &object.Commit{Author: c.Author, Committer: c.Committer, Message: c.Message, TreeHash: c.TreeHash, ParentHashes: []plumbing.Hash{parent}})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), parent)); err != nil {
+		return 0, err
+	}
+	return old, nil
+}
+
+// writeCommit stores a commit object and returns its hash
+func writeCommit(repo *gogit.Repository, c *object.Commit) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	if err := c.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// Rewritten reports whether another machine has squashed the history since
+// HEAD last caught up with the remote (see Git.Rewritten)
+func (g *GoGit) Rewritten() (bool, error) {
+	repo, err := g.open()
+	if err != nil {
+		return false, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return false, nil
+	}
+	remote, err := g.GetRemoteCommit()
+	if err != nil {
+		return false, nil
+	}
+
+	root, err := repo.CommitObject(plumbing.NewHash(remote))
+	for err == nil && root.NumParents() > 0 {
+		root, err = root.Parent(0)
+	}
+	if err != nil || !strings.Contains(root.Message, SquashMarker) {
+		return false, nil
+	}
+	local, err := ancestors(repo, head.Hash())
+	if err != nil {
+		return false, err
+	}
+	return !local[root.Hash], nil
+}
+
+// FollowRewrite resets the branch to the remote when another machine has
+// squashed the history since the last pull (see Git.FollowRewrite)
+func (g *GoGit) FollowRewrite(keepFiles bool) (bool, error) {
+	if rewritten, err := g.Rewritten(); err != nil || !rewritten {
+		return false, err
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return false, err
+	}
+	remote, err := g.GetRemoteCommit()
+	if err != nil {
+		return false, err
+	}
+
+	mode := gogit.HardReset
+	if keepFiles {
+		mode = gogit.SoftReset
+	}
+	if err := wt.Reset(&gogit.ResetOptions{Commit: plumbing.NewHash(remote), Mode: mode}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ForcePush overwrites the current branch on origin
+func (g *GoGit) ForcePush() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("+%s:%s", head.Name(), head.Name()))
+	err = repo.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{refSpec},
+		Auth:       g.auth(repo),
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("git push --force origin HEAD: %w", err)
+	}
+	return nil
+}
+
+// GC drops unreachable objects and repacks the repo
+func (g *GoGit) GC() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	err = repo.Prune(gogit.PruneOptions{OnlyObjectsOlderThan: time.Now(), Handler: repo.DeleteObject})
+	if err != nil && !errors.Is(err, gogit.ErrLooseObjectsNotSupported) {
+		return err
+	}
+	err = repo.RepackObjects(&gogit.RepackConfig{})
+	if errors.Is(err, gogit.ErrPackedObjectsNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// Bundle is not supported: go-git cannot write bundles
+func (g *GoGit) Bundle(path string) error {
+	return fmt.Errorf("go-git cannot write bundles; install git and set git_backend: cli")
+}
+
+// PullBundle is not supported: go-git cannot read bundles
+func (g *GoGit) PullBundle(path string) error {
+	return fmt.Errorf("go-git cannot read bundles; install git and set git_backend: cli")
+}
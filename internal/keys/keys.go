@@ -0,0 +1,125 @@
+// Package keys finds this machine's age identity. It comes from the
+// CLAUDE_SYNC_KEY environment variable if set, then from the output of
+// key_command in config.yaml, then from identity.key, so CI machines and
+// password manager users never need the key on disk.
+package keys
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	gosync "sync"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// EnvVar holds the key itself (the AGE-SECRET-KEY- line, or a whole key file)
+const EnvVar = "CLAUDE_SYNC_KEY"
+
+// Where a key was found
+const (
+	SourceEnv     = "env"
+	SourceCommand = "command"
+	SourceFile    = "file"
+)
+
+// Commands are run once per process; password managers may prompt each time
+var (
+	commandMu   gosync.Mutex
+	commandKeys = map[string]*age.X25519Identity{}
+)
+
+// Source returns where Load will read the key from, or "" if nowhere
+func Source(paths config.Paths, keyCommand string) string {
+	switch {
+	case os.Getenv(EnvVar) != "":
+		return SourceEnv
+	case keyCommand != "":
+		return SourceCommand
+	case sync.FileExists(paths.KeyFile):
+		return SourceFile
+	}
+	return ""
+}
+
+// Available reports whether a key is configured, without running key_command
+func Available(paths config.Paths, keyCommand string) bool {
+	return Source(paths, keyCommand) != ""
+}
+
+// Describe names the key's source for messages, e.g. "$CLAUDE_SYNC_KEY"
+func Describe(paths config.Paths, keyCommand string) string {
+	switch Source(paths, keyCommand) {
+	case SourceEnv:
+		return "$" + EnvVar
+	case SourceCommand:
+		return fmt.Sprintf("key_command (%s)", keyCommand)
+	case SourceFile:
+		return paths.KeyFile
+	}
+	return "none"
+}
+
+// Load returns the identity from the first configured source
+func Load(paths config.Paths, keyCommand string) (*age.X25519Identity, error) {
+	switch Source(paths, keyCommand) {
+	case SourceEnv:
+		identity, err := crypto.ParseKey(os.Getenv(EnvVar))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in %s: %w", EnvVar, err)
+		}
+		return identity, nil
+	case SourceCommand:
+		return runCommand(keyCommand)
+	case SourceFile:
+		return crypto.LoadKey(paths.KeyFile)
+	}
+	return nil, fmt.Errorf("no key found. Run 'claude-code-sync init' or 'claude-code-sync import-key', or set %s", EnvVar)
+}
+
+// PublicKey returns the recipient of the configured identity
+func PublicKey(paths config.Paths, keyCommand string) (string, error) {
+	identity, err := Load(paths, keyCommand)
+	if err != nil {
+		return "", err
+	}
+	return identity.Recipient().String(), nil
+}
+
+// runCommand runs key_command through the shell and parses what it prints
+func runCommand(command string) (*age.X25519Identity, error) {
+	commandMu.Lock()
+	defer commandMu.Unlock()
+	if identity, ok := commandKeys[command]; ok {
+		return identity, nil
+	}
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	} else {
+		c = exec.Command("sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	c.Stdin, c.Stderr = os.Stdin, &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("key_command failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("key_command failed: %w", err)
+	}
+
+	identity, err := crypto.ParseKey(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("key_command did not print a key: %w", err)
+	}
+	commandKeys[command] = identity
+	return identity, nil
+}
@@ -0,0 +1,125 @@
+package keys
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/shamir"
+	"gopkg.in/yaml.v3"
+)
+
+// ShareFile is one share of a split key, as written by 'key split'
+type ShareFile struct {
+	Version   int    `yaml:"version"`
+	Set       string `yaml:"set"` // Random ID shared by the shares of one split
+	Threshold int    `yaml:"threshold"`
+	Shares    int    `yaml:"shares"`
+	Index     int    `yaml:"index"`
+	PublicKey string `yaml:"public_key"` // Checks the recovered key
+	Share     string `yaml:"share"`      // Hex
+}
+
+const shareVersion = 1
+
+// SplitKey divides identity into n share files, any k of which recover it
+func SplitKey(identity *age.X25519Identity, n, k int) ([]ShareFile, error) {
+	shares, err := shamir.Split([]byte(identity.String()), n, k)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	files := make([]ShareFile, n)
+	for i, s := range shares {
+		files[i] = ShareFile{
+			Version:   shareVersion,
+			Set:       hex.EncodeToString(id),
+			Threshold: k,
+			Shares:    n,
+			Index:     int(s.X),
+			PublicKey: identity.Recipient().String(),
+			Share:     hex.EncodeToString(s.Y),
+		}
+	}
+	return files, nil
+}
+
+// RecoverKey combines share files from one split back into the identity
+func RecoverKey(files []ShareFile) (*age.X25519Identity, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no shares given")
+	}
+	first := files[0]
+	var shares []shamir.Share
+	for _, f := range files {
+		if f.Set != first.Set || f.PublicKey != first.PublicKey {
+			return nil, errors.New("the shares come from different splits")
+		}
+		y, err := hex.DecodeString(f.Share)
+		if err != nil || f.Index < 1 || f.Index > shamir.MaxShares {
+			return nil, fmt.Errorf("share %d is corrupt", f.Index)
+		}
+		shares = append(shares, shamir.Share{X: byte(f.Index), Y: y})
+	}
+	if len(shares) < first.Threshold {
+		return nil, fmt.Errorf("need %d of the %d shares, got %d", first.Threshold, first.Shares, len(shares))
+	}
+
+	secret, err := shamir.Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := crypto.ParseKey(string(secret))
+	if err != nil || identity.Recipient().String() != first.PublicKey {
+		return nil, errors.New("the shares do not recover the key (one may be corrupt)")
+	}
+	return identity, nil
+}
+
+// WriteShareFile saves a share with a comment explaining what it is. It
+// refuses to replace an existing file.
+func WriteShareFile(path string, s ShareFile) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# claude-code-sync key share %d of %d. Any %d shares recover the key with\n"+
+		"# 'claude-code-sync key recover'; fewer reveal nothing about it.\n"+
+		"# Fingerprint: %s\n",
+		s.Index, s.Shares, s.Threshold, crypto.Fingerprint(s.PublicKey))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(header + string(data)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadShareFile loads a share written by WriteShareFile
+func ReadShareFile(path string) (ShareFile, error) {
+	var s ShareFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := yaml.Unmarshal(data, &s); err != nil || s.Version == 0 || strings.TrimSpace(s.Share) == "" {
+		return s, fmt.Errorf("%s is not a key share", path)
+	}
+	if s.Version > shareVersion {
+		return s, fmt.Errorf("%s was written by a newer claude-code-sync", path)
+	}
+	return s, nil
+}
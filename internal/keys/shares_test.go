@@ -0,0 +1,48 @@
+package keys
+
+import (
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestRecoverKey(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := SplitKey(identity, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := RecoverKey([]ShareFile{files[4], files[0], files[2]})
+	if err != nil {
+		t.Fatalf("RecoverKey(3 of 5): %v", err)
+	}
+	if got.String() != identity.String() {
+		t.Error("RecoverKey(3 of 5) recovered a different key")
+	}
+	if _, err := RecoverKey(files); err != nil {
+		t.Errorf("RecoverKey(5 of 5): %v", err)
+	}
+
+	if _, err := RecoverKey(files[:2]); err == nil || !strings.Contains(err.Error(), "need 3 of the 5 shares") {
+		t.Errorf("RecoverKey(2 of 5) = %v, want the threshold error", err)
+	}
+
+	other, err := SplitKey(identity, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverKey([]ShareFile{files[0], files[1], other[2]}); err == nil {
+		t.Error("RecoverKey accepted shares from two splits")
+	}
+
+	corrupt := append([]ShareFile(nil), files[:3]...)
+	corrupt[1].Share = strings.Repeat("00", len(corrupt[1].Share)/2)
+	if _, err := RecoverKey(corrupt); err == nil {
+		t.Error("RecoverKey accepted a corrupt share")
+	}
+}
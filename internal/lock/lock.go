@@ -0,0 +1,86 @@
+// Package lock provides an exclusive cross-process lock on a file, so a
+// scheduled push and a manual pull can't run at the same time.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked")
+
+// pollInterval is how often Acquire retries while waiting
+const pollInterval = 200 * time.Millisecond
+
+// Lock is a held lock; release it with Unlock
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the lock at path, waiting up to timeout for another process
+// to release it. The lock is released automatically if the process dies.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("another claude-code-sync process%s is running (lock: %s)", holder(path), path)
+		}
+		time.Sleep(pollInterval)
+	}
+
+	// Record our PID for the error message other processes show
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	l.f.Truncate(0)
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// holder describes the process holding the lock, e.g. " (pid 1234)"
+func holder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(data))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return ""
+	}
+	return fmt.Sprintf(" (pid %s)", pid)
+}
@@ -0,0 +1,89 @@
+// Package logfile writes timestamped, levelled operation logs to a file in
+// the sync dir, rotating it so it never grows without bound. Several
+// processes (a manual pull and a hook-triggered push) may append at once.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Levels, lowest first
+const (
+	Debug = "DEBUG"
+	Info  = "INFO"
+	Warn  = "WARN"
+	Error = "ERROR"
+)
+
+// Name is the current log file; rotated files get .1, .2, ... appended
+const Name = "claude-code-sync.log"
+
+// Rotation limits
+const (
+	MaxSize  = 1 << 20 // Rotate once the file reaches 1 MiB
+	MaxFiles = 5       // Rotated files to keep besides the current one
+)
+
+// Log is an open log file
+type Log struct {
+	mu      sync.Mutex
+	f       *os.File
+	command string
+}
+
+// Open opens the log in dir for appending, rotating it first if it is too
+// large. command is written on every line so interleaved runs can be told
+// apart.
+func Open(dir, command string) (*Log, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, Name)
+	if info, err := os.Stat(path); err == nil && info.Size() >= MaxSize {
+		rotate(path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{f: f, command: command}, nil
+}
+
+// Write appends one line per line of msg
+func (l *Log) Write(level, msg string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(msg, "\n"), "\n") {
+		fmt.Fprintf(&b, "%s %-5s [%d %s] %s\n", stamp, level, os.Getpid(), l.command, line)
+	}
+	l.f.WriteString(b.String())
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// rotate shifts path to path.1, path.1 to path.2 and so on, dropping the
+// oldest
+func rotate(path string) {
+	os.Remove(fmt.Sprintf("%s.%d", path, MaxFiles))
+	for i := MaxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
@@ -0,0 +1,93 @@
+// Package minisign verifies minisign signatures (https://jedisct1.github.io/minisign/),
+// used to check that release files were signed by the project's key.
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signature algorithms
+const (
+	algPure      = "Ed" // Ed25519 over the file
+	algPrehashed = "ED" // Ed25519 over the BLAKE2b-512 hash of the file (minisign's default)
+)
+
+const (
+	untrustedPrefix = "untrusted comment: "
+	trustedPrefix   = "trusted comment: "
+)
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  uint64
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey reads a public key in minisign.pub format (an untrusted
+// comment line followed by the base64 key) or as the bare base64 line
+func ParsePublicKey(s string) (*PublicKey, error) {
+	var line string
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, untrustedPrefix) {
+			line = l
+			break
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algPure {
+		return nil, errors.New("invalid minisign public key")
+	}
+	return &PublicKey{
+		ID:  binary.LittleEndian.Uint64(raw[2:10]),
+		key: ed25519.PublicKey(raw[10:]),
+	}, nil
+}
+
+// Verify checks a .minisig signature over msg, including its trusted
+// comment, and returns the trusted comment
+func (pk *PublicKey) Verify(msg, sig []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return "", errors.New("malformed minisign signature")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+	alg, id, signature := string(raw[:2]), binary.LittleEndian.Uint64(raw[2:10]), raw[10:]
+	if id != pk.ID {
+		return "", fmt.Errorf("signed with key %016X, expected %016X", id, pk.ID)
+	}
+
+	switch alg {
+	case algPure:
+	case algPrehashed:
+		sum := blake2b.Sum512(msg)
+		msg = sum[:]
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	if !ed25519.Verify(pk.key, msg, signature) {
+		return "", errors.New("signature does not match")
+	}
+
+	comment := strings.TrimSuffix(strings.TrimPrefix(lines[2], trustedPrefix), "\r")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+	if !ed25519.Verify(pk.key, bytes.Join([][]byte{signature, []byte(comment)}, nil), global) {
+		return "", errors.New("trusted comment signature does not match")
+	}
+	return comment, nil
+}
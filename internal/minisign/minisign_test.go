@@ -0,0 +1,99 @@
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const testKeyID = 0x1122334455667788
+
+var testKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+// testPublicKey formats testKey the way minisign -G writes minisign.pub
+func testPublicKey() string {
+	raw := append([]byte(algPure), make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(raw[2:], testKeyID)
+	raw = append(raw, testKey.Public().(ed25519.PublicKey)...)
+	return "untrusted comment: minisign public key 1122334455667788\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// sign writes a .minisig for msg the way minisign -S does
+func sign(alg string, keyID uint64, msg []byte, comment string) []byte {
+	signed := msg
+	if alg == algPrehashed {
+		sum := blake2b.Sum512(msg)
+		signed = sum[:]
+	}
+	signature := ed25519.Sign(testKey, signed)
+	raw := append([]byte(alg), make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(raw[2:], keyID)
+	raw = append(raw, signature...)
+	global := ed25519.Sign(testKey, append(append([]byte{}, signature...), comment...))
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestVerify(t *testing.T) {
+	pk, err := ParsePublicKey(testPublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.ID != testKeyID {
+		t.Fatalf("ID = %016X, want %016X", pk.ID, uint64(testKeyID))
+	}
+	msg := []byte("abc123  claude-code-sync_linux_amd64.tar.gz\n")
+
+	for _, alg := range []string{algPrehashed, algPure} {
+		comment, err := pk.Verify(msg, sign(alg, testKeyID, msg, "claude-code-sync v1.2.3"))
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if comment != "claude-code-sync v1.2.3" {
+			t.Errorf("%s: comment = %q", alg, comment)
+		}
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	pk, err := ParsePublicKey(testPublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("abc123  claude-code-sync_linux_amd64.tar.gz\n")
+	good := sign(algPrehashed, testKeyID, msg, "claude-code-sync v1.2.3")
+
+	tests := []struct {
+		name string
+		msg  []byte
+		sig  []byte
+		want string
+	}{
+		{"changed file", []byte("def456  claude-code-sync_linux_amd64.tar.gz\n"), good, "signature does not match"},
+		{"changed trusted comment", msg, []byte(strings.Replace(string(good), "v1.2.3", "v9.9.9", 1)), "trusted comment signature does not match"},
+		{"other key", msg, sign(algPrehashed, 0x99, msg, "x"), "signed with key"},
+		{"unknown algorithm", msg, sign("XX", testKeyID, msg, "x"), "unsupported signature algorithm"},
+		{"truncated", msg, good[:len(good)/2], "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pk.Verify(tt.msg, tt.sig)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublicKeyRejectsPlaceholder(t *testing.T) {
+	if _, err := ParsePublicKey("untrusted comment: claude-code-sync release signing key\n"); err == nil {
+		t.Fatal("accepted a key file without a key")
+	}
+}
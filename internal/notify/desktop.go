@@ -0,0 +1,50 @@
+// Package notify reports sync results outside the terminal, for pushes
+// that run in the background where nobody reads the output.
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// AppName is shown as the sender of desktop notifications
+const AppName = "claude-code-sync"
+
+// toastScript shows a Windows toast with the title and body passed in the
+// environment, which avoids quoting them into the script
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode($env:CLAUDE_SYNC_NOTIFY_TITLE)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode($env:CLAUDE_SYNC_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + AppName + `').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// Desktop shows a notification with the platform's notifier: Notification
+// Center on macOS, notify-send (libnotify) on Linux and the BSDs, and a
+// toast on Windows.
+func Desktop(title, body string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body)
+	case "windows":
+		c = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+		c.Env = append(os.Environ(), "CLAUDE_SYNC_NOTIFY_TITLE="+title, "CLAUDE_SYNC_NOTIFY_BODY="+body)
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return fmt.Errorf("notify-send not found; install libnotify to get desktop notifications")
+		}
+		c = exec.Command("notify-send", "--app-name="+AppName, title, body)
+	}
+
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", c.Args[0], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook payload formats
+const (
+	FormatSlack   = "slack"   // {"text": ...} for Slack incoming webhooks (and Mattermost)
+	FormatDiscord = "discord" // {"content": ...} for Discord webhooks
+	FormatNtfy    = "ntfy"    // Plain text body with a Title header, for ntfy topics
+	FormatJSON    = "json"    // The Event itself, for anything else
+)
+
+// Event kinds
+const (
+	EventPush     = "push"     // A push committed and uploaded changes
+	EventPull     = "pull"     // A pull changed local files
+	EventConflict = "conflict" // Another machine pushed first, or a pull replaced local edits
+	EventFailure  = "failure"  // A push or pull failed
+)
+
+// Event is a sync event posted to webhooks
+type Event struct {
+	Kind    string    `json:"event"`
+	Machine string    `json:"machine"`
+	Profile string    `json:"profile,omitempty"`
+	Summary string    `json:"summary"`
+	Files   []string  `json:"files,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// maxListedFiles caps the files named in chat messages
+const maxListedFiles = 10
+
+// Text renders the event as a one-line chat message plus the files changed
+func (e Event) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", e.Machine, e.Summary)
+	if e.Profile != "" {
+		fmt.Fprintf(&b, " (profile %s)", e.Profile)
+	}
+	for i, f := range e.Files {
+		if i == maxListedFiles {
+			fmt.Fprintf(&b, "\n• ... and %d more", len(e.Files)-maxListedFiles)
+			break
+		}
+		fmt.Fprintf(&b, "\n• %s", f)
+	}
+	return b.String()
+}
+
+// webhookTimeout bounds a webhook post so a dead endpoint can't stall a sync
+const webhookTimeout = 10 * time.Second
+
+// GuessFormat picks a payload format from the webhook URL
+func GuessFormat(url string) string {
+	switch {
+	case strings.Contains(url, "hooks.slack.com"):
+		return FormatSlack
+	case strings.Contains(url, "discord.com/api/webhooks"), strings.Contains(url, "discordapp.com/api/webhooks"):
+		return FormatDiscord
+	case strings.Contains(url, "ntfy.sh/"):
+		return FormatNtfy
+	}
+	return FormatJSON
+}
+
+// Post sends ev to a webhook. An empty format is guessed from the URL.
+func Post(url, format string, ev Event) error {
+	if format == "" {
+		format = GuessFormat(url)
+	}
+
+	var body []byte
+	contentType := "application/json"
+	var err error
+	switch format {
+	case FormatSlack:
+		body, err = json.Marshal(map[string]string{"text": ev.Text()})
+	case FormatDiscord:
+		body, err = json.Marshal(map[string]string{"content": ev.Text()})
+	case FormatNtfy:
+		body, contentType = []byte(ev.Text()), "text/plain; charset=utf-8"
+	default:
+		body, err = json.Marshal(ev)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", AppName)
+	if format == FormatNtfy {
+		req.Header.Set("Title", AppName+": "+ev.Kind)
+		if ev.Kind == EventFailure || ev.Kind == EventConflict {
+			req.Header.Set("Tags", "warning")
+		}
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package peer syncs the repo directly between machines on the same network.
+// A machine running 'serve --lan' hands out its repo as a git bundle; peers
+// pull it over HTTP and merge it like a pull from the remote.
+//
+// Both sides hold the same age identity. Requests and responses are signed
+// with an HMAC key derived from it, so only machines with the key can fetch
+// or forge a bundle, and bundles are age-encrypted so plain-text files are
+// not readable on the wire.
+package peer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+// DefaultPort is used when a peer address or --lan has no port
+const DefaultPort = 8731
+
+// bundlePath serves the encrypted repo bundle
+const bundlePath = "/v1/bundle"
+
+// Limits
+const (
+	maxSkew      = 5 * time.Minute // Requests signed further from now are rejected
+	maxBundle    = 1 << 30         // Largest bundle a client accepts
+	fetchTimeout = 5 * time.Minute
+)
+
+// Headers
+const (
+	authHeader = "Authorization"
+	macHeader  = "X-Claude-Sync-Mac"
+	authScheme = "Claude-Sync-HMAC"
+)
+
+// Addr adds DefaultPort to a host without one
+func Addr(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), strconv.Itoa(DefaultPort))
+}
+
+// authKey derives the HMAC key shared by every machine with identity
+func authKey(identity *age.X25519Identity) []byte {
+	sum := sha256.Sum256([]byte("claude-code-sync peer v1\n" + identity.String()))
+	return sum[:]
+}
+
+// sign returns the hex HMAC of the newline-joined parts
+func sign(key []byte, parts ...string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves bundles written by bundle to peers holding identity
+func Handler(identity *age.X25519Identity, bundle func(path string) error) http.Handler {
+	key := authKey(identity)
+	recipient := identity.Recipient().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(bundlePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		stamp, ok := checkRequest(key, r)
+		if !ok {
+			http.Error(w, "not signed with this sync key", http.StatusUnauthorized)
+			return
+		}
+
+		dir, err := os.MkdirTemp("", "claude-sync-bundle-")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "repo.bundle")
+		if err := bundle(path); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := crypto.Encrypt(recipient, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		w.Header().Set(macHeader, sign(key, "response", stamp, hex.EncodeToString(sum[:])))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(body)
+	})
+	return mux
+}
+
+// checkRequest verifies the request signature and returns its timestamp
+func checkRequest(key []byte, r *http.Request) (string, bool) {
+	stamp, mac, ok := parseAuth(r.Header.Get(authHeader))
+	if !ok {
+		return "", false
+	}
+	secs, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	skew := time.Since(time.Unix(secs, 0))
+	if skew > maxSkew || skew < -maxSkew {
+		return "", false
+	}
+	want := sign(key, r.Method, r.URL.Path, stamp)
+	return stamp, hmac.Equal([]byte(mac), []byte(want))
+}
+
+// parseAuth splits "Claude-Sync-HMAC ts=<unix>,mac=<hex>"
+func parseAuth(header string) (stamp, mac string, ok bool) {
+	params, found := strings.CutPrefix(header, authScheme+" ")
+	if !found {
+		return "", "", false
+	}
+	for _, kv := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		switch k {
+		case "ts":
+			stamp = v
+		case "mac":
+			mac = v
+		}
+	}
+	return stamp, mac, stamp != "" && mac != ""
+}
+
+// Fetch downloads the bundle served by the peer at addr, checks that it was
+// signed with identity's key, and writes it decrypted to dest
+func Fetch(addr string, identity *age.X25519Identity, dest string) error {
+	key := authKey(identity)
+	stamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+Addr(addr)+bundlePath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(authHeader, fmt.Sprintf("%s ts=%s,mac=%s", authScheme, stamp, sign(key, http.MethodGet, bundlePath, stamp)))
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach peer %s (is 'claude-code-sync serve --lan' running there?): %w", addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("peer %s rejected the request: it uses a different sync key, or the clocks differ by more than %s", addr, maxSkew)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("peer %s returned %s: %s", addr, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBundle+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxBundle {
+		return fmt.Errorf("bundle from %s is larger than %d MiB", addr, maxBundle>>20)
+	}
+	sum := sha256.Sum256(body)
+	want := sign(key, "response", stamp, hex.EncodeToString(sum[:]))
+	if !hmac.Equal([]byte(resp.Header.Get(macHeader)), []byte(want)) {
+		return fmt.Errorf("response from %s is not signed with this sync key", addr)
+	}
+
+	data, err := crypto.Decrypt(identity, body)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt bundle from %s: %w", addr, err)
+	}
+	if !bytes.HasPrefix(data, []byte("# v2 git bundle")) && !bytes.HasPrefix(data, []byte("# v3 git bundle")) {
+		return fmt.Errorf("peer %s did not send a git bundle", addr)
+	}
+	return os.WriteFile(dest, data, 0600)
+}
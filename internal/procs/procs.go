@@ -0,0 +1,57 @@
+// Package procs finds running Claude Code processes, so a pull doesn't
+// replace settings under a live session without saying so.
+package procs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// proc is one entry of the process table
+type proc struct {
+	ppid int
+	name string
+}
+
+// Claude returns the process IDs of running Claude Code sessions, in order.
+// This process and its ancestors are left out: a pull run from inside a
+// session (its shell tool, a ! command) doesn't count that session.
+func Claude() ([]int, error) {
+	all, err := list()
+	if err != nil {
+		return nil, err
+	}
+	own := ancestors(all, os.Getpid())
+	var pids []int
+	for pid, p := range all {
+		if !own[pid] && isClaude(p.name) {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+	return pids, nil
+}
+
+// ancestors returns pid and every process above it in the table
+func ancestors(all map[int]proc, pid int) map[int]bool {
+	own := map[int]bool{}
+	for pid > 0 && !own[pid] {
+		own[pid] = true
+		p, ok := all[pid]
+		if !ok {
+			break
+		}
+		pid = p.ppid
+	}
+	return own
+}
+
+// isClaude reports whether a process name is Claude Code's. The npm install
+// runs under node but sets its title to "claude"; the native install is a
+// claude binary.
+func isClaude(name string) bool {
+	name = strings.ToLower(filepath.Base(strings.TrimSpace(name)))
+	return name == "claude" || name == "claude.exe"
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package procs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// list reads the process table, from /proc where there is one and ps
+// elsewhere
+func list() (map[int]proc, error) {
+	if entries, err := os.ReadDir("/proc"); err == nil && len(entries) > 0 {
+		procs := map[int]proc{}
+		for _, entry := range entries {
+			pid, err := strconv.Atoi(entry.Name())
+			if err != nil {
+				continue
+			}
+			stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+			if err != nil {
+				continue
+			}
+			if p, ok := parseStat(string(stat)); ok {
+				procs[pid] = p
+			}
+		}
+		return procs, nil
+	}
+
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	procs := map[int]proc{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 == nil && err2 == nil {
+			procs[pid] = proc{ppid: ppid, name: strings.Join(fields[2:], " ")}
+		}
+	}
+	return procs, nil
+}
+
+// parseStat reads the name and parent of /proc/<pid>/stat: "pid (name) state
+// ppid ...", where the name may itself contain spaces and parentheses
+func parseStat(stat string) (proc, bool) {
+	open, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return proc{}, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return proc{}, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return proc{}, false
+	}
+	return proc{ppid: ppid, name: stat[open+1 : end]}, true
+}
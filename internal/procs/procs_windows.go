@@ -0,0 +1,33 @@
+//go:build windows
+
+package procs
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// list reads the process table from a toolhelp snapshot
+func list() (map[int]proc, error) {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snap)
+
+	procs := map[int]proc{}
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snap, &entry); err == nil; err = windows.Process32Next(snap, &entry) {
+		procs[int(entry.ProcessID)] = proc{
+			ppid: int(entry.ParentProcessID),
+			name: windows.UTF16ToString(entry.ExeFile[:]),
+		}
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return nil, err
+	}
+	return procs, nil
+}
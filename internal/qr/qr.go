@@ -0,0 +1,517 @@
+// Package qr encodes short text as a QR code (ISO/IEC 18004, versions 1-10
+// at error correction level M) and draws it with block characters for a
+// terminal. It covers what claude-code-sync needs, a private key of under
+// 100 characters, rather than the whole standard.
+package qr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Code is an encoded QR symbol
+type Code struct {
+	Size    int      // Modules per side
+	modules [][]bool // [y][x], true is dark
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// ErrTooLong is returned for text that doesn't fit in a version 10 symbol
+var ErrTooLong = errors.New("text is too long for a QR code")
+
+// ecBlocks describes the error correction blocks of one version at level M
+type ecBlocks struct {
+	ecLen   int // EC codewords per block
+	blocks  int // Blocks with data codewords each
+	data    int
+	blocks2 int // Blocks with data2 (data+1) codewords each, after those
+	data2   int
+}
+
+// levelM is the level M block structure of versions 1-10
+var levelM = [...]ecBlocks{
+	1:  {10, 1, 16, 0, 0},
+	2:  {16, 1, 28, 0, 0},
+	3:  {26, 1, 44, 0, 0},
+	4:  {18, 2, 32, 0, 0},
+	5:  {24, 2, 43, 0, 0},
+	6:  {16, 4, 27, 0, 0},
+	7:  {18, 4, 31, 0, 0},
+	8:  {22, 2, 38, 2, 39},
+	9:  {22, 3, 36, 2, 37},
+	10: {26, 4, 43, 1, 44},
+}
+
+// alignment lists the alignment pattern centers of versions 2-10
+var alignment = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+const maxVersion = 10
+
+// alphanumeric is the character set of alphanumeric mode, in code order
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Encode returns the smallest QR code holding text. Text made only of
+// digits, upper-case letters and " $%*+-./:" (such as an age secret key)
+// is stored in the denser alphanumeric mode.
+func Encode(text string) (*Code, error) {
+	for version := 1; version <= maxVersion; version++ {
+		bits := encodeData(text, version)
+		if len(bits) <= dataCodewords(version)*8 {
+			return build(version, bits), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// dataCodewords is the number of data codewords a version holds
+func dataCodewords(version int) int {
+	b := levelM[version]
+	return b.blocks*b.data + b.blocks2*b.data2
+}
+
+// bitBuffer collects the encoded data bit by bit
+type bitBuffer []bool
+
+func (b *bitBuffer) put(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// encodeData returns the mode indicator, length and data bits of text
+func encodeData(text string, version int) bitBuffer {
+	var bits bitBuffer
+	if isAlphanumeric(text) {
+		bits.put(0b0010, 4)
+		bits.put(len(text), countBits(version, 9, 11))
+		for i := 0; i+1 < len(text); i += 2 {
+			bits.put(strings.IndexByte(alphanumeric, text[i])*45+strings.IndexByte(alphanumeric, text[i+1]), 11)
+		}
+		if len(text)%2 == 1 {
+			bits.put(strings.IndexByte(alphanumeric, text[len(text)-1]), 6)
+		}
+		return bits
+	}
+
+	bits.put(0b0100, 4)
+	bits.put(len(text), countBits(version, 8, 16))
+	for i := 0; i < len(text); i++ {
+		bits.put(int(text[i]), 8)
+	}
+	return bits
+}
+
+// countBits is the width of the length field: small for versions 1-9
+func countBits(version, small, large int) int {
+	if version <= 9 {
+		return small
+	}
+	return large
+}
+
+func isAlphanumeric(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(alphanumeric, text[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// build lays out the data bits, with error correction, in a symbol of version
+func build(version int, bits bitBuffer) *Code {
+	codewords := addErrorCorrection(version, pad(bits, dataCodewords(version)))
+
+	size := 17 + 4*version
+	c := &Code{Size: size, modules: grid(size)}
+	function := grid(size)
+	set := func(x, y int, dark bool) {
+		c.modules[y][x] = dark
+		function[y][x] = true
+	}
+
+	// Timing patterns, then finders and alignment patterns over them
+	for i := 0; i < size; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					d := max(abs(dx), abs(dy))
+					set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	if version > 1 {
+		pos := alignment[version]
+		last := len(pos) - 1
+		for i, cx := range pos {
+			for j, cy := range pos {
+				if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+					continue // Taken by a finder
+				}
+				for dy := -2; dy <= 2; dy++ {
+					for dx := -2; dx <= 2; dx++ {
+						set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+					}
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once the mask is chosen
+	drawFormat(set, size, 0)
+	if version >= 7 {
+		drawVersion(set, size, version)
+	}
+
+	// Data, in two-module columns zigzagging up and down from the bottom right
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !function[y][x] && i < len(codewords)*8 {
+					c.modules[y][x] = codewords[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+
+	// Pick the mask that makes the symbol easiest to read
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		applyMask(c, function, mask)
+		drawFormat(set, size, mask)
+		if p := penalty(c); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		applyMask(c, function, mask) // Undo
+	}
+	applyMask(c, function, best)
+	drawFormat(set, size, best)
+	return c
+}
+
+func grid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+// pad ends the bit stream and fills it up to n codewords
+func pad(bits bitBuffer, n int) []byte {
+	capacity := n * 8
+	bits.put(0, min(4, capacity-len(bits))) // Terminator
+	bits.put(0, (8-len(bits)%8)%8)
+	data := make([]byte, 0, n)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		data = append(data, b)
+	}
+	for filler := byte(0xEC); len(data) < n; filler ^= 0xEC ^ 0x11 {
+		data = append(data, filler)
+	}
+	return data
+}
+
+// addErrorCorrection splits data into blocks, appends Reed-Solomon codewords
+// to each and interleaves them
+func addErrorCorrection(version int, data []byte) []byte {
+	b := levelM[version]
+	divisor := rsDivisor(b.ecLen)
+
+	var blocks, ecs [][]byte
+	for i := 0; i < b.blocks+b.blocks2; i++ {
+		n := b.data
+		if i >= b.blocks {
+			n = b.data2
+		}
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+
+	var out []byte
+	for i := 0; i < max(b.data, b.data2); i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecLen; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the generator polynomial of degree n, highest
+// coefficient (always 1) omitted
+func rsDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords of data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// drawFormat draws both copies of the level and mask bits
+func drawFormat(set func(x, y int, dark bool), size, mask int) {
+	const levelBits = 0b00 // M
+	data := levelBits<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		set(8, i, bit(i))
+	}
+	set(8, 7, bit(6))
+	set(8, 8, bit(7))
+	set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		set(8, size-15+i, bit(i))
+	}
+	set(8, size-8, true) // Always dark
+}
+
+// drawVersion draws both copies of the version number (versions 7 and up)
+func drawVersion(set func(x, y int, dark bool), size, version int) {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := size-11+i%3, i/3
+		set(a, b, dark)
+		set(b, a, dark)
+	}
+}
+
+// applyMask flips the data modules selected by mask; applying it twice undoes it
+func applyMask(c *Code, function [][]bool, mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, by the four rules of the
+// standard: long runs, 2x2 blocks, finder-like patterns and dark/light balance
+func penalty(c *Code) int {
+	n := c.Size
+	score := 0
+	line := make([]bool, n)
+	for _, vertical := range []bool{false, true} {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if vertical {
+					line[j] = c.modules[j][i]
+				} else {
+					line[j] = c.modules[i][j]
+				}
+			}
+			score += linePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					score += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + k*10
+}
+
+// finderLike is the 1:1:3:1:1 dark pattern with four light modules on one side
+var finderLike = [...][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores runs and finder-like patterns in one row or column
+func linePenalty(line []bool) int {
+	score := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += 3 + run - 5
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, p := range finderLike {
+			if equal(line[i:i+11], p) {
+				score += 40
+			}
+		}
+	}
+	return score
+}
+
+func equal(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// quietZone is the light border scanners need around the symbol
+const quietZone = 4
+
+// Terminal draws the code with half-block characters, two rows per line,
+// plus a light border. Light modules are drawn as blocks, so it reads
+// correctly on a dark terminal background; print it white on black to be
+// sure.
+func (c *Code) Terminal() string {
+	light := func(x, y int) bool {
+		x, y = x-quietZone, y-quietZone
+		return x < 0 || y < 0 || x >= c.Size || y >= c.Size || !c.modules[y][x]
+	}
+	var sb strings.Builder
+	n := c.Size + 2*quietZone
+	for y := 0; y < n; y += 2 {
+		for x := 0; x < n; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,361 @@
+package qr
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// The example symbol in ISO/IEC 18004 Annex I: "01234567" at 1-M
+func TestReedSolomon(t *testing.T) {
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("EC codewords = % X, want % X", got, want)
+	}
+}
+
+// The worked example of thonky.com's QR code tutorial: "HELLO WORLD" at 1-M
+func TestCodewords(t *testing.T) {
+	data := pad(encodeData("HELLO WORLD", 1), dataCodewords(1))
+	want := []byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	if !bytes.Equal(data, want) {
+		t.Errorf("data codewords = % X, want % X", data, want)
+	}
+	ec := []byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, ec) {
+		t.Errorf("EC codewords = % X, want % X", got, ec)
+	}
+}
+
+// Format information for level M, masks 0-7 (ISO/IEC 18004 Table C.1)
+func TestFormatBits(t *testing.T) {
+	want := []string{
+		"101010000010010",
+		"101000100100101",
+		"101111001111100",
+		"101101101001011",
+		"100010111111001",
+		"100000011001110",
+		"100111110010111",
+		"100101010100000",
+	}
+	const size = 21
+	for mask, w := range want {
+		c := &Code{Size: size, modules: grid(size)}
+		drawFormat(func(x, y int, dark bool) { c.modules[y][x] = dark }, size, mask)
+		if got := fmt.Sprintf("%015b", formatBits(c)); got != w {
+			t.Errorf("mask %d: format bits %s, want %s", mask, got, w)
+		}
+	}
+}
+
+// Version information for version 7 (ISO/IEC 18004 Table D.1)
+func TestVersionBits(t *testing.T) {
+	const size = 17 + 4*7
+	c := &Code{Size: size, modules: grid(size)}
+	drawVersion(func(x, y int, dark bool) { c.modules[y][x] = dark }, size, 7)
+	bits := 0
+	for i := 17; i >= 0; i-- {
+		bits <<= 1
+		if c.modules[i/3][size-11+i%3] {
+			bits |= 1
+		}
+	}
+	if bits != 0x07C94 {
+		t.Errorf("version 7 bits = %#05x, want 0x07c94", bits)
+	}
+}
+
+var samples = []struct {
+	name    string
+	text    string
+	version int
+}{
+	{"hello", "HELLO WORLD", 1},
+	{"url", "https://github.com/felixisaac/claude-code-sync", 4},
+	{"key", "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX", 4},
+	{"long", strings.Repeat("claude-code-sync ", 12), 10},
+}
+
+func TestGolden(t *testing.T) {
+	for _, s := range samples {
+		t.Run(s.name, func(t *testing.T) {
+			c, err := Encode(s.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := 17 + 4*s.version; c.Size != want {
+				t.Fatalf("size %d, want %d (version %d)", c.Size, want, s.version)
+			}
+			if got := decode(t, c); got != s.text {
+				t.Fatalf("decoded %q, want %q", got, s.text)
+			}
+
+			got := matrix(c)
+			path := filepath.Join("testdata", s.name+".txt")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("matrix differs from %s:\n%s", path, got)
+			}
+		})
+	}
+}
+
+// Every version decodes back to its text
+func TestRoundTrip(t *testing.T) {
+	for n := 1; n <= 300; n += 7 {
+		for _, text := range []string{strings.Repeat("A1 ", n)[:n], strings.Repeat("a1!", n)[:n]} {
+			c, err := Encode(text)
+			if err == ErrTooLong {
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decode(t, c); got != text {
+				t.Errorf("version %d: decoded %q, want %q", (c.Size-17)/4, got, text)
+			}
+		}
+	}
+	if _, err := Encode(strings.Repeat("a", 300)); err != ErrTooLong {
+		t.Errorf("Encode(300 bytes) = %v, want ErrTooLong", err)
+	}
+}
+
+func TestTerminalQuietZone(t *testing.T) {
+	c, err := Encode("HELLO WORLD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(), "\n"), "\n")
+	if want := (c.Size + 8 + 1) / 2; len(lines) != want {
+		t.Errorf("%d lines, want %d", len(lines), want)
+	}
+	light := strings.Repeat("█", c.Size+8)
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != c.Size+8 {
+			t.Fatalf("line %d is %d modules wide, want %d", i, n, c.Size+8)
+		}
+		// Two lines top and bottom, and four columns each side, are border
+		if i < 2 && line != light {
+			t.Errorf("line %d is not all light: %s", i, line)
+		}
+		if !strings.HasPrefix(line, strings.Repeat("█", 4)) || !strings.HasSuffix(line, strings.Repeat("█", 4)) {
+			t.Errorf("line %d has no 4-module border: %s", i, line)
+		}
+	}
+}
+
+// matrix renders c one row per line, # for dark
+func matrix(c *Code) string {
+	var sb strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// formatBits reads the format information next to the top left finder
+func formatBits(c *Code) int {
+	var pos [][2]int
+	for i := 0; i <= 5; i++ {
+		pos = append(pos, [2]int{8, i})
+	}
+	pos = append(pos, [2]int{8, 7}, [2]int{8, 8}, [2]int{7, 8})
+	for i := 9; i < 15; i++ {
+		pos = append(pos, [2]int{14 - i, 8})
+	}
+	bits := 0
+	for i := 14; i >= 0; i-- {
+		bits <<= 1
+		if c.Dark(pos[i][0], pos[i][1]) {
+			bits |= 1
+		}
+	}
+	return bits
+}
+
+// decode reads a symbol back the way a scanner would, written separately
+// from the encoder: it finds the data modules from the version alone,
+// removes the mask named in the format information, checks every block's
+// error correction and parses the segment
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	version := (c.Size - 17) / 4
+	format := formatBits(c) ^ 0x5412
+	if format>>13 != 0b00 {
+		t.Fatalf("error correction level %02b, want M (00)", format>>13)
+	}
+	mask := format >> 10 & 7
+
+	reserved := func(x, y int) bool {
+		n := c.Size
+		switch {
+		case x <= 8 && y <= 8, x >= n-8 && y <= 8, x <= 8 && y >= n-8: // Finders and format
+			return true
+		case x == 6 || y == 6: // Timing
+			return true
+		case version >= 7 && (x >= n-11 && y < 6 || y >= n-11 && x < 6): // Version
+			return true
+		}
+		if version > 1 {
+			step := 0
+			centers := []int{6}
+			last := n - 7
+			if count := version/7 + 2; count > 2 {
+				step = (last - 6) / (count - 1)
+				if step%2 == 1 || (last-6)%(count-1) != 0 {
+					step = ((last-6)/(count-1) + 1) &^ 1
+				}
+				for p := last - step*(count-2); p < last; p += step {
+					centers = append(centers, p)
+				}
+			}
+			centers = append(centers, last)
+			for _, cx := range centers {
+				for _, cy := range centers {
+					if (cx == 6 && cy == 6) || (cx == 6 && cy == last) || (cx == last && cy == 6) {
+						continue
+					}
+					if abs(x-cx) <= 2 && abs(y-cy) <= 2 {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	masked := func(x, y int) bool {
+		switch mask {
+		case 0:
+			return (y+x)%2 == 0
+		case 1:
+			return y%2 == 0
+		case 2:
+			return x%3 == 0
+		case 3:
+			return (y+x)%3 == 0
+		case 4:
+			return (y/2+x/3)%2 == 0
+		case 5:
+			return (y*x)%2+(y*x)%3 == 0
+		case 6:
+			return ((y*x)%2+(y*x)%3)%2 == 0
+		default:
+			return ((y+x)%2+(y*x)%3)%2 == 0
+		}
+	}
+
+	// Columns pairs from the right, up then down, skipping the timing column
+	var bits []bool
+	up := true
+	for right := c.Size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for i := 0; i < c.Size; i++ {
+			y := i
+			if up {
+				y = c.Size - 1 - i
+			}
+			for _, x := range []int{right, right - 1} {
+				if !reserved(x, y) {
+					bits = append(bits, c.Dark(x, y) != masked(x, y))
+				}
+			}
+		}
+		up = !up
+	}
+
+	b := levelM[version]
+	total := b.blocks*(b.data+b.ecLen) + b.blocks2*(b.data2+b.ecLen)
+	if len(bits)/8 < total {
+		t.Fatalf("%d data modules, want at least %d", len(bits), total*8)
+	}
+	codewords := make([]byte, total)
+	for i := range codewords {
+		for _, bit := range bits[i*8 : i*8+8] {
+			codewords[i] <<= 1
+			if bit {
+				codewords[i] |= 1
+			}
+		}
+	}
+
+	// Undo the interleaving
+	blocks := make([][]byte, b.blocks+b.blocks2)
+	next := 0
+	for i := 0; i < max(b.data, b.data2); i++ {
+		for j := range blocks {
+			if i < b.data || j >= b.blocks {
+				blocks[j] = append(blocks[j], codewords[next])
+				next++
+			}
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		ec := make([]byte, b.ecLen)
+		for i := range ec {
+			ec[i] = codewords[next+i*len(blocks)+j]
+		}
+		if got := rsRemainder(block, rsDivisor(b.ecLen)); !bytes.Equal(got, ec) {
+			t.Fatalf("block %d: error correction % X, want % X", j, ec, got)
+		}
+		data = append(data, block...)
+	}
+
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(data[pos>>3]>>(7-pos&7)&1)
+			pos++
+		}
+		return v
+	}
+	var sb strings.Builder
+	switch read(4) {
+	case 0b0010:
+		n := read(map[bool]int{true: 9, false: 11}[version <= 9])
+		for ; n >= 2; n -= 2 {
+			v := read(11)
+			sb.WriteByte(alphanumeric[v/45])
+			sb.WriteByte(alphanumeric[v%45])
+		}
+		if n == 1 {
+			sb.WriteByte(alphanumeric[read(6)])
+		}
+	case 0b0100:
+		n := read(map[bool]int{true: 8, false: 16}[version <= 9])
+		for i := 0; i < n; i++ {
+			sb.WriteByte(byte(read(8)))
+		}
+	default:
+		t.Fatal("unknown mode")
+	}
+	return sb.String()
+}
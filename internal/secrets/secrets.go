@@ -0,0 +1,98 @@
+// Package secrets finds credentials in files that are about to be synced in
+// plain text.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Finding is a likely secret in a file
+type Finding struct {
+	Path string // As given to ScanFile
+	Line int
+	Rule string
+	Text string // The match with most of the secret masked
+}
+
+// rule is a named pattern. The secret is the whole match, or the last
+// capture group if the pattern has one.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var rules = []rule{
+	{"Anthropic API key", regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{"OpenAI API key", regexp.MustCompile(`sk-(?:proj-)?[A-Za-z0-9_\-]{32,}`)},
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{20,}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`)},
+	{"API key assignment", regexp.MustCompile(`(?i)\b[A-Z0-9_]*(?:API_KEY|SECRET|TOKEN|PASSWORD)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-/+=.]{16,})`)},
+}
+
+// placeholders are values that look like secrets in documentation
+var placeholders = regexp.MustCompile(`(?i)^(?:x+|\*+|your[_-]?.*|example.*|changeme|\$\{?[a-z_]+\}?|<.*>)$`)
+
+// maxScanSize skips files too large to be hand-written config
+const maxScanSize = 5 << 20
+
+// ScanFile reports likely secrets in a text file. Binary and very large files
+// are skipped.
+func ScanFile(path, name string) ([]Finding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxScanSize {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, nil
+	}
+	return Scan(data, name), nil
+}
+
+// Scan reports likely secrets in data, one finding per line at most
+func Scan(data []byte, name string) []Finding {
+	var findings []Finding
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), maxScanSize)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		for _, r := range rules {
+			m := r.pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			secret := m[len(m)-1]
+			if placeholders.MatchString(secret) {
+				continue
+			}
+			findings = append(findings, Finding{Path: name, Line: n, Rule: r.name, Text: mask(m[0], secret)})
+			break
+		}
+	}
+	return findings
+}
+
+// mask hides all but the first few characters of secret inside match
+func mask(match, secret string) string {
+	keep := 6
+	if len(secret) <= keep*2 {
+		keep = len(secret) / 3
+	}
+	return strings.Replace(match, secret, secret[:keep]+strings.Repeat("*", 8), 1)
+}
@@ -0,0 +1,116 @@
+// Package shamir splits a secret into shares with Shamir's secret sharing
+// over GF(2^8): any threshold of the shares recover the secret, and fewer
+// reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// MaxShares is the most shares a secret can be split into
+const MaxShares = 255
+
+// Share is one piece of a split secret
+type Share struct {
+	X byte   // Evaluation point, 1-255, unique within a split
+	Y []byte // Polynomial values, one per secret byte
+}
+
+// Split divides secret into n shares, any k of which recover it
+func Split(secret []byte, n, k int) ([]Share, error) {
+	if k < 2 || k > n || n > MaxShares {
+		return nil, fmt.Errorf("need 2 <= threshold <= shares <= %d (got threshold %d, shares %d)", MaxShares, k, n)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+	coeffs := make([]byte, k)
+	for b, s := range secret {
+		// Random polynomial of degree k-1 with the secret byte as constant term
+		coeffs[0] = s
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i].Y[b] = eval(coeffs, shares[i].X)
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from at least threshold shares of one split.
+// With too few shares, or shares from different splits, the result is
+// garbage; callers check it.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("need at least 2 shares")
+	}
+	size := len(shares[0].Y)
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.X == 0 || seen[s.X] {
+			return nil, fmt.Errorf("duplicate or invalid share number %d", s.X)
+		}
+		if len(s.Y) != size {
+			return nil, errors.New("shares have different lengths")
+		}
+		seen[s.X] = true
+	}
+
+	// Lagrange interpolation at x = 0
+	secret := make([]byte, size)
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i != j {
+				basis = mul(basis, div(sj.X, sj.X^si.X))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= mul(si.Y[b], basis)
+		}
+	}
+	return secret, nil
+}
+
+// eval evaluates the polynomial with coefficients (lowest first) at x
+func eval(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// mul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1 (as AES does)
+func mul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1B
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// div divides a by b (b != 0), using b^254 as the inverse of b
+func div(a, b byte) byte {
+	inv := byte(1)
+	for i := 0; i < 254; i++ {
+		inv = mul(inv, b)
+	}
+	return mul(a, inv)
+}
@@ -0,0 +1,123 @@
+package shamir
+
+import (
+	"bytes"
+	"math/bits"
+	"testing"
+)
+
+// subsets returns every subset of shares with exactly size members
+func subsets(shares []Share, size int) [][]Share {
+	var result [][]Share
+	for mask := 0; mask < 1<<len(shares); mask++ {
+		if bits.OnesCount(uint(mask)) != size {
+			continue
+		}
+		var subset []Share
+		for i, s := range shares {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, s)
+			}
+		}
+		result = append(result, subset)
+	}
+	return result
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("AGE-SECRET-KEY-1QQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQ")
+	for _, tt := range []struct{ n, k int }{{2, 2}, {3, 2}, {3, 3}, {5, 3}, {6, 4}, {7, 7}} {
+		shares, err := Split(secret, tt.n, tt.k)
+		if err != nil {
+			t.Fatalf("Split(n=%d, k=%d): %v", tt.n, tt.k, err)
+		}
+		if len(shares) != tt.n {
+			t.Fatalf("Split(n=%d, k=%d) returned %d shares", tt.n, tt.k, len(shares))
+		}
+
+		for size := tt.k; size <= tt.n; size++ {
+			for _, subset := range subsets(shares, size) {
+				got, err := Combine(subset)
+				if err != nil {
+					t.Fatalf("n=%d k=%d: Combine(%d shares): %v", tt.n, tt.k, size, err)
+				}
+				if !bytes.Equal(got, secret) {
+					t.Errorf("n=%d k=%d: %d shares %v did not recover the secret", tt.n, tt.k, size, xs(subset))
+				}
+			}
+		}
+
+		// One share short of the threshold gives garbage, never the secret
+		if tt.k > 2 {
+			for _, subset := range subsets(shares, tt.k-1) {
+				got, err := Combine(subset)
+				if err != nil {
+					t.Fatalf("n=%d k=%d: Combine(%d shares): %v", tt.n, tt.k, tt.k-1, err)
+				}
+				if bytes.Equal(got, secret) {
+					t.Errorf("n=%d k=%d: %d shares %v recovered the secret below the threshold", tt.n, tt.k, tt.k-1, xs(subset))
+				}
+			}
+		}
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		secret []byte
+		n, k   int
+	}{
+		{[]byte("x"), 3, 1},
+		{[]byte("x"), 2, 3},
+		{[]byte("x"), MaxShares + 1, 2},
+		{nil, 3, 2},
+	} {
+		if _, err := Split(tt.secret, tt.n, tt.k); err == nil {
+			t.Errorf("Split(%q, n=%d, k=%d) succeeded", tt.secret, tt.n, tt.k)
+		}
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		shares []Share
+	}{
+		{"one share", shares[:1]},
+		{"duplicate", []Share{shares[0], shares[0]}},
+		{"zero x", []Share{{X: 0, Y: shares[0].Y}, shares[1]}},
+		{"lengths differ", []Share{shares[0], {X: shares[1].X, Y: shares[1].Y[:3]}}},
+	}
+	for _, tt := range tests {
+		if _, err := Combine(tt.shares); err == nil {
+			t.Errorf("Combine(%s) succeeded", tt.name)
+		}
+	}
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	// Known AES field products (FIPS-197 section 4.2)
+	if got := mul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("mul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+	if got := mul(0x57, 0x13); got != 0xfe {
+		t.Errorf("mul(0x57, 0x13) = %#x, want 0xfe", got)
+	}
+	for a := 1; a < 256; a++ {
+		if got := mul(byte(a), div(1, byte(a))); got != 1 {
+			t.Fatalf("%#x * 1/%#x = %#x, want 1", a, a, got)
+		}
+	}
+}
+
+func xs(shares []Share) []byte {
+	var x []byte
+	for _, s := range shares {
+		x = append(x, s.X)
+	}
+	return x
+}
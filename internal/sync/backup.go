@@ -0,0 +1,393 @@
+package sync
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+// ClaudeJSONEntry is the zip entry holding ~/.claude.json. Files in the
+// claude dir are stored under its base name, e.g. ".claude/CLAUDE.md".
+const ClaudeJSONEntry = ".claude.json"
+
+// Backup is a zip snapshot in the backups directory
+type Backup struct {
+	Name      string // backup-20251219-120000.zip, or .zip.age if encrypted
+	Path      string
+	Time      time.Time
+	Size      int64
+	Entries   int // 0 for encrypted backups, which can't be read without the key
+	Encrypted bool
+}
+
+// CreateBackupZip creates a zip backup of the claude directory and
+// claude.json. With a publicKey the zip is age-encrypted as it is written,
+// so no plaintext copy touches the disk; dest should then end in .zip.age.
+func CreateBackupZip(claudeDir, claudeJSON, dest, publicKey string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	var zipOut io.Writer = out
+	var enc io.WriteCloser
+	if publicKey != "" {
+		if enc, err = crypto.EncryptWriter(publicKey, out); err != nil {
+			out.Close()
+			os.Remove(dest)
+			return err
+		}
+		zipOut = enc
+	}
+
+	w := zip.NewWriter(zipOut)
+	err = writeBackupZip(w, claudeDir, claudeJSON)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if enc != nil {
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dest)
+	}
+	return err
+}
+
+// writeBackupZip adds the claude directory and claude.json to w
+func writeBackupZip(w *zip.Writer, claudeDir, claudeJSON string) error {
+	// Add claude directory
+	if FileExists(claudeDir) {
+		err := filepath.Walk(claudeDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			relPath, _ := filepath.Rel(filepath.Dir(claudeDir), path)
+			return AddZipFile(w, filepath.ToSlash(relPath), path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add claude.json
+	if FileExists(claudeJSON) {
+		return AddZipFile(w, ClaudeJSONEntry, claudeJSON)
+	}
+	return nil
+}
+
+// AddZipFile copies the file at path into the zip as name
+func AddZipFile(w *zip.Writer, name, path string) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(f, src)
+	return err
+}
+
+// IsBackupName reports whether name is a backup zip, encrypted or not
+func IsBackupName(name string) bool {
+	return strings.HasPrefix(name, "backup-") && (strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".zip.age"))
+}
+
+// openBackup reads a backup zip, decrypting it in memory with identity if
+// it is encrypted
+func openBackup(path string, identity *age.X25519Identity) (*zip.Reader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".age") {
+		if identity == nil {
+			return nil, fmt.Errorf("%s is encrypted and no key is available", filepath.Base(path))
+		}
+		if data, err = crypto.Decrypt(identity, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// FileBackupsDir is the folder in the backups dir holding copies of single
+// files replaced by pull, get or restore --file
+const FileBackupsDir = "files"
+
+// BackupFile copies src to backups/files/<stamp>/<relPath>, keeping the
+// layout of ~/.claude. Files backed up by one command share a stamp. Returns
+// "" if src doesn't exist.
+func BackupFile(src, backupDir, stamp, relPath string) (string, error) {
+	if !FileExists(src) {
+		return "", nil
+	}
+
+	backupPath := filepath.Join(backupDir, FileBackupsDir, stamp, relPath)
+	if err := EnsureDir(filepath.Dir(backupPath)); err != nil {
+		return "", err
+	}
+	return backupPath, CopyFile(src, backupPath)
+}
+
+// PruneFileBackups keeps only the last N folders in backups/files
+func PruneFileBackups(backupDir string, maxCount int) error {
+	dir := filepath.Join(backupDir, FileBackupsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var stamps []string
+	for _, e := range entries {
+		if e.IsDir() {
+			stamps = append(stamps, e.Name())
+		}
+	}
+
+	// Timestamp names sort chronologically; remove the oldest
+	for i := 0; i < len(stamps)-maxCount; i++ {
+		if err := os.RemoveAll(filepath.Join(dir, stamps[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneBackups keeps only the last N backups
+func PruneBackups(backupDir string, maxCount int) error {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if IsBackupName(e.Name()) {
+			backups = append(backups, filepath.Join(backupDir, e.Name()))
+		}
+	}
+
+	if len(backups) <= maxCount {
+		return nil
+	}
+
+	// The names are like backup-20251219-120000.zip(.age) so alphabetical =
+	// chronological, and os.ReadDir returns entries sorted by name. Remove oldest.
+	for i := 0; i < len(backups)-maxCount; i++ {
+		if err := os.Remove(backups[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListBackups returns the backups in backupDir, oldest first
+func ListBackups(backupDir string) ([]Backup, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if !IsBackupName(name) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		b := Backup{Name: name, Path: filepath.Join(backupDir, name), Size: info.Size(), Time: info.ModTime(), Encrypted: strings.HasSuffix(name, ".age")}
+		if t, err := time.ParseInLocation("20060102-150405", backupStamp(name), time.Local); err == nil {
+			b.Time = t
+		}
+		if !b.Encrypted {
+			if r, err := zip.OpenReader(b.Path); err == nil {
+				b.Entries = len(r.File)
+				r.Close()
+			}
+		}
+		backups = append(backups, b)
+	}
+
+	return backups, nil
+}
+
+// FindBackup resolves a backup by name. "backup-20251219-120000.zip",
+// "backup-20251219-120000" and "20251219-120000" all match; an empty name
+// returns the newest backup.
+func FindBackup(backupDir, name string) (*Backup, error) {
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups found in %s", backupDir)
+	}
+	if name == "" {
+		return &backups[len(backups)-1], nil
+	}
+
+	name = backupStamp(filepath.Base(name))
+	for i := range backups {
+		if backupStamp(backups[i].Name) == name {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("backup %q not found. Run 'claude-code-sync restore --list' to see available backups", name)
+}
+
+// backupStamp strips the prefix and extensions from a backup name
+func backupStamp(name string) string {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".age"), ".zip")
+	return strings.TrimPrefix(name, "backup-")
+}
+
+// BackupFiles lists the files in a backup as paths relative to ~/.claude,
+// with ~/.claude.json reported as "claude.json". identity is only needed for
+// encrypted backups.
+func BackupFiles(zipPath string, identity *age.X25519Identity) ([]string, error) {
+	r, err := openBackup(zipPath, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range r.File {
+		if rel, ok := backupRelPath(f.Name); ok {
+			files = append(files, rel)
+		}
+	}
+	return files, nil
+}
+
+// RestoreBackup extracts a backup into claudeDir and claudeJSON. If only is
+// set, just that file (relative to ~/.claude, or "claude.json") is restored.
+// Files that are not in the backup are left alone. Returns the restored files.
+// identity is only needed for encrypted backups.
+func RestoreBackup(zipPath string, identity *age.X25519Identity, claudeDir, claudeJSON, only string) ([]string, error) {
+	r, err := openBackup(zipPath, identity)
+	if err != nil {
+		return nil, err
+	}
+	return RestoreZip(r, claudeDir, claudeJSON, only)
+}
+
+// RestoreZip extracts the ~/.claude and ~/.claude.json entries of a zip laid
+// out like a backup. If only is set, just that file is restored.
+func RestoreZip(r *zip.Reader, claudeDir, claudeJSON, only string) ([]string, error) {
+	only = filepath.ToSlash(strings.TrimPrefix(only, "./"))
+	if only == ClaudeJSONEntry {
+		only = "claude.json"
+	}
+
+	var restored []string
+	for _, f := range r.File {
+		rel, ok := backupRelPath(f.Name)
+		if !ok || (only != "" && rel != only) {
+			continue
+		}
+
+		dest := claudeJSON
+		if f.Name != ClaudeJSONEntry {
+			dest = filepath.Join(claudeDir, filepath.FromSlash(rel))
+			// backupRelPath already refuses "..", but check where it landed
+			if inside, err := filepath.Rel(claudeDir, dest); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+				return restored, fmt.Errorf("backup entry %s is outside %s", f.Name, claudeDir)
+			}
+		}
+		if err := extractZipFile(f, dest); err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+		restored = append(restored, rel)
+	}
+
+	if only != "" && len(restored) == 0 {
+		return nil, fmt.Errorf("%s is not in the backup", only)
+	}
+	return restored, nil
+}
+
+// backupRelPath maps a zip entry to a slash-separated path relative to
+// ~/.claude. Entries are stored under the claude dir's base name
+// (".claude/..."), plus ".claude.json". Entries that would escape the target
+// directory are rejected; backslashes count as separators too, since
+// Windows treats them as such.
+func backupRelPath(name string) (string, bool) {
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, `\`) {
+		return "", false
+	}
+	if name == ClaudeJSONEntry {
+		return "claude.json", true
+	}
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "", false
+	}
+	rel := strings.ReplaceAll(name[i+1:], `\`, "/")
+	for _, part := range strings.Split(rel, "/") {
+		if part == ".." || part == "." || part == "" {
+			return "", false
+		}
+	}
+	return rel, true
+}
+
+// extractZipFile writes a single zip entry to dest
+func extractZipFile(f *zip.File, dest string) error {
+	if err := EnsureDir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,32 @@
+package sync
+
+import "testing"
+
+func TestBackupRelPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{".claude/settings.json", "settings.json", true},
+		{".claude/commands/deploy.md", "commands/deploy.md", true},
+		{ClaudeJSONEntry, "claude.json", true},
+		{".claude/commands/", "", false},
+		{"settings.json", "", false},
+		{".claude/../.bashrc", "", false},
+		{".claude/commands/../../.bashrc", "", false},
+		{`.claude/..\.bashrc`, "", false},
+		{`.claude/commands\..\..\.bashrc`, "", false},
+		{`.claude/commands\deploy.md`, "commands/deploy.md", true},
+		{".claude//etc/passwd", "", false},
+		{".claude/./settings.json", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := backupRelPath(tt.name)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("backupRelPath(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
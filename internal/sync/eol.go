@@ -0,0 +1,34 @@
+package sync
+
+import "bytes"
+
+// textSniffLen is how much of a file IsText looks at, as git does
+const textSniffLen = 8000
+
+// IsText reports whether data looks like text: no NUL byte near the start
+func IsText(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), textSniffLen)], 0) < 0
+}
+
+// ToLF returns text data with CRLF line endings turned into LF. Binary data
+// is returned unchanged.
+func ToLF(data []byte) []byte {
+	if !IsText(data) || !bytes.Contains(data, []byte("\r\n")) {
+		return data
+	}
+	return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+}
+
+// ConvertEOL returns text data with every line ending turned into eol, "\n"
+// or "\r\n". Binary data, and any data for an empty eol, is returned
+// unchanged.
+func ConvertEOL(data []byte, eol string) []byte {
+	if eol == "" {
+		return data
+	}
+	data = ToLF(data)
+	if eol == "\n" || !IsText(data) || !bytes.Contains(data, []byte("\n")) {
+		return data
+	}
+	return bytes.ReplaceAll(data, []byte("\n"), []byte(eol))
+}
@@ -0,0 +1,25 @@
+package sync
+
+import "testing"
+
+func TestConvertEOL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		eol  string
+		want string
+	}{
+		{"to lf", "a\r\nb\r\n", "\n", "a\nb\n"},
+		{"to crlf", "a\nb\n", "\r\n", "a\r\nb\r\n"},
+		{"mixed to crlf", "a\r\nb\n", "\r\n", "a\r\nb\r\n"},
+		{"binary", "a\x00\nb\n", "\r\n", "a\x00\nb\n"},
+		{"unset", "a\r\nb\n", "", "a\r\nb\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ConvertEOL([]byte(tt.in), tt.eol)); got != tt.want {
+				t.Errorf("ConvertEOL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
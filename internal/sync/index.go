@@ -0,0 +1,170 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// racyWindow is how recently a file may have changed before its checksum is
+// no longer cached: a write in the same mtime tick could go unnoticed
+const racyWindow = 2 * time.Second
+
+// Index caches file checksums keyed on size and mtime, so unchanged files
+// are not read again, and remembers what each repo file was pushed from.
+// A nil Index computes every checksum.
+type Index struct {
+	path  string
+	dirty bool
+
+	Files  map[string]IndexEntry  `json:"files"`            // By absolute path
+	Pushed map[string]PushedEntry `json:"pushed,omitempty"` // By slash-separated repo path
+	Key    string                 `json:"key,omitempty"`    // Public key the pushed files were encrypted to
+}
+
+// IndexEntry is the cached checksum of one file
+type IndexEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // Unix nanoseconds
+	Sum     string `json:"sha256"`
+}
+
+// PushedEntry ties a repo file to the local content it was written from
+type PushedEntry struct {
+	Local string `json:"local"` // Checksum of the local file
+	Repo  string `json:"repo"`  // Checksum of the repo file it produced
+}
+
+// LoadIndex reads the index at path. A missing or unreadable index starts
+// empty; it is only a cache.
+func LoadIndex(path string) *Index {
+	idx := &Index{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, idx)
+	}
+	if idx.Files == nil {
+		idx.Files = map[string]IndexEntry{}
+	}
+	if idx.Pushed == nil {
+		idx.Pushed = map[string]PushedEntry{}
+	}
+	return idx
+}
+
+// Checksum returns the SHA256 of path, from the cache if the file's size and
+// mtime haven't changed
+func (idx *Index) Checksum(path string) (string, error) {
+	if idx == nil {
+		return FileChecksum(path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if e, ok := idx.Files[path]; ok && e.Size == info.Size() && e.ModTime == info.ModTime().UnixNano() {
+		return e.Sum, nil
+	}
+
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return "", err
+	}
+	if time.Since(info.ModTime()) > racyWindow {
+		idx.Files[path] = IndexEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Sum: sum}
+	} else {
+		delete(idx.Files, path)
+	}
+	idx.dirty = true
+	return sum, nil
+}
+
+// Unchanged reports whether repoFile was last pushed from local content with
+// checksum localSum and has not been modified since, e.g. by a pull
+func (idx *Index) Unchanged(repoPath, repoFile, localSum string) bool {
+	if idx == nil {
+		return false
+	}
+	p, ok := idx.Pushed[filepath.ToSlash(repoPath)]
+	if !ok || p.Local != localSum {
+		return false
+	}
+	repoSum, err := idx.Checksum(repoFile)
+	return err == nil && repoSum == p.Repo
+}
+
+// PushedFrom returns the checksum of the local content repoPath was last
+// pushed from, or "" if unknown
+func (idx *Index) PushedFrom(repoPath string) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.Pushed[filepath.ToSlash(repoPath)].Local
+}
+
+// SetPushed records that repoFile was written from local content with
+// checksum localSum
+func (idx *Index) SetPushed(repoPath, repoFile, localSum string) {
+	if idx == nil {
+		return
+	}
+	repoSum, err := idx.Checksum(repoFile)
+	if err != nil {
+		return
+	}
+	idx.Pushed[filepath.ToSlash(repoPath)] = PushedEntry{Local: localSum, Repo: repoSum}
+	idx.dirty = true
+}
+
+// Forget drops the pushed record of a repo path
+func (idx *Index) Forget(repoPath string) {
+	if idx == nil {
+		return
+	}
+	if _, ok := idx.Pushed[filepath.ToSlash(repoPath)]; ok {
+		delete(idx.Pushed, filepath.ToSlash(repoPath))
+		idx.dirty = true
+	}
+}
+
+// UseKey forgets pushed files if they were encrypted to a different key
+func (idx *Index) UseKey(pubKey string) {
+	if idx == nil || idx.Key == pubKey {
+		return
+	}
+	idx.Key = pubKey
+	idx.Pushed = map[string]PushedEntry{}
+	idx.dirty = true
+}
+
+// Save writes the index if it changed. Entries for files that no longer
+// exist are dropped.
+func (idx *Index) Save() error {
+	if idx == nil || !idx.dirty {
+		return nil
+	}
+	for path := range idx.Files {
+		if !FileExists(path) {
+			delete(idx.Files, path)
+		}
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := EnsureDir(filepath.Dir(idx.path)); err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	idx.dirty = false
+	return nil
+}
@@ -0,0 +1,34 @@
+package sync
+
+import (
+	"os"
+	"runtime"
+)
+
+// DefaultMode is recorded for files whose permissions are unknown
+const DefaultMode os.FileMode = 0644
+
+// modesSupported is false on Windows, where permission bits don't map to
+// the Unix ones: modes are neither read from nor applied to files there
+var modesSupported = runtime.GOOS != "windows"
+
+// FileMode returns the permission bits of path, or 0 if they are unknown
+func FileMode(path string) os.FileMode {
+	if !modesSupported {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Mode().Perm()
+}
+
+// SetMode applies recorded permission bits to path. A zero mode, or any mode
+// on Windows, leaves the file as it is.
+func SetMode(path string, mode os.FileMode) error {
+	if mode == 0 || !modesSupported {
+		return nil
+	}
+	return os.Chmod(path, mode.Perm())
+}
@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NFC returns name with decomposed characters composed, the way macOS
+// leaves them decomposed (NFD) and Linux and Windows usually don't, so the
+// same file has one name in the repo wherever it was pushed from. Names
+// that are already composed, ASCII ones included, are returned as they are.
+func NFC(name string) string {
+	if isASCII(name) {
+		return name
+	}
+	return norm.NFC.String(name)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalName returns relPath, a name under root in NFC, spelled the way a
+// file or directory already under root spells it. That keeps pull writing
+// to a file saved under its decomposed name on Linux rather than creating
+// a second one next to it. Parts that don't exist yet are left in NFC.
+func LocalName(root, relPath string) string {
+	if isASCII(relPath) {
+		return relPath
+	}
+	parts := strings.Split(relPath, string(filepath.Separator))
+	dir := root
+	for i, part := range parts {
+		if _, err := os.Lstat(filepath.Join(dir, part)); err != nil {
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if NFC(entry.Name()) == part {
+					parts[i] = entry.Name()
+					break
+				}
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return filepath.Join(parts...)
+}
+
+// SameFile reports whether a and b name the same existing file, as a
+// composed and a decomposed name do on macOS
+func SameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,46 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNFC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ascii", "commands/deploy.md", "commands/deploy.md"},
+		{"composed", "caf\u00e9.md", "caf\u00e9.md"},
+		{"decomposed", "cafe\u0301.md", "caf\u00e9.md"},
+		{"two marks", "Vie\u0323\u0302t.md", "Vi\u1ec7t.md"},
+		{"blocked", "a\u0301\u0301", "\u00e1\u0301"},
+		{"mark past a lower class", "a\u0323\u0301", "\u1ea1\u0301"},
+		{"kana", "\u30ab\u3099", "\u30ac"},
+		{"hangul", "\u1112\u1161\u11ab", "\ud55c"},
+		{"reordered marks", "a\u0301\u0323", "\u1ea1\u0301"},
+		{"singleton", "\u212b", "\u00c5"},
+		{"devanagari nukta", "\u0928\u093c", "\u0929"},
+		{"bengali vowel sign", "\u09c7\u09be", "\u09cb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NFC(tt.in); got != tt.want {
+				t.Errorf("NFC(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "café"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	got := LocalName(root, filepath.Join("café", "résumé.md"))
+	if want := filepath.Join("café", "résumé.md"); got != want {
+		t.Errorf("LocalName() = %q, want %q", got, want)
+	}
+}
@@ -1,112 +1,104 @@
 package sync
 
 import (
-	"encoding/json"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 // ClaudeDirPlaceholder is used to replace platform-specific paths in synced files
 const ClaudeDirPlaceholder = "$CLAUDE_DIR"
 
-// NormalizePathsInJSON replaces absolute ClaudeDir paths with a cross-platform placeholder.
-// This allows plugin configs to be synced across Windows/macOS/Linux.
-func NormalizePathsInJSON(data []byte, claudeDir string) []byte {
-	content := string(data)
-
-	// Handle escaped backslashes in JSON (Windows paths like C:\\Users\\...)
-	escapedClaudeDir := strings.ReplaceAll(claudeDir, `\`, `\\`)
-	content = strings.ReplaceAll(content, escapedClaudeDir, ClaudeDirPlaceholder)
-
-	// Handle forward slash version (normalized paths)
-	forwardSlashDir := filepath.ToSlash(claudeDir)
-	content = strings.ReplaceAll(content, forwardSlashDir, ClaudeDirPlaceholder)
-
-	// Handle raw backslash version (shouldn't normally appear in JSON, but just in case)
-	content = strings.ReplaceAll(content, claudeDir, ClaudeDirPlaceholder)
+// More placeholders for values that differ between machines, picked in the
+// placeholders section of config.yaml
+const (
+	HomePlaceholder     = "$HOME"
+	UserPlaceholder     = "$USER"
+	HostnamePlaceholder = "$HOSTNAME"
+)
 
-	return []byte(content)
+// PathValues are this machine's values for the placeholders. A placeholder
+// whose value is empty is left alone both ways.
+type PathValues struct {
+	ClaudeDir string
+	Home      string
+	User      string
+	Hostname  string
 }
 
-// ExpandPathsInJSON replaces the cross-platform placeholder with the local ClaudeDir path.
-// The expanded path uses the native format for the current platform.
-// Uses JSON parsing to safely handle escape sequences.
-func ExpandPathsInJSON(data []byte, claudeDir string) []byte {
-	// First, parse as JSON to get the structure
-	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
-		// If not valid JSON, fall back to string replacement
-		return fallbackExpandPaths(data, claudeDir)
-	}
+// placeholderRef matches a placeholder, with the path that follows a
+// directory placeholder
+var placeholderRef = regexp.MustCompile(`\$(?:(CLAUDE_DIR|HOME)\b((?:[/\\][^\s"'` + "`" + `<>|*?,;:)\]}]*)?)|(?:USER|HOSTNAME)\b)`)
 
-	// Recursively replace placeholders in the parsed object
-	expanded := expandInObject(obj, claudeDir)
+var userProfileRef = regexp.MustCompile(`(?i)%USERPROFILE%`)
 
-	// Marshal back to JSON with proper formatting
-	result, err := json.MarshalIndent(expanded, "", "  ")
-	if err != nil {
-		// If marshaling fails, fall back
-		return fallbackExpandPaths(data, claudeDir)
-	}
-
-	return result
-}
-
-// expandInObject recursively expands placeholders in JSON objects
-func expandInObject(obj interface{}, claudeDir string) interface{} {
-	switch v := obj.(type) {
-	case map[string]interface{}:
-		for k, val := range v {
-			v[k] = expandInObject(val, claudeDir)
+// NormalizePlaceholders replaces this machine's paths, username and hostname
+// in data with placeholders: the Claude dir before the home dir, and the
+// home dir however it is written (native or forward slashes, escaped in
+// JSON, or as %USERPROFILE%). The username and hostname are only replaced
+// as whole words.
+func NormalizePlaceholders(data []byte, v PathValues, jsonFile bool) []byte {
+	content := string(data)
+	for _, p := range []struct{ dir, placeholder string }{{v.ClaudeDir, ClaudeDirPlaceholder}, {v.Home, HomePlaceholder}} {
+		if p.dir == "" {
+			continue
 		}
-		return v
-	case []interface{}:
-		for i, val := range v {
-			v[i] = expandInObject(val, claudeDir)
+		if jsonFile {
+			content = strings.ReplaceAll(content, strings.ReplaceAll(p.dir, `\`, `\\`), p.placeholder)
 		}
-		return v
-	case string:
-		if strings.Contains(v, ClaudeDirPlaceholder) {
-			// Replace placeholder with local path
-			expanded := strings.ReplaceAll(v, ClaudeDirPlaceholder, claudeDir)
-
-			// On Unix systems, convert backslashes to forward slashes in paths
-			if !strings.Contains(claudeDir, `\`) {
-				// This is Unix - convert Windows-style backslashes to forward slashes
-				// Use string replacement with explicit backslash character
-				// The JSON unmarshaler has already converted \\ to \, so we just need to convert \ to /
-				backslash := string(rune(92)) // ASCII 92 = backslash
-				expanded = strings.ReplaceAll(expanded, backslash, `/`)
-			}
-
-			return expanded
+		// Not filepath.ToSlash: Windows paths are normalized from WSL too
+		content = strings.ReplaceAll(content, strings.ReplaceAll(p.dir, `\`, "/"), p.placeholder)
+		content = strings.ReplaceAll(content, p.dir, p.placeholder)
+	}
+	if v.Home != "" {
+		content = userProfileRef.ReplaceAllLiteralString(content, HomePlaceholder)
+	}
+	for _, p := range []struct{ value, placeholder string }{{v.Hostname, HostnamePlaceholder}, {v.User, UserPlaceholder}} {
+		if p.value == "" {
+			continue
 		}
-		return v
-	default:
-		return v
+		word := regexp.MustCompile(`(^|[^\w$.-])` + regexp.QuoteMeta(p.value) + `\b`)
+		content = word.ReplaceAllString(content, "${1}"+strings.ReplaceAll(p.placeholder, "$", "$$"))
 	}
+	return []byte(content)
 }
 
-// fallbackExpandPaths is a safe string-based fallback that only replaces in quoted strings
-func fallbackExpandPaths(data []byte, claudeDir string) []byte {
-	content := string(data)
-
-	// For JSON files, we need to use escaped backslashes on Windows
-	if strings.Contains(claudeDir, `\`) {
-		// Windows: use escaped backslashes for JSON
-		escapedClaudeDir := strings.ReplaceAll(claudeDir, `\`, `\\`)
-		content = strings.ReplaceAll(content, ClaudeDirPlaceholder, escapedClaudeDir)
-	} else {
-		// Unix: replace placeholder with forward-slash path
-		normalizedPath := filepath.ToSlash(claudeDir) // ensure forward slashes
-		content = strings.ReplaceAll(content, ClaudeDirPlaceholder, normalizedPath)
-
-		// Also convert Windows-style backslashes to forward slashes
-		// This handles cases where the stored JSON has raw backslashes from Windows paths
-		backslash := string(rune(92)) // ASCII 92 = backslash
-		forwardSlash := `/`
-		content = strings.ReplaceAll(content, backslash, forwardSlash)
-	}
-
-	return []byte(content)
+// ExpandPlaceholders replaces the placeholders in data with this machine's
+// values. Paths after $CLAUDE_DIR and $HOME get this platform's separators,
+// escaped in JSON.
+func ExpandPlaceholders(data []byte, v PathValues, jsonFile bool) []byte {
+	windows := strings.Contains(v.ClaudeDir+v.Home, `\`)
+	return placeholderRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := placeholderRef.FindSubmatch(ref)
+		var value string
+		switch {
+		case len(m[1]) > 0:
+			value = v.Home
+			if string(m[1]) == "CLAUDE_DIR" {
+				value = v.ClaudeDir
+			}
+			if value == "" {
+				return ref
+			}
+			rest := strings.ReplaceAll(string(m[2]), `\\`, `\`)
+			if windows {
+				rest = strings.ReplaceAll(rest, "/", `\`)
+			} else {
+				rest = strings.ReplaceAll(rest, `\`, "/")
+				value = filepath.ToSlash(value)
+			}
+			value += rest
+			if jsonFile {
+				value = strings.ReplaceAll(value, `\`, `\\`)
+			}
+		case string(ref) == UserPlaceholder:
+			value = v.User
+		case string(ref) == HostnamePlaceholder:
+			value = v.Hostname
+		}
+		if value == "" {
+			return ref
+		}
+		return []byte(value)
+	})
 }
@@ -0,0 +1,56 @@
+package sync
+
+import "testing"
+
+var macValues = PathValues{ClaudeDir: "/Users/felix/.claude", Home: "/Users/felix", User: "felix", Hostname: "felix-mbp"}
+
+var windowsValues = PathValues{ClaudeDir: `C:\Users\fi\.claude`, Home: `C:\Users\fi`, User: "fi", Hostname: "DESKTOP-1"}
+
+func TestNormalizePlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   PathValues
+		jsonFile bool
+		in       string
+		want     string
+	}{
+		{"claude dir first", macValues, true, `{"a": "/Users/felix/.claude/hooks/x.sh"}`, `{"a": "$CLAUDE_DIR/hooks/x.sh"}`},
+		{"home", macValues, true, `{"command": "/Users/felix/bin/mcp"}`, `{"command": "$HOME/bin/mcp"}`},
+		{"escaped windows path", windowsValues, true, `{"command": "C:\\Users\\fi\\bin\\mcp.exe"}`, `{"command": "$HOME\\bin\\mcp.exe"}`},
+		{"windows path with forward slashes", windowsValues, false, "See C:/Users/fi/notes", "See $HOME/notes"},
+		{"userprofile", windowsValues, false, `Run %UserProfile%\bin\x`, `Run $HOME\bin\x`},
+		{"user and hostname as words", macValues, false, "felix on felix-mbp, not felixa", "$USER on $HOSTNAME, not felixa"},
+		{"disabled", PathValues{ClaudeDir: "/Users/felix/.claude"}, false, "/Users/felix/notes felix", "/Users/felix/notes felix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NormalizePlaceholders([]byte(tt.in), tt.values, tt.jsonFile)); got != tt.want {
+				t.Errorf("NormalizePlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   PathValues
+		jsonFile bool
+		in       string
+		want     string
+	}{
+		{"windows path on mac", macValues, true, `{"command": "$HOME\\bin\\mcp.exe"}`, `{"command": "/Users/felix/bin/mcp.exe"}`},
+		{"mac path on windows", windowsValues, true, `{"command": "$CLAUDE_DIR/hooks/x.sh"}`, `{"command": "C:\\Users\\fi\\.claude\\hooks\\x.sh"}`},
+		{"markdown on windows", windowsValues, false, "Edit $HOME/notes.md", `Edit C:\Users\fi\notes.md`},
+		{"user and hostname", macValues, false, "$USER@$HOSTNAME", "felix@felix-mbp"},
+		{"other variables", macValues, false, "$HOMEBREW_PREFIX $USERNAME", "$HOMEBREW_PREFIX $USERNAME"},
+		{"disabled", PathValues{ClaudeDir: "/c"}, false, "$HOME $USER $CLAUDE_DIR", "$HOME $USER /c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ExpandPlaceholders([]byte(tt.in), tt.values, tt.jsonFile)); got != tt.want {
+				t.Errorf("ExpandPlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
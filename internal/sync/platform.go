@@ -66,6 +66,37 @@ func GetPlatformVariantName(filename, platform string) string {
 	return base + "." + platform + ext
 }
 
+// MachineTag returns the tag a machine's variants carry: its hostname up to
+// the first dot, lowercased, e.g. "workmac" for WorkMac.local
+func MachineTag(hostname string) string {
+	tag, _, _ := strings.Cut(strings.ToLower(hostname), ".")
+	return tag
+}
+
+// GetMachineVariantName returns the variant of filename for the machine
+// with tag, e.g. "settings.json", "workmac" -> "settings.workmac.json"
+func GetMachineVariantName(filename, tag string) string {
+	return GetPlatformVariantName(filename, tag)
+}
+
+// SplitMachineVariant returns the file a machine variant varies and the
+// machine's tag, if filename is a variant for one of tags
+// e.g., "settings.workmac.json" -> "settings.json", "workmac"
+func SplitMachineVariant(filename string, tags []string) (string, string, bool) {
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext)
+	tag := filepath.Ext(stem)
+	if ext == "" || tag == "" || tag == stem {
+		return "", "", false
+	}
+	for _, t := range tags {
+		if t != "" && tag[1:] == t {
+			return strings.TrimSuffix(stem, tag) + ext, t, true
+		}
+	}
+	return "", "", false
+}
+
 // ShouldSkipForPlatform determines if a file should be skipped on current platform
 // Returns true if the file is a variant for a different platform
 func ShouldSkipForPlatform(filename string) bool {
@@ -144,6 +175,11 @@ func DetectPlatformContent(filePath string) (string, string) {
 		return "", ""
 	}
 
+	// A template already has a block for each platform
+	if IsTemplate(data) {
+		return "", ""
+	}
+
 	content := string(data)
 
 	// Check Unix patterns
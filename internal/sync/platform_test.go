@@ -0,0 +1,34 @@
+package sync
+
+import "testing"
+
+func TestSplitMachineVariant(t *testing.T) {
+	tags := []string{"workmac", "desktop-1"}
+	tests := []struct {
+		filename string
+		base     string
+		tag      string
+		ok       bool
+	}{
+		{"settings.workmac.json", "settings.json", "workmac", true},
+		{"commands/deploy.desktop-1.md", "commands/deploy.md", "desktop-1", true},
+		{"settings.json", "", "", false},
+		{"settings.laptop.json", "", "", false},
+		{"workmac.json", "", "", false},
+		{".workmac.json", "", "", false},
+	}
+	for _, tt := range tests {
+		base, tag, ok := SplitMachineVariant(tt.filename, tags)
+		if base != tt.base || tag != tt.tag || ok != tt.ok {
+			t.Errorf("SplitMachineVariant(%q) = %q, %q, %v, want %q, %q, %v", tt.filename, base, tag, ok, tt.base, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestMachineTag(t *testing.T) {
+	for hostname, want := range map[string]string{"WorkMac.local": "workmac", "desktop-1": "desktop-1"} {
+		if got := MachineTag(hostname); got != want {
+			t.Errorf("MachineTag(%q) = %q, want %q", hostname, got, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -37,6 +38,12 @@ func FileChecksum(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// Checksum computes SHA256 of data, as FileChecksum does for a file
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // WalkFiles walks a directory and returns all file paths
 func WalkFiles(root string) ([]string, error) {
 	var files []string
@@ -86,11 +93,17 @@ func CopyFile(src, dst string) error {
 // ManifestEntry represents a single file in the manifest
 type ManifestEntry struct {
 	Checksum string
+	Mode     os.FileMode // Permission bits of the local file, 0 if unknown
 	Path     string
 }
 
-// GenerateManifest creates a manifest of all files in a directory
-func GenerateManifest(repoDir string) ([]ManifestEntry, error) {
+// GenerateManifest creates a manifest of all files in a directory, taking
+// checksums from idx where it has them. modes
+// holds the local files' permissions by slash-separated repo path; files
+// without one (and every file on Windows, where permissions aren't known)
+// keep the mode from the previous manifest, so a push from Windows doesn't
+// strip executable bits.
+func GenerateManifest(repoDir string, modes map[string]os.FileMode, idx *Index) ([]ManifestEntry, error) {
 	var entries []ManifestEntry
 
 	files, err := WalkFiles(repoDir)
@@ -98,6 +111,12 @@ func GenerateManifest(repoDir string) ([]ManifestEntry, error) {
 		return nil, err
 	}
 
+	previous := map[string]os.FileMode{}
+	old, _ := ReadManifest(filepath.Join(repoDir, ".sync-manifest"))
+	for _, e := range old {
+		previous[filepath.ToSlash(e.Path)] = e.Mode
+	}
+
 	for _, file := range files {
 		relPath := RelPath(repoDir, file)
 
@@ -106,13 +125,19 @@ func GenerateManifest(repoDir string) ([]ManifestEntry, error) {
 			continue
 		}
 
-		checksum, err := FileChecksum(file)
+		checksum, err := idx.Checksum(file)
 		if err != nil {
 			return nil, err
 		}
 
+		mode := modes[filepath.ToSlash(relPath)]
+		if mode == 0 {
+			mode = previous[filepath.ToSlash(relPath)]
+		}
+
 		entries = append(entries, ManifestEntry{
 			Checksum: checksum,
+			Mode:     mode,
 			Path:     relPath,
 		})
 	}
@@ -124,16 +149,21 @@ func GenerateManifest(repoDir string) ([]ManifestEntry, error) {
 func WriteManifest(path string, entries []ManifestEntry) error {
 	var lines []string
 	lines = append(lines, fmt.Sprintf("# claude-code-sync manifest - %s", time.Now().Format(time.RFC3339)))
-	lines = append(lines, "# Format: checksum  path")
+	lines = append(lines, "# Format: checksum  mode  path")
 
 	for _, e := range entries {
-		lines = append(lines, fmt.Sprintf("%s  %s", e.Checksum, e.Path))
+		mode := e.Mode
+		if mode == 0 {
+			mode = DefaultMode
+		}
+		lines = append(lines, fmt.Sprintf("%s  %04o  %s", e.Checksum, mode, e.Path))
 	}
 
 	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
 }
 
-// ReadManifest reads the manifest from a file
+// ReadManifest reads the manifest from a file. Manifests written before
+// modes were recorded have no mode column; their entries get Mode 0.
 func ReadManifest(path string) ([]ManifestEntry, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -152,10 +182,14 @@ func ReadManifest(path string) ([]ManifestEntry, error) {
 			continue
 		}
 
-		entries = append(entries, ManifestEntry{
-			Checksum: parts[0],
-			Path:     parts[1],
-		})
+		entry := ManifestEntry{Checksum: parts[0], Path: parts[1]}
+		if rest := strings.SplitN(parts[1], "  ", 2); len(rest) == 2 && len(rest[0]) == 4 {
+			if mode, err := strconv.ParseUint(rest[0], 8, 32); err == nil {
+				entry.Mode = os.FileMode(mode)
+				entry.Path = rest[1]
+			}
+		}
+		entries = append(entries, entry)
 	}
 
 	return entries, nil
@@ -166,13 +200,3 @@ func FileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
-
-// BackupFile creates a backup of a file with timestamp
-func BackupFile(src string) (string, error) {
-	if !FileExists(src) {
-		return "", nil
-	}
-
-	backupPath := fmt.Sprintf("%s.local-backup-%s", src, Timestamp())
-	return backupPath, CopyFile(src, backupPath)
-}
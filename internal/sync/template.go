@@ -0,0 +1,297 @@
+package sync
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Template blocks let one file hold content for both platforms, e.g.
+//
+//	{{#windows}}
+//	Run deploy.ps1
+//	{{/windows}}
+//	{{#unix}}
+//	Run ./deploy.sh
+//	{{/unix}}
+//
+// The repo keeps the file as written; pull renders it for the local
+// platform. A marker alone on its line takes the line with it.
+var templateMarker = regexp.MustCompile(`\{\{([#/])(windows|unix)\}\}`)
+
+// maxRetemplateLines bounds the line diff Retemplate runs
+const maxRetemplateLines = 4000
+
+// templatePiece is a run of template text: shared text (platform ""), the
+// inside of a block, or a marker
+type templatePiece struct {
+	text     string
+	platform string
+	marker   bool
+}
+
+// parseTemplate splits data into pieces. Returns false if it has no blocks,
+// or if blocks are nested, mismatched or left open, in which case the file is
+// treated as plain text.
+func parseTemplate(data []byte) ([]templatePiece, bool) {
+	content := string(data)
+	matches := templateMarker.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	var pieces []templatePiece
+	open := ""
+	pos := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		closing, platform := content[m[2]:m[3]] == "/", content[m[4]:m[5]]
+		if closing != (open != "") || (closing && platform != open) {
+			return nil, false
+		}
+
+		// A marker alone on its line takes the line and its newline
+		lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+		lineEnd := strings.IndexByte(content[end:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += end
+		}
+		if lineStart >= pos && strings.TrimSpace(content[lineStart:start]) == "" && strings.TrimSpace(content[end:lineEnd]) == "" {
+			start, end = lineStart, min(lineEnd+1, len(content))
+		}
+
+		if start > pos {
+			pieces = append(pieces, templatePiece{text: content[pos:start], platform: open})
+		}
+		pieces = append(pieces, templatePiece{text: content[start:end], platform: platform, marker: true})
+		pos = end
+		if closing {
+			open = ""
+		} else {
+			open = platform
+		}
+	}
+	if open != "" {
+		return nil, false
+	}
+	if pos < len(content) {
+		pieces = append(pieces, templatePiece{text: content[pos:]})
+	}
+	return pieces, true
+}
+
+// IsTemplate reports whether data has platform blocks
+func IsTemplate(data []byte) bool {
+	if !bytes.Contains(data, []byte("{{")) {
+		return false
+	}
+	_, ok := parseTemplate(data)
+	return ok
+}
+
+// RenderTemplate returns data with the blocks for other platforms removed
+// and the markers around this platform's blocks dropped. Data that isn't a
+// template is returned unchanged.
+func RenderTemplate(data []byte, platform string) []byte {
+	pieces, ok := parseTemplate(data)
+	if !ok {
+		return data
+	}
+	var out strings.Builder
+	for _, p := range pieces {
+		if renders(p, platform) {
+			out.WriteString(p.text)
+		}
+	}
+	return []byte(out.String())
+}
+
+func renders(p templatePiece, platform string) bool {
+	return !p.marker && (p.platform == "" || p.platform == platform)
+}
+
+// Retemplate returns what to push for a file rendered from template on
+// platform and possibly edited since. Content that is itself a template is
+// pushed as it is. Otherwise the edits are carried into the template, with
+// markers and other platforms' blocks kept where they were relative to the
+// surrounding lines. Returns false, and rendered unchanged, if the file is
+// too large to line up with the template.
+func Retemplate(template, rendered []byte, platform string) ([]byte, bool) {
+	pieces, ok := parseTemplate(template)
+	if !ok || IsTemplate(rendered) {
+		return rendered, true
+	}
+
+	// The render, with everything it leaves out as inserts at its offsets
+	type insert struct {
+		pos  int
+		text string
+	}
+	var before strings.Builder
+	var inserts []insert
+	for _, p := range pieces {
+		if renders(p, platform) {
+			before.WriteString(p.text)
+		} else {
+			inserts = append(inserts, insert{pos: before.Len(), text: p.text})
+		}
+	}
+	if before.String() == string(rendered) {
+		return template, true
+	}
+
+	old, cur := splitLines(before.String()), splitLines(string(rendered))
+	if len(old) > maxRetemplateLines || len(cur) > maxRetemplateLines {
+		return rendered, false
+	}
+	pair := pairLines(old, cur)
+	oldStart, curStart := lineStarts(old), lineStarts(cur)
+
+	// Moves an offset in the old render to the same place in the new one. An
+	// offset at the start of a line stays just after the line before it.
+	mapPos := func(pos int) int {
+		i := 0
+		for i < len(old) && pos >= oldStart[i]+len(old[i]) {
+			i++
+		}
+		if (i == len(old) || pos == oldStart[i]) && i > 0 && pair[i-1] >= 0 {
+			return curStart[pair[i-1]] + len(cur[pair[i-1]])
+		}
+		if i < len(old) && pair[i] >= 0 {
+			return curStart[pair[i]] + lineOffset(old[i], cur[pair[i]], pos-oldStart[i])
+		}
+		for k := i + 1; k < len(old); k++ {
+			if pair[k] >= 0 {
+				return curStart[pair[k]]
+			}
+		}
+		return len(rendered)
+	}
+
+	var out strings.Builder
+	pos := 0
+	for _, in := range inserts {
+		at := max(mapPos(in.pos), pos)
+		out.Write(rendered[pos:at])
+		out.WriteString(in.text)
+		pos = at
+	}
+	out.Write(rendered[pos:])
+	return []byte(out.String()), true
+}
+
+// pairLines lines up the lines of a with lines of b, returning for each
+// line of a its index in b or -1. Equal lines are matched along a longest
+// common subsequence; between them, changed lines pair up in order.
+func pairLines(a, b []string) []int {
+	pair := make([]int, len(a))
+	for i := range pair {
+		pair[i] = -1
+	}
+
+	// Common prefix and suffix need no table
+	lo := 0
+	for lo < len(a) && lo < len(b) && a[lo] == b[lo] {
+		pair[lo] = lo
+		lo++
+	}
+	hiA, hiB := len(a), len(b)
+	for hiA > lo && hiB > lo && a[hiA-1] == b[hiB-1] {
+		hiA--
+		hiB--
+		pair[hiA] = hiB
+	}
+
+	n, m := hiA-lo, hiB-lo
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[lo+i] == b[lo+j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[lo+i] == b[lo+j]:
+			pair[lo+i] = lo + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	// Pair the changed lines of each hunk
+	next := 0
+	for i := 0; i < len(a); i++ {
+		if pair[i] >= 0 {
+			next = pair[i] + 1
+			continue
+		}
+		limit := len(b)
+		for k := i + 1; k < len(a); k++ {
+			if pair[k] >= 0 {
+				limit = pair[k]
+				break
+			}
+		}
+		if next < limit {
+			pair[i] = next
+			next++
+		}
+	}
+	return pair
+}
+
+// lineOffset moves an offset in line a to line b, which replaced it: text
+// the two share at the start or end keeps its place, and anything in the
+// changed middle goes to the start of the change
+func lineOffset(a, b string, off int) int {
+	if a == b {
+		return off
+	}
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	switch {
+	case off <= prefix:
+		return off
+	case off >= len(a)-suffix:
+		return len(b) - (len(a) - off)
+	}
+	return prefix
+}
+
+// splitLines splits s after each newline, without an empty last line
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func lineStarts(lines []string) []int {
+	starts := make([]int, len(lines))
+	pos := 0
+	for i, line := range lines {
+		starts[i] = pos
+		pos += len(line)
+	}
+	return starts
+}
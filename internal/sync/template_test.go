@@ -0,0 +1,103 @@
+package sync
+
+import "testing"
+
+const deployTemplate = `# Deploy
+
+{{#windows}}
+Run deploy.ps1 from PowerShell.
+{{/windows}}
+{{#unix}}
+Run ./deploy.sh.
+{{/unix}}
+
+Then check the {{#windows}}Event Log{{/windows}}{{#unix}}journal{{/unix}}.
+`
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{PlatformUnix, "# Deploy\n\nRun ./deploy.sh.\n\nThen check the journal.\n"},
+		{PlatformWindows, "# Deploy\n\nRun deploy.ps1 from PowerShell.\n\nThen check the Event Log.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			if got := string(RenderTemplate([]byte(deployTemplate), tt.platform)); got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTemplate(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{deployTemplate, true},
+		{"no blocks {{ .Name }}", false},
+		{"{{#unix}}open", false},
+		{"{{/unix}}", false},
+		{"{{#unix}}{{/windows}}", false},
+		{"{{#unix}}{{#windows}}{{/windows}}{{/unix}}", false},
+	}
+	for _, tt := range tests {
+		if got := IsTemplate([]byte(tt.content)); got != tt.want {
+			t.Errorf("IsTemplate(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRetemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rendered string
+		want     string
+	}{
+		{
+			name:     "unchanged",
+			rendered: "# Deploy\n\nRun ./deploy.sh.\n\nThen check the journal.\n",
+			want:     deployTemplate,
+		},
+		{
+			name:     "shared line edited",
+			rendered: "# Deploy to prod\n\nRun ./deploy.sh.\n\nThen check the journal.\n",
+			want:     "# Deploy to prod\n" + deployTemplate[len("# Deploy\n"):],
+		},
+		{
+			name:     "block line edited",
+			rendered: "# Deploy\n\nRun ./deploy.sh --prod.\n\nThen check the journal.\n",
+			want:     "# Deploy\n\n{{#windows}}\nRun deploy.ps1 from PowerShell.\n{{/windows}}\n{{#unix}}\nRun ./deploy.sh --prod.\n{{/unix}}\n\nThen check the {{#windows}}Event Log{{/windows}}{{#unix}}journal{{/unix}}.\n",
+		},
+		{
+			name:     "line appended",
+			rendered: "# Deploy\n\nRun ./deploy.sh.\n\nThen check the journal.\nDone.\n",
+			want:     deployTemplate + "Done.\n",
+		},
+		{
+			name:     "inline block edited",
+			rendered: "# Deploy\n\nRun ./deploy.sh.\n\nThen check the logs.\n",
+			want:     "# Deploy\n\n{{#windows}}\nRun deploy.ps1 from PowerShell.\n{{/windows}}\n{{#unix}}\nRun ./deploy.sh.\n{{/unix}}\n\nThen check the {{#windows}}Event Log{{/windows}}{{#unix}}logs{{/unix}}.\n",
+		},
+		{
+			name:     "written as a template",
+			rendered: "{{#unix}}\nunix only\n{{/unix}}\n",
+			want:     "{{#unix}}\nunix only\n{{/unix}}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Retemplate([]byte(deployTemplate), []byte(tt.rendered), PlatformUnix)
+			if !ok || string(got) != tt.want {
+				t.Errorf("Retemplate() = %q, %v, want %q", got, ok, tt.want)
+			}
+			if !IsTemplate([]byte(tt.rendered)) {
+				if back := string(RenderTemplate(got, PlatformUnix)); back != tt.rendered {
+					t.Errorf("rendering the result gives %q, want %q", back, tt.rendered)
+				}
+			}
+		})
+	}
+}
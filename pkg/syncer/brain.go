@@ -0,0 +1,136 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/secrets"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// SourceClaudeBrain imports from a claude-brain repo
+const SourceClaudeBrain = "claude-brain"
+
+// brainPaths are the parts of ~/.claude a claude-brain repo tracks, at the
+// same paths relative to its root. Everything else in it (its git hooks,
+// README and own state) belongs to claude-brain and is left alone.
+var brainPaths = []string{
+	"CLAUDE.md",
+	"settings.json",
+	"agents",
+	"commands",
+	"skills",
+	"rules",
+	"output-styles",
+	"hooks",
+}
+
+// brainFiles lists the files in a claude-brain repo that belong in ~/.claude
+func brainFiles(dir string) (map[string]string, error) {
+	found := map[string]string{}
+	for _, p := range brainPaths {
+		path := filepath.Join(dir, filepath.FromSlash(p))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			found[p] = path
+			continue
+		}
+		files, err := sync.WalkFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for _, file := range files {
+			found[filepath.ToSlash(sync.RelPath(dir, file))] = file
+		}
+	}
+	return found, nil
+}
+
+// inBrain reports whether a path relative to ~/.claude is one a
+// claude-brain repo tracks
+func inBrain(relPath string) bool {
+	top, _, _ := strings.Cut(relPath, "/")
+	return slices.Contains(brainPaths, top)
+}
+
+// BrainExportResult describes an export to a claude-brain repo
+type BrainExportResult struct {
+	Files    []string          // Written, relative to the repo root
+	Removed  []string          // Gone from ~/.claude, so removed from the repo
+	Withheld []string          // Matched encrypt patterns, so not written
+	Flagged  []string          // Had likely secrets, so not written
+	Secrets  []secrets.Finding // The likely secrets in Flagged
+}
+
+// ExportBrain writes the files a claude-brain repo tracks into dir in
+// plain text, the way claude-brain lays them out, so its git hooks commit
+// and push them. Like share, files matching encrypt patterns and files with
+// likely secrets are withheld, and redact rules are applied. Files in the
+// tracked paths that are no longer in ~/.claude are removed; nothing else
+// in dir is touched. Committing is left to claude-brain.
+func (e *Engine) ExportBrain(dir string, dryRun bool) (*BrainExportResult, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	if !sync.FileExists(e.paths.ClaudeDir) {
+		return nil, fmt.Errorf("no ~/.claude directory found. Nothing to export")
+	}
+
+	all, err := sync.WalkFiles(e.paths.ClaudeDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range all {
+		if inBrain(filepath.ToSlash(sync.RelPath(e.paths.ClaudeDir, file))) {
+			files = append(files, file)
+		}
+	}
+
+	plain, contents, err := e.plainFiles(files, "export", dryRun)
+	if err != nil {
+		return nil, err
+	}
+	result := &BrainExportResult{Withheld: plain.Withheld, Flagged: plain.Flagged, Secrets: plain.Secrets}
+
+	existing, err := brainFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for relPath, file := range existing {
+		if slices.Contains(plain.Files, relPath) || slices.Contains(plain.Withheld, relPath) || slices.Contains(plain.Flagged, relPath) {
+			continue
+		}
+		if dryRun {
+			e.log.Info(fmt.Sprintf("  [remove] %s", relPath))
+		} else if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, relPath)
+	}
+	slices.Sort(result.Removed)
+
+	for i, relPath := range plain.Files {
+		dest := filepath.Join(dir, filepath.FromSlash(relPath))
+		if current, err := os.ReadFile(dest); err == nil && string(current) == string(contents[i]) {
+			continue
+		}
+		result.Files = append(result.Files, relPath)
+		if dryRun {
+			continue
+		}
+		if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, contents[i], 0644); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// claudeJSONSubset returns the keys of claude.json selected by the
+// claude_json include/exclude lists
+func (e *Engine) claudeJSONSubset(data []byte) ([]byte, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse claude.json: %w", err)
+	}
+
+	subset := map[string]json.RawMessage{}
+	for key, value := range all {
+		if e.cfg.ClaudeJSON.Synced(key) {
+			subset[key] = value
+		}
+	}
+	return marshalJSON(subset)
+}
+
+// mergeClaudeJSON applies a synced subset onto the local claude.json. Synced
+// keys are replaced or removed to match the subset; all other local keys
+// are kept as they are.
+func (e *Engine) mergeClaudeJSON(local, synced []byte) ([]byte, error) {
+	merged := map[string]json.RawMessage{}
+	if len(local) > 0 {
+		if err := json.Unmarshal(local, &merged); err != nil {
+			return nil, fmt.Errorf("failed to parse local claude.json: %w", err)
+		}
+	}
+	var subset map[string]json.RawMessage
+	if err := json.Unmarshal(synced, &subset); err != nil {
+		return nil, fmt.Errorf("failed to parse synced claude.json: %w", err)
+	}
+
+	for key := range merged {
+		if _, ok := subset[key]; !ok && e.cfg.ClaudeJSON.Synced(key) {
+			delete(merged, key)
+		}
+	}
+	for key, value := range subset {
+		if e.cfg.ClaudeJSON.Synced(key) {
+			merged[key] = value
+		}
+	}
+	return marshalJSON(merged)
+}
+
+// marshalJSON formats v the way Claude Code writes claude.json, leaving
+// characters like & in hook commands as they are
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergesClaudeJSON reports whether f is claude.json synced by key
+func (e *Engine) mergesClaudeJSON(f repoFile) bool {
+	return f.encrypted && f.dest == e.paths.ClaudeJSON && e.cfg.ClaudeJSON.Filtered()
+}
+
+// jsonEqual compares two JSON documents ignoring formatting and key order
+func jsonEqual(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,188 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// CleanOptions controls Clean
+type CleanOptions struct {
+	DryRun bool // Only list the files that would be removed
+}
+
+// CleanResult describes a clean run
+type CleanResult struct {
+	Removed     []string // Repo files removed, or that would be with DryRun
+	Excluded    []string // Of Removed, those exclude_patterns now leave out
+	Committed   bool
+	Pushed      bool
+	Queued      bool   // Committed, but the remote was unreachable
+	PullRequest string // With push_mode: review, the pull request opened, if known
+}
+
+// Clean removes the repo files whose source is gone from this machine, such
+// as files deleted from ~/.claude after they were pushed, and pushes the
+// removal as one commit, along with the files exclude_patterns now leave
+// out everywhere, which pushes before they were added left in the repo.
+// Only files this machine would restore are considered deleted: other
+// platforms' and machines' variants are left alone. The
+// repo isn't pulled first, so files other machines pushed since this
+// machine's last pull aren't mistaken for deleted ones; the commit is
+// rebased onto theirs when pushed.
+func (e *Engine) Clean(opts CleanOptions) (*CleanResult, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init <repo-url>' first")
+	}
+	if !opts.DryRun {
+		if err := e.cfg.CanPush(); err != nil {
+			return nil, err
+		}
+	}
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+
+	e.openIndex()
+	defer e.saveIndex()
+
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	result := &CleanResult{}
+	for _, f := range files {
+		// MCP servers merge into claude.json rather than restore a file
+		if f.src == f.mcpServers || sync.FileExists(f.dest) {
+			continue
+		}
+		// Team files come from the team clone, which only push changes
+		repoPath := sync.RelPath(e.paths.RepoDir, f.src)
+		if !filepath.IsLocal(repoPath) || e.cfg.IsTeamFile(f.relPath) {
+			continue
+		}
+		repoPath = filepath.ToSlash(repoPath)
+		result.Removed = append(result.Removed, repoPath)
+	}
+	excluded, err := e.excludedRepoFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, repoPath := range excluded {
+		if !slices.Contains(result.Removed, repoPath) {
+			result.Removed = append(result.Removed, repoPath)
+		}
+	}
+	result.Excluded = excluded
+	slices.Sort(result.Removed)
+	if opts.DryRun || len(result.Removed) == 0 {
+		return result, nil
+	}
+
+	for _, repoPath := range result.Removed {
+		if err := os.Remove(filepath.Join(e.paths.RepoDir, filepath.FromSlash(repoPath))); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", repoPath, err)
+		}
+		e.removeEmptyDirs(filepath.Dir(filepath.Join(e.paths.RepoDir, filepath.FromSlash(repoPath))))
+		e.index.Forget(repoPath)
+		e.debug(fmt.Sprintf("Removed %s", repoPath))
+	}
+	if err := e.commitCleanup(b, fmt.Sprintf("Remove %d files deleted locally or excluded", len(result.Removed)), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// removeEmptyDirs removes dir and its parents inside the repo while they
+// are empty
+func (e *Engine) removeEmptyDirs(dir string) {
+	for dir != e.paths.RepoDir && strings.HasPrefix(dir, e.paths.RepoDir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// excludedRepoFiles lists the repo files exclude_patterns leave out on
+// every machine. Team files and extra_paths are left to their own rules.
+func (e *Engine) excludedRepoFiles() ([]string, error) {
+	files, err := sync.WalkFiles(e.paths.RepoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repo: %w", err)
+	}
+	tags := e.machineTags()
+	var excluded []string
+	for _, file := range files {
+		relPath := sync.RelPath(e.paths.RepoDir, file)
+		if strings.HasPrefix(relPath, ".git") || relPath == ".sync-manifest" || relPath == "README.md" || relPath == RecipientsFile || relPath == MCPServersFile || isMachineRecord(relPath) {
+			continue
+		}
+		basePath := sync.NFC(strings.TrimSuffix(relPath, ".age"))
+		if isExtraPath(basePath) {
+			continue
+		}
+		if varies, _, ok := sync.SplitMachineVariant(basePath, tags); ok {
+			basePath = varies
+		}
+		if e.cfg.ExcludedEverywhere(basePath) && !e.cfg.IsTeamFile(basePath) {
+			excluded = append(excluded, filepath.ToSlash(relPath))
+		}
+	}
+	return excluded, nil
+}
+
+// commitCleanup commits the files removed from the repo and pushes them
+func (e *Engine) commitCleanup(b backend.Backend, summary string, result *CleanResult) error {
+	if err := e.writeManifest(nil); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("%s\n\n%s %s", summary, machineTrailer, config.Hostname())
+	committed, err := b.Commit(message)
+	if err != nil {
+		return err
+	}
+	result.Committed = committed
+	if !committed || !b.HasRemote() {
+		return nil
+	}
+
+	if e.cfg.PushMode == config.PushModeReview {
+		if result.PullRequest, err = e.pushReview(b, message, result.Removed); err != nil {
+			if !backend.IsOffline(err) {
+				return err
+			}
+			e.queueReview(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			return nil
+		}
+	} else {
+		e.log.Info("Pushing to remote...")
+		if _, err := e.pushRemote(b, message, nil); err != nil {
+			if !backend.IsOffline(err) {
+				return err
+			}
+			e.queuePush(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			return nil
+		}
+	}
+	e.clearPending()
+	result.Pushed = true
+	return nil
+}
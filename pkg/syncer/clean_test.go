@@ -0,0 +1,142 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+)
+
+// newTestEngine returns an engine with a fresh home directory holding
+// config.yaml and files, by path relative to the home directory. The repo
+// is an empty git repo without a remote.
+func newTestEngine(t *testing.T, configYAML string, files map[string]string) *Engine {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(config.SyncDirEnv, "")
+	t.Setenv(config.ClaudeConfigDirEnv, "")
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "test")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "test@example.com")
+	}
+
+	files[".claude-sync/config.yaml"] = configYAML
+	for path, content := range files {
+		writeTestFile(t, filepath.Join(home, filepath.FromSlash(path)), content)
+	}
+	paths := config.GetPaths()
+	if err := os.MkdirAll(paths.RepoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitpkg.New(paths.RepoDir).Init(); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	e, err := New(paths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		files    map[string]string
+		removed  []string
+		excluded []string
+		kept     []string // Paths relative to the home directory
+	}{
+		{
+			name:   "deleted locally",
+			config: "exclude_patterns: [todos]\n",
+			files: map[string]string{
+				".claude/CLAUDE.md":                   "hi",
+				".claude-sync/repo/CLAUDE.md":         "hi",
+				".claude-sync/repo/commands/gone.md":  "old",
+				".claude-sync/repo/settings.json.age": "x",
+			},
+			removed: []string{"commands/gone.md", "settings.json.age"},
+			kept:    []string{".claude-sync/repo/CLAUDE.md"},
+		},
+		{
+			name:   "excluded after push",
+			config: "exclude_patterns: [todos]\n",
+			files: map[string]string{
+				".claude/todos/a.json":             "[]",
+				".claude-sync/repo/todos/a.json":   "[]",
+				".claude-sync/repo/todos/b.json":   "[]",
+				".claude/CLAUDE.md":                "hi",
+				".claude-sync/repo/CLAUDE.md":      "hi",
+				".claude-sync/repo/.sync-manifest": "",
+			},
+			removed:  []string{"todos/a.json", "todos/b.json"},
+			excluded: []string{"todos/a.json", "todos/b.json"},
+			kept:     []string{".claude/todos/a.json", ".claude-sync/repo/CLAUDE.md"},
+		},
+		{
+			name:   "excluded on this machine only",
+			config: "exclude_patterns: [todos]\nplatforms:\n  linux:\n    exclude_patterns: [notes]\n  darwin:\n    exclude_patterns: [notes]\n  windows:\n    exclude_patterns: [notes]\n",
+			files: map[string]string{
+				".claude/notes/a.md":           "n",
+				".claude-sync/repo/notes/a.md": "n",
+			},
+			kept: []string{".claude-sync/repo/notes/a.md"},
+		},
+		{
+			name:   "team file deleted locally",
+			config: "exclude_patterns: [todos]\nteam:\n  url: https://example.com/team.git\n  patterns: [commands]\n",
+			files: map[string]string{
+				".claude-sync/team/commands/shared.md": "team",
+			},
+			kept: []string{".claude-sync/team/commands/shared.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, tt.config, tt.files)
+			home, _ := os.UserHomeDir()
+
+			result, err := e.Clean(CleanOptions{})
+			if err != nil {
+				t.Fatalf("Clean: %v", err)
+			}
+			if !slices.Equal(result.Removed, tt.removed) {
+				t.Errorf("Removed = %q, want %q", result.Removed, tt.removed)
+			}
+			if !slices.Equal(result.Excluded, tt.excluded) {
+				t.Errorf("Excluded = %q, want %q", result.Excluded, tt.excluded)
+			}
+			if len(tt.removed) > 0 && !result.Committed {
+				t.Error("cleanup was not committed")
+			}
+			for _, path := range tt.removed {
+				if _, err := os.Stat(filepath.Join(e.paths.RepoDir, filepath.FromSlash(path))); !os.IsNotExist(err) {
+					t.Errorf("%s is still in the repo", path)
+				}
+			}
+			for _, path := range tt.kept {
+				if _, err := os.Stat(filepath.Join(home, filepath.FromSlash(path))); err != nil {
+					t.Errorf("%s was removed", path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Conflict is a file that differs between this machine and the remote
+type Conflict struct {
+	Path       string     // Relative to ~/.claude (without .age)
+	Kind       ChangeKind // ChangeChanged; ChangeNew if only on the remote, ChangeAdded if only here
+	Encrypted  bool
+	LocalSize  int64 // -1 if not on this machine
+	LocalTime  time.Time
+	RemoteSize int64     // Decrypted, as a pull would write it; -1 if not on the remote
+	RemoteTime time.Time // Last commit on the remote that changed the file
+	Machine    string    // Machine that made that commit
+}
+
+// Conflicts fetches from the remote and lists the files whose content on
+// this machine differs from the remote's latest sync, decrypting encrypted
+// files to compare them. Nothing is pulled or changed.
+func (e *Engine) Conflicts() ([]Conflict, error) {
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	repo, err := e.gitRepo("conflicts")
+	if err != nil {
+		return nil, err
+	}
+	if !repo.HasRemote() {
+		return nil, fmt.Errorf("no remote configured")
+	}
+
+	e.log.Info("Fetching from remote...")
+	if err := repo.Fetch(); err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	remote, err := repo.GetRemoteCommit()
+	if err != nil || remote == "" {
+		return nil, fmt.Errorf("the remote has no syncs yet")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "claude-code-sync-conflicts-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := repo.ExportTree(remote, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", shortRev(remote), err)
+	}
+
+	// Compare with the remote's snapshot as if it were the repo. The
+	// checksum cache is only read: nothing here holds the lock.
+	snapshot := *e
+	snapshot.paths.RepoDir = tmpDir
+	snapshot.index = sync.LoadIndex(e.paths.IndexFile)
+
+	changes, err := snapshot.compareLocal(identity)
+	if err != nil {
+		return nil, err
+	}
+	files, err := snapshot.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	bySrc := map[string]repoFile{}
+	for _, f := range files {
+		bySrc[f.src] = f
+	}
+
+	var conflicts []Conflict
+	repoPaths := map[string]int{} // Repo path of each conflict on the remote
+	for _, c := range changes {
+		conflict := Conflict{Path: filepath.ToSlash(c.Path), Kind: c.Kind, Encrypted: c.Encrypted, LocalSize: -1, RemoteSize: -1}
+		if c.Kind != ChangeNew {
+			if info, err := os.Stat(c.LocalPath); err == nil {
+				conflict.LocalSize, conflict.LocalTime = info.Size(), info.ModTime()
+			}
+		}
+		if f, ok := bySrc[c.RepoPath]; ok && c.Kind != ChangeAdded {
+			if content, err := snapshot.incomingContent(identity, f); err == nil {
+				conflict.RemoteSize = int64(len(content))
+			}
+			repoPaths[filepath.ToSlash(sync.RelPath(tmpDir, c.RepoPath))] = len(conflicts)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	lastChanged(repo, remote, repoPaths, conflicts)
+
+	slices.SortFunc(conflicts, func(a, b Conflict) int { return strings.Compare(a.Path, b.Path) })
+	return conflicts, nil
+}
+
+// lastChanged fills in when and by which machine each repo path was last
+// changed in the history of rev. repoPaths maps repo paths to their index
+// in conflicts.
+func lastChanged(repo gitpkg.Repo, rev string, repoPaths map[string]int, conflicts []Conflict) {
+	if len(repoPaths) == 0 {
+		return
+	}
+	commits, err := repo.LogFrom(rev, 0)
+	if err != nil {
+		return
+	}
+	for _, c := range commits {
+		for _, f := range c.Files {
+			i, ok := repoPaths[f.Path]
+			if !ok {
+				continue
+			}
+			conflicts[i].RemoteTime = c.Time
+			conflicts[i].Machine = commitMachine(c)
+			delete(repoPaths, f.Path)
+		}
+		if len(repoPaths) == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Dotfiles managers import --from reads
+const (
+	SourceChezmoi = "chezmoi"  // A chezmoi source dir, e.g. ~/.local/share/chezmoi
+	SourceStow    = "stow"     // A stow dir, or one package in it
+	SourceBareGit = "bare-git" // A bare repo whose work tree is $HOME, e.g. ~/.dotfiles
+)
+
+// pointerNote is left in a dotfiles repo once its Claude Code files have
+// been handed over
+const pointerNote = `# Claude Code config
+
+~/.claude and ~/.claude.json are synced by claude-code-sync now, not by this
+repo. They were imported on %s:
+
+%s
+Run 'claude-code-sync status' to see where they are synced to.
+`
+
+// claudeTarget maps a path relative to $HOME to its import key, or "" if it
+// isn't a Claude Code file
+func claudeTarget(home string) string {
+	home = filepath.ToSlash(home)
+	if home == ".claude.json" {
+		return importClaudeJSON
+	}
+	if rel, ok := strings.CutPrefix(home, ".claude/"); ok && rel != "" {
+		return rel
+	}
+	return ""
+}
+
+// chezmoiFiles reads a chezmoi source dir, mapping source names such as
+// private_dot_claude/executable_hook.sh to their targets. Templates,
+// encrypted files, symlinks and scripts need chezmoi itself, so they are
+// reported instead.
+func (e *Engine) chezmoiFiles(dir, _ string) (map[string]string, []string, error) {
+	root := dir
+	if data, err := os.ReadFile(filepath.Join(dir, ".chezmoiroot")); err == nil {
+		root = filepath.Join(dir, strings.TrimSpace(string(data)))
+	}
+	files, err := sync.WalkFiles(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+
+	found := map[string]string{}
+	var unsupported []string
+	for _, file := range files {
+		parts := strings.Split(filepath.ToSlash(sync.RelPath(root, file)), "/")
+		var target []string
+		reason := ""
+		for i, part := range parts {
+			// chezmoi ignores its own files and anything else starting with "."
+			if strings.HasPrefix(part, ".") {
+				target = nil
+				break
+			}
+			name, why := chezmoiName(part, i == len(parts)-1)
+			if name == "" {
+				target = nil
+				break
+			}
+			if why != "" {
+				reason = why
+			}
+			target = append(target, name)
+		}
+		if target == nil {
+			continue
+		}
+		key := claudeTarget(strings.Join(target, "/"))
+		if key == "" {
+			continue
+		}
+		if reason != "" {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", sync.RelPath(dir, file), reason))
+			continue
+		}
+		found[key] = file
+	}
+	return found, unsupported, nil
+}
+
+// chezmoiName turns one component of a chezmoi source path into the target
+// name. Returns "" for entries that don't map to a file (removals, scripts)
+// and a reason for files only chezmoi can produce.
+func chezmoiName(part string, file bool) (name, unsupported string) {
+	if !file {
+		for _, prefix := range []string{"external_", "exact_", "private_", "readonly_"} {
+			part = strings.TrimPrefix(part, prefix)
+		}
+		if strings.HasPrefix(part, "remove_") {
+			return "", ""
+		}
+		return chezmoiDot(part), ""
+	}
+
+	switch {
+	case strings.HasPrefix(part, "remove_"), strings.HasPrefix(part, "run_"):
+		return "", ""
+	case strings.HasPrefix(part, "modify_"):
+		unsupported = "modify script"
+	case strings.HasPrefix(part, "symlink_"):
+		unsupported = "symlink"
+	case strings.HasPrefix(part, "encrypted_"), strings.Contains(part, "_encrypted_"):
+		unsupported = "encrypted by chezmoi; decrypt it with 'chezmoi cat' first"
+	case strings.HasSuffix(part, ".tmpl"):
+		unsupported = "template; render it with 'chezmoi cat' first"
+	}
+	part = strings.TrimSuffix(part, ".tmpl")
+	for _, prefix := range []string{"create_", "modify_", "symlink_", "encrypted_", "private_", "readonly_", "empty_", "executable_", "once_", "onchange_", "before_", "after_"} {
+		part = strings.TrimPrefix(part, prefix)
+	}
+	if name, ok := strings.CutSuffix(part, ".literal"); ok {
+		part = name
+	}
+	return chezmoiDot(part), unsupported
+}
+
+func chezmoiDot(part string) string {
+	if name, ok := strings.CutPrefix(part, "literal_"); ok {
+		return name
+	}
+	if name, ok := strings.CutPrefix(part, "dot_"); ok {
+		return "." + name
+	}
+	return part
+}
+
+// chezmoiPointer removes the imported files from the source dir and adds
+// them to .chezmoiignore, so chezmoi leaves them alone even under an exact_
+// directory
+func (e *Engine) chezmoiPointer(dir string, imported map[string]string) (string, error) {
+	ignore := filepath.Join(dir, ".chezmoiignore")
+	if data, err := os.ReadFile(filepath.Join(dir, ".chezmoiroot")); err == nil {
+		ignore = filepath.Join(dir, strings.TrimSpace(string(data)), ".chezmoiignore")
+	}
+	f, err := os.OpenFile(ignore, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintln(f, "\n# Synced by claude-code-sync")
+	for _, key := range sortedKeys(imported) {
+		fmt.Fprintln(f, strings.TrimPrefix(homePath(key), "~/"))
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := removeSources(dir, imported); err != nil {
+		return "", err
+	}
+	return ignore, writePointerNote(filepath.Join(dir, ".claude-code-sync.md"), imported)
+}
+
+// stowFiles reads the .claude dir and .claude.json of a stow package, or of
+// every package when dir is the stow dir
+func (e *Engine) stowFiles(dir, _ string) (map[string]string, []string, error) {
+	packages := []string{dir}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			packages = append(packages, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	found := map[string]string{}
+	for _, pkg := range packages {
+		if file := filepath.Join(pkg, ".claude.json"); sync.FileExists(file) {
+			found[importClaudeJSON] = file
+		}
+		claudeDir := filepath.Join(pkg, ".claude")
+		if info, err := os.Stat(claudeDir); err != nil || !info.IsDir() {
+			continue
+		}
+		files, err := sync.WalkFiles(claudeDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", claudeDir, err)
+		}
+		for _, file := range files {
+			found[filepath.ToSlash(sync.RelPath(claudeDir, file))] = file
+		}
+	}
+	return found, nil, nil
+}
+
+// stowPointer removes the imported files from their packages. Links in
+// ~/.claude were already replaced with copies.
+func (e *Engine) stowPointer(dir string, imported map[string]string) (string, error) {
+	if err := removeSources(dir, imported); err != nil {
+		return "", err
+	}
+	// stow skips README.* at the top of a package, so the note is never linked
+	note := filepath.Join(dir, "README.claude-code-sync.md")
+	return note, writePointerNote(note, imported)
+}
+
+// bareGitFiles reads .claude and .claude.json from HEAD of a bare dotfiles
+// repo, extracted into scratch
+func (e *Engine) bareGitFiles(dir, scratch string) (map[string]string, []string, error) {
+	if !sync.FileExists(filepath.Join(dir, "HEAD")) || !sync.FileExists(filepath.Join(dir, "objects")) {
+		return nil, nil, fmt.Errorf("%s is not a bare git repo", dir)
+	}
+	if err := gitpkg.Open(dir, e.cfg.GitBackend).ExportTree("HEAD", scratch); err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	files, err := sync.WalkFiles(scratch)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := map[string]string{}
+	for _, file := range files {
+		if key := claudeTarget(sync.RelPath(scratch, file)); key != "" {
+			found[key] = file
+		}
+	}
+	return found, nil, nil
+}
+
+// bareGitPointer stops the bare repo tracking the imported files, leaving
+// them in $HOME, and says why in the commit message. Needs the git CLI.
+func (e *Engine) bareGitPointer(dir string, imported map[string]string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	git := func(args ...string) error {
+		out, err := exec.Command("git", append([]string{"--git-dir", dir, "--work-tree", home}, args...)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	args := []string{"rm", "-r", "--cached", "--quiet", "--ignore-unmatch", "--"}
+	var list strings.Builder
+	for _, key := range sortedKeys(imported) {
+		args = append(args, strings.TrimPrefix(homePath(key), "~/"))
+		fmt.Fprintf(&list, "- %s\n", homePath(key))
+	}
+	if err := git(args...); err != nil {
+		return "", err
+	}
+	message := "Stop tracking Claude Code config\n\n" + fmt.Sprintf(pointerNote, time.Now().Format("2006-01-02"), list.String())
+	if err := git("commit", "--quiet", "-m", message); err != nil {
+		return "", err
+	}
+	return "a commit in " + dir, nil
+}
+
+// removeSources deletes imported files from a dotfiles dir, then any
+// directories left empty, up to dir
+func removeSources(dir string, imported map[string]string) error {
+	for _, src := range imported {
+		if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for parent := filepath.Dir(src); parent != dir && strings.HasPrefix(parent, dir); parent = filepath.Dir(parent) {
+			if os.Remove(parent) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func writePointerNote(path string, imported map[string]string) error {
+	var list strings.Builder
+	for _, key := range sortedKeys(imported) {
+		fmt.Fprintf(&list, "- %s\n", homePath(key))
+	}
+	return os.WriteFile(path, []byte(fmt.Sprintf(pointerNote, time.Now().Format("2006-01-02"), list.String())), 0644)
+}
+
+// homePath returns an import key as a path under ~
+func homePath(key string) string {
+	if key == importClaudeJSON {
+		return key
+	}
+	return "~/.claude/" + key
+}
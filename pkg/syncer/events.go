@@ -0,0 +1,69 @@
+package syncer
+
+import "time"
+
+// EventType identifies a machine-readable engine event
+type EventType string
+
+const (
+	EventFileEncrypted EventType = "file_encrypted" // Push: a changed file was encrypted into the repo
+	EventFileCopied    EventType = "file_copied"    // Push or pull: a changed plain-text file was copied
+	EventFileDecrypted EventType = "file_decrypted" // Pull: a changed file was decrypted into ~/.claude
+	EventFileConflict  EventType = "file_conflict"  // Pull: a locally edited file was replaced; the local copy is in backups/files
+	EventPushComplete  EventType = "push_complete"  // Push finished; Files is the number of files changed
+	EventPullComplete  EventType = "pull_complete"  // Pull finished; Files is the number of files changed
+	EventError         EventType = "error"          // A command failed (sent by the CLI, not the engine)
+)
+
+// Event is a machine-readable record of something the engine did. The CLI
+// writes events as newline-delimited JSON with --events.
+type Event struct {
+	Type        EventType `json:"event"`
+	Time        time.Time `json:"time"`
+	Path        string    `json:"path,omitempty"`  // File events: path relative to ~/.claude
+	Files       int       `json:"files,omitempty"` // Complete events: files changed
+	Committed   bool      `json:"committed,omitempty"`
+	Pushed      bool      `json:"pushed,omitempty"`
+	Queued      bool      `json:"queued,omitempty"`
+	Rebased     bool      `json:"rebased,omitempty"`
+	BackupPath  string    `json:"backup,omitempty"`
+	PullRequest string    `json:"pull_request,omitempty"` // Push events: pull request opened for review
+	Message     string    `json:"message,omitempty"`      // Error events
+}
+
+// EventLogger is a Logger that also takes Events. The engine only sends
+// events to loggers implementing it.
+type EventLogger interface {
+	Logger
+	Event(ev Event)
+}
+
+// emit sends ev to the logger if it takes events
+func (e *Engine) emit(ev Event) {
+	if l, ok := e.log.(EventLogger); ok {
+		ev.Time = time.Now().UTC()
+		l.Event(ev)
+	}
+}
+
+// emitFiles sends a file event for each changed file, typed by its action
+func (e *Engine) emitFiles(files []FileAction, changed []string) {
+	actions := make(map[string]Action, len(files))
+	for _, f := range files {
+		actions[f.Path] = f.Action
+	}
+	for _, path := range changed {
+		var t EventType
+		switch actions[path] {
+		case ActionEncrypt:
+			t = EventFileEncrypted
+		case ActionDecrypt:
+			t = EventFileDecrypted
+		case ActionCopy:
+			t = EventFileCopied
+		default:
+			continue
+		}
+		e.emit(Event{Type: t, Path: path})
+	}
+}
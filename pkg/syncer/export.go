@@ -0,0 +1,162 @@
+package syncer
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// archiveKeyEntry is the zip entry holding the private key in an export
+const archiveKeyEntry = "identity.key"
+
+// ExportOptions controls Export
+type ExportOptions struct {
+	All        bool // Include files matching exclude patterns
+	IncludeKey bool // Add the private key so the new machine can join the repo
+}
+
+// ExportResult describes an export
+type ExportResult struct {
+	Files      int
+	IncludeKey bool
+}
+
+// Export writes a zip of ~/.claude and ~/.claude.json to w, laid out like
+// a backup, for moving a setup to another machine without a repo. The
+// caller encrypts w; the zip itself is plaintext.
+func (e *Engine) Export(w io.Writer, opts ExportOptions) (*ExportResult, error) {
+	paths := e.paths
+	if !sync.FileExists(paths.ClaudeDir) {
+		return nil, fmt.Errorf("no ~/.claude directory found. Nothing to export")
+	}
+
+	files, err := sync.WalkFiles(paths.ClaudeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExportResult{}
+	z := zip.NewWriter(w)
+	base := filepath.Base(paths.ClaudeDir)
+	for _, file := range files {
+		relPath := sync.RelPath(paths.ClaudeDir, file)
+		if file == paths.ClaudeJSON || (!opts.All && e.cfg.ShouldExclude(relPath)) {
+			continue
+		}
+		if err := sync.AddZipFile(z, base+"/"+filepath.ToSlash(relPath), file); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", relPath, err)
+		}
+		result.Files++
+	}
+
+	if sync.FileExists(paths.ClaudeJSON) {
+		if err := sync.AddZipFile(z, sync.ClaudeJSONEntry, paths.ClaudeJSON); err != nil {
+			return nil, fmt.Errorf("failed to add claude.json: %w", err)
+		}
+		result.Files++
+	}
+
+	if opts.IncludeKey {
+		if err := e.addKey(z); err != nil {
+			return nil, fmt.Errorf("failed to add key: %w", err)
+		}
+		result.IncludeKey = true
+	}
+
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportResult describes an import
+type ImportResult struct {
+	Restored    []string
+	BackupPath  string // Backup of ~/.claude taken before importing, if any
+	KeyImported bool   // The archive's key was written to the key file
+	KeySkipped  bool   // The archive has a key, but a different one is already installed
+}
+
+// Import restores an archive written by Export into ~/.claude, backing up
+// the current files first. A key in the archive is installed if this
+// machine has none; an existing key is never replaced.
+func (e *Engine) Import(r *zip.Reader) (*ImportResult, error) {
+	paths := e.paths
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+
+	result := &ImportResult{}
+	if sync.FileExists(paths.ClaudeDir) {
+		if result.BackupPath, err = e.backupCurrent(); err != nil {
+			return nil, fmt.Errorf("backup failed: %w", err)
+		}
+	}
+
+	if result.Restored, err = sync.RestoreZip(r, paths.ClaudeDir, paths.ClaudeJSON, ""); err != nil {
+		return nil, err
+	}
+
+	for _, f := range r.File {
+		if f.Name != archiveKeyEntry {
+			continue
+		}
+		key, err := readZipEntry(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+		if err := crypto.ValidateKeyContent(string(key)); err != nil {
+			return nil, fmt.Errorf("invalid key in archive: %w", err)
+		}
+		if keys.Available(paths, e.cfg.KeyCommand) {
+			current, _ := keys.PublicKey(paths, e.cfg.KeyCommand)
+			imported, _ := crypto.GetPublicKeyFromContent(string(key))
+			result.KeySkipped = current != imported
+			break
+		}
+		if err := sync.EnsureDir(paths.SyncDir); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(paths.KeyFile, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write key: %w", err)
+		}
+		result.KeyImported = true
+	}
+	return result, nil
+}
+
+// addKey adds this machine's private key to an export, copying
+// identity.key or writing one for a key from the environment or key_command
+func (e *Engine) addKey(z *zip.Writer) error {
+	if keys.Source(e.paths, e.cfg.KeyCommand) == keys.SourceFile {
+		return sync.AddZipFile(z, archiveKeyEntry, e.paths.KeyFile)
+	}
+	identity, err := keys.Load(e.paths, e.cfg.KeyCommand)
+	if err != nil {
+		return err
+	}
+	w, err := z.Create(archiveKeyEntry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "# public key: %s\n%s\n", identity.Recipient(), identity)
+	return err
+}
+
+// readZipEntry returns the contents of one zip entry
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
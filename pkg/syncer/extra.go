@@ -0,0 +1,134 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// extraDir is the repo folder holding extra_paths entries, one folder per entry
+const extraDir = "extra-paths"
+
+// extraFile is one local file belonging to an extra_paths entry
+type extraFile struct {
+	relPath string // Path in the repo, e.g. extra-paths/codex/config.toml
+	local   string
+	encrypt bool
+}
+
+// extraFiles lists the local files of all extra_paths entries. Missing
+// entries are reported and skipped.
+func (e *Engine) extraFiles() ([]extraFile, error) {
+	var files []extraFile
+	for _, x := range e.cfg.ExtraPaths {
+		local := x.LocalPath(e.paths.ClaudeDir)
+		info, err := os.Stat(local)
+		if err != nil {
+			if os.IsNotExist(err) {
+				e.log.Info(fmt.Sprintf("Skipping extra path %s: not found", local))
+				continue
+			}
+			return nil, err
+		}
+
+		base := extraDir + "/" + x.RepoName()
+		if !info.IsDir() {
+			files = append(files, e.extraFile(x, base, local))
+			continue
+		}
+
+		walked, err := sync.WalkFiles(local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", local, err)
+		}
+		for _, f := range walked {
+			rel := sync.RelPath(local, f)
+			if e.cfg.ShouldExclude(rel) {
+				continue
+			}
+			files = append(files, e.extraFile(x, base+"/"+rel, f))
+		}
+	}
+	return files, nil
+}
+
+func (e *Engine) extraFile(x config.ExtraPath, relPath, local string) extraFile {
+	return extraFile{
+		relPath: relPath,
+		local:   local,
+		encrypt: x.Encrypt || e.cfg.ShouldEncrypt(filepath.Base(local)),
+	}
+}
+
+// pushExtraPaths copies or encrypts the extra_paths files into the repo
+func (e *Engine) pushExtraPaths(files []extraFile, pubKey string, opts PushOptions, result *PushResult) error {
+	var tasks []func() error
+	for _, f := range files {
+		dest := filepath.Join(e.paths.RepoDir, filepath.FromSlash(f.relPath))
+		if f.encrypt {
+			if opts.DryRun {
+				e.log.Info(fmt.Sprintf("  [encrypt] %s", f.local))
+			} else if sum, unchanged := e.pushedUnchanged(f.local, f.relPath, f.relPath+".age", result); !unchanged {
+				e.log.Info(fmt.Sprintf("Encrypting: %s", f.relPath))
+				tasks = append(tasks, func() error {
+					if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+						return err
+					}
+					if err := crypto.EncryptFileTo(e.recipients(pubKey, f.relPath), f.local, dest+".age"); err != nil {
+						return fmt.Errorf("failed to encrypt %s: %w", f.local, err)
+					}
+					return nil
+				})
+				result.wrote(f.relPath+".age", sum)
+				result.Changed = append(result.Changed, f.relPath)
+			}
+			result.recordMode(f.relPath+".age", f.local)
+			result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionEncrypt})
+			continue
+		}
+
+		if opts.DryRun {
+			e.log.Info(fmt.Sprintf("  [copy] %s", f.local))
+		} else if sum, unchanged := e.pushedUnchanged(f.local, f.relPath, f.relPath, result); !unchanged {
+			e.log.Info(fmt.Sprintf("Copying: %s", f.relPath))
+			tasks = append(tasks, func() error {
+				if err := sync.CopyFile(f.local, dest); err != nil {
+					return fmt.Errorf("failed to copy %s: %w", f.local, err)
+				}
+				return nil
+			})
+			result.wrote(f.relPath, sum)
+			result.Changed = append(result.Changed, f.relPath)
+		}
+		result.recordMode(f.relPath, f.local)
+		result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionCopy})
+	}
+	return runJobs(opts.Jobs, tasks)
+}
+
+// extraDest maps a repo path under extra-paths/ to its location on this
+// machine. ok is false for entries that are not configured here.
+func (e *Engine) extraDest(relPath string) (dest string, ok bool) {
+	rest := strings.TrimPrefix(relPath, extraDir+"/")
+	for _, x := range e.cfg.ExtraPaths {
+		name := x.RepoName()
+		local := x.LocalPath(e.paths.ClaudeDir)
+		if rest == name {
+			return local, true
+		}
+		if strings.HasPrefix(rest, name+"/") {
+			return filepath.Join(local, filepath.FromSlash(strings.TrimPrefix(rest, name+"/"))), true
+		}
+	}
+	return "", false
+}
+
+// isExtraPath reports whether a repo path belongs to extra_paths
+func isExtraPath(relPath string) bool {
+	return strings.HasPrefix(filepath.ToSlash(relPath), extraDir+"/")
+}
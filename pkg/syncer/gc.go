@@ -0,0 +1,177 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+)
+
+// RepoSize describes the disk usage of the local sync repo
+type RepoSize struct {
+	GitBytes  int64 // .git: every version of every file
+	FileBytes int64 // The checked-out files
+	Commits   int
+	Oldest    time.Time // First commit
+}
+
+// GCOptions controls GC
+type GCOptions struct {
+	Before time.Time // Squash commits made before this; zero skips the squash
+}
+
+// GCResult describes a gc run
+type GCResult struct {
+	Squashed   int // Commits replaced by the snapshot commit
+	Pushed     bool
+	SizeBefore *RepoSize
+	SizeAfter  *RepoSize
+}
+
+// RepoSize reports how much disk the local sync repo uses
+func (e *Engine) RepoSize() (*RepoSize, error) {
+	repo, err := e.gitRepo("repo size")
+	if err != nil {
+		return nil, err
+	}
+	return e.repoSize(repo)
+}
+
+func (e *Engine) repoSize(repo gitpkg.Repo) (*RepoSize, error) {
+	size := &RepoSize{}
+	gitDir := filepath.Join(e.paths.RepoDir, ".git")
+	err := filepath.Walk(e.paths.RepoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if path == gitDir || strings.HasPrefix(path, gitDir+string(os.PathSeparator)) {
+			size.GitBytes += info.Size()
+		} else {
+			size.FileBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := repo.Log(0)
+	if err != nil {
+		return size, nil // No commits yet
+	}
+	size.Commits = len(commits)
+	if len(commits) > 0 {
+		size.Oldest = commits[len(commits)-1].Time
+	}
+	return size, nil
+}
+
+// SquashCount returns how many commits GC would squash for the given cutoff
+func (e *Engine) SquashCount(before time.Time) (int, error) {
+	repo, err := e.gitRepo("gc")
+	if err != nil {
+		return 0, err
+	}
+	commits, err := repo.Log(0)
+	if err != nil {
+		return 0, err
+	}
+
+	// Follow first parents from HEAD, like Squash does
+	byHash := make(map[string]gitpkg.Commit, len(commits))
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+	n := 0
+	hash := ""
+	if len(commits) > 0 {
+		hash = commits[0].Hash
+	}
+	for c, ok := byHash[hash]; ok; c, ok = byHash[c.Parent] {
+		if n > 0 || c.Time.Before(before) {
+			n++
+		}
+	}
+	if n < 2 {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// GC shrinks the sync repo. Commits older than opts.Before are squashed into
+// a single snapshot commit and the rewritten branch is force-pushed; other
+// machines follow the rewrite on their next pull. Unreachable objects are
+// then pruned and the repo repacked.
+func (e *Engine) GC(opts GCOptions) (*GCResult, error) {
+	if e.cfg.GitBranches == config.BranchesPerMachine && !opts.Before.IsZero() {
+		return nil, fmt.Errorf("squashing history is not supported with git_branches: per-machine")
+	}
+	if !opts.Before.IsZero() {
+		if err := e.cfg.CanPush(); err != nil {
+			return nil, err
+		}
+	}
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+
+	repo, err := e.gitRepo("gc")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GCResult{}
+	if result.SizeBefore, err = e.repoSize(repo); err != nil {
+		return nil, err
+	}
+
+	if !opts.Before.IsZero() {
+		if err := e.squash(repo, opts.Before, result); err != nil {
+			return nil, err
+		}
+	}
+
+	e.log.Info("Pruning and repacking...")
+	if err := repo.GC(); err != nil {
+		return nil, fmt.Errorf("git gc failed: %w", err)
+	}
+	if result.SizeAfter, err = e.repoSize(repo); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// squash rewrites the history before the cutoff and force-pushes it
+func (e *Engine) squash(repo gitpkg.Repo, before time.Time, result *GCResult) error {
+	hasRemote := repo.HasRemote()
+	if hasRemote {
+		e.log.Info("Fetching from remote...")
+		repo.Fetch()
+		if _, behind, err := repo.AheadBehind(); err == nil && behind > 0 {
+			return fmt.Errorf("the remote has %d commit(s) this machine hasn't pulled. Run 'claude-code-sync pull' first", behind)
+		}
+	}
+
+	message := fmt.Sprintf("Squash history before %s %s", before.Format("2006-01-02"), gitpkg.SquashMarker)
+	n, err := repo.Squash(before, message)
+	if err != nil {
+		return fmt.Errorf("squash failed: %w", err)
+	}
+	result.Squashed = n
+	if n == 0 || !hasRemote {
+		return nil
+	}
+
+	e.log.Info("Force-pushing rewritten history...")
+	if err := repo.ForcePush(); err != nil {
+		return fmt.Errorf("squashed %d commit(s) locally, but the force push failed: %w\nFix the problem, then run: git -C %q push --force-with-lease origin HEAD", n, err, e.paths.RepoDir)
+	}
+	result.Pushed = true
+	return nil
+}
@@ -0,0 +1,124 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Get restores selected files from the local repo into ~/.claude without
+// pulling from the remote or touching other files. Each path is relative to
+// ~/.claude and may name a directory, which restores everything under it.
+// Local copies that differ are saved to backups/files first.
+func (e *Engine) Get(files []string) (*PullResult, error) {
+	if err := e.cfg.CanPull(); err != nil {
+		return nil, err
+	}
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+
+	e.openIndex()
+	defer e.saveIndex()
+
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := e.selectRepoFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PullResult{}
+	if err := e.applyFiles(selected, nil, PullOptions{}, StrategyTheirs, identity, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadRepoFile returns the decrypted contents of a synced file from the
+// local repo, as of the last push or pull. file is relative to ~/.claude;
+// "claude.json" refers to ~/.claude.json. Plaintext is only held in memory.
+func (e *Engine) ReadRepoFile(file string) (*ShownFile, error) {
+	selected, err := e.selectRepoFiles([]string{file})
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) != 1 || filepath.ToSlash(selected[0].relPath) != cleanSyncPath(file) {
+		return nil, fmt.Errorf("%s is a directory in the repo", cleanSyncPath(file))
+	}
+	f := selected[0]
+
+	data, err := os.ReadFile(f.src)
+	if err != nil {
+		return nil, err
+	}
+	shown := &ShownFile{Path: f.relPath, Encrypted: f.encrypted, Content: data}
+	if !f.encrypted {
+		return shown, nil
+	}
+
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	shown.Content, err = crypto.Decrypt(identity, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+	}
+	return shown, nil
+}
+
+// selectRepoFiles returns the restorable repo files matching each path
+// exactly or as a directory prefix. It fails if a path matches nothing.
+func (e *Engine) selectRepoFiles(files []string) ([]repoFile, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+	}
+	all, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []repoFile
+	seen := map[string]bool{}
+	for _, file := range files {
+		want := cleanSyncPath(file)
+		found := false
+		for _, f := range all {
+			rel := filepath.ToSlash(f.relPath)
+			if rel != want && want != "." && !strings.HasPrefix(rel, want+"/") {
+				continue
+			}
+			found = true
+			if !seen[f.src] {
+				seen[f.src] = true
+				selected = append(selected, f)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not in the repo", want)
+		}
+	}
+	return selected, nil
+}
+
+// cleanSyncPath normalizes a user-supplied path to the slash-separated form
+// used for repo files, without .age
+func cleanSyncPath(file string) string {
+	file = strings.TrimSuffix(path.Clean(strings.ReplaceAll(file, `\`, "/")), ".age")
+	file = strings.TrimPrefix(file, "./")
+	if file == ".claude.json" {
+		file = "claude.json"
+	}
+	return file
+}
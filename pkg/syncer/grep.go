@@ -0,0 +1,59 @@
+package syncer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+// GrepMatch is one line of a synced file that matched a Grep pattern
+type GrepMatch struct {
+	Path      string // Path relative to ~/.claude (without .age)
+	Line      int    // 1-based line number
+	Text      string
+	Encrypted bool
+}
+
+// Grep searches every synced file in the local repo for pattern, decrypting
+// .age files in memory. Binary files are skipped.
+func (e *Engine) Grep(pattern *regexp.Regexp) ([]GrepMatch, error) {
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].relPath < files[j].relPath })
+
+	var matches []GrepMatch
+	for _, f := range files {
+		content, err := os.ReadFile(f.src)
+		if err != nil {
+			return nil, err
+		}
+		if f.encrypted {
+			if content, err = crypto.Decrypt(identity, content); err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+			}
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(nil, len(content)+1)
+		for n := 1; scanner.Scan(); n++ {
+			if line := scanner.Text(); pattern.MatchString(line) {
+				matches = append(matches, GrepMatch{Path: f.relPath, Line: n, Text: line, Encrypted: f.encrypted})
+			}
+		}
+	}
+	return matches, nil
+}
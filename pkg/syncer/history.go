@@ -0,0 +1,110 @@
+package syncer
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+)
+
+// machineTrailer prefixes the machine name in sync commit messages
+const machineTrailer = "Machine:"
+
+// HistoryEntry is one sync commit
+type HistoryEntry struct {
+	Commit  string
+	Time    time.Time
+	Machine string // Hostname that pushed, or the commit author for older syncs
+	Message string // First line of the commit message
+	Files   []HistoryFile
+}
+
+// HistoryFile is a file changed by a sync commit
+type HistoryFile struct {
+	Path      string // Path relative to ~/.claude (without .age)
+	Status    string // "A" (added), "M" (modified) or "D" (deleted)
+	Encrypted bool
+}
+
+// History lists up to limit sync commits (0 = all), newest first. Encrypted
+// files are re-encrypted on every push, so modified .age files are decrypted
+// and only reported when their contents actually changed.
+func (e *Engine) History(limit int) ([]HistoryEntry, error) {
+	repo, err := e.gitRepo("history")
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := repo.Log(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a key, fall back to reporting every re-encrypted file
+	identity, _ := e.loadIdentity()
+
+	entries := make([]HistoryEntry, 0, len(commits))
+	for _, c := range commits {
+		entry := HistoryEntry{
+			Commit:  c.Hash,
+			Time:    c.Time,
+			Machine: commitMachine(c),
+			Message: strings.SplitN(c.Message, "\n", 2)[0],
+		}
+
+		for _, f := range c.Files {
+			if f.Path == ".sync-manifest" || f.Path == "README.md" || f.Path == RecipientsFile || strings.HasPrefix(f.Path, ".git") || isMachineRecord(f.Path) {
+				continue
+			}
+			encrypted := strings.HasSuffix(f.Path, ".age")
+			if encrypted && f.Status == "M" && identity != nil && c.Parent != "" {
+				if same, err := sameEncrypted(repo, identity, c.Parent, c.Hash, f.Path); err == nil && same {
+					continue
+				}
+			}
+			entry.Files = append(entry.Files, HistoryFile{
+				Path:      strings.TrimSuffix(f.Path, ".age"),
+				Status:    f.Status,
+				Encrypted: encrypted,
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// commitMachine extracts the machine name from a sync commit
+func commitMachine(c gitpkg.Commit) string {
+	for _, line := range strings.Split(c.Message, "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), machineTrailer); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return c.Author
+}
+
+// sameEncrypted reports whether an encrypted file decrypts to the same
+// contents at two revisions
+func sameEncrypted(repo gitpkg.Repo, identity *age.X25519Identity, oldRev, newRev, path string) (bool, error) {
+	oldData, err := repo.ShowFile(oldRev, path)
+	if err != nil {
+		return false, err
+	}
+	newData, err := repo.ShowFile(newRev, path)
+	if err != nil {
+		return false, err
+	}
+	oldPlain, err := crypto.Decrypt(identity, oldData)
+	if err != nil {
+		return false, err
+	}
+	newPlain, err := crypto.Decrypt(identity, newData)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(oldPlain, newPlain), nil
+}
@@ -0,0 +1,65 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+)
+
+// Hook points configured under hooks: in config.yaml
+const (
+	HookPrePush  = "pre_push"
+	HookPostPush = "post_push"
+	HookPrePull  = "pre_pull"
+	HookPostPull = "post_pull"
+)
+
+// runHook runs a user hook command through the shell with CLAUDE_SYNC_*
+// variables describing the operation. files are the changed files, for
+// post hooks.
+func (e *Engine) runHook(name, command string, files []string, env map[string]string) error {
+	if command == "" {
+		return nil
+	}
+	e.log.Info(fmt.Sprintf("Running %s hook...", name))
+
+	c := shellCommand(command)
+	c.Dir = e.paths.ClaudeDir
+	c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	c.Env = append(os.Environ(),
+		"CLAUDE_SYNC_HOOK="+name,
+		"CLAUDE_SYNC_CLAUDE_DIR="+e.paths.ClaudeDir,
+		"CLAUDE_SYNC_REPO_DIR="+e.paths.RepoDir,
+		config.ProfileEnv+"="+config.Profile(),
+		"CLAUDE_SYNC_FILES="+strings.Join(files, "\n"),
+		"CLAUDE_SYNC_FILE_COUNT="+strconv.Itoa(len(files)),
+	)
+	for k, v := range env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// runPostHook runs a post hook, reporting failures instead of returning them
+func (e *Engine) runPostHook(name, command string, files []string, env map[string]string) {
+	if err := e.runHook(name, command, files, env); err != nil {
+		e.log.Warn(err.Error())
+	}
+}
+
+// shellCommand runs command with the platform's shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
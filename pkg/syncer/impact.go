@@ -0,0 +1,315 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+// Impact summarizes what a pull would change, in Claude Code's terms
+// rather than file paths
+type Impact struct {
+	Changes    []ImpactChange
+	Settings   int      // settings.json keys added, removed or changed
+	ClaudeJSON int      // claude.json keys changed, besides MCP servers
+	Other      []string // Changed files that are none of the above
+}
+
+// ImpactChange counts the items of one kind a pull would add, modify or remove
+type ImpactChange struct {
+	Kind   string // e.g. "slash command", "agent", "MCP server"
+	Change string // "added", "modified" or "removed"
+	Count  int
+}
+
+// Kinds of item, in the order the summary lists them
+var impactKinds = []string{"slash command", "agent", "skill", "output style", "hook script", "MCP server"}
+
+var impactChanges = []string{"added", "modified", "removed"}
+
+// Summary describes the impact in one line, e.g. "3 slash commands added,
+// 1 agent modified, settings.json: 4 keys changed"
+func (i *Impact) Summary() string {
+	var parts []string
+	for _, c := range i.Changes {
+		parts = append(parts, fmt.Sprintf("%s %s", plural(c.Count, c.Kind), c.Change))
+	}
+	if i.Settings > 0 {
+		parts = append(parts, fmt.Sprintf("settings.json: %s changed", plural(i.Settings, "key")))
+	}
+	if i.ClaudeJSON > 0 {
+		parts = append(parts, fmt.Sprintf("claude.json: %s changed", plural(i.ClaudeJSON, "key")))
+	}
+	switch {
+	case len(i.Other) == 0:
+	case len(i.Other) <= 2:
+		parts = append(parts, strings.Join(i.Other, ", ")+" changed")
+	default:
+		parts = append(parts, fmt.Sprintf("%s changed", plural(len(i.Other), "other file")))
+	}
+	if len(parts) == 0 {
+		return "nothing would change"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// impactCounter tallies items by kind and change, counting each item once
+type impactCounter struct {
+	seen   map[string]bool
+	counts map[[2]string]int
+}
+
+func (c *impactCounter) add(kind, change, item string) {
+	key := kind + "\x00" + item
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.counts[[2]string{kind, change}]++
+}
+
+func (c *impactCounter) changes() []ImpactChange {
+	var out []ImpactChange
+	for _, kind := range impactKinds {
+		for _, change := range impactChanges {
+			if n := c.counts[[2]string{kind, change}]; n > 0 {
+				out = append(out, ImpactChange{Kind: kind, Change: change, Count: n})
+			}
+		}
+	}
+	return out
+}
+
+// impact works out what pulling files and the subscription plan would
+// change locally. Encrypted files are decrypted in memory.
+func (e *Engine) impact(files []repoFile, subs *subscriptionPlan, strategy Strategy, identity *age.X25519Identity) (*Impact, error) {
+	impact := &Impact{}
+	counter := &impactCounter{seen: map[string]bool{}, counts: map[[2]string]int{}}
+
+	for _, f := range files {
+		local, err := os.ReadFile(f.dest)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		exists := err == nil
+		if exists && strategy == StrategyOurs {
+			continue
+		}
+		incoming, err := e.pendingContent(identity, f)
+		if err != nil {
+			return nil, err
+		}
+		if exists && (bytes.Equal(incoming, local) || (e.rewrites(f) && (jsonEqual(incoming, local) || rendersTo(local, incoming)))) {
+			continue
+		}
+
+		switch {
+		case f.dest == e.paths.ClaudeJSON:
+			impact.ClaudeJSON += claudeJSONImpact(local, incoming, counter)
+		case f.relPath == "settings.json":
+			impact.Settings += len(changedKeys(local, incoming))
+		default:
+			impact.classify(counter, f.relPath, exists, e.localDirExists)
+		}
+	}
+
+	if subs != nil {
+		for _, c := range subs.installs {
+			local, err := os.ReadFile(c.dest)
+			if err == nil {
+				if incoming, err := os.ReadFile(c.src); err == nil && bytes.Equal(incoming, local) {
+					continue
+				}
+			}
+			impact.classify(counter, c.relPath, err == nil, e.localDirExists)
+		}
+		for _, c := range subs.removals {
+			if kind, item := impactItem(c.relPath); kind != "" {
+				counter.add(kind, "removed", item)
+			} else {
+				impact.Other = append(impact.Other, c.relPath)
+			}
+		}
+	}
+
+	impact.Changes = counter.changes()
+	return impact, nil
+}
+
+// pendingContent returns what a pull would write for f
+func (e *Engine) pendingContent(identity *age.X25519Identity, f repoFile) ([]byte, error) {
+	if e.rewrites(f) {
+		return e.incomingContent(identity, f)
+	}
+	content, err := os.ReadFile(f.src)
+	if err != nil || !f.encrypted {
+		return content, err
+	}
+	if content, err = crypto.Decrypt(identity, content); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+	}
+	return content, nil
+}
+
+func (e *Engine) localDirExists(relPath string) bool {
+	info, err := os.Stat(filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(relPath)))
+	return err == nil && info.IsDir()
+}
+
+// classify counts a changed file as the item it belongs to. A skill counts
+// as added only when its whole directory is new.
+func (i *Impact) classify(counter *impactCounter, relPath string, exists bool, dirExists func(string) bool) {
+	kind, item := impactItem(relPath)
+	if kind == "" {
+		i.Other = append(i.Other, relPath)
+		return
+	}
+	if kind == "skill" {
+		exists = dirExists(item)
+	}
+	if exists {
+		counter.add(kind, "modified", item)
+	} else {
+		counter.add(kind, "added", item)
+	}
+}
+
+// impactItem returns the kind of item relPath belongs to and the path that
+// identifies the item, or "" if it isn't one Claude Code knows by name
+func impactItem(relPath string) (kind, item string) {
+	p := path.Clean(strings.ReplaceAll(relPath, "\\", "/"))
+	top, rest, _ := strings.Cut(p, "/")
+	if rest == "" {
+		return "", ""
+	}
+	md := strings.HasSuffix(p, ".md")
+	switch {
+	case top == "commands" && md:
+		return "slash command", p
+	case top == "agents" && md:
+		return "agent", p
+	case top == "output-styles" && md:
+		return "output style", p
+	case top == "hooks":
+		return "hook script", p
+	case top == "skills":
+		name, _, _ := strings.Cut(rest, "/")
+		return "skill", top + "/" + name
+	}
+	return "", ""
+}
+
+// claudeJSONImpact counts MCP servers added, modified or removed between
+// two versions of claude.json, both global and per project, and returns how
+// many other keys changed
+func claudeJSONImpact(local, incoming []byte, counter *impactCounter) int {
+	var before, after map[string]any
+	json.Unmarshal(local, &before)
+	json.Unmarshal(incoming, &after)
+
+	scopes := map[string]bool{"": true}
+	for _, doc := range []map[string]any{before, after} {
+		projects, _ := doc["projects"].(map[string]any)
+		for p := range projects {
+			scopes[p] = true
+		}
+	}
+	for scope := range scopes {
+		old := takeMCPServers(before, scope)
+		cur := takeMCPServers(after, scope)
+		for name, cfg := range cur {
+			prev, ok := old[name]
+			switch {
+			case !ok:
+				counter.add("MCP server", "added", scope+"\x00"+name)
+			case !reflect.DeepEqual(prev, cfg):
+				counter.add("MCP server", "modified", scope+"\x00"+name)
+			}
+		}
+		for name := range old {
+			if _, ok := cur[name]; !ok {
+				counter.add("MCP server", "removed", scope+"\x00"+name)
+			}
+		}
+	}
+
+	return len(changedKeys(marshalOrNil(before), marshalOrNil(after)))
+}
+
+// takeMCPServers removes and returns the mcpServers of a project, or of
+// the top level for scope ""
+func takeMCPServers(doc map[string]any, scope string) map[string]any {
+	parent := doc
+	if scope != "" {
+		projects, _ := doc["projects"].(map[string]any)
+		parent, _ = projects[scope].(map[string]any)
+	}
+	servers, _ := parent["mcpServers"].(map[string]any)
+	delete(parent, "mcpServers")
+	return servers
+}
+
+func marshalOrNil(doc map[string]any) []byte {
+	if doc == nil {
+		return nil
+	}
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+// changedKeys returns the dotted paths of JSON keys that were added,
+// removed or changed between two documents. Objects are compared key by
+// key; arrays and other values as a whole.
+func changedKeys(before, after []byte) []string {
+	var a, b any
+	json.Unmarshal(before, &a)
+	json.Unmarshal(after, &b)
+	flatA, flatB := map[string]any{}, map[string]any{}
+	flattenJSON(a, "", flatA)
+	flattenJSON(b, "", flatB)
+
+	var keys []string
+	for k, v := range flatB {
+		if old, ok := flatA[k]; !ok || !reflect.DeepEqual(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range flatA {
+		if _, ok := flatB[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func flattenJSON(v any, prefix string, out map[string]any) {
+	obj, ok := v.(map[string]any)
+	if !ok || len(obj) == 0 {
+		if prefix != "" {
+			out[prefix] = v
+		}
+		return
+	}
+	for k, child := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		flattenJSON(child, key, out)
+	}
+}
@@ -0,0 +1,262 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// ImportFromOptions controls ImportFrom
+type ImportFromOptions struct {
+	From    string // Tool whose files to import, e.g. "claude-brain"
+	Path    string // Its repo or directory
+	DryRun  bool   // Only report what would be copied
+	Pointer bool   // Afterwards, stop the tool managing the files and leave a note saying where they went
+}
+
+// ImportFromResult describes an import from another tool
+type ImportFromResult struct {
+	Files       []string // Copied into ~/.claude, relative to it
+	Unchanged   []string // Already the same in ~/.claude
+	Encrypted   []string // Of Files and Unchanged, the ones push will encrypt
+	Skipped     []string // Match exclude patterns, so not copied
+	Unsupported []string // Found but not importable, with the reason
+	BackupPath  string   // Backup of ~/.claude taken before importing, if any
+	Pointer     string   // What was left behind in the source, if Pointer was set
+}
+
+// importClaudeJSON is the key import sources use for ~/.claude.json
+const importClaudeJSON = "~/.claude.json"
+
+// importSource is another tool import --from can read
+type importSource struct {
+	// find lists the files in dir that belong in ~/.claude, keyed by
+	// slash-separated path relative to it (importClaudeJSON for
+	// ~/.claude.json), and the files it can't import with the reason.
+	// scratch is an empty temp dir for files that must be extracted first.
+	find func(e *Engine, dir, scratch string) (map[string]string, []string, error)
+
+	// pointer stops the tool managing the imported files and returns what
+	// it left behind; nil if the tool's files can't be handed over
+	pointer func(e *Engine, dir string, imported map[string]string) (string, error)
+}
+
+var importSources = map[string]importSource{
+	SourceClaudeBrain: {find: func(_ *Engine, dir, _ string) (map[string]string, []string, error) {
+		found, err := brainFiles(dir)
+		return found, nil, err
+	}},
+	SourceChezmoi: {find: (*Engine).chezmoiFiles, pointer: (*Engine).chezmoiPointer},
+	SourceStow:    {find: (*Engine).stowFiles, pointer: (*Engine).stowPointer},
+	SourceBareGit: {find: (*Engine).bareGitFiles, pointer: (*Engine).bareGitPointer},
+}
+
+// ImportSources returns the names ImportFrom accepts
+func ImportSources() []string {
+	var names []string
+	for name := range importSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImportFrom copies the Claude Code files kept by another tool into
+// ~/.claude, backing up the current files first. Files already in ~/.claude
+// but not in the source are left alone. Push afterwards to sync them, with
+// encrypt patterns deciding which are encrypted.
+func (e *Engine) ImportFrom(opts ImportFromOptions) (*ImportFromResult, error) {
+	source, ok := importSources[opts.From]
+	if !ok {
+		return nil, fmt.Errorf("unknown import source %q (use %s)", opts.From, strings.Join(ImportSources(), ", "))
+	}
+	if opts.Pointer && source.pointer == nil {
+		return nil, fmt.Errorf("leaving a pointer isn't supported for %s", opts.From)
+	}
+	if info, err := os.Stat(opts.Path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", opts.Path)
+	}
+	scratch, err := os.MkdirTemp("", "claude-code-sync-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	found, unsupported, err := source.find(e, opts.Path, scratch)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportFromResult{Unsupported: unsupported}
+	for _, u := range unsupported {
+		e.log.Warn(fmt.Sprintf("Not importing %s", u))
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no Claude Code files found in %s", opts.Path)
+	}
+
+	relPaths := sortedKeys(found)
+
+	var copies []string
+	imported := map[string]string{}
+	for _, relPath := range relPaths {
+		if relPath != importClaudeJSON && e.cfg.ShouldExclude(relPath) {
+			result.Skipped = append(result.Skipped, relPath)
+			continue
+		}
+		imported[relPath] = found[relPath]
+		if relPath == importClaudeJSON || e.cfg.ShouldEncrypt(relPath) {
+			result.Encrypted = append(result.Encrypted, relPath)
+		}
+		if sameFile(found[relPath], e.importDest(relPath)) {
+			result.Unchanged = append(result.Unchanged, relPath)
+		} else {
+			copies = append(copies, relPath)
+		}
+	}
+	if opts.DryRun {
+		for _, relPath := range copies {
+			e.log.Info(fmt.Sprintf("  [import] %s%s", relPath, encryptedNote(result.Encrypted, relPath)))
+		}
+		result.Files = copies
+		return result, nil
+	}
+
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+	if len(copies) > 0 && sync.FileExists(e.paths.ClaudeDir) {
+		if result.BackupPath, err = e.backupCurrent(); err != nil {
+			return nil, fmt.Errorf("backup failed: %w", err)
+		}
+	}
+	for _, relPath := range copies {
+		if err := sync.CopyFile(found[relPath], e.importDest(relPath)); err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", relPath, err)
+		}
+		e.log.Info(fmt.Sprintf("Imported: %s%s", relPath, encryptedNote(result.Encrypted, relPath)))
+		result.Files = append(result.Files, relPath)
+	}
+
+	if opts.Pointer {
+		// Files linked into the source (stow) must become real files before
+		// the source stops holding them
+		for relPath := range imported {
+			if err := e.detachLinks(relPath, opts.Path); err != nil {
+				return nil, fmt.Errorf("failed to copy linked %s: %w", relPath, err)
+			}
+		}
+		if result.Pointer, err = source.pointer(e, opts.Path, imported); err != nil {
+			return nil, fmt.Errorf("imported, but failed to hand over the files: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// importDest returns where an imported file goes on this machine
+func (e *Engine) importDest(relPath string) string {
+	if relPath == importClaudeJSON {
+		return e.paths.ClaudeJSON
+	}
+	return filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(relPath))
+}
+
+func encryptedNote(encrypted []string, relPath string) string {
+	for _, p := range encrypted {
+		if p == relPath {
+			return " (encrypted on push)"
+		}
+	}
+	return ""
+}
+
+// detachLinks replaces any symlink on the way to an imported file that
+// points into the source dir with a copy of what it points to
+func (e *Engine) detachLinks(relPath, sourceDir string) error {
+	source, err := filepath.EvalSymlinks(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	var steps []string
+	if relPath == importClaudeJSON {
+		steps = []string{e.paths.ClaudeJSON}
+	} else {
+		path := e.paths.ClaudeDir
+		steps = []string{path}
+		for _, part := range strings.Split(relPath, "/") {
+			path = filepath.Join(path, part)
+			steps = append(steps, path)
+		}
+	}
+
+	for _, path := range steps {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(source, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if err := replaceLink(path, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceLink swaps the symlink at path for a copy of target, a file or a
+// directory tree
+func replaceLink(path, target string) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".import-tmp"
+	os.RemoveAll(tmp)
+	if info.IsDir() {
+		files, err := sync.WalkFiles(target)
+		if err != nil {
+			return err
+		}
+		if err := sync.EnsureDir(tmp); err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := sync.CopyFile(file, filepath.Join(tmp, sync.RelPath(target, file))); err != nil {
+				os.RemoveAll(tmp)
+				return err
+			}
+		}
+	} else if err := sync.CopyFile(target, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// sameFile reports whether two files have the same content
+func sameFile(a, b string) bool {
+	sumA, err := sync.FileChecksum(a)
+	if err != nil {
+		return false
+	}
+	sumB, err := sync.FileChecksum(b)
+	return err == nil && sumA == sumB
+}
@@ -0,0 +1,47 @@
+package syncer
+
+import (
+	"runtime"
+	gosync "sync"
+	"sync/atomic"
+)
+
+// runJobs runs tasks on up to jobs goroutines (one per CPU if jobs < 1) and
+// returns the error of the first failed task in list order. Tasks not yet
+// started when one fails are skipped.
+func runJobs(jobs int, tasks []func() error) error {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	errs := make([]error, len(tasks))
+	var failed atomic.Bool
+	next := make(chan int)
+	var wg gosync.WaitGroup
+	for w := 0; w < jobs && w < len(tasks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if failed.Load() {
+					continue
+				}
+				if errs[i] = tasks[i](); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range tasks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
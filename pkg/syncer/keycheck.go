@@ -0,0 +1,56 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// KeyCheck is the result of VerifyKey
+type KeyCheck struct {
+	Checked  int      // Encrypted files tried
+	Mismatch []string // Encrypted to a different key
+	Failed   []string // Unreadable or corrupt, "path: error"
+}
+
+// OK reports whether identity decrypted every encrypted file
+func (c *KeyCheck) OK() bool {
+	return len(c.Mismatch) == 0 && len(c.Failed) == 0
+}
+
+// VerifyKey decrypts every encrypted file in the local repo with identity,
+// or with this machine's key if identity is nil. The remote is not contacted.
+func (e *Engine) VerifyKey(identity *age.X25519Identity) (*KeyCheck, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init <repo-url>' first")
+	}
+	if identity == nil {
+		var err error
+		if identity, err = e.loadIdentity(); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	check := &KeyCheck{}
+	for _, f := range files {
+		if !f.encrypted {
+			continue
+		}
+		check.Checked++
+		var noMatch *age.NoIdentityMatchError
+		switch err := crypto.CheckFile(identity, f.src); {
+		case errors.As(err, &noMatch):
+			check.Mismatch = append(check.Mismatch, f.relPath)
+		case err != nil:
+			check.Failed = append(check.Failed, fmt.Sprintf("%s: %v", f.relPath, err))
+		}
+	}
+	return check, nil
+}
@@ -0,0 +1,227 @@
+package syncer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// lfsAttributes routes a path in .gitattributes through Git LFS
+const lfsAttributes = "filter=lfs diff=lfs merge=lfs -text"
+
+// pushPolicy applies max_file_size and binary_files to a file about to be
+// pushed. It reports whether the file is left out, and whether it goes to
+// the repo through Git LFS.
+func (e *Engine) pushPolicy(path, relPath string) (skip, lfs bool) {
+	max := e.cfg.MaxFileBytes()
+	if max == 0 && (e.cfg.BinaryFiles == config.BinarySync || e.cfg.BinaryFiles == "") {
+		return false, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, false
+	}
+	if max > 0 && info.Size() > max {
+		e.log.Warn(fmt.Sprintf("Skipping %s: %s is over max_file_size (%s)", relPath, formatBytes(info.Size()), e.cfg.MaxFileSize))
+		return true, false
+	}
+	if e.cfg.BinaryFiles == config.BinarySync || e.cfg.BinaryFiles == "" || isTextFile(path) {
+		return false, false
+	}
+	switch e.cfg.BinaryFiles {
+	case config.BinaryWarn:
+		e.log.Warn(fmt.Sprintf("%s is a binary file (%s)", relPath, formatBytes(info.Size())))
+	case config.BinarySkip:
+		e.debug(fmt.Sprintf("Skipping binary file %s", relPath))
+		return true, false
+	case config.BinaryLFS:
+		return false, true
+	}
+	return false, false
+}
+
+// isTextFile reports whether the file at path looks like text, from its
+// first bytes
+func isTextFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	buf := make([]byte, 8000)
+	n, _ := io.ReadFull(f, buf)
+	return sync.IsText(buf[:n])
+}
+
+// formatBytes renders a byte count for humans
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// lfsRepo returns the repo when it can store files with Git LFS: the git
+// backend using the git CLI, with git-lfs installed
+func (e *Engine) lfsRepo() (*gitpkg.Git, error) {
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+	repo, ok := backend.GitRepo(b)
+	if !ok {
+		return nil, fmt.Errorf("Git LFS requires the git backend (current backend: %s)", b.Name())
+	}
+	g, ok := repo.(*gitpkg.Git)
+	if !ok {
+		return nil, fmt.Errorf("Git LFS requires the git CLI (set git_backend: cli)")
+	}
+	if !gitpkg.LFSInstalled() {
+		return nil, fmt.Errorf("git-lfs is not installed (see https://git-lfs.com)")
+	}
+	return g, nil
+}
+
+// largeEncrypted lists the repo's encrypted files over lfs.threshold
+func (e *Engine) largeEncrypted() []string {
+	threshold := e.cfg.LFS.ThresholdBytes()
+	if threshold == 0 {
+		return nil
+	}
+	files, err := sync.WalkFiles(e.paths.RepoDir)
+	if err != nil {
+		return nil
+	}
+	var large []string
+	for _, file := range files {
+		relPath := sync.RelPath(e.paths.RepoDir, file)
+		if strings.HasPrefix(relPath, ".git") || !strings.HasSuffix(relPath, ".age") {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && info.Size() > threshold {
+			large = append(large, relPath)
+		}
+	}
+	return large
+}
+
+// fetchLFS checks out the content of the files the repo stores with Git LFS
+// in place of their pointers, once pulled
+func (e *Engine) fetchLFS() error {
+	if !gitpkg.UsesLFS(e.paths.RepoDir) {
+		return nil
+	}
+	g, err := e.lfsRepo()
+	if err != nil {
+		return err
+	}
+	if err := g.InstallLFS(); err != nil {
+		return err
+	}
+	return g.PullLFS()
+}
+
+// trackLFS adds repo paths to the ones .gitattributes stores with Git LFS,
+// dropping those no longer in the repo, and sets up the LFS filters before
+// they are committed
+func (e *Engine) trackLFS(repoPaths []string) error {
+	attrPath := filepath.Join(e.paths.RepoDir, ".gitattributes")
+	var other, tracked []string
+	if f, err := os.Open(attrPath); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			path, ok := strings.CutSuffix(line, " "+lfsAttributes)
+			if !ok || !strings.HasPrefix(path, "/") {
+				other = append(other, line)
+				continue
+			}
+			tracked = append(tracked, unquoteAttrPath(path))
+		}
+		f.Close()
+	}
+	for _, p := range repoPaths {
+		tracked = append(tracked, filepath.ToSlash(p))
+	}
+	tracked = slices.DeleteFunc(tracked, func(p string) bool {
+		return !sync.FileExists(filepath.Join(e.paths.RepoDir, filepath.FromSlash(p)))
+	})
+	slices.Sort(tracked)
+	tracked = slices.Compact(tracked)
+	if len(tracked) == 0 && !sync.FileExists(attrPath) {
+		return nil
+	}
+
+	if len(tracked) > 0 {
+		g, err := e.lfsRepo()
+		if err != nil {
+			return err
+		}
+		if err := g.InstallLFS(); err != nil {
+			return err
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range other {
+		b.WriteString(line + "\n")
+	}
+	for _, p := range tracked {
+		b.WriteString(quoteAttrPath(p) + " " + lfsAttributes + "\n")
+	}
+	if b.Len() == 0 {
+		return os.Remove(attrPath)
+	}
+	return os.WriteFile(attrPath, []byte(b.String()), 0644)
+}
+
+// quoteAttrPath writes a repo path as a .gitattributes pattern matching it
+// alone: anchored, with glob characters escaped and spaces written as
+// [[:space:]], as git lfs track does
+func quoteAttrPath(p string) string {
+	var b strings.Builder
+	b.WriteString("/")
+	for _, c := range p {
+		switch c {
+		case ' ':
+			b.WriteString("[[:space:]]")
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// unquoteAttrPath reverses quoteAttrPath
+func unquoteAttrPath(pattern string) string {
+	pattern = strings.ReplaceAll(strings.TrimPrefix(pattern, "/"), "[[:space:]]", " ")
+	var b strings.Builder
+	escaped := false
+	for _, c := range pattern {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package syncer
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// List classifies every local file a push would consider: ~/.claude,
+// ~/.claude.json (as "claude.json") and extra_paths entries (under
+// extra-paths/), sorted by path
+func (e *Engine) List() ([]FileStatus, error) {
+	files, err := e.localFiles()
+	if err != nil {
+		return nil, err
+	}
+	if sync.FileExists(e.paths.ClaudeJSON) {
+		files = append(files, FileStatus{Path: "claude.json", Class: ClassEncrypted})
+	}
+
+	extras, err := e.extraFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range extras {
+		class := ClassPlain
+		if x.encrypt {
+			class = ClassEncrypted
+		}
+		files = append(files, FileStatus{Path: filepath.FromSlash(x.relPath), Class: class})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
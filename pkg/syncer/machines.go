@@ -0,0 +1,179 @@
+package syncer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// machinesDir is the repo folder holding one metadata file per machine
+const machinesDir = ".machines"
+
+// MachineInfo describes a machine that syncs with the repo
+type MachineInfo struct {
+	ID       string    `json:"id"`
+	Hostname string    `json:"hostname"`
+	Platform string    `json:"platform"` // GOOS/GOARCH
+	LastPush time.Time `json:"last_push"`
+	LastPull time.Time `json:"last_pull"`
+	Pushes   SyncTimes `json:"pushes"`
+	Pulls    SyncTimes `json:"pulls"`
+	Current  bool      `json:"-"` // The machine this engine runs on
+}
+
+// SyncTimes adds up how long a machine's pushes or pulls took
+type SyncTimes struct {
+	Count   int   `json:"count"`
+	TotalMS int64 `json:"total_ms"`
+}
+
+// Average is the mean duration, 0 if nothing was recorded
+func (s SyncTimes) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return time.Duration(s.TotalMS/int64(s.Count)) * time.Millisecond
+}
+
+func (s *SyncTimes) add(d time.Duration) {
+	s.Count++
+	s.TotalMS += d.Milliseconds()
+}
+
+// LastSeen is the most recent push or pull
+func (m MachineInfo) LastSeen() time.Time {
+	if m.LastPull.After(m.LastPush) {
+		return m.LastPull
+	}
+	return m.LastPush
+}
+
+// machineFile is this machine's record in the sync dir. Pull times are kept
+// there and published with the next push, since pull doesn't commit.
+func (e *Engine) machineFile() string {
+	return filepath.Join(e.paths.SyncDir, "machine.json")
+}
+
+// localMachine loads this machine's record, creating an ID on first use
+func (e *Engine) localMachine() MachineInfo {
+	var m MachineInfo
+	if data, err := os.ReadFile(e.machineFile()); err == nil {
+		json.Unmarshal(data, &m)
+	}
+	if m.ID == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		m.ID = hex.EncodeToString(b)
+	}
+	m.Hostname = config.Hostname()
+	m.Platform = runtime.GOOS + "/" + runtime.GOARCH
+	m.Current = true
+	return m
+}
+
+func (e *Engine) saveLocalMachine(m MachineInfo) error {
+	data, err := marshalJSON(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.machineFile(), data, 0644)
+}
+
+// recordPull notes the time and duration of a successful pull
+func (e *Engine) recordPull(took time.Duration) {
+	m := e.localMachine()
+	m.LastPull = time.Now().UTC()
+	m.Pulls.add(took)
+	if err := e.saveLocalMachine(m); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to record pull time: %v", err))
+	}
+}
+
+// recordPushTime notes how long a push took. Like pull times, it is
+// published with the next push.
+func (e *Engine) recordPushTime(took time.Duration) {
+	m := e.localMachine()
+	m.Pushes.add(took)
+	if err := e.saveLocalMachine(m); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to record push time: %v", err))
+	}
+}
+
+// writeMachineRecord stamps the push time and writes this machine's record
+// into the repo so it is committed with the sync
+func (e *Engine) writeMachineRecord() error {
+	m := e.localMachine()
+	m.LastPush = time.Now().UTC()
+	if err := e.saveLocalMachine(m); err != nil {
+		return err
+	}
+
+	data, err := marshalJSON(m)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(e.paths.RepoDir, machinesDir)
+	if err := sync.EnsureDir(dir); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, m.ID+".json"), data, 0644)
+}
+
+// Machines updates the repo from the remote and lists the machines that have
+// pushed to it, most recently active first. This machine is included even if
+// it hasn't pushed yet.
+func (e *Engine) Machines() ([]MachineInfo, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+	}
+	e.pullRemote()
+	return e.machines()
+}
+
+// machines lists the machines recorded in the local repo
+func (e *Engine) machines() ([]MachineInfo, error) {
+	local := e.localMachine()
+	var machines []MachineInfo
+	entries, err := os.ReadDir(filepath.Join(e.paths.RepoDir, machinesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(e.paths.RepoDir, machinesDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var m MachineInfo
+		if json.Unmarshal(data, &m) != nil || m.ID == "" {
+			continue
+		}
+		if m.ID == local.ID {
+			continue // Added below with the latest local times
+		}
+		machines = append(machines, m)
+	}
+	machines = append(machines, local)
+
+	sort.SliceStable(machines, func(i, j int) bool {
+		return machines[i].LastSeen().After(machines[j].LastSeen())
+	})
+	return machines, nil
+}
+
+// isMachineRecord reports whether a repo path is a machine metadata file
+func isMachineRecord(relPath string) bool {
+	return strings.HasPrefix(filepath.ToSlash(relPath), machinesDir+"/")
+}
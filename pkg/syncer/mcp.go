@@ -0,0 +1,303 @@
+package syncer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/secrets"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// MCPServersFile holds, in the repo, the mcpServers of ~/.claude.json when
+// claude_json.mcp_servers is set. It is plain JSON, so server changes show
+// up in diffs; only env and header values, and other strings that look like
+// secrets, are encrypted, one by one.
+const MCPServersFile = ".mcp-servers.json"
+
+// sealedPrefix marks a value encrypted on its own, followed by the base64
+// age ciphertext
+const sealedPrefix = "age:"
+
+// mcpServersDoc is the layout of MCPServersFile
+type mcpServersDoc struct {
+	Recipients string                 `json:"recipients"` // Fingerprint of the keys the values are encrypted to
+	Servers    map[string]interface{} `json:"mcpServers"`
+}
+
+// localMCPServers returns the global mcpServers of ~/.claude.json, with
+// this machine's paths replaced by placeholders
+func (e *Engine) localMCPServers() (map[string]interface{}, error) {
+	data, err := os.ReadFile(e.paths.ClaudeJSON)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := decodeObject(e.normalizePlaceholders(data, "claude.json"))
+	if !ok {
+		return nil, fmt.Errorf("failed to parse claude.json")
+	}
+	servers, _ := doc["mcpServers"].(map[string]interface{})
+	if servers == nil {
+		servers = map[string]interface{}{}
+	}
+	return servers, nil
+}
+
+// pushMCPServers writes the MCP servers of ~/.claude.json to MCPServersFile.
+// Values that decrypt to what they were last time keep their ciphertext, so
+// an unchanged secret doesn't show up in the diff.
+func (e *Engine) pushMCPServers(identity *age.X25519Identity, pubKey string, opts PushOptions, result *PushResult) error {
+	dest := filepath.Join(e.paths.RepoDir, MCPServersFile)
+	result.Files = append(result.Files, FileAction{Path: MCPServersFile, Action: ActionEncrypt})
+	if opts.DryRun {
+		e.log.Info(fmt.Sprintf("  [encrypt values] %s", MCPServersFile))
+		return nil
+	}
+
+	servers, err := e.localMCPServers()
+	if err != nil {
+		return fmt.Errorf("failed to read MCP servers: %w", err)
+	}
+	plaintext, err := marshalJSON(servers)
+	if err != nil {
+		return err
+	}
+	recipients := e.recipients(pubKey, MCPServersFile)
+	sum := sync.Checksum([]byte(strings.Join(recipients, "\n") + "\n" + string(plaintext)))
+	if e.index.Unchanged(MCPServersFile, dest, sum) {
+		result.unchanged++
+		return nil
+	}
+
+	doc := mcpServersDoc{Recipients: recipientsFingerprint(recipients), Servers: map[string]interface{}{}}
+	reuse := e.previousSealed(identity, dest, doc.Recipients)
+	for name, server := range servers {
+		sealed, err := sealSecrets(server, false, func(value string) (string, error) {
+			if ciphertext, ok := reuse[name+"\x00"+value]; ok {
+				return ciphertext, nil
+			}
+			return sealValue(recipients, value)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encrypt MCP server %s: %w", name, err)
+		}
+		doc.Servers[name] = sealed
+	}
+	data, err := marshalJSON(doc)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(dest); err == nil && bytes.Equal(existing, data) {
+		result.unchanged++
+	} else {
+		e.log.Info(fmt.Sprintf("Encrypting MCP server secrets: %s", MCPServersFile))
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", MCPServersFile, err)
+		}
+		result.Changed = append(result.Changed, MCPServersFile)
+	}
+	result.wrote(MCPServersFile, sum)
+	return nil
+}
+
+// previousSealed decrypts the values in the repo's MCPServersFile, keyed by
+// server name and plaintext, if they were encrypted to the same recipients
+func (e *Engine) previousSealed(identity *age.X25519Identity, path, fingerprint string) map[string]string {
+	reuse := map[string]string{}
+	doc, err := readMCPServers(path)
+	if err != nil || doc.Recipients != fingerprint {
+		return reuse
+	}
+	for name, server := range doc.Servers {
+		openSecrets(server, func(sealed string) (string, error) {
+			value, err := openValue(identity, sealed)
+			if err == nil {
+				reuse[name+"\x00"+value] = sealed
+			}
+			return value, err
+		})
+	}
+	return reuse
+}
+
+// mergeMCPServers replaces the mcpServers of a claude.json document with
+// the decrypted servers from the repo's MCPServersFile, placeholders expanded
+func (e *Engine) mergeMCPServers(identity *age.X25519Identity, path string, content []byte) ([]byte, error) {
+	doc, err := readMCPServers(path)
+	if err != nil {
+		return nil, err
+	}
+	servers := map[string]interface{}{}
+	for name, server := range doc.Servers {
+		opened, err := openSecrets(server, func(sealed string) (string, error) {
+			return openValue(identity, sealed)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt MCP server %s: %w", name, err)
+		}
+		servers[name] = opened
+	}
+	if data, err := marshalJSON(servers); err == nil {
+		if expanded, ok := decodeObject(e.expandPlaceholders(data, "claude.json")); ok {
+			servers = expanded
+		}
+	}
+
+	target := map[string]interface{}{}
+	if len(bytes.TrimSpace(content)) > 0 {
+		var ok bool
+		if target, ok = decodeObject(content); !ok {
+			return nil, fmt.Errorf("failed to parse local claude.json")
+		}
+	}
+	target["mcpServers"] = servers
+	return marshalJSON(target)
+}
+
+// reencryptMCPServers rewrites every value in the repo's MCPServersFile for
+// the current recipients. Returns false if there is no such file.
+func (e *Engine) reencryptMCPServers(identity *age.X25519Identity, pubKey string, dryRun bool) (bool, error) {
+	path := filepath.Join(e.paths.RepoDir, MCPServersFile)
+	doc, err := readMCPServers(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	recipients := e.recipients(pubKey, MCPServersFile)
+	if dryRun {
+		e.log.Info(fmt.Sprintf("  [re-encrypt] %s (%d recipients)", MCPServersFile, len(recipients)))
+		return true, nil
+	}
+
+	doc.Recipients = recipientsFingerprint(recipients)
+	for name, server := range doc.Servers {
+		if doc.Servers[name], err = openSecrets(server, func(sealed string) (string, error) {
+			value, err := openValue(identity, sealed)
+			if err != nil {
+				return "", err
+			}
+			return sealValue(recipients, value)
+		}); err != nil {
+			return false, fmt.Errorf("failed to re-encrypt MCP server %s: %w", name, err)
+		}
+	}
+	data, err := marshalJSON(doc)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, data, 0644)
+}
+
+func readMCPServers(path string) (*mcpServersDoc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := decodeObject(data)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s", MCPServersFile)
+	}
+	doc := &mcpServersDoc{}
+	doc.Recipients, _ = obj["recipients"].(string)
+	doc.Servers, _ = obj["mcpServers"].(map[string]interface{})
+	return doc, nil
+}
+
+// sealSecrets returns v with its secret-bearing strings replaced by seal:
+// everything under "env" and "headers", and any other string the secret
+// scanner flags, such as a token passed in args or the URL. Strings that
+// happen to start with sealedPrefix are sealed too, so pull can't mistake them.
+func sealSecrets(v interface{}, secret bool, seal func(string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			sealed, err := sealSecrets(child, secret || key == "env" || key == "headers", seal)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = sealed
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			sealed, err := sealSecrets(child, secret, seal)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = sealed
+		}
+		return out, nil
+	case string:
+		if secret || strings.HasPrefix(v, sealedPrefix) || len(secrets.Scan([]byte(v), "")) > 0 {
+			return seal(v)
+		}
+	}
+	return v, nil
+}
+
+// openSecrets returns v with every sealed string replaced by open
+func openSecrets(v interface{}, open func(string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			opened, err := openSecrets(child, open)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = opened
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			opened, err := openSecrets(child, open)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = opened
+		}
+		return out, nil
+	case string:
+		if strings.HasPrefix(v, sealedPrefix) {
+			return open(v)
+		}
+	}
+	return v, nil
+}
+
+func sealValue(recipients []string, value string) (string, error) {
+	ciphertext, err := crypto.EncryptTo(recipients, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func openValue(identity *age.X25519Identity, sealed string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	plaintext, err := crypto.Decrypt(identity, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// recipientsFingerprint identifies a set of recipients without listing them
+func recipientsFingerprint(recipients []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(recipients, "\n")))
+	return fmt.Sprintf("%x", sum[:8])
+}
@@ -0,0 +1,63 @@
+package syncer
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/peer"
+)
+
+// PeerHandler returns an HTTP handler that serves this machine's repo to
+// peers on the LAN holding the same key (see 'serve --lan')
+func (e *Engine) PeerHandler() (http.Handler, error) {
+	repo, err := e.peerRepo()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return peer.Handler(identity, func(path string) error {
+		e.debug("Serving repo bundle to a peer")
+		return repo.Bundle(path)
+	}), nil
+}
+
+// peerRepo returns the git repo for LAN sync
+func (e *Engine) peerRepo() (gitpkg.Repo, error) {
+	if e.cfg.GitBranches == config.BranchesPerMachine {
+		return nil, fmt.Errorf("LAN sync is not supported with git_branches: per-machine")
+	}
+	return e.gitRepo("LAN sync")
+}
+
+// pullPeer merges the repo of a peer running 'serve --lan' instead of
+// pulling from the remote
+func (e *Engine) pullPeer(addr string, identity *age.X25519Identity) error {
+	repo, err := e.peerRepo()
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(e.paths.SyncDir, "peer-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "repo.bundle")
+
+	e.log.Info(fmt.Sprintf("Pulling from peer %s...", peer.Addr(addr)))
+	if err := peer.Fetch(addr, identity, bundle); err != nil {
+		return err
+	}
+	if err := repo.PullBundle(bundle); err != nil {
+		return fmt.Errorf("failed to merge the peer's repo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// PendingPush describes a sync commit that could not be pushed because the
+// remote was unreachable
+type PendingPush struct {
+	Since  time.Time `json:"since"`            // When the first unpushed commit was made
+	Error  string    `json:"error"`            // Why the last attempt failed
+	Review bool      `json:"review,omitempty"` // Push to the review branch, not the shared one
+}
+
+func (e *Engine) pendingFile() string {
+	return filepath.Join(e.paths.SyncDir, "push-pending")
+}
+
+// PendingPush returns the queued push, or nil if nothing is waiting
+func (e *Engine) PendingPush() *PendingPush {
+	data, err := os.ReadFile(e.pendingFile())
+	if err != nil {
+		return nil
+	}
+	p := &PendingPush{}
+	json.Unmarshal(data, p)
+	return p
+}
+
+// queuePush remembers that the local sync commits still need pushing
+func (e *Engine) queuePush(cause error) {
+	e.queue(cause, false)
+}
+
+// queueReview remembers that the local sync commits still need pushing for
+// review
+func (e *Engine) queueReview(cause error) {
+	e.queue(cause, true)
+}
+
+func (e *Engine) queue(cause error, review bool) {
+	p := e.PendingPush()
+	if p == nil {
+		p = &PendingPush{Since: time.Now().UTC()}
+	}
+	p.Error = strings.TrimSpace(cause.Error())
+	p.Review = p.Review || review
+	data, err := marshalJSON(p)
+	if err == nil {
+		err = os.WriteFile(e.pendingFile(), data, 0644)
+	}
+	if err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to queue push: %v", err))
+	}
+}
+
+func (e *Engine) clearPending() {
+	os.Remove(e.pendingFile())
+}
+
+// FlushPending pushes sync commits queued while the remote was unreachable.
+// It returns false without error when nothing is queued or the remote is
+// still unreachable.
+func (e *Engine) FlushPending() (bool, error) {
+	if e.PendingPush() == nil {
+		return false, nil
+	}
+	l, err := e.lock()
+	if err != nil {
+		return false, err
+	}
+	defer l.Unlock()
+
+	return e.flushPending()
+}
+
+// flushPending is FlushPending for callers that hold the lock
+func (e *Engine) flushPending() (bool, error) {
+	if e.PendingPush() == nil {
+		return false, nil
+	}
+	if err := e.cfg.CanPush(); err != nil {
+		return false, err
+	}
+	b, err := e.backend()
+	if err != nil {
+		return false, err
+	}
+	if !b.HasRemote() {
+		return false, nil
+	}
+
+	if e.PendingPush().Review {
+		url, err := e.pushReview(b, syncMessage(), nil)
+		if err != nil {
+			if backend.IsOffline(err) {
+				e.queueReview(err)
+				return false, nil
+			}
+			return false, err
+		}
+		if url != "" {
+			e.log.Success(fmt.Sprintf("Opened pull request: %s", url))
+		}
+	} else if _, err := e.pushRemote(b, syncMessage(), nil); err != nil {
+		if backend.IsOffline(err) {
+			e.queuePush(err)
+			return false, nil
+		}
+		return false, err
+	}
+	e.clearPending()
+	return true, nil
+}
+
+// syncMessage is the commit message of a sync commit
+func syncMessage() string {
+	return fmt.Sprintf("Sync %s\n\n%s %s", sync.Timestamp(), machineTrailer, config.Hostname())
+}
@@ -0,0 +1,78 @@
+package syncer
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// placeholderFile reports whether relPath holds placeholders in the repo,
+// and whether it is JSON: JSON files always (settings.json hooks,
+// claude.json MCP servers, plugin configs), Markdown and YAML files if
+// placeholders.markdown or placeholders.yaml is set. Another platform's
+// variant, restored by pull --all-platforms, keeps them as they are.
+func (e *Engine) placeholderFile(relPath string) (ok, jsonFile bool) {
+	if sync.ShouldSkipForPlatform(relPath) {
+		return false, false
+	}
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".json":
+		return true, true
+	case ".md":
+		return e.cfg.Placeholders.Markdown, false
+	case ".yaml", ".yml":
+		return e.cfg.Placeholders.YAML, false
+	}
+	return false, false
+}
+
+// pathValues returns this machine's values for the placeholders enabled in
+// config.yaml
+func (e *Engine) pathValues() sync.PathValues {
+	v := sync.PathValues{ClaudeDir: e.paths.ClaudeDir}
+	p := e.cfg.Placeholders
+	if p.HomeEnabled() {
+		v.Home, _ = os.UserHomeDir()
+	}
+	if p.User {
+		if u, err := user.Current(); err == nil {
+			// DOMAIN\name on Windows
+			v.User = u.Username[strings.LastIndex(u.Username, `\`)+1:]
+		}
+	}
+	if p.Hostname {
+		v.Hostname = config.Hostname()
+	}
+	return v
+}
+
+// normalizePlaceholders replaces this machine's values in a file being
+// pushed with placeholders
+func (e *Engine) normalizePlaceholders(data []byte, relPath string) []byte {
+	if ok, jsonFile := e.placeholderFile(relPath); ok {
+		return sync.NormalizePlaceholders(data, e.pathValues(), jsonFile)
+	}
+	return data
+}
+
+// expandPlaceholders puts this machine's values back into a pulled file
+func (e *Engine) expandPlaceholders(data []byte, relPath string) []byte {
+	if ok, jsonFile := e.placeholderFile(relPath); ok {
+		return sync.ExpandPlaceholders(data, e.pathValues(), jsonFile)
+	}
+	return data
+}
+
+// expandedOnPull reports whether a platform warning is about a placeholder
+// that pull expands for each platform, so isn't platform-specific
+func (e *Engine) expandedOnPull(w PlatformWarning) bool {
+	if ok, _ := e.placeholderFile(w.File); !ok {
+		return false
+	}
+	p := e.cfg.Placeholders
+	return (w.Pattern == sync.HomePlaceholder && p.HomeEnabled()) || (w.Pattern == sync.UserPlaceholder && p.User)
+}
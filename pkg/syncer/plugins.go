@@ -0,0 +1,164 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// MissingPlugin is a plugin or marketplace listed in the synced plugin config
+// that is not installed on this machine
+type MissingPlugin struct {
+	Kind    string   // "marketplace" or "plugin"
+	Name    string   // Marketplace name, or plugin@marketplace
+	Install []string // Arguments to the claude CLI that install it
+}
+
+// Command returns the install command as a shell string
+func (m MissingPlugin) Command() string {
+	return "claude " + strings.Join(m.Install, " ")
+}
+
+// marketplaceEntry is one entry of plugins/known_marketplaces.json
+type marketplaceEntry struct {
+	Source struct {
+		Source string `json:"source"`
+		Repo   string `json:"repo"`
+		URL    string `json:"url"`
+		Path   string `json:"path"`
+	} `json:"source"`
+	InstallLocation string `json:"installLocation"`
+}
+
+// pluginInstall is one installation record in plugins/installed_plugins.json.
+// Version 1 stores one record per plugin, version 2 a list of them.
+type pluginInstall struct {
+	InstallPath string `json:"installPath"`
+}
+
+// MissingPlugins compares the plugin config in ~/.claude/plugins with what is
+// installed on disk. Marketplaces come first so their plugins can be installed.
+func (e *Engine) MissingPlugins() ([]MissingPlugin, error) {
+	if e.cfg.Plugins.Sync == config.PluginsNone {
+		return nil, nil
+	}
+	pluginsDir := filepath.Join(e.paths.ClaudeDir, "plugins")
+
+	var missing []MissingPlugin
+
+	var marketplaces map[string]marketplaceEntry
+	if err := readJSONIfExists(filepath.Join(pluginsDir, "known_marketplaces.json"), &marketplaces); err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(marketplaces) {
+		m := marketplaces[name]
+		if m.InstallLocation != "" && sync.FileExists(m.InstallLocation) {
+			continue
+		}
+		source := firstOf(m.Source.Repo, m.Source.URL, m.Source.Path)
+		if source == "" {
+			continue
+		}
+		missing = append(missing, MissingPlugin{Kind: "marketplace", Name: name, Install: []string{"plugin", "marketplace", "add", source}})
+	}
+
+	var installed struct {
+		Plugins map[string]json.RawMessage `json:"plugins"`
+	}
+	if err := readJSONIfExists(filepath.Join(pluginsDir, "installed_plugins.json"), &installed); err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(installed.Plugins) {
+		var records []pluginInstall
+		if err := json.Unmarshal(installed.Plugins[name], &records); err != nil {
+			var one pluginInstall
+			if err := json.Unmarshal(installed.Plugins[name], &one); err != nil {
+				continue
+			}
+			records = []pluginInstall{one}
+		}
+
+		present := false
+		for _, r := range records {
+			if r.InstallPath == "" || sync.FileExists(r.InstallPath) {
+				present = true
+				break
+			}
+		}
+		if !present {
+			missing = append(missing, MissingPlugin{Kind: "plugin", Name: name, Install: []string{"plugin", "install", name}})
+		}
+	}
+
+	return missing, nil
+}
+
+// repairPlugins reinstalls missing plugins with the claude CLI when
+// plugins.reinstall is set, and returns the ones still missing
+func (e *Engine) repairPlugins() []MissingPlugin {
+	missing, err := e.MissingPlugins()
+	if err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to check plugins: %v", err))
+		return nil
+	}
+	if len(missing) == 0 || !e.cfg.Plugins.Reinstall {
+		return missing
+	}
+
+	claude, err := exec.LookPath("claude")
+	if err != nil {
+		e.log.Warn("plugins.reinstall is set but the claude CLI is not in PATH")
+		return missing
+	}
+
+	var remaining []MissingPlugin
+	for _, m := range missing {
+		e.log.Info(fmt.Sprintf("Installing %s %s...", m.Kind, m.Name))
+		out, err := exec.Command(claude, m.Install...).CombinedOutput()
+		if err != nil {
+			e.log.Warn(fmt.Sprintf("'%s' failed: %s", m.Command(), strings.TrimSpace(string(out))))
+			remaining = append(remaining, m)
+		}
+	}
+	return remaining
+}
+
+// readJSONIfExists decodes a JSON file, leaving v untouched if it is missing
+func readJSONIfExists(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,756 @@
+package syncer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Strategy decides what happens when a local file differs from the repo
+type Strategy string
+
+const (
+	StrategyTheirs Strategy = "theirs" // Apply remote files, backing up local ones (default)
+	StrategyOurs   Strategy = "ours"   // Keep local files when they differ
+)
+
+// PullOptions controls a pull
+type PullOptions struct {
+	DryRun       bool // Report what would be restored without touching ~/.claude
+	Strategy     Strategy
+	Jobs         int    // Files decrypted/copied in parallel, 0 for one per CPU
+	Peer         string // Pull from this LAN peer (host[:port]) instead of the remote
+	Force        bool   // Pull even while Claude Code is running, whatever claude_running says
+	AllPlatforms bool   // Also restore other platforms' variants, e.g. deploy.windows.md on macOS, to edit them here
+}
+
+// PullResult describes what a pull did (or would do, for a dry run)
+type PullResult struct {
+	Files          []FileAction
+	Changed        []string        // Files whose local copy was replaced or created
+	Conflicts      []string        // Changed files whose differing local copy was saved to backups/files first
+	BackupPath     string          // Zip backup of ~/.claude taken before restoring, if any
+	MissingPlugins []MissingPlugin // Plugins in the synced config that are not installed here
+	Impact         *Impact         // What a dry run would change, in Claude Code's terms
+}
+
+// ChangeKind classifies a difference between local and repo files
+type ChangeKind string
+
+const (
+	ChangeNew     ChangeKind = "new"     // File exists only in the repo
+	ChangeChanged ChangeKind = "changed" // File exists in both with different content
+	ChangeAdded   ChangeKind = "added"   // File exists only locally
+)
+
+// Change is a single difference reported by Diff
+type Change struct {
+	Path      string // Path relative to ~/.claude (without .age)
+	Kind      ChangeKind
+	Encrypted bool
+	LocalPath string // Destination on this machine
+	RepoPath  string // Source file in the repo
+}
+
+// repoFile is a restorable file found in the repo
+type repoFile struct {
+	relPath   string // Path relative to ~/.claude (without .age)
+	src       string // Full path in the repo
+	dest      string // Full path on this machine
+	encrypted bool
+	mode      os.FileMode // Permissions recorded in the manifest, 0 if unknown
+
+	// MCPServersFile to merge into claude.json. Equal to src when claude.json
+	// itself isn't synced.
+	mcpServers string
+}
+
+// Pull updates the repo from the remote and restores its files into ~/.claude
+func (e *Engine) Pull(opts PullOptions) (*PullResult, error) {
+	paths := e.paths
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = StrategyTheirs
+	}
+
+	if !opts.DryRun {
+		if err := e.cfg.CanPull(); err != nil {
+			return nil, err
+		}
+		if !opts.Force {
+			if err := e.checkClaudeRunning(); err != nil {
+				return nil, err
+			}
+		}
+		l, err := e.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer l.Unlock()
+
+		e.openIndex()
+		defer e.saveIndex()
+	}
+	start := time.Now()
+
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		if err := e.runHook(HookPrePull, e.cfg.Hooks.PrePull, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Peer != "" && !opts.DryRun {
+		if err := e.pullPeer(opts.Peer, identity); err != nil {
+			return nil, err
+		}
+	} else if !opts.DryRun {
+		e.pullRemote()
+	}
+	if !opts.DryRun {
+		e.updateTeam()
+	}
+
+	// Files stored with Git LFS are only pointers without it
+	if gitpkg.UsesLFS(paths.RepoDir) {
+		if _, err := e.lfsRepo(); err != nil {
+			return nil, fmt.Errorf("the repo stores files with Git LFS: %w", err)
+		}
+	}
+
+	if err := e.checkKey(identity); err != nil {
+		return nil, err
+	}
+
+	result := &PullResult{}
+
+	// Backup current config
+	if sync.FileExists(paths.ClaudeDir) && !opts.DryRun {
+		backupPath, err := e.backupCurrent()
+		if err != nil {
+			e.log.Warn(fmt.Sprintf("Backup failed: %v", err))
+		} else {
+			result.BackupPath = backupPath
+		}
+	}
+
+	if err := e.restore(opts, strategy, identity, result); err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		// The other side of WSL gets what was pulled too
+		if side := e.wslSideFor(config.WSLMirror); side != nil {
+			if err := e.mirrorWSL(side); err != nil {
+				e.log.Warn(fmt.Sprintf("Failed to mirror %s: %v", side.Dir, err))
+			}
+		} else if side := e.wslSideFor(config.WSLVariants); side != nil {
+			if err := e.restoreWSLVariants(side, identity, strategy); err != nil {
+				e.log.Warn(fmt.Sprintf("Failed to restore %s: %v", side.Dir, err))
+			}
+		}
+
+		e.debug(fmt.Sprintf("Pull took %s", time.Since(start).Round(time.Millisecond)))
+		e.recordPull(time.Since(start))
+		for _, path := range result.Conflicts {
+			e.emit(Event{Type: EventFileConflict, Path: path})
+		}
+		e.emitFiles(result.Files, result.Changed)
+		e.emit(Event{Type: EventPullComplete, Files: len(result.Changed), BackupPath: result.BackupPath})
+		if pushed, err := e.flushPending(); err != nil {
+			e.log.Warn(fmt.Sprintf("Failed to push commits queued while offline: %v", err))
+		} else if pushed {
+			e.log.Success("Pushed commits queued while offline.")
+		}
+		result.MissingPlugins = e.repairPlugins()
+		e.runPostHook(HookPostPull, e.cfg.Hooks.PostPull, result.Changed, map[string]string{
+			"CLAUDE_SYNC_BACKUP": result.BackupPath,
+		})
+	}
+	return result, nil
+}
+
+// backupCurrent zips ~/.claude and ~/.claude.json into the backups dir and
+// prunes old backups
+func (e *Engine) backupCurrent() (string, error) {
+	backupPath, err := e.Backup()
+	if err != nil {
+		return "", err
+	}
+
+	// Keep only last N backups
+	if err := sync.PruneBackups(e.paths.BackupDir, e.cfg.Backup.MaxCount); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to prune backups: %v", err))
+	}
+	return backupPath, nil
+}
+
+// Backup zips ~/.claude and ~/.claude.json into the backups dir, encrypted
+// to this machine's key as backup.encrypt decides, and returns its path.
+// Old backups are not pruned.
+func (e *Engine) Backup() (string, error) {
+	paths := e.paths
+	backupPath := filepath.Join(paths.BackupDir, fmt.Sprintf("backup-%s.zip", sync.Timestamp()))
+
+	publicKey := ""
+	if e.encryptBackups() {
+		key, err := keys.PublicKey(paths, e.cfg.KeyCommand)
+		if err != nil {
+			e.log.Warn(fmt.Sprintf("Backup will not be encrypted: %v", err))
+		} else {
+			publicKey = key
+			backupPath += ".age"
+		}
+	}
+
+	e.log.Info(fmt.Sprintf("Backing up current config to %s...", backupPath))
+	if err := sync.CreateBackupZip(paths.ClaudeDir, paths.ClaudeJSON, backupPath, publicKey); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// encryptBackups reports whether backup zips should be encrypted: always,
+// never, or (auto) when ~/.claude.json or any file matching an encrypt
+// pattern would end up in the zip
+func (e *Engine) encryptBackups() bool {
+	switch e.cfg.Backup.Encrypt {
+	case config.BackupEncryptAlways:
+		return true
+	case config.BackupEncryptNever:
+		return false
+	}
+	if sync.FileExists(e.paths.ClaudeJSON) {
+		return true
+	}
+	files, err := sync.WalkFiles(e.paths.ClaudeDir)
+	if err != nil {
+		return true
+	}
+	for _, file := range files {
+		if e.cfg.ShouldEncrypt(sync.RelPath(e.paths.ClaudeDir, file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// restore decrypts/copies the repo files into ~/.claude, along with
+// subscription files, appending to result. Files are staged first and
+// swapped in together, so an error part way through leaves ~/.claude as it
+// was.
+func (e *Engine) restore(opts PullOptions, strategy Strategy, identity *age.X25519Identity, result *PullResult) error {
+	paths := e.paths
+
+	if opts.DryRun {
+		e.log.Info("[DRY RUN] Would restore the following files:")
+	} else {
+		if err := sync.EnsureDir(paths.ClaudeDir); err != nil {
+			return err
+		}
+		if strategy == StrategyOurs {
+			e.log.Info("Pulling with --ours: keeping local files where they differ")
+		} else {
+			e.log.Info("Restoring files...")
+		}
+	}
+
+	files, err := e.platformFiles(opts.AllPlatforms)
+	if err != nil {
+		return err
+	}
+	subs, err := e.planSubscriptions(files, opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		if result.Impact, err = e.impact(files, subs, strategy, identity); err != nil {
+			return err
+		}
+	}
+	return e.applyFiles(files, subs, opts, strategy, identity, result)
+}
+
+// applyFiles stages files, and subscription files if subs isn't nil, and
+// swaps them into place together
+func (e *Engine) applyFiles(files []repoFile, subs *subscriptionPlan, opts PullOptions, strategy Strategy, identity *age.X25519Identity, result *PullResult) error {
+	var txn *pullTxn
+	var err error
+	if !opts.DryRun {
+		txn, err = newPullTxn(e.paths.SyncDir, e.paths.BackupDir)
+		if err != nil {
+			return err
+		}
+		defer txn.cleanup()
+	}
+
+	if err := e.stageFiles(files, opts, strategy, identity, txn, result); err != nil {
+		if txn != nil {
+			txn.rollback()
+		}
+		return fmt.Errorf("%w; no files were changed", err)
+	}
+	if subs != nil {
+		e.stageSubscriptions(subs, txn, result)
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	if err := txn.prepare(opts.Jobs); err != nil {
+		txn.rollback()
+		return fmt.Errorf("%w; no files were changed", err)
+	}
+	if err := e.validateStaged(txn); err != nil {
+		txn.rollback()
+		return fmt.Errorf("%w; no files were changed", err)
+	}
+	if result.Changed, err = txn.commit(); err != nil {
+		return err
+	}
+	if subs != nil {
+		if err := e.saveOverlays(subs.installed); err != nil {
+			return fmt.Errorf("failed to record subscription files: %w", err)
+		}
+	}
+	// Encrypted files are backed up before decrypting, so drop the ones
+	// that turned out to be unchanged
+	conflicts := result.Conflicts[:0]
+	for _, path := range result.Conflicts {
+		if slices.Contains(result.Changed, path) {
+			conflicts = append(conflicts, path)
+		}
+	}
+	result.Conflicts = conflicts
+	if len(txn.backups) > 0 {
+		e.log.Info(fmt.Sprintf("Replaced local files were saved to %s", filepath.Join(e.paths.BackupDir, sync.FileBackupsDir, txn.stamp)))
+		if err := sync.PruneFileBackups(e.paths.BackupDir, e.cfg.Backup.MaxCount); err != nil {
+			e.log.Warn(fmt.Sprintf("Failed to prune file backups: %v", err))
+		}
+	}
+
+	return nil
+}
+
+// stageFiles decides what happens to each repo file and stages the new
+// contents in txn (nil for a dry run). Decrypting and copying is left to
+// txn.prepare.
+func (e *Engine) stageFiles(files []repoFile, opts PullOptions, strategy Strategy, identity *age.X25519Identity, txn *pullTxn, result *PullResult) error {
+	for _, f := range files {
+		if !opts.DryRun && e.rewrites(f) {
+			if err := e.restoreRewritten(identity, f, strategy, txn, result); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.encrypted {
+			if opts.DryRun {
+				e.log.Info(fmt.Sprintf("  [decrypt] %s", f.relPath))
+				result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionDecrypt})
+				continue
+			}
+
+			localExists := sync.FileExists(f.dest)
+			if localExists && strategy == StrategyOurs {
+				e.log.Info(fmt.Sprintf("Keeping local: %s", f.relPath))
+				result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionKeep})
+				continue
+			}
+
+			if localExists {
+				backupPath, _ := txn.backupFile(f.dest, f.relPath)
+				if backupPath != "" {
+					e.log.Warn(fmt.Sprintf("Conflict: backing up %s", f.relPath))
+					result.Conflicts = append(result.Conflicts, f.relPath)
+				}
+			}
+
+			e.log.Info(fmt.Sprintf("Decrypting: %s%s", f.relPath, variantNote(f.relPath)))
+			txn.decryptFile(identity, f.src, f.dest, f.relPath, f.mode)
+			result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionDecrypt})
+			continue
+		}
+
+		if opts.DryRun {
+			e.log.Info(fmt.Sprintf("  [copy] %s", f.relPath))
+			result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionCopy})
+			continue
+		}
+
+		// Check if local exists and differs
+		localExists := sync.FileExists(f.dest)
+		var differs bool
+		if localExists {
+			srcHash, _ := e.index.Checksum(f.src)
+			dstHash, _ := e.index.Checksum(f.dest)
+			differs = srcHash != dstHash
+		}
+
+		// Same content, only the permissions changed: nothing to back up
+		if localExists && !differs && modeDiffers(f) {
+			e.log.Info(fmt.Sprintf("Updating mode: %s", f.relPath))
+			txn.copyFile(f.src, f.dest, f.relPath, f.mode)
+		}
+
+		if localExists && differs && strategy == StrategyOurs {
+			e.log.Info(fmt.Sprintf("Keeping local: %s", f.relPath))
+			result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionKeep})
+			continue
+		}
+
+		if !localExists || differs {
+			if localExists {
+				backupPath, _ := txn.backupFile(f.dest, f.relPath)
+				if backupPath != "" {
+					e.log.Warn(fmt.Sprintf("Conflict: backing up %s", f.relPath))
+					result.Conflicts = append(result.Conflicts, f.relPath)
+				}
+			}
+
+			e.log.Info(fmt.Sprintf("Copying: %s%s", f.relPath, variantNote(f.relPath)))
+			txn.copyFile(f.src, f.dest, f.relPath, f.mode)
+		}
+		result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionCopy})
+	}
+	return nil
+}
+
+// Diff updates the repo from the remote and reports files that differ from
+// ~/.claude, without applying anything. Encrypted files are decrypted in memory.
+func (e *Engine) Diff() ([]Change, error) {
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	e.pullRemote()
+	e.updateTeam()
+
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, f := range files {
+		change := Change{
+			Path:      f.relPath,
+			Encrypted: f.encrypted || f.mcpServers != "",
+			LocalPath: f.dest,
+			RepoPath:  f.src,
+		}
+
+		if !sync.FileExists(f.dest) {
+			change.Kind = ChangeNew
+			changes = append(changes, change)
+			continue
+		}
+
+		same, err := e.sameContent(identity, f)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			change.Kind = ChangeChanged
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// sameContent reports whether the local file matches the repo copy
+func (e *Engine) sameContent(identity *age.X25519Identity, f repoFile) (bool, error) {
+	if e.rewrites(f) {
+		content, err := e.incomingContent(identity, f)
+		if err != nil {
+			return false, err
+		}
+		local, err := os.ReadFile(f.dest)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(content, local) || jsonEqual(content, local) || rendersTo(local, content), nil
+	}
+	if !f.encrypted {
+		srcHash, _ := e.index.Checksum(f.src)
+		dstHash, _ := e.index.Checksum(f.dest)
+		return srcHash == dstHash, nil
+	}
+
+	ciphertext, err := os.ReadFile(f.src)
+	if err != nil {
+		return false, err
+	}
+	plaintext, err := crypto.Decrypt(identity, ciphertext)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+	}
+	local, err := os.ReadFile(f.dest)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(plaintext, local), nil
+}
+
+// loadIdentity checks pull prerequisites and loads the private key
+func (e *Engine) loadIdentity() (*age.X25519Identity, error) {
+	if !keys.Available(e.paths, e.cfg.KeyCommand) {
+		return nil, fmt.Errorf("not initialized. Run 'claude-code-sync init' or 'claude-code-sync import-key' first, or set %s", keys.EnvVar)
+	}
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init <repo-url>' first")
+	}
+
+	identity, err := keys.Load(e.paths, e.cfg.KeyCommand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key: %w", err)
+	}
+	return identity, nil
+}
+
+// ErrKeyMismatch is returned by Pull when the local key cannot decrypt the
+// repo's encrypted files
+var ErrKeyMismatch = errors.New("this key cannot decrypt this repo")
+
+// checkKey test-decrypts one encrypted repo file, so a wrong key is caught
+// before ~/.claude is backed up or touched
+func (e *Engine) checkKey(identity *age.X25519Identity) error {
+	files, err := e.repoFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.encrypted {
+			continue
+		}
+		var noMatch *age.NoIdentityMatchError
+		if err := crypto.CheckFile(identity, f.src); errors.As(err, &noMatch) {
+			return fmt.Errorf("%w: %s cannot be decrypted with %s. Import the key used on your other machines with 'claude-code-sync import-key'",
+				ErrKeyMismatch, f.relPath, identity.Recipient().String())
+		}
+		return nil
+	}
+	return nil
+}
+
+// pullRemote pulls the latest commits. Failures are reported but not fatal:
+// the cached repo contents are used instead.
+func (e *Engine) pullRemote() {
+	b, err := e.backend()
+	if err != nil {
+		e.log.Warn(fmt.Sprintf("Pull failed: %v", err))
+		return
+	}
+	if !b.HasRemote() {
+		return
+	}
+
+	e.log.Info("Pulling from remote...")
+	e.debug(fmt.Sprintf("Backend: %s", b.Name()))
+	if err := b.Pull(); err != nil {
+		e.log.Warn(fmt.Sprintf("Pull failed: %v", err))
+		e.log.Warn("You may need to resolve conflicts manually.")
+
+		// Show age of local repo when pull fails
+		if age := repoAge(b); age != "" {
+			e.log.Warn(fmt.Sprintf("Using cached files from: %s", age))
+		}
+		return
+	}
+	if err := e.fetchLFS(); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to fetch Git LFS files: %v", err))
+	}
+}
+
+// repoFiles lists the files in the repo that should be restored on this machine
+func (e *Engine) repoFiles() ([]repoFile, error) {
+	return e.platformFiles(false)
+}
+
+// platformFiles is repoFiles, with every platform's variants if
+// allPlatforms is set
+func (e *Engine) platformFiles(allPlatforms bool) ([]repoFile, error) {
+	paths := e.paths
+
+	files, err := sync.WalkFiles(paths.RepoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repo: %w", err)
+	}
+	modes := e.manifestModes()
+	tags, own := e.machineTags(), e.machineTag()
+	ownVariants := map[string]string{} // Files this machine has a variant of, to the variant
+
+	var result []repoFile
+	for _, file := range files {
+		relPath := sync.RelPath(paths.RepoDir, file)
+
+		// Skip git and manifest
+		if strings.HasPrefix(relPath, ".git") || relPath == ".sync-manifest" || relPath == "README.md" || relPath == RecipientsFile || relPath == MCPServersFile || isMachineRecord(relPath) {
+			continue
+		}
+
+		// Names are NFC in the repo; an older push may have left a
+		// decomposed one, which gives way to the NFC copy if both are there
+		basePath := strings.TrimSuffix(relPath, ".age")
+		if nfc := sync.NFC(basePath); nfc != basePath {
+			if other := filepath.Join(paths.RepoDir, sync.NFC(relPath)); sync.FileExists(other) && !sync.SameFile(file, other) {
+				continue
+			}
+			basePath = nfc
+		}
+
+		// extra_paths files were filtered on push and map outside ~/.claude
+		if isExtraPath(basePath) {
+			dest, ok := e.extraDest(filepath.ToSlash(basePath))
+			if !ok {
+				continue
+			}
+			result = append(result, repoFile{relPath: basePath, src: file, dest: dest, encrypted: strings.HasSuffix(relPath, ".age"), mode: modes[filepath.ToSlash(relPath)]})
+			continue
+		}
+
+		// A machine variant restores as the file it varies, on its machine only
+		if varies, tag, ok := sync.SplitMachineVariant(basePath, tags); ok {
+			if tag != own {
+				continue
+			}
+			ownVariants[varies] = file
+			basePath = varies
+		}
+
+		// Check base name (without .age) against exclude patterns. Team
+		// files come from the team repo, even if an old copy is left here.
+		if e.cfg.ShouldExclude(basePath) || e.cfg.IsTeamFile(basePath) {
+			continue
+		}
+
+		// Skip platform variants for other platforms
+		// e.g., on Windows, skip .unix.md files; on Unix, skip .windows.md files
+		if e.skipsVariant(basePath, allPlatforms) {
+			continue
+		}
+
+		f := repoFile{
+			relPath:   basePath,
+			src:       file,
+			encrypted: strings.HasSuffix(relPath, ".age"),
+			mode:      modes[filepath.ToSlash(relPath)],
+		}
+
+		// Special case for claude.json
+		if f.encrypted && basePath == "claude.json" {
+			if e.cfg.ClaudeJSON.Skip {
+				continue
+			}
+			f.dest = paths.ClaudeJSON
+		} else {
+			f.dest = filepath.Join(paths.ClaudeDir, sync.LocalName(paths.ClaudeDir, basePath))
+		}
+
+		result = append(result, f)
+	}
+
+	// This machine's variant wins over the file it varies and its platform
+	// variants
+	if len(ownVariants) > 0 {
+		result = slices.DeleteFunc(result, func(f repoFile) bool {
+			varies := sync.GetBaseName(f.relPath)
+			variant, ok := ownVariants[varies]
+			return ok && f.src != variant && (f.relPath == varies || sync.IsPlatformVariant(f.relPath))
+		})
+	}
+
+	// MCP servers synced on their own are merged into claude.json
+	if mcp := filepath.Join(paths.RepoDir, MCPServersFile); e.cfg.ClaudeJSON.MCPServers && sync.FileExists(mcp) {
+		i := slices.IndexFunc(result, func(f repoFile) bool { return f.dest == paths.ClaudeJSON })
+		if i >= 0 {
+			result[i].mcpServers = mcp
+		} else {
+			result = append(result, repoFile{relPath: "claude.json", src: mcp, dest: paths.ClaudeJSON, mcpServers: mcp})
+		}
+	}
+
+	team, err := e.teamFiles(allPlatforms)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, team...), nil
+}
+
+// skipsVariant reports whether relPath is another platform's variant left
+// out here. Variants restored by pull --all-platforms are kept up to date
+// by later pulls.
+func (e *Engine) skipsVariant(relPath string, allPlatforms bool) bool {
+	return sync.ShouldSkipForPlatform(relPath) && !allPlatforms && !sync.FileExists(filepath.Join(e.paths.ClaudeDir, relPath))
+}
+
+// variantNote marks another platform's variant in the log
+func variantNote(relPath string) string {
+	if sync.ShouldSkipForPlatform(relPath) {
+		return fmt.Sprintf(" (%s variant)", sync.GetPlatformSuffix(relPath))
+	}
+	return ""
+}
+
+// manifestModes returns the permissions recorded in the repo's manifest by
+// slash-separated repo path. Older manifests have none.
+func (e *Engine) manifestModes() map[string]os.FileMode {
+	modes := map[string]os.FileMode{}
+	entries, err := sync.ReadManifest(filepath.Join(e.paths.RepoDir, ".sync-manifest"))
+	if err != nil {
+		return modes
+	}
+	for _, entry := range entries {
+		if entry.Mode != 0 {
+			modes[filepath.ToSlash(entry.Path)] = entry.Mode
+		}
+	}
+	return modes
+}
+
+// modeDiffers reports whether the local file's permissions differ from the
+// ones recorded for it
+func modeDiffers(f repoFile) bool {
+	local := sync.FileMode(f.dest)
+	return f.mode != 0 && local != 0 && local != f.mode
+}
+
+// repoAge returns a human-readable string showing when the repo was last updated
+func repoAge(b backend.Backend) string {
+	lastCommit, err := b.LastUpdated()
+	if err != nil {
+		return ""
+	}
+
+	age := time.Since(lastCommit)
+
+	// Format based on age
+	if age < time.Hour {
+		return fmt.Sprintf("minutes ago (%s)", lastCommit.Format("2006-01-02 15:04"))
+	} else if age < 24*time.Hour {
+		hours := int(age.Hours())
+		return fmt.Sprintf("%d hour(s) ago (%s)", hours, lastCommit.Format("2006-01-02 15:04"))
+	} else {
+		days := int(age.Hours() / 24)
+		return fmt.Sprintf("%d day(s) ago (%s)", days, lastCommit.Format("2006-01-02"))
+	}
+}
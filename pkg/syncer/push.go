@@ -0,0 +1,632 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/keys"
+	"github.com/felixisaac/claude-code-sync/internal/secrets"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// PushOptions controls a push
+type PushOptions struct {
+	DryRun          bool // Report what would be synced without touching the repo
+	NoPlatformCheck bool // Skip platform-specific content detection
+	AllowSecrets    bool // Push even if likely secrets are found in plain-text files
+	Jobs            int  // Files encrypted/copied in parallel, 0 for one per CPU
+	PR              bool // Push to a review branch and open a pull request, as push_mode: review does
+}
+
+// PushResult describes what a push did (or would do, for a dry run)
+type PushResult struct {
+	Files            []FileAction
+	Changed          []string // Files written to the repo; unchanged ones are skipped
+	PlatformWarnings []PlatformWarning
+	Secrets          []secrets.Finding // Likely secrets in files synced as plain text
+	Committed        bool              // A sync commit was created
+	Queued           bool              // The remote was unreachable; the push will be retried later
+	Pushed           bool              // The commit was pushed to the remote
+	Rebased          bool              // Another machine pushed first; this commit was replayed on top of theirs
+	PullRequest      string            // URL of the pull request opened for review, if known
+	BackupPath       string            // Zip backup of ~/.claude taken after committing, if backup.on_push is set
+	Skipped          []string          // Files left out by max_file_size or binary_files: skip
+
+	modes     map[string]os.FileMode // Local permissions by repo path, for the manifest
+	written   []writtenFile          // Repo files written by this push
+	unchanged int                    // Files skipped because neither copy changed
+	lfs       []string               // Repo paths of binary files stored with Git LFS
+}
+
+// writtenFile is a repo file written from local content with checksum sum
+type writtenFile struct {
+	repoPath string
+	sum      string
+}
+
+// recordMode remembers the permissions of the local file behind a repo path
+func (r *PushResult) recordMode(repoPath, local string) {
+	if r.modes == nil {
+		r.modes = map[string]os.FileMode{}
+	}
+	r.modes[filepath.ToSlash(repoPath)] = sync.FileMode(local)
+}
+
+// Push encrypts/copies ~/.claude into the repo, commits, and pushes to the remote
+func (e *Engine) Push(opts PushOptions) (*PushResult, error) {
+	paths := e.paths
+	cfg := e.cfg
+
+	// Check prerequisites
+	if !keys.Available(paths, cfg.KeyCommand) {
+		return nil, fmt.Errorf("not initialized. Run 'claude-code-sync init' first, or set %s", keys.EnvVar)
+	}
+	if !sync.FileExists(paths.ClaudeDir) {
+		return nil, fmt.Errorf("no ~/.claude directory found. Nothing to sync")
+	}
+	if !opts.DryRun {
+		if err := cfg.CanPush(); err != nil {
+			return nil, err
+		}
+		l, err := e.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer l.Unlock()
+
+		e.openIndex()
+		defer e.saveIndex()
+
+		if err := e.runHook(HookPrePush, cfg.Hooks.PrePush, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+
+	result, err := e.push(opts)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.DryRun {
+		e.debug(fmt.Sprintf("Push took %s", time.Since(start).Round(time.Millisecond)))
+		e.recordPushTime(time.Since(start))
+		e.emitFiles(result.Files, result.Changed)
+		e.emit(Event{
+			Type:        EventPushComplete,
+			Files:       len(result.Changed),
+			Committed:   result.Committed,
+			Pushed:      result.Pushed,
+			Queued:      result.Queued,
+			Rebased:     result.Rebased,
+			BackupPath:  result.BackupPath,
+			PullRequest: result.PullRequest,
+		})
+		e.runPostHook(HookPostPush, cfg.Hooks.PostPush, result.Changed, map[string]string{
+			"CLAUDE_SYNC_COMMITTED": strconv.FormatBool(result.Committed),
+			"CLAUDE_SYNC_PUSHED":    strconv.FormatBool(result.Pushed),
+		})
+	}
+	return result, nil
+}
+
+// push does the work of Push once the prerequisites are checked
+func (e *Engine) push(opts PushOptions) (*PushResult, error) {
+	paths := e.paths
+	cfg := e.cfg
+
+	// Get public key
+	identity, err := keys.Load(paths, cfg.KeyCommand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+	pubKey := identity.Recipient().String()
+	if e.repoKeys, err = e.RepoRecipients(); err != nil {
+		return nil, err
+	}
+	if err := e.checkRecipients(opts.DryRun); err != nil {
+		return nil, err
+	}
+	if cfg.UsesLFS() && !opts.DryRun {
+		if _, err := e.lfsRepo(); err != nil {
+			return nil, fmt.Errorf("can't store files with Git LFS: %w", err)
+		}
+	}
+	e.index.UseKey(e.recipientsID(pubKey))
+
+	if opts.DryRun {
+		e.log.Info("[DRY RUN] Would sync the following files:")
+	} else {
+		e.log.Info("Syncing files to repo...")
+	}
+
+	// Mirrored from the other side of WSL first, so its changes go too
+	if side := e.wslSideFor(config.WSLMirror); side != nil && !opts.DryRun {
+		if err := e.mirrorWSL(side); err != nil {
+			e.log.Warn(fmt.Sprintf("Failed to mirror %s: %v", side.Dir, err))
+		}
+	}
+
+	// Process ~/.claude directory
+	files, err := sync.WalkFiles(paths.ClaudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk claude dir: %w", err)
+	}
+	// Files installed from subscriptions belong to those repos
+	files = e.withoutOverlays(files)
+	extras, err := e.extraFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PushResult{}
+
+	// Refuse to copy credentials into the repo in plain text
+	if err := e.checkSecrets(files, extras, opts, result); err != nil {
+		return nil, err
+	}
+
+	// Files are picked and logged in order; the encrypting and copying runs
+	// in parallel afterwards
+	var tasks []func() error
+	var teamFiles []string
+	pushed := map[string]string{} // Names in the repo, to the local file
+	for _, file := range files {
+		// claude.json lives inside the Claude dir when CLAUDE_CONFIG_DIR is
+		// set; it is always synced (encrypted) on its own below
+		if file == paths.ClaudeJSON {
+			continue
+		}
+		// Names go to the repo in NFC, however this filesystem spells them
+		localPath := sync.RelPath(paths.ClaudeDir, file)
+		relPath := sync.NFC(localPath)
+		if other, ok := pushed[relPath]; ok {
+			e.log.Warn(fmt.Sprintf("Skipping %s: same name as %s once normalized", localPath, other))
+			continue
+		}
+		pushed[relPath] = localPath
+
+		// Skip excluded files
+		if cfg.ShouldExclude(relPath) {
+			e.debug(fmt.Sprintf("Excluded: %s", relPath))
+			continue
+		}
+		if cfg.IsTeamFile(relPath) {
+			teamFiles = append(teamFiles, file)
+			continue
+		}
+
+		// Too large, or binary with binary_files set
+		skip, lfs := e.pushPolicy(file, relPath)
+		if skip {
+			result.Skipped = append(result.Skipped, relPath)
+			continue
+		}
+
+		// This machine's variant of the file, if it has one
+		repoPath := e.pushPath(relPath)
+		dest := filepath.Join(paths.RepoDir, repoPath)
+		if lfs && cfg.ShouldEncrypt(relPath) {
+			result.lfs = append(result.lfs, repoPath+".age")
+		} else if lfs {
+			result.lfs = append(result.lfs, repoPath)
+		}
+
+		if cfg.ShouldEncrypt(relPath) {
+			if opts.DryRun {
+				e.log.Info(fmt.Sprintf("  [encrypt] %s", relPath))
+			} else if sum, unchanged := e.pushedUnchanged(file, relPath, repoPath+".age", result); !unchanged {
+				e.log.Info(fmt.Sprintf("Encrypting: %s%s%s", relPath, variantNote(relPath), machineNote(relPath, repoPath)))
+				tasks = append(tasks, func() error {
+					if err := sync.EnsureDir(filepath.Dir(dest + ".age")); err != nil {
+						return err
+					}
+					if err := e.encryptRedacted(e.recipients(pubKey, relPath), file, relPath, dest+".age"); err != nil {
+						return fmt.Errorf("failed to encrypt %s: %w", relPath, err)
+					}
+					return nil
+				})
+				result.wrote(repoPath+".age", sum)
+				result.Changed = append(result.Changed, relPath)
+			}
+			result.recordMode(repoPath+".age", file)
+			result.Files = append(result.Files, FileAction{Path: relPath, Action: ActionEncrypt})
+		} else {
+			if opts.DryRun {
+				e.log.Info(fmt.Sprintf("  [copy] %s", relPath))
+			} else if sum, unchanged := e.pushedUnchanged(file, relPath, repoPath, result); !unchanged {
+				e.log.Info(fmt.Sprintf("Copying: %s%s%s", relPath, variantNote(relPath), machineNote(relPath, repoPath)))
+				tasks = append(tasks, func() error {
+					if err := e.copyRedacted(file, relPath, dest); err != nil {
+						return fmt.Errorf("failed to copy %s: %w", relPath, err)
+					}
+					return nil
+				})
+				result.wrote(repoPath, sum)
+				result.Changed = append(result.Changed, relPath)
+			}
+			result.recordMode(repoPath, file)
+			result.Files = append(result.Files, FileAction{Path: relPath, Action: ActionCopy})
+		}
+		if localPath != relPath && !opts.DryRun {
+			if err := e.dropDecomposed(localPath, relPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := runJobs(opts.Jobs, tasks); err != nil {
+		return nil, err
+	}
+
+	// Shared files go to the team repo
+	if err := e.pushTeam(teamFiles, opts, result); err != nil {
+		return nil, err
+	}
+
+	// Files outside ~/.claude listed in extra_paths
+	if err := e.pushExtraPaths(extras, pubKey, opts, result); err != nil {
+		return nil, err
+	}
+
+	// The other side of WSL, as its platform's variants
+	if side := e.wslSideFor(config.WSLVariants); side != nil {
+		if err := e.pushWSLVariants(side, identity, pubKey, opts, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Also sync ~/.claude.json if it exists
+	if cfg.ClaudeJSON.Skip || !cfg.Included("claude.json") {
+		if err := e.dropClaudeJSON(opts, result); err != nil {
+			return nil, err
+		}
+	} else if sync.FileExists(paths.ClaudeJSON) {
+		dest := filepath.Join(paths.RepoDir, "claude.json.age")
+		if opts.DryRun {
+			e.log.Info("  [encrypt] ~/.claude.json")
+		} else {
+			data, err := e.claudeJSONContent()
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt claude.json: %w", err)
+			}
+			// Tracked by the content pushed, so changes to machine-local
+			// state alone don't re-encrypt it
+			recipients := e.recipients(pubKey, "claude.json")
+			sum := sync.Checksum([]byte(strings.Join(recipients, "\n") + "\n" + string(data)))
+			if e.index.Unchanged("claude.json.age", dest, sum) {
+				result.unchanged++
+			} else if e.index.PushedFrom("claude.json.age") != "" && holdsClaudeJSON(identity, dest, data) {
+				// Same content, e.g. as pulled: new ciphertext would only
+				// make a commit with nothing in it. Only while the
+				// recipients are the ones this machine last pushed it to:
+				// changing them empties the index, and the file has to be
+				// encrypted to the new ones.
+				result.unchanged++
+				result.wrote("claude.json.age", sum)
+			} else {
+				e.log.Info("Encrypting: claude.json")
+				ciphertext, err := crypto.EncryptTo(recipients, data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encrypt claude.json: %w", err)
+				}
+				if err := os.WriteFile(dest, ciphertext, 0644); err != nil {
+					return nil, fmt.Errorf("failed to encrypt claude.json: %w", err)
+				}
+				result.Changed = append(result.Changed, "claude.json")
+				result.wrote("claude.json.age", sum)
+			}
+		}
+		result.recordMode("claude.json.age", paths.ClaudeJSON)
+		result.Files = append(result.Files, FileAction{Path: "claude.json", Action: ActionEncrypt})
+	}
+	if cfg.ClaudeJSON.MCPServers && sync.FileExists(paths.ClaudeJSON) {
+		if err := e.pushMCPServers(identity, pubKey, opts, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if result.unchanged > 0 {
+		e.log.Info(fmt.Sprintf("%d files unchanged since the last push.", result.unchanged))
+	}
+
+	// Remember what the repo files were written from, so the next push can
+	// skip them
+	for _, w := range result.written {
+		e.index.SetPushed(w.repoPath, filepath.Join(paths.RepoDir, filepath.FromSlash(w.repoPath)), w.sum)
+	}
+
+	// Binary files and large encrypted ones stored with Git LFS
+	if cfg.UsesLFS() {
+		if err := e.trackLFS(append(result.lfs, e.largeEncrypted()...)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check for platform-specific content without variants
+	if !opts.NoPlatformCheck {
+		repoFiles, err := sync.WalkFiles(paths.RepoDir)
+		if err == nil {
+			result.PlatformWarnings = slices.DeleteFunc(sync.CheckPlatformVariants(paths.RepoDir, repoFiles), e.expandedOnPull)
+		}
+	}
+
+	// Pull skips files excluded since they were pushed, but they stay in
+	// the repo until cleaned
+	if excluded, err := e.excludedRepoFiles(); err == nil && len(excluded) > 0 {
+		e.log.Warn(fmt.Sprintf("%d files in the repo are excluded now. Run 'claude-code-sync clean' to remove them.", len(excluded)))
+	}
+
+	// Let other machines see when this one last synced
+	if err := e.writeMachineRecord(); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to write machine record: %v", err))
+	}
+
+	e.log.Info("Generating manifest...")
+	if err := e.writeManifest(result.modes); err != nil {
+		return nil, err
+	}
+
+	// Commit and push
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+
+	e.log.Info("Committing changes...")
+	message := syncMessage()
+	committed, err := b.Commit(message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commits queued while offline still go out even if nothing new changed
+	if !committed && e.PendingPush() == nil {
+		e.log.Info("No changes to commit.")
+		return result, nil
+	}
+	result.Committed = committed
+
+	// Snapshot what was just pushed so it can be restored exactly
+	if committed && cfg.Backup.OnPush {
+		if result.BackupPath, err = e.backupCurrent(); err != nil {
+			e.log.Warn(fmt.Sprintf("Backup failed: %v", err))
+		}
+	}
+
+	if b.HasRemote() && e.reviewing(opts) {
+		url, err := e.pushReview(b, message, result.Changed)
+		if err != nil {
+			if !backend.IsOffline(err) {
+				return nil, err
+			}
+			e.queueReview(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			e.log.Warn("Changes are committed locally and will be pushed for review by the next command that can reach the remote.")
+			return result, nil
+		}
+		e.clearPending()
+		result.Pushed = true
+		result.PullRequest = url
+	} else if b.HasRemote() {
+		e.log.Info("Pushing to remote...")
+		rebased, err := e.pushRemote(b, message, result.modes)
+		result.Rebased = rebased
+		if err != nil {
+			if !backend.IsOffline(err) {
+				return nil, err
+			}
+			e.queuePush(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			e.log.Warn("Changes are committed locally and will be pushed by the next command that can reach the remote.")
+			return result, nil
+		}
+		e.clearPending()
+		result.Pushed = true
+		e.log.Success(fmt.Sprintf("Pushed %d files to remote.", len(result.Files)))
+	} else {
+		e.log.Warn("No remote configured. Changes committed locally only.")
+		e.log.Info(fmt.Sprintf("Add a remote with: git -C %s remote add origin <url>", paths.RepoDir))
+	}
+
+	return result, nil
+}
+
+// writeManifest regenerates .sync-manifest from the repo contents
+func (e *Engine) writeManifest(modes map[string]os.FileMode) error {
+	entries, err := sync.GenerateManifest(e.paths.RepoDir, modes, e.index)
+	if err != nil {
+		return fmt.Errorf("failed to generate manifest: %w", err)
+	}
+	if err := sync.WriteManifest(filepath.Join(e.paths.RepoDir, ".sync-manifest"), entries); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// maxPushAttempts is how often a push rejected by newer remote commits is
+// rebased and tried again
+const maxPushAttempts = 3
+
+// pushRemote pushes the sync commit. If another machine pushed first, the
+// commit is rebased onto theirs, the manifest is regenerated and the push is
+// retried. If both changed the same file, nothing is pushed and the user
+// picks a version with pull. Reports whether a rebase was needed.
+func (e *Engine) pushRemote(b backend.Backend, message string, modes map[string]os.FileMode) (bool, error) {
+	rebaser, canRebase := b.(backend.Rebaser)
+	for attempt := 1; ; attempt++ {
+		err := b.Push()
+		if err == nil {
+			if attempt > 1 {
+				e.log.Warn("The remote had changes from another machine. Run 'claude-code-sync pull' to apply them here.")
+			}
+			return attempt > 1, nil
+		}
+		if !errors.Is(err, gitpkg.ErrRejected) || !canRebase || attempt == maxPushAttempts {
+			return attempt > 1, err
+		}
+
+		e.log.Warn("Push rejected: another machine pushed first. Rebasing and retrying...")
+		if err := rebaser.Rebase(); err != nil {
+			var conflict *gitpkg.ConflictError
+			if errors.As(err, &conflict) {
+				return true, fmt.Errorf("another machine also changed %s, so nothing was pushed\nRun 'claude-code-sync pull' to take its version (yours is backed up first) or 'claude-code-sync pull --ours' to keep yours, then push again", strings.Join(conflict.Paths, ", "))
+			}
+			return true, fmt.Errorf("rebase failed: %w\nRun 'claude-code-sync pull', then push again", err)
+		}
+		if err := e.writeManifest(modes); err != nil {
+			return true, err
+		}
+		if _, err := b.Commit(message); err != nil {
+			return true, err
+		}
+	}
+}
+
+// wrote records a repo file written from local content with checksum sum
+func (r *PushResult) wrote(repoPath, sum string) {
+	if sum != "" {
+		r.written = append(r.written, writtenFile{repoPath: repoPath, sum: sum})
+	}
+}
+
+// pushedUnchanged reports whether local was pushed to repoPath before and
+// neither copy has changed since, so it needn't be encrypted or copied
+// again. It also returns local's checksum, or for a file rewritten on its
+// way into the repo the checksum of what is pushed, so that reformatting it
+// or changing a redacted key alone isn't a change.
+func (e *Engine) pushedUnchanged(local, relPath, repoPath string, result *PushResult) (string, bool) {
+	var sum string
+	if e.transforms(relPath) {
+		data, err := e.readRedacted(local, relPath)
+		if err != nil {
+			return "", false
+		}
+		sum = sync.Checksum(data)
+	} else {
+		var err error
+		if sum, err = e.index.Checksum(local); err != nil {
+			return "", false
+		}
+	}
+	if !e.index.Unchanged(repoPath, filepath.Join(e.paths.RepoDir, filepath.FromSlash(repoPath)), sum) {
+		return sum, false
+	}
+	result.unchanged++
+	return sum, true
+}
+
+// claudeJSONContent returns ~/.claude.json as pushed: limited to the keys
+// selected in claude_json if set, with redacted keys and machine-local state
+// removed, and formatted the same way every time
+func (e *Engine) claudeJSONContent() ([]byte, error) {
+	data, err := e.readRedacted(e.paths.ClaudeJSON, "claude.json")
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.ClaudeJSON.Filtered() {
+		return e.claudeJSONSubset(data)
+	}
+	// Canonical form, so Claude Code reordering keys isn't a change
+	return canonicalJSON(data), nil
+}
+
+// dropClaudeJSON removes claude.json.age from the repo once claude_json.skip
+// is set or include_patterns leaves it out, so other machines stop restoring
+// it
+func (e *Engine) dropClaudeJSON(opts PushOptions, result *PushResult) error {
+	dest := filepath.Join(e.paths.RepoDir, "claude.json.age")
+	if !sync.FileExists(dest) {
+		return nil
+	}
+	reason := "claude_json.skip is set"
+	if !e.cfg.ClaudeJSON.Skip {
+		reason = "include_patterns leaves it out"
+	}
+	if opts.DryRun {
+		e.log.Info(fmt.Sprintf("  [remove] claude.json (%s)", reason))
+		return nil
+	}
+	e.log.Info(fmt.Sprintf("Removing claude.json from the repo (%s)", reason))
+	if err := os.Remove(dest); err != nil {
+		return fmt.Errorf("failed to remove claude.json.age: %w", err)
+	}
+	result.Changed = append(result.Changed, "claude.json")
+	return nil
+}
+
+// dropDecomposed removes the copy of relPath an older push left in the repo
+// under localPath, its decomposed name, now that it goes there in NFC.
+// Where the filesystem doesn't tell the two apart, as on macOS, they are
+// the same file and it is left alone.
+func (e *Engine) dropDecomposed(localPath, relPath string) error {
+	for _, ext := range []string{"", ".age"} {
+		old, current := filepath.Join(e.paths.RepoDir, localPath+ext), filepath.Join(e.paths.RepoDir, relPath+ext)
+		if !sync.FileExists(old) || sync.SameFile(old, current) {
+			continue
+		}
+		e.debug(fmt.Sprintf("Removing %s from the repo: pushed as %s", localPath+ext, relPath+ext))
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holdsClaudeJSON reports whether the repo's encrypted claude.json already
+// decrypts to data, ignoring formatting and key order
+func holdsClaudeJSON(identity *age.X25519Identity, dest string, data []byte) bool {
+	ciphertext, err := os.ReadFile(dest)
+	if err != nil {
+		return false
+	}
+	plaintext, err := crypto.Decrypt(identity, ciphertext)
+	return err == nil && jsonEqual(plaintext, data)
+}
+
+// encryptRedacted encrypts a file into the repo with the redact rules applied
+func (e *Engine) encryptRedacted(recipients []string, src, relPath, dest string) error {
+	if !e.transforms(relPath) {
+		return crypto.EncryptFileTo(recipients, src, dest)
+	}
+	data, err := e.readRedacted(src, relPath)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := crypto.EncryptTo(recipients, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, ciphertext, 0644)
+}
+
+// copyRedacted copies a file into the repo with the redact rules applied
+func (e *Engine) copyRedacted(src, relPath, dest string) error {
+	if !e.transforms(relPath) {
+		return sync.CopyFile(src, dest)
+	}
+	data, err := e.readRedacted(src, relPath)
+	if err != nil {
+		return err
+	}
+	if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0644)
+}
@@ -0,0 +1,55 @@
+package syncer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+func TestPushReencryptsClaudeJSONForNewRecipients(t *testing.T) {
+	e := newTestEngine(t, "exclude_patterns: [todos]\n", map[string]string{
+		".claude/CLAUDE.md": "hi",
+		".claude.json":      `{"theme": "dark"}`,
+	})
+	identity, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crypto.SaveKey(identity, e.paths.KeyFile); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(e.paths.RepoDir, "claude.json.age")
+	push := func() []byte {
+		t.Helper()
+		if _, err := e.Push(PushOptions{NoPlatformCheck: true}); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+		data, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := push()
+	if again := push(); !bytes.Equal(again, first) {
+		t.Error("unchanged claude.json was encrypted again")
+	}
+
+	teammate, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.cfg.Recipients = []config.RecipientRule{{Pattern: "claude.json", Keys: []string{teammate.Recipient().String()}}}
+	rewritten := push()
+	if bytes.Equal(rewritten, first) {
+		t.Fatal("claude.json was not encrypted to the new recipients")
+	}
+	if _, err := crypto.Decrypt(teammate, rewritten); err != nil {
+		t.Errorf("new recipient can't decrypt claude.json: %v", err)
+	}
+}
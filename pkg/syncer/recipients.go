@@ -0,0 +1,147 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// RecipientsFile lists, in the repo, the public keys every encrypted file is
+// encrypted to, so a team adds a member by committing one line
+const RecipientsFile = ".recipients"
+
+// ErrNewRecipients is returned by push and reencrypt when .recipients lists
+// keys this machine hasn't accepted
+var ErrNewRecipients = errors.New("the repo's .recipients lists keys not accepted on this machine")
+
+// RepoRecipients returns the public keys in the repo's .recipients file, or
+// nil if there is none
+func (e *Engine) RepoRecipients() ([]string, error) {
+	keys, err := crypto.LoadRecipients(filepath.Join(e.paths.RepoDir, RecipientsFile))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", RecipientsFile, err)
+	}
+	return keys, nil
+}
+
+// recipients returns the public keys a file is encrypted to: this machine's,
+// plus those of the recipients rule matching relPath or, without one, those
+// in .recipients
+func (e *Engine) recipients(pubKey, relPath string) []string {
+	extra := e.cfg.RecipientsFor(relPath)
+	if extra == nil {
+		extra = e.repoKeys
+	}
+	keys := []string{pubKey}
+	for _, key := range extra {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// recipientsID identifies this machine's key together with the recipients
+// rules and .recipients, so changing any of them re-encrypts every file on
+// the next push
+func (e *Engine) recipientsID(pubKey string) string {
+	if len(e.cfg.Recipients) == 0 && len(e.repoKeys) == 0 {
+		return pubKey
+	}
+	h := sha256.New()
+	for _, rule := range e.cfg.Recipients {
+		fmt.Fprintf(h, "%s=%s\n", rule.Pattern, strings.Join(rule.Keys, ","))
+	}
+	fmt.Fprintf(h, "%s=%s\n", RecipientsFile, strings.Join(e.repoKeys, ","))
+	return fmt.Sprintf("%s+%x", pubKey, h.Sum(nil)[:8])
+}
+
+// UnacceptedRecipients returns the keys in .recipients that this machine
+// hasn't accepted. Before the first push nothing has been accepted, and the
+// keys found then are trusted as they are.
+func (e *Engine) UnacceptedRecipients() ([]string, error) {
+	keys, err := e.RepoRecipients()
+	if err != nil {
+		return nil, err
+	}
+	added, _, err := e.newRecipients(keys)
+	return added, err
+}
+
+// AcceptRecipients records the keys in .recipients as accepted, so push
+// encrypts to them
+func (e *Engine) AcceptRecipients() error {
+	keys, err := e.RepoRecipients()
+	if err != nil {
+		return err
+	}
+	return e.saveAccepted(keys)
+}
+
+// newRecipients returns the keys not in the accepted set, and whether any
+// accepted key is gone. Without an accepted set, none are new.
+func (e *Engine) newRecipients(keys []string) ([]string, bool, error) {
+	if !sync.FileExists(e.paths.AcceptedFile) {
+		return nil, true, nil
+	}
+	accepted, err := crypto.LoadRecipients(e.paths.AcceptedFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", e.paths.AcceptedFile, err)
+	}
+	var added []string
+	for _, key := range keys {
+		if !slices.Contains(accepted, key) {
+			added = append(added, key)
+		}
+	}
+	removed := false
+	for _, key := range accepted {
+		if !slices.Contains(keys, key) {
+			removed = true
+		}
+	}
+	return added, removed, nil
+}
+
+// checkRecipients compares e.repoKeys with the keys accepted on this
+// machine. Anyone who can commit to the repo can add a line to
+// .recipients, and every file pushed afterwards could be read with that
+// key, so a new key stops the push until it is accepted here. Dry runs only
+// warn.
+func (e *Engine) checkRecipients(dryRun bool) error {
+	added, changed, err := e.newRecipients(e.repoKeys)
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		if changed && !dryRun {
+			return e.saveAccepted(e.repoKeys)
+		}
+		return nil
+	}
+	if dryRun {
+		e.log.Warn(fmt.Sprintf("%s lists keys not accepted on this machine: %s", RecipientsFile, strings.Join(added, ", ")))
+		return nil
+	}
+	return fmt.Errorf("%w: %s\nIf you expected them, run again with --accept-recipients", ErrNewRecipients, strings.Join(added, ", "))
+}
+
+// saveAccepted records keys as the accepted .recipients
+func (e *Engine) saveAccepted(keys []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Keys from the repo's %s accepted on this machine\n", RecipientsFile)
+	for _, key := range keys {
+		b.WriteString(key + "\n")
+	}
+	if err := os.WriteFile(e.paths.AcceptedFile, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to record accepted recipients: %w", err)
+	}
+	return nil
+}
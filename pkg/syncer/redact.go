@@ -0,0 +1,315 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// redactPaths returns the key paths stripped from a JSON file before push.
+// Rules without a "file:" prefix apply to every JSON file. claude.json also
+// loses its machine-local state.
+func (e *Engine) redactPaths(relPath string) [][]string {
+	relPath = filepath.ToSlash(relPath)
+	if !strings.HasSuffix(relPath, ".json") {
+		return nil
+	}
+
+	var paths [][]string
+	for _, rule := range e.cfg.Redact {
+		if file, key, ok := strings.Cut(rule, ":"); ok {
+			if file != relPath {
+				continue
+			}
+			rule = key
+		}
+		paths = append(paths, strings.Split(rule, "."))
+	}
+	if relPath == "claude.json" {
+		for _, key := range e.cfg.ClaudeJSON.MachineLocal() {
+			paths = append(paths, strings.Split(key, "."))
+		}
+		// Synced on its own in MCPServersFile
+		if e.cfg.ClaudeJSON.MCPServers {
+			paths = append(paths, []string{"mcpServers"})
+		}
+	}
+	return paths
+}
+
+// transforms reports whether a file is rewritten on its way into the repo:
+// a JSON file with redacted keys, one pushed in canonical form, one holding
+// placeholders, one rendered from a template in the repo, or any file when
+// eol is set
+func (e *Engine) transforms(relPath string) bool {
+	if ok, _ := e.placeholderFile(relPath); ok || e.cfg.EOL != "" {
+		return true
+	}
+	return len(e.redactPaths(relPath)) > 0 || e.cfg.ShouldCanonicalize(relPath) || e.repoTemplate(relPath) != nil
+}
+
+// maxTemplateSize is the largest repo file checked for platform blocks
+const maxTemplateSize = 1 << 20
+
+// repoTemplate returns the repo's plain copy of relPath (this machine's
+// variant of it, if any) if it has platform blocks, which pull rendered for
+// this platform
+func (e *Engine) repoTemplate(relPath string) []byte {
+	path := filepath.Join(e.paths.RepoDir, e.pushPath(relPath))
+	if info, err := os.Stat(path); err != nil || info.Size() > maxTemplateSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !sync.IsTemplate(data) {
+		return nil
+	}
+	return data
+}
+
+// readRedacted reads a file for pushing with the redact rules applied, in
+// canonical form if canonical_json matches it, and with this machine's
+// paths replaced by placeholders. A file rendered from a template has its
+// edits carried back into the template. Text files get LF line endings when
+// eol is set.
+func (e *Engine) readRedacted(path, relPath string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.EOL != "" {
+		data = sync.ToLF(data)
+	}
+	data = e.normalizePlaceholders(data, relPath)
+	if template := e.repoTemplate(relPath); template != nil {
+		var ok bool
+		if data, ok = sync.Retemplate(template, data, sync.GetPlatform()); !ok {
+			e.log.Warn(fmt.Sprintf("%s is too long to keep its platform blocks; pushing it as it is", relPath))
+		}
+	}
+	if paths := e.redactPaths(relPath); len(paths) > 0 {
+		data, _ = redactJSON(data, paths)
+	}
+	if e.cfg.ShouldCanonicalize(relPath) {
+		data = canonicalJSON(data)
+	}
+	return data, nil
+}
+
+// canonicalJSON formats a JSON document with sorted keys and two-space
+// indent. Data that isn't JSON is returned unchanged.
+func canonicalJSON(data []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return data
+	}
+	out, err := marshalJSON(doc)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// redactJSON removes the given key paths. Data that isn't a JSON object, or
+// has none of the keys, is returned unchanged.
+func redactJSON(data []byte, paths [][]string) ([]byte, bool) {
+	doc, ok := decodeObject(data)
+	if !ok {
+		return data, false
+	}
+	removed := false
+	for _, path := range paths {
+		if deletePath(doc, path) {
+			removed = true
+		}
+	}
+	if !removed {
+		return data, false
+	}
+	out, err := marshalJSON(doc)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+// keepRedacted puts the local values of redacted keys back into content
+// arriving from the repo, so pull never erases them
+func keepRedacted(content, local []byte, paths [][]string) []byte {
+	in, ok := decodeObject(content)
+	if !ok {
+		return content
+	}
+	loc, ok := decodeObject(local)
+	if !ok {
+		return content
+	}
+	for _, path := range paths {
+		if value, found := lookupPath(loc, path); found {
+			setPath(in, path, value)
+		}
+	}
+	out, err := marshalJSON(in)
+	if err != nil {
+		return content
+	}
+	return out
+}
+
+func decodeObject(data []byte) (map[string]interface{}, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil || doc == nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+func deletePath(doc map[string]interface{}, path []string) bool {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		doc = next
+	}
+	last := path[len(path)-1]
+	if _, ok := doc[last]; !ok {
+		return false
+	}
+	delete(doc, last)
+	return true
+}
+
+func lookupPath(doc map[string]interface{}, path []string) (interface{}, bool) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	value, ok := doc[path[len(path)-1]]
+	return value, ok
+}
+
+func setPath(doc map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			doc[key] = next
+		}
+		doc = next
+	}
+	doc[path[len(path)-1]] = value
+}
+
+// rewrites reports whether restoring f needs more than a decrypt or copy:
+// claude.json merged by key, a JSON file with redacted keys to keep, or one
+// in canonical form that may only differ from the local copy in formatting
+func (e *Engine) rewrites(f repoFile) bool {
+	return e.mergesClaudeJSON(f) || e.transforms(f.relPath) || f.mcpServers != ""
+}
+
+// incomingContent returns what the local copy of f should contain after a
+// pull: the repo content, rendered for this platform if it is a template,
+// with placeholders expanded, merged into claude.json if it is synced by key, with local values of
+// redacted keys put back, and with this machine's line endings if eol is set
+func (e *Engine) incomingContent(identity *age.X25519Identity, f repoFile) ([]byte, error) {
+	local, err := os.ReadFile(f.dest)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// Only the MCP servers are synced: the rest of claude.json stays as it is
+	if f.src == f.mcpServers {
+		return e.mergeMCPServers(identity, f.mcpServers, local)
+	}
+
+	content, err := os.ReadFile(f.src)
+	if err != nil {
+		return nil, err
+	}
+	if f.encrypted {
+		if content, err = crypto.Decrypt(identity, content); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+		}
+	} else {
+		content = sync.RenderTemplate(content, sync.GetPlatform())
+	}
+	content = e.expandPlaceholders(content, f.relPath)
+
+	if e.mergesClaudeJSON(f) {
+		if content, err = e.mergeClaudeJSON(local, content); err != nil {
+			return nil, err
+		}
+	}
+	if paths := e.redactPaths(f.relPath); len(paths) > 0 && local != nil {
+		content = keepRedacted(content, local, paths)
+	}
+	if f.mcpServers != "" {
+		return e.mergeMCPServers(identity, f.mcpServers, content)
+	}
+	// extra_paths files are pushed as they are, so keep theirs
+	if f.dest == e.paths.ClaudeJSON || isExtraPath(f.relPath) {
+		return content, nil
+	}
+	return sync.ConvertEOL(content, e.cfg.LineEnding()), nil
+}
+
+// rendersTo reports whether local is a template that renders to content on
+// this platform, as on the machine it was written on
+func rendersTo(local, content []byte) bool {
+	return sync.IsTemplate(local) && bytes.Equal(sync.RenderTemplate(local, sync.GetPlatform()), content)
+}
+
+// restoreRewritten stages incomingContent for f, with the same conflict
+// handling as a plain restore
+func (e *Engine) restoreRewritten(identity *age.X25519Identity, f repoFile, strategy Strategy, txn *pullTxn, result *PullResult) error {
+	content, err := e.incomingContent(identity, f)
+	if err != nil {
+		return err
+	}
+
+	action, verb, perm := ActionCopy, "Copying", os.FileMode(0644)
+	if f.encrypted || f.mcpServers != "" {
+		action, verb, perm = ActionDecrypt, "Decrypting", 0600
+	}
+	if e.mergesClaudeJSON(f) || f.mcpServers != "" {
+		verb = "Merging"
+	}
+
+	local, err := os.ReadFile(f.dest)
+	localExists := err == nil
+	if localExists && (bytes.Equal(content, local) || jsonEqual(content, local) || rendersTo(local, content)) && !modeDiffers(f) {
+		result.Files = append(result.Files, FileAction{Path: f.relPath, Action: action})
+		return nil
+	}
+	if localExists && strategy == StrategyOurs {
+		e.log.Info(fmt.Sprintf("Keeping local: %s", f.relPath))
+		result.Files = append(result.Files, FileAction{Path: f.relPath, Action: ActionKeep})
+		return nil
+	}
+
+	if localExists {
+		if backupPath, _ := txn.backupFile(f.dest, f.relPath); backupPath != "" {
+			e.log.Warn(fmt.Sprintf("Conflict: backing up %s", f.relPath))
+			result.Conflicts = append(result.Conflicts, f.relPath)
+		}
+	}
+	e.log.Info(fmt.Sprintf("%s: %s", verb, f.relPath))
+	if err := txn.writeFile(f.dest, f.relPath, content, perm, f.mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.relPath, err)
+	}
+	result.Files = append(result.Files, FileAction{Path: f.relPath, Action: action})
+	return nil
+}
@@ -0,0 +1,83 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRedactRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		relPath string
+		local   string
+		pushed  string // The repo's copy, with the redacted keys gone
+		pulled  string // The local file after another machine changed theme
+	}{
+		{
+			name:    "every file",
+			config:  "redact: [env.API_KEY]\n",
+			relPath: "settings.json",
+			local:   `{"env": {"API_KEY": "secret", "DEBUG": "1"}, "theme": "dark"}`,
+			pushed:  `{"env": {"DEBUG": "1"}, "theme": "dark"}`,
+			pulled:  `{"env": {"API_KEY": "secret", "DEBUG": "1"}, "theme": "light"}`,
+		},
+		{
+			name:    "one file",
+			config:  "redact: [\"settings.json:token\"]\n",
+			relPath: "settings.json",
+			local:   `{"token": "secret", "theme": "dark"}`,
+			pushed:  `{"theme": "dark"}`,
+			pulled:  `{"token": "secret", "theme": "light"}`,
+		},
+		{
+			name:    "other file",
+			config:  "redact: [\"settings.json:token\"]\n",
+			relPath: "keybindings.json",
+			local:   `{"token": "kept", "theme": "dark"}`,
+			pushed:  `{"token": "kept", "theme": "dark"}`,
+			pulled:  `{"token": "kept", "theme": "light"}`,
+		},
+		{
+			name:    "key missing",
+			config:  "redact: [env.API_KEY]\n",
+			relPath: "settings.json",
+			local:   `{"theme": "dark"}`,
+			pushed:  `{"theme": "dark"}`,
+			pulled:  `{"theme": "light"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, tt.config, map[string]string{".claude/" + tt.relPath: tt.local})
+			path := filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(tt.relPath))
+
+			pushed, err := e.readRedacted(path, tt.relPath)
+			if err != nil {
+				t.Fatalf("readRedacted: %v", err)
+			}
+			assertJSON(t, "pushed", pushed, tt.pushed)
+
+			incoming := bytes.Replace(pushed, []byte(`"dark"`), []byte(`"light"`), 1)
+			pulled := keepRedacted(incoming, []byte(tt.local), e.redactPaths(tt.relPath))
+			assertJSON(t, "pulled", pulled, tt.pulled)
+		})
+	}
+}
+
+func assertJSON(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("%s isn't JSON: %v\n%s", name, err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// ReencryptResult describes a re-encryption
+type ReencryptResult struct {
+	Files       []string // Repo files rewritten for the current recipients
+	Skipped     []string // Encrypted to other keys only; left as they are
+	Committed   bool
+	Pushed      bool
+	Queued      bool   // Committed, but the remote was unreachable
+	PullRequest string // With push_mode: review, the pull request opened, if known
+}
+
+// Reencrypt rewrites every encrypted file in the repo for the current
+// recipients (this machine's key, the recipients rules and .recipients) and
+// pushes the result as one commit. Files this machine's key cannot decrypt
+// are skipped. With dryRun nothing is pulled, written or pushed.
+func (e *Engine) Reencrypt(dryRun bool) (*ReencryptResult, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init <repo-url>' first")
+	}
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := e.cfg.CanPush(); err != nil {
+			return nil, err
+		}
+		l, err := e.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer l.Unlock()
+
+		e.openIndex()
+		defer e.saveIndex()
+
+		// Start from the latest commit so no file is missed or conflicts
+		e.pullRemote()
+	}
+
+	if e.repoKeys, err = e.RepoRecipients(); err != nil {
+		return nil, err
+	}
+	if err := e.checkRecipients(dryRun); err != nil {
+		return nil, err
+	}
+	pubKey := identity.Recipient().String()
+
+	files, err := sync.WalkFiles(e.paths.RepoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repo: %w", err)
+	}
+
+	// Files last pushed from this machine stay recorded as such, so the next
+	// push doesn't encrypt them all over again
+	pushedFrom := map[string]string{}
+	for _, file := range files {
+		repoPath := filepath.ToSlash(sync.RelPath(e.paths.RepoDir, file))
+		if local := e.index.PushedFrom(repoPath); local != "" && e.index.Unchanged(repoPath, file, local) {
+			pushedFrom[repoPath] = local
+		}
+	}
+	e.index.UseKey(e.recipientsID(pubKey))
+
+	result := &ReencryptResult{}
+	for _, file := range files {
+		repoPath := filepath.ToSlash(sync.RelPath(e.paths.RepoDir, file))
+		if strings.HasPrefix(repoPath, ".git") || !strings.HasSuffix(repoPath, ".age") {
+			continue
+		}
+
+		ciphertext, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := crypto.Decrypt(identity, ciphertext)
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			e.log.Warn(fmt.Sprintf("Not encrypted to this key, skipping: %s", repoPath))
+			result.Skipped = append(result.Skipped, repoPath)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", repoPath, err)
+		}
+
+		result.Files = append(result.Files, repoPath)
+		recipients := e.recipients(pubKey, strings.TrimSuffix(repoPath, ".age"))
+		if dryRun {
+			e.log.Info(fmt.Sprintf("  [re-encrypt] %s (%d recipients)", repoPath, len(recipients)))
+			continue
+		}
+		e.debug(fmt.Sprintf("Re-encrypting %s for %d recipients", repoPath, len(recipients)))
+		if ciphertext, err = crypto.EncryptTo(recipients, plaintext); err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", repoPath, err)
+		}
+		if err := os.WriteFile(file, ciphertext, 0644); err != nil {
+			return nil, err
+		}
+		if local, ok := pushedFrom[repoPath]; ok {
+			e.index.SetPushed(repoPath, file, local)
+		}
+	}
+	if ok, err := e.reencryptMCPServers(identity, pubKey, dryRun); err != nil {
+		return nil, err
+	} else if ok {
+		result.Files = append(result.Files, MCPServersFile)
+	}
+	if dryRun || len(result.Files) == 0 {
+		return result, nil
+	}
+
+	if err := e.writeManifest(nil); err != nil {
+		return nil, err
+	}
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Re-encrypt %d files for current recipients\n\n%s %s", len(result.Files), machineTrailer, config.Hostname())
+	if result.Committed, err = b.Commit(message); err != nil {
+		return nil, err
+	}
+	if !b.HasRemote() {
+		return result, nil
+	}
+
+	if e.cfg.PushMode == config.PushModeReview {
+		if result.PullRequest, err = e.pushReview(b, message, result.Files); err != nil {
+			if !backend.IsOffline(err) {
+				return nil, err
+			}
+			e.queueReview(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			return result, nil
+		}
+	} else {
+		e.log.Info("Pushing to remote...")
+		if _, err := e.pushRemote(b, message, nil); err != nil {
+			if !backend.IsOffline(err) {
+				return nil, err
+			}
+			e.queuePush(err)
+			result.Queued = true
+			e.log.Warn(fmt.Sprintf("Remote unreachable: %v", err))
+			return result, nil
+		}
+	}
+	e.clearPending()
+	result.Pushed = true
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/forge"
+)
+
+// reviewing reports whether pushes go through a pull request
+func (e *Engine) reviewing(opts PushOptions) bool {
+	return opts.PR || e.cfg.PushMode == config.PushModeReview
+}
+
+// pushReview pushes the local sync commits to this machine's review branch
+// and opens a pull request for them against the current branch, returning
+// its URL. If the branch already has an open pull request, the push has
+// updated it and its URL is not known.
+func (e *Engine) pushReview(b backend.Backend, message string, changed []string) (string, error) {
+	repo, ok := backend.GitRepo(b)
+	if !ok {
+		return "", fmt.Errorf("pull requests need the git backend (backend: %s)", b.Name())
+	}
+	base, err := repo.CurrentBranch()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the current branch: %w", err)
+	}
+	remoteURL, err := repo.RemoteURL()
+	if err != nil {
+		return "", err
+	}
+
+	branch := backend.ReviewBranch(config.Hostname())
+	e.log.Info(fmt.Sprintf("Pushing to %s for review...", branch))
+	if err := repo.PushBranch(branch); err != nil {
+		return "", fmt.Errorf("git push failed: %w", err)
+	}
+
+	provider, name, err := forge.ForRemote(remoteURL, e.cfg.Forge)
+	if err != nil {
+		return "", fmt.Errorf("pushed %s, but cannot open a pull request: %w", branch, err)
+	}
+	title, _, _ := strings.Cut(message, "\n")
+	pr, err := provider.OpenPullRequest(name, branch, base, fmt.Sprintf("%s from %s", title, config.Hostname()), reviewBody(changed))
+	if errors.Is(err, forge.ErrPullRequestExists) {
+		e.log.Info(fmt.Sprintf("Updated the open pull request from %s", branch))
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("pushed %s, but %w", branch, err)
+	}
+	return pr.URL, nil
+}
+
+// reviewBody describes a sync commit for its pull request
+func reviewBody(changed []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Config changes pushed by claude-code-sync from %s.\n", config.Hostname()))
+	if len(changed) > 0 {
+		sb.WriteString("\nChanged files:\n\n")
+		for _, path := range changed {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", path))
+		}
+	}
+	sb.WriteString("\nOther machines get these changes once this is merged.\n")
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// RollbackResult describes a rollback
+type RollbackResult struct {
+	Commit     string // Full hash of the commit that was restored
+	Files      []FileAction
+	BackupPath string // Zip backup of ~/.claude taken before rolling back
+}
+
+// Rollback restores ~/.claude to the state of an earlier sync commit. rev is
+// anything git understands (hash, HEAD~2, ...). The repo itself is not
+// modified: push afterwards to make the rolled-back state the latest sync.
+func (e *Engine) Rollback(rev string, dryRun bool) (*RollbackResult, error) {
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		l, err := e.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer l.Unlock()
+	}
+
+	repo, err := e.gitRepo("rollback")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "claude-code-sync-rollback-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := repo.ExportTree(hash, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", shortRev(hash), err)
+	}
+
+	result := &RollbackResult{Commit: hash}
+
+	// Never roll back without a way back
+	if sync.FileExists(e.paths.ClaudeDir) && !dryRun {
+		result.BackupPath, err = e.backupCurrent()
+		if err != nil {
+			return nil, fmt.Errorf("backup failed, not rolling back: %w", err)
+		}
+	}
+
+	// Restore from the exported snapshot as if it were the repo
+	snapshot := *e
+	snapshot.paths.RepoDir = tmpDir
+
+	pulled := &PullResult{}
+	if err := snapshot.restore(PullOptions{DryRun: dryRun}, StrategyTheirs, identity, pulled); err != nil {
+		return nil, err
+	}
+	result.Files = pulled.Files
+
+	return result, nil
+}
+
+// shortRev abbreviates a commit hash for messages
+func shortRev(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,76 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/procs"
+)
+
+// ErrClaudeRunning is returned by Pull when Claude Code is running and
+// claude_running is refuse, or wait ran out of time
+var ErrClaudeRunning = errors.New("Claude Code is running")
+
+// claudeRunningTimeout is how long claude_running: wait waits for sessions
+// to exit
+const claudeRunningTimeout = 10 * time.Minute
+
+// claudeRunningPoll is how often it checks
+const claudeRunningPoll = 2 * time.Second
+
+// ClaudeSessions returns the process IDs of running Claude Code sessions.
+// Failing to list processes counts as none running.
+func ClaudeSessions() []int {
+	pids, err := procs.Claude()
+	if err != nil {
+		return nil
+	}
+	return pids
+}
+
+// checkClaudeRunning applies claude_running before a pull replaces files a
+// running session may have loaded or be writing
+func (e *Engine) checkClaudeRunning() error {
+	if e.cfg.ClaudeRunning == config.ClaudeRunningOff {
+		return nil
+	}
+	pids := ClaudeSessions()
+	if len(pids) == 0 {
+		return nil
+	}
+
+	switch e.cfg.ClaudeRunning {
+	case config.ClaudeRunningRefuse:
+		return fmt.Errorf("%w (%s); exit it first, or pull with --force", ErrClaudeRunning, describePIDs(pids))
+	case config.ClaudeRunningWait:
+		e.log.Info(fmt.Sprintf("Claude Code is running (%s); waiting for it to exit...", describePIDs(pids)))
+		deadline := time.Now().Add(claudeRunningTimeout)
+		for len(pids) > 0 {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%w (%s) after waiting %s; exit it first, or pull with --force", ErrClaudeRunning, describePIDs(pids), claudeRunningTimeout)
+			}
+			time.Sleep(claudeRunningPoll)
+			pids = ClaudeSessions()
+		}
+		return nil
+	default:
+		e.log.Warn(fmt.Sprintf("Claude Code is running (%s); restart it to load the pulled settings", describePIDs(pids)))
+		return nil
+	}
+}
+
+// describePIDs formats process IDs for messages
+func describePIDs(pids []int) string {
+	ids := make([]string, len(pids))
+	for i, pid := range pids {
+		ids[i] = strconv.Itoa(pid)
+	}
+	if len(pids) == 1 {
+		return "pid " + ids[0]
+	}
+	return "pids " + strings.Join(ids, ", ")
+}
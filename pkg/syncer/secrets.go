@@ -0,0 +1,69 @@
+package syncer
+
+import (
+	"fmt"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/secrets"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// checkSecrets scans the files that would be synced in plain text. Findings
+// are recorded in result; with secrets.scan: block they stop the push unless
+// opts.AllowSecrets is set. Dry runs only report.
+func (e *Engine) checkSecrets(files []string, extras []extraFile, opts PushOptions, result *PushResult) error {
+	if e.cfg.Secrets.Scan == config.SecretScanOff {
+		return nil
+	}
+
+	scan := func(path, relPath string) {
+		if e.cfg.Secrets.ScanAllowed(relPath) {
+			return
+		}
+		var found []secrets.Finding
+		var err error
+		if len(e.redactPaths(relPath)) > 0 {
+			// Redacted keys never reach the repo, so don't flag them
+			var data []byte
+			if data, err = e.readRedacted(path, relPath); err == nil {
+				found = secrets.Scan(data, relPath)
+			}
+		} else {
+			found, err = secrets.ScanFile(path, relPath)
+		}
+		if err != nil {
+			e.log.Warn(fmt.Sprintf("Failed to scan %s: %v", relPath, err))
+			return
+		}
+		result.Secrets = append(result.Secrets, found...)
+	}
+
+	for _, file := range files {
+		if file == e.paths.ClaudeJSON {
+			continue
+		}
+		relPath := sync.RelPath(e.paths.ClaudeDir, file)
+		if e.cfg.ShouldExclude(relPath) || e.cfg.ShouldEncrypt(relPath) {
+			continue
+		}
+		scan(file, relPath)
+	}
+	for _, f := range extras {
+		if !f.encrypt {
+			scan(f.local, f.relPath)
+		}
+	}
+
+	if len(result.Secrets) == 0 {
+		return nil
+	}
+	for _, f := range result.Secrets {
+		e.log.Warn(fmt.Sprintf("Possible %s in %s:%d: %s", f.Rule, f.Path, f.Line, f.Text))
+	}
+	if opts.DryRun || opts.AllowSecrets || e.cfg.Secrets.Scan == config.SecretScanWarn {
+		return nil
+	}
+	return fmt.Errorf("found %d possible secrets in files that would be pushed unencrypted.\n"+
+		"Encrypt the files ('claude-code-sync encrypt add <file>'), remove the secrets, allow them with\n"+
+		"secrets.allow in config.yaml, or push anyway with --allow-secrets", len(result.Secrets))
+}
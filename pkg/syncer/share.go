@@ -0,0 +1,188 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/secrets"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// ShareOptions controls a share
+type ShareOptions struct {
+	Paths   []string // Files or directories in ~/.claude
+	To      string   // URL of the repo to publish to
+	Message string   // Commit message, default "Share <files> from <host>"
+	DryRun  bool     // Only report what would be shared
+}
+
+// ShareResult describes what a share did (or would do, for a dry run)
+type ShareResult struct {
+	Files     []string          // Shared, relative to ~/.claude
+	Withheld  []string          // Matched encrypt patterns, so never shared
+	Flagged   []string          // Had likely secrets, so not shared
+	Secrets   []secrets.Finding // The likely secrets in Flagged
+	Committed bool              // false if the target already had these contents
+	Pushed    bool
+}
+
+// Share publishes selected files from ~/.claude in plain text to a separate
+// repo, e.g. a public one for sharing prompts. Excluded files are skipped,
+// files matching encrypt patterns and files with likely secrets are withheld,
+// and redact rules are applied. The target is cloned to a temp dir, the files
+// are written at the same paths as in ~/.claude, and one commit is pushed.
+func (e *Engine) Share(opts ShareOptions) (*ShareResult, error) {
+	if len(opts.Paths) == 0 {
+		return nil, fmt.Errorf("no files to share")
+	}
+	if !gitpkg.IsValidRepoURL(opts.To) {
+		return nil, fmt.Errorf("invalid repo URL %q", opts.To)
+	}
+
+	files, err := e.shareFiles(opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	result, contents, err := e.plainFiles(files, "share", opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun || len(result.Files) == 0 {
+		return result, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "claude-code-sync-share-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	e.log.Info(fmt.Sprintf("Cloning %s...", opts.To))
+	if err := gitpkg.Clone(opts.To, tmpDir, e.cfg.GitBackend); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", opts.To, err)
+	}
+	for i, relPath := range result.Files {
+		dest := filepath.Join(tmpDir, filepath.FromSlash(relPath))
+		if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, contents[i], 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	repo := gitpkg.Open(tmpDir, e.cfg.GitBackend)
+	if err := repo.AddAll(); err != nil {
+		return nil, err
+	}
+	if changed, err := repo.HasChanges(); err != nil || !changed {
+		return result, err
+	}
+	message := opts.Message
+	if message == "" {
+		message = fmt.Sprintf("Share %s from %s", shareSummary(result.Files), config.Hostname())
+	}
+	if err := repo.Commit(message); err != nil {
+		return nil, err
+	}
+	result.Committed = true
+
+	e.log.Info(fmt.Sprintf("Pushing to %s...", opts.To))
+	if err := repo.Push(); err != nil {
+		return nil, fmt.Errorf("failed to push to %s: %w", opts.To, err)
+	}
+	result.Pushed = true
+	return result, nil
+}
+
+// plainFiles picks the files in ~/.claude that are safe to write out in
+// plain text, for share or export: excluded files are skipped, files
+// matching encrypt patterns and files with likely secrets are withheld.
+// Returns the redacted contents by result.Files index.
+func (e *Engine) plainFiles(files []string, verb string, dryRun bool) (*ShareResult, [][]byte, error) {
+	gerund := strings.TrimSuffix(verb, "e") + "ing"
+	result := &ShareResult{}
+	var contents [][]byte
+	for _, file := range files {
+		relPath := filepath.ToSlash(sync.RelPath(e.paths.ClaudeDir, file))
+		switch {
+		case e.cfg.ShouldExclude(relPath):
+			e.debug(fmt.Sprintf("Excluded: %s", relPath))
+			continue
+		case e.cfg.ShouldEncrypt(relPath) || file == e.paths.ClaudeJSON:
+			e.log.Warn(fmt.Sprintf("Not %s %s: it matches an encrypt pattern", gerund, relPath))
+			result.Withheld = append(result.Withheld, relPath)
+			continue
+		}
+
+		data, err := e.readRedacted(file, relPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !e.cfg.Secrets.ScanAllowed(relPath) {
+			if found := secrets.Scan(data, relPath); len(found) > 0 {
+				for _, f := range found {
+					e.log.Warn(fmt.Sprintf("Not %s %s: possible %s on line %d: %s", gerund, relPath, f.Rule, f.Line, f.Text))
+				}
+				result.Flagged = append(result.Flagged, relPath)
+				result.Secrets = append(result.Secrets, found...)
+				continue
+			}
+		}
+
+		if dryRun {
+			e.log.Info(fmt.Sprintf("  [%s] %s", verb, relPath))
+		}
+		result.Files = append(result.Files, relPath)
+		contents = append(contents, data)
+	}
+	return result, contents, nil
+}
+
+// shareFiles resolves the paths given to Share, relative to ~/.claude or
+// absolute inside it, to the files under them
+func (e *Engine) shareFiles(paths []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, p := range paths {
+		local := config.ExpandHome(p)
+		if !filepath.IsAbs(local) {
+			local = filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(cleanSyncPath(p)))
+		}
+		rel, err := filepath.Rel(e.paths.ClaudeDir, local)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is not in %s", p, e.paths.ClaudeDir)
+		}
+
+		info, err := os.Stat(local)
+		if err != nil {
+			return nil, fmt.Errorf("%s not found in %s", p, e.paths.ClaudeDir)
+		}
+		found := []string{local}
+		if info.IsDir() {
+			if found, err = sync.WalkFiles(local); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range found {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// shareSummary names the shared files for the default commit message
+func shareSummary(files []string) string {
+	if len(files) == 1 {
+		return files[0]
+	}
+	return fmt.Sprintf("%d files", len(files))
+}
@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+)
+
+// ShownFile is a synced file as stored at some revision, decrypted
+type ShownFile struct {
+	Path      string // Path relative to ~/.claude (without .age)
+	Commit    string
+	Encrypted bool
+	Content   []byte
+}
+
+// Show returns the decrypted contents of a synced file at rev (default HEAD).
+// path is relative to ~/.claude; "claude.json" refers to ~/.claude.json.
+// Plaintext is only held in memory.
+// Without git history (object-store backends), only the latest version can
+// be shown and Commit is empty.
+func (e *Engine) Show(file, rev string) (*ShownFile, error) {
+	if rev == "" {
+		b, err := e.backend()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := backend.GitRepo(b); !ok {
+			return e.ReadRepoFile(file)
+		}
+		rev = "HEAD"
+	}
+
+	repo, err := e.gitRepo("show")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	file = cleanSyncPath(file)
+
+	shown := &ShownFile{Path: file, Commit: hash}
+
+	if data, err := repo.ShowFile(hash, file); err == nil {
+		shown.Content = data
+		return shown, nil
+	}
+
+	data, err := repo.ShowFile(hash, file+".age")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in the repo at %s", file, shortRev(hash))
+	}
+
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	shown.Content, err = crypto.Decrypt(identity, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", file, err)
+	}
+	shown.Encrypted = true
+	return shown, nil
+}
+
+// CommitAt returns the latest sync commit made at or before t
+func (e *Engine) CommitAt(t time.Time) (string, error) {
+	repo, err := e.gitRepo("show --at")
+	if err != nil {
+		return "", err
+	}
+	commits, err := repo.Log(0)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range commits {
+		if !c.Time.After(t) {
+			return c.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("no sync at or before %s", t.Format("2006-01-02 15:04"))
+}
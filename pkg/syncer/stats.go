@@ -0,0 +1,93 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Stats summarizes the sync repo and this machine's sync data
+type Stats struct {
+	Backend        string
+	Repo           *RepoSize // nil for non-git backends
+	EncryptedFiles int
+	EncryptedBytes int64
+	PlainFiles     int
+	PlainBytes     int64
+	Backups        int   // Backup zips
+	BackupBytes    int64 // Backup zips plus single-file backups
+	Machines       []MachineInfo
+}
+
+// Stats reports repo size, synced files by class, backup disk usage and
+// per-machine sync times. It reads the local repo only; run pull first for
+// the other machines' latest times.
+func (e *Engine) Stats() (*Stats, error) {
+	if !sync.FileExists(e.paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+	}
+
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{Backend: b.Name()}
+	if repo, ok := backend.GitRepo(b); ok && repo.IsRepo() {
+		if stats.Repo, err = e.repoSize(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		info, err := os.Stat(f.src)
+		if err != nil {
+			continue
+		}
+		if f.encrypted {
+			stats.EncryptedFiles++
+			stats.EncryptedBytes += info.Size()
+		} else {
+			stats.PlainFiles++
+			stats.PlainBytes += info.Size()
+		}
+	}
+
+	backups, err := sync.ListBackups(e.paths.BackupDir)
+	if err != nil {
+		return nil, err
+	}
+	stats.Backups = len(backups)
+	if stats.BackupBytes, err = dirSize(e.paths.BackupDir); err != nil {
+		return nil, err
+	}
+
+	if stats.Machines, err = e.machines(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// dirSize adds up the size of the files under dir; 0 if it doesn't exist
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
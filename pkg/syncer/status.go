@@ -0,0 +1,283 @@
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// RemoteState summarizes how the local repo relates to its remote
+type RemoteState string
+
+const (
+	RemoteUpToDate      RemoteState = "up-to-date"
+	RemoteOutOfSync     RemoteState = "out-of-sync"
+	RemoteUnknown       RemoteState = "unknown"
+	RemoteNotConfigured RemoteState = "not-configured"
+)
+
+// FileClass is how a file is treated by sync
+type FileClass string
+
+const (
+	ClassExcluded  FileClass = "excluded"
+	ClassEncrypted FileClass = "encrypted"
+	ClassPlain     FileClass = "plain"
+)
+
+// FileStatus is a single file and how it is synced
+type FileStatus struct {
+	Path  string
+	Class FileClass
+}
+
+// StatusResult is a snapshot of local and remote sync state
+type StatusResult struct {
+	Remote       RemoteState
+	LocalCommit  string
+	RemoteCommit string
+	Ahead        int          // Local commits not on the remote (git only)
+	Behind       int          // Remote commits not pulled yet (git only)
+	LocalFiles   []FileStatus // Files in ~/.claude
+	ClaudeJSON   bool         // ~/.claude.json exists and will be synced
+	RepoFiles    []FileStatus // Files in the local repo
+	Pending      *PendingPush // Commits waiting for the remote to be reachable
+	Drift        []Change     // Local files that differ from the repo since the last push or pull
+	Conflicts    []Change     // Drift entries that the remote has also changed (git only)
+}
+
+// Summary counts local files by how they relate to the repo
+type Summary struct {
+	Synced    int // Synced files that match the repo
+	Excluded  int
+	Changed   int // Local files added, modified or missing since the last sync
+	Conflicts int // Changed locally and on the remote
+}
+
+// Summary reduces the status to counts
+func (r *StatusResult) Summary() Summary {
+	s := Summary{Changed: len(r.Drift) - len(r.Conflicts), Conflicts: len(r.Conflicts)}
+	drifted := map[string]bool{}
+	for _, c := range r.Drift {
+		drifted[c.Path] = true
+	}
+	for _, f := range r.LocalFiles {
+		if f.Class == ClassExcluded {
+			s.Excluded++
+		} else if !drifted[f.Path] {
+			s.Synced++
+		}
+	}
+	return s
+}
+
+// Status fetches from the remote and classifies local and repo files
+func (e *Engine) Status() (*StatusResult, error) {
+	paths := e.paths
+
+	if !sync.FileExists(paths.RepoDir) {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+	}
+
+	result := &StatusResult{Remote: RemoteNotConfigured, Pending: e.PendingPush()}
+
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+	if b.HasRemote() {
+		result.LocalCommit, result.RemoteCommit, _ = b.Revisions()
+
+		if result.LocalCommit == result.RemoteCommit && result.LocalCommit != "" {
+			result.Remote = RemoteUpToDate
+		} else if result.LocalCommit != "" && result.RemoteCommit != "" {
+			result.Remote = RemoteOutOfSync
+		} else {
+			result.Remote = RemoteUnknown
+		}
+	}
+
+	result.Drift, err = e.drift()
+	if err != nil {
+		return nil, err
+	}
+
+	if repo, ok := backend.GitRepo(b); ok && result.Remote == RemoteOutOfSync {
+		result.Ahead, result.Behind, _ = repo.AheadBehind()
+		if result.Behind > 0 {
+			result.Conflicts = e.conflicts(repo, result.RemoteCommit, result.Drift)
+		}
+	}
+
+	result.LocalFiles, err = e.localFiles()
+	if err != nil {
+		return nil, err
+	}
+	result.ClaudeJSON = sync.FileExists(paths.ClaudeJSON)
+
+	files, err := sync.WalkFiles(paths.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		relPath := sync.RelPath(paths.RepoDir, file)
+
+		if strings.HasPrefix(relPath, ".git") || isMachineRecord(relPath) {
+			continue
+		}
+
+		class := ClassPlain
+		if strings.HasSuffix(relPath, ".age") {
+			class = ClassEncrypted
+		}
+		result.RepoFiles = append(result.RepoFiles, FileStatus{Path: relPath, Class: class})
+	}
+
+	return result, nil
+}
+
+// localFiles classifies the files in ~/.claude, except ~/.claude.json
+func (e *Engine) localFiles() ([]FileStatus, error) {
+	if !sync.FileExists(e.paths.ClaudeDir) {
+		return nil, nil
+	}
+	files, err := sync.WalkFiles(e.paths.ClaudeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []FileStatus
+	for _, file := range files {
+		if file == e.paths.ClaudeJSON {
+			continue // Reported separately
+		}
+		relPath := sync.RelPath(e.paths.ClaudeDir, file)
+		result = append(result, FileStatus{Path: relPath, Class: e.classify(relPath)})
+	}
+	return result, nil
+}
+
+// classify returns how a path relative to ~/.claude is synced
+func (e *Engine) classify(relPath string) FileClass {
+	if e.cfg.ShouldExclude(relPath) {
+		return ClassExcluded
+	} else if e.cfg.ShouldEncrypt(relPath) {
+		return ClassEncrypted
+	}
+	return ClassPlain
+}
+
+// drift compares ~/.claude with the repo as of the last push or pull.
+// Encrypted files are decrypted to compare them unless the checksum cache
+// shows they haven't changed since they were pushed.
+func (e *Engine) drift() ([]Change, error) {
+	identity, _ := e.loadIdentity()
+	e.index = sync.LoadIndex(e.paths.IndexFile) // Read-only: status doesn't hold the lock
+	defer func() { e.index = nil }()
+
+	return e.compareLocal(identity)
+}
+
+// VerifyLocal compares every file in ~/.claude with the repo, decrypting
+// encrypted files and ignoring the checksum cache. It reports files that
+// were modified (ChangeChanged), are missing locally (ChangeNew) or exist
+// only locally (ChangeAdded).
+func (e *Engine) VerifyLocal() ([]Change, error) {
+	identity, err := e.loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return e.compareLocal(identity)
+}
+
+// compareLocal lists the differences between ~/.claude and the repo.
+// Without an identity, encrypted files are compared through e.index only.
+func (e *Engine) compareLocal(identity *age.X25519Identity) ([]Change, error) {
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	tracked := map[string]bool{}
+	for _, f := range files {
+		tracked[f.dest] = true
+		change := Change{Path: f.relPath, Encrypted: f.encrypted, LocalPath: f.dest, RepoPath: f.src}
+
+		if !sync.FileExists(f.dest) {
+			change.Kind = ChangeNew
+			changes = append(changes, change)
+			continue
+		}
+		if !e.localMatches(identity, f) {
+			change.Kind = ChangeChanged
+			changes = append(changes, change)
+		}
+	}
+
+	if sync.FileExists(e.paths.ClaudeDir) {
+		local, err := sync.WalkFiles(e.paths.ClaudeDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range e.withoutOverlays(local) {
+			relPath := sync.RelPath(e.paths.ClaudeDir, file)
+			if tracked[file] || file == e.paths.ClaudeJSON || e.cfg.ShouldExclude(relPath) {
+				continue
+			}
+			changes = append(changes, Change{Path: relPath, Kind: ChangeAdded, Encrypted: e.cfg.ShouldEncrypt(relPath), LocalPath: file})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// conflicts returns the drift entries whose repo file differs between HEAD
+// and the remote commit, so pulling would replace local edits
+func (e *Engine) conflicts(repo gitpkg.Repo, remote string, drift []Change) []Change {
+	var result []Change
+	for _, c := range drift {
+		repoPath := filepath.ToSlash(c.Path)
+		if c.RepoPath != "" {
+			repoPath = filepath.ToSlash(sync.RelPath(e.paths.RepoDir, c.RepoPath))
+		} else if c.Encrypted {
+			repoPath += ".age"
+		}
+
+		ours, oursErr := repo.ShowFile("HEAD", repoPath)
+		theirs, theirsErr := repo.ShowFile(remote, repoPath)
+		if theirsErr != nil && oursErr != nil {
+			continue // Not in either commit
+		}
+		if theirsErr != nil || oursErr != nil || !bytes.Equal(ours, theirs) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// localMatches reports whether the local copy of f has the repo's content.
+// Without an identity, encrypted files only match if the checksum cache
+// says so.
+func (e *Engine) localMatches(identity *age.X25519Identity, f repoFile) bool {
+	if f.encrypted {
+		sum, err := e.index.Checksum(f.dest)
+		if err == nil && e.index.Unchanged(sync.RelPath(e.paths.RepoDir, f.src), f.src, sum) {
+			return true
+		}
+		if identity == nil {
+			return false
+		}
+	}
+	same, err := e.sameContent(identity, f)
+	return err == nil && same
+}
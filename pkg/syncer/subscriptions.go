@@ -0,0 +1,280 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// overlayEntry is a file pull installed into ~/.claude from a subscription
+type overlayEntry struct {
+	Subscription string `json:"subscription"`
+	Sum          string `json:"sha256"` // As installed; a different local file is the user's own
+}
+
+// overlays maps paths relative to ~/.claude (slash-separated) to the
+// subscription files installed there
+type overlays map[string]overlayEntry
+
+func (e *Engine) loadOverlays() overlays {
+	o := overlays{}
+	if data, err := os.ReadFile(e.paths.OverlayFile); err == nil {
+		_ = json.Unmarshal(data, &o)
+	}
+	return o
+}
+
+func (e *Engine) saveOverlays(o overlays) error {
+	if len(o) == 0 {
+		if err := os.Remove(e.paths.OverlayFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.paths.OverlayFile, data, 0644)
+}
+
+// isOverlay reports whether a local file is a subscription file the user
+// hasn't changed, which push and status leave alone
+func (e *Engine) isOverlay(o overlays, file string) bool {
+	entry, ok := o[filepath.ToSlash(sync.RelPath(e.paths.ClaudeDir, file))]
+	if !ok {
+		return false
+	}
+	sum, err := e.index.Checksum(file)
+	return err == nil && sum == entry.Sum
+}
+
+// withoutOverlays drops unchanged subscription files from a list of files in
+// ~/.claude
+func (e *Engine) withoutOverlays(files []string) []string {
+	o := e.loadOverlays()
+	if len(o) == 0 {
+		return files
+	}
+	var result []string
+	for _, file := range files {
+		if e.isOverlay(o, file) {
+			e.debug(fmt.Sprintf("Subscription file: %s", sync.RelPath(e.paths.ClaudeDir, file)))
+			continue
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// subscriptionPlan is what a pull does with subscription files. They are
+// staged in the same pullTxn as the repo files, so they are validated and
+// rolled back with them.
+type subscriptionPlan struct {
+	installs  []subscriptionChange
+	removals  []subscriptionChange
+	installed overlays // Saved once the pull has committed
+}
+
+type subscriptionChange struct {
+	subscription string
+	src          string // "" for removals
+	dest         string
+	relPath      string
+}
+
+// planSubscriptions updates each subscription's clone and decides which of
+// its files to install into ~/.claude. Precedence, highest first: files in
+// the sync repo, local files that didn't come from a subscription (including
+// subscription files edited since), then subscriptions in the order listed.
+// Files that left every subscription are removed unless they were edited.
+// Returns nil if there are no subscriptions.
+func (e *Engine) planSubscriptions(files []repoFile, opts PullOptions) (*subscriptionPlan, error) {
+	old := e.loadOverlays()
+	if len(e.cfg.Subscriptions) == 0 && len(old) == 0 {
+		return nil, nil
+	}
+
+	personal := map[string]bool{}
+	for _, f := range files {
+		personal[filepath.ToSlash(f.relPath)] = true
+	}
+
+	plan := &subscriptionPlan{installed: overlays{}}
+	installed := plan.installed
+	for _, sub := range e.cfg.Subscriptions {
+		name := sub.RepoName()
+		dir, err := e.updateSubscription(sub, opts.DryRun)
+		if err != nil {
+			e.log.Warn(fmt.Sprintf("Subscription %s: %v", name, err))
+		}
+		if !sync.FileExists(dir) {
+			continue // Not cloned yet
+		}
+		subFiles, err := subscriptionFiles(dir, sub)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, src := range subFiles {
+			relPath := filepath.ToSlash(sync.RelPath(dir, src))
+			dest := filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(relPath))
+			switch {
+			case e.cfg.ShouldExclude(relPath):
+				continue
+			case personal[relPath]:
+				e.debug(fmt.Sprintf("Subscription %s: %s is overridden by your synced copy", name, relPath))
+				continue
+			case installed[relPath].Subscription != "":
+				e.debug(fmt.Sprintf("Subscription %s: %s is provided by %s", name, relPath, installed[relPath].Subscription))
+				continue
+			}
+
+			sum, err := sync.FileChecksum(src)
+			if err != nil {
+				return nil, err
+			}
+			if sync.FileExists(dest) {
+				local, err := e.index.Checksum(dest)
+				if err != nil {
+					return nil, err
+				}
+				prev, ours := old[relPath]
+				if !ours || local != prev.Sum {
+					if local != sum {
+						e.log.Warn(fmt.Sprintf("Subscription %s: keeping your own %s", name, relPath))
+					}
+					continue
+				}
+				if local == sum {
+					installed[relPath] = overlayEntry{Subscription: name, Sum: sum}
+					continue
+				}
+			}
+
+			installed[relPath] = overlayEntry{Subscription: name, Sum: sum}
+			plan.installs = append(plan.installs, subscriptionChange{subscription: name, src: src, dest: dest, relPath: relPath})
+		}
+	}
+
+	// Files no subscription provides any more, unless edited since
+	var gone []string
+	for relPath := range old {
+		if _, ok := installed[relPath]; !ok {
+			gone = append(gone, relPath)
+		}
+	}
+	sort.Strings(gone)
+	for _, relPath := range gone {
+		dest := filepath.Join(e.paths.ClaudeDir, filepath.FromSlash(relPath))
+		if !sync.FileExists(dest) || personal[relPath] {
+			continue
+		}
+		if local, err := e.index.Checksum(dest); err != nil || local != old[relPath].Sum {
+			continue
+		}
+		plan.removals = append(plan.removals, subscriptionChange{subscription: old[relPath].Subscription, dest: dest, relPath: relPath})
+	}
+	return plan, nil
+}
+
+// stageSubscriptions stages a plan's installs and removals in txn (nil for a
+// dry run)
+func (e *Engine) stageSubscriptions(plan *subscriptionPlan, txn *pullTxn, result *PullResult) {
+	for _, c := range plan.installs {
+		result.Files = append(result.Files, FileAction{Path: c.relPath, Action: ActionCopy})
+		if txn == nil {
+			result.Changed = append(result.Changed, c.relPath)
+			e.log.Info(fmt.Sprintf("  [%s] %s", c.subscription, c.relPath))
+			continue
+		}
+		e.log.Info(fmt.Sprintf("Installing from %s: %s", c.subscription, c.relPath))
+		txn.copyFile(c.src, c.dest, c.relPath, 0)
+	}
+	for _, c := range plan.removals {
+		if txn == nil {
+			e.log.Info(fmt.Sprintf("  [remove] %s (no longer in %s)", c.relPath, c.subscription))
+			continue
+		}
+		e.log.Info(fmt.Sprintf("Removing %s (no longer in %s)", c.relPath, c.subscription))
+		txn.removeFile(c.dest, c.relPath)
+	}
+}
+
+// updateSubscription clones a subscription, or pulls it if it was cloned
+// before, and returns its directory. Dry runs use the existing clone as is.
+func (e *Engine) updateSubscription(sub config.Subscription, dryRun bool) (string, error) {
+	dir := filepath.Join(e.paths.SubsDir, sub.RepoName())
+	if dryRun {
+		return dir, nil
+	}
+	return dir, e.updateClone(sub.URL, dir, "subscription "+sub.RepoName())
+}
+
+// updateClone pulls the clone of url in dir, or clones it there first.
+// what names the repo in messages.
+func (e *Engine) updateClone(url, dir, what string) error {
+	if !gitpkg.IsValidRepoURL(url) {
+		return fmt.Errorf("invalid repo URL %q", url)
+	}
+
+	repo := gitpkg.Open(dir, e.cfg.GitBackend)
+	if repo.IsRepo() {
+		e.debug(fmt.Sprintf("Updating %s", what))
+		if err := repo.Pull(); err != nil {
+			return fmt.Errorf("update failed, using the copy from the last pull: %w", err)
+		}
+		return nil
+	}
+
+	e.log.Info(fmt.Sprintf("Cloning %s...", what))
+	if err := sync.EnsureDir(filepath.Dir(dir)); err != nil {
+		return err
+	}
+	os.RemoveAll(dir)
+	if err := gitpkg.Clone(url, dir, e.cfg.GitBackend); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("clone failed: %w", err)
+	}
+	return nil
+}
+
+// subscriptionFiles lists the files a subscription provides: those under
+// sub.Paths, or all of them except git files and the repo's own README and
+// LICENSE
+func subscriptionFiles(dir string, sub config.Subscription) ([]string, error) {
+	all, err := sync.WalkFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range all {
+		relPath := filepath.ToSlash(sync.RelPath(dir, file))
+		if strings.HasPrefix(relPath, ".git") {
+			continue
+		}
+		if len(sub.Paths) == 0 {
+			upper := strings.ToUpper(relPath)
+			if strings.HasPrefix(upper, "README") || strings.HasPrefix(upper, "LICENSE") {
+				continue
+			}
+			files = append(files, file)
+			continue
+		}
+		for _, p := range sub.Paths {
+			p = strings.Trim(filepath.ToSlash(p), "/")
+			if relPath == p || strings.HasPrefix(relPath, p+"/") {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,157 @@
+// Package syncer exposes the claude-code-sync engine as a Go library.
+//
+// Tools that want to use claude-code-sync as a transport (e.g. claude-brain)
+// can embed an Engine instead of shelling out to the CLI:
+//
+//	engine, err := syncer.Default(nil)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := engine.Push(syncer.PushOptions{})
+package syncer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felixisaac/claude-code-sync/internal/backend"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/lock"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// Paths holds the filesystem locations used by the engine
+type Paths = config.Paths
+
+// Config holds the encrypt/exclude patterns and other user settings
+type Config = config.Config
+
+// PlatformWarning describes a file with platform-specific content but no variant
+type PlatformWarning = sync.PlatformWarning
+
+// Logger receives human-readable progress messages from the engine
+type Logger interface {
+	Info(msg string)
+	Warn(msg string)
+	Success(msg string)
+}
+
+// DebugLogger is a Logger that also takes detail messages, such as files
+// skipped and timings. The engine only sends them to loggers implementing it.
+type DebugLogger interface {
+	Logger
+	Debug(msg string)
+}
+
+// nopLogger discards all messages
+type nopLogger struct{}
+
+func (nopLogger) Info(string)    {}
+func (nopLogger) Warn(string)    {}
+func (nopLogger) Success(string) {}
+
+// Action is what the engine did (or would do) with a single file
+type Action string
+
+const (
+	ActionEncrypt Action = "encrypt" // Encrypted into the repo
+	ActionCopy    Action = "copy"    // Copied as plain text
+	ActionDecrypt Action = "decrypt" // Decrypted out of the repo
+	ActionKeep    Action = "keep"    // Local version kept (--ours)
+)
+
+// FileAction records the action taken for one file
+type FileAction struct {
+	Path   string
+	Action Action
+}
+
+// Engine runs push/pull/status/diff against a set of paths
+type Engine struct {
+	paths Paths
+	cfg   *Config
+	log   Logger
+	index *sync.Index // Checksum cache, loaded by Push and Pull
+
+	repoKeys []string // Public keys in the repo's .recipients, loaded by Push
+}
+
+// New creates an engine for the given paths, loading the config file they point to.
+// A nil logger discards progress output.
+func New(paths Paths, log Logger) (*Engine, error) {
+	cfg, err := config.Load(paths.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.UseIgnoreFiles(paths.ClaudeDir)
+	if log == nil {
+		log = nopLogger{}
+	}
+	return &Engine{paths: paths, cfg: cfg, log: log}, nil
+}
+
+// debug sends a detail message to the logger if it wants them
+func (e *Engine) debug(msg string) {
+	if d, ok := e.log.(DebugLogger); ok {
+		d.Debug(msg)
+	}
+}
+
+// Default creates an engine for the current user's standard paths
+func Default(log Logger) (*Engine, error) {
+	return New(config.GetPaths(), log)
+}
+
+// Paths returns the paths the engine operates on
+func (e *Engine) Paths() Paths {
+	return e.paths
+}
+
+// Config returns the loaded configuration
+func (e *Engine) Config() *Config {
+	return e.cfg
+}
+
+// backend opens the configured storage backend
+func (e *Engine) backend() (backend.Backend, error) {
+	return backend.Open(e.cfg, e.paths)
+}
+
+// gitRepo returns the sync repo for features that need git history
+func (e *Engine) gitRepo(feature string) (gitpkg.Repo, error) {
+	b, err := e.backend()
+	if err != nil {
+		return nil, err
+	}
+	repo, ok := backend.GitRepo(b)
+	if !ok {
+		return nil, fmt.Errorf("%s requires the git backend (current backend: %s)", feature, b.Name())
+	}
+	if !repo.IsRepo() {
+		return nil, fmt.Errorf("no repo found. Run 'claude-code-sync init' first")
+	}
+	return repo, nil
+}
+
+// lockTimeout is how long a command waits for another one to finish
+const lockTimeout = 30 * time.Second
+
+// lock takes the sync lock so pushes, pulls and rollbacks from different
+// processes never interleave. Release it with Unlock.
+func (e *Engine) lock() (*lock.Lock, error) {
+	return lock.Acquire(e.paths.LockFile, lockTimeout)
+}
+
+// openIndex loads the checksum cache for a push or pull. Save it with
+// saveIndex.
+func (e *Engine) openIndex() {
+	e.index = sync.LoadIndex(e.paths.IndexFile)
+}
+
+// saveIndex writes the checksum cache back. Failing to is not fatal.
+func (e *Engine) saveIndex() {
+	if err := e.index.Save(); err != nil {
+		e.log.Warn(fmt.Sprintf("Failed to save %s: %v", e.paths.IndexFile, err))
+	}
+}
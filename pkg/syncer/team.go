@@ -0,0 +1,139 @@
+package syncer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitpkg "github.com/felixisaac/claude-code-sync/internal/git"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// updateTeam pulls the team repo, cloning it the first time. Failures are
+// reported but not fatal: the last copy pulled is used instead.
+func (e *Engine) updateTeam() {
+	if e.cfg.Team.URL == "" {
+		return
+	}
+	if err := e.updateClone(e.cfg.Team.URL, e.paths.TeamDir, "team repo"); err != nil {
+		e.log.Warn(fmt.Sprintf("Team repo: %v", err))
+	}
+}
+
+// teamFiles lists the files in the team repo clone that should be restored
+// on this machine. Only files matching team.patterns are taken, so the team
+// repo's own README and the like stay out of ~/.claude.
+func (e *Engine) teamFiles(allPlatforms bool) ([]repoFile, error) {
+	if e.cfg.Team.URL == "" || !sync.FileExists(e.paths.TeamDir) {
+		return nil, nil
+	}
+	files, err := sync.WalkFiles(e.paths.TeamDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk team repo: %w", err)
+	}
+
+	var result []repoFile
+	for _, file := range files {
+		relPath := sync.RelPath(e.paths.TeamDir, file)
+		if strings.HasPrefix(relPath, ".git") || !e.cfg.IsTeamFile(relPath) || e.cfg.ShouldExclude(relPath) || e.skipsVariant(relPath, allPlatforms) {
+			continue
+		}
+		result = append(result, repoFile{relPath: relPath, src: file, dest: filepath.Join(e.paths.ClaudeDir, relPath)})
+	}
+	return result, nil
+}
+
+// pushTeam copies files matching team.patterns into the team repo, commits
+// and pushes them. Copies left in this machine's repo from before are
+// removed, so the next sync commit moves them out. An unreachable team repo
+// is reported but doesn't fail the push.
+func (e *Engine) pushTeam(files []string, opts PushOptions, result *PushResult) error {
+	if len(files) == 0 {
+		return nil
+	}
+	for _, file := range files {
+		relPath := sync.RelPath(e.paths.ClaudeDir, file)
+		result.Files = append(result.Files, FileAction{Path: relPath, Action: ActionCopy})
+		if opts.DryRun {
+			e.log.Info(fmt.Sprintf("  [team] %s", relPath))
+		}
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	e.updateTeam()
+	repo := gitpkg.Open(e.paths.TeamDir, e.cfg.GitBackend)
+	if !repo.IsRepo() {
+		e.log.Warn(fmt.Sprintf("Team repo unavailable; %d team files were not pushed", len(files)))
+		return nil
+	}
+
+	var changed []string
+	for _, file := range files {
+		relPath := sync.RelPath(e.paths.ClaudeDir, file)
+		moved := false
+		for _, old := range []string{relPath, relPath + ".age"} {
+			if personal := filepath.Join(e.paths.RepoDir, old); sync.FileExists(personal) {
+				if err := os.Remove(personal); err != nil {
+					return err
+				}
+				e.index.Forget(filepath.ToSlash(old))
+				moved = true
+			}
+		}
+		if moved {
+			e.log.Info(fmt.Sprintf("Moving to team repo: %s", relPath))
+		}
+
+		data, err := e.readRedacted(file, relPath)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(e.paths.TeamDir, relPath)
+		if current, err := os.ReadFile(dest); err == nil && bytes.Equal(current, data) {
+			continue
+		}
+		e.log.Info(fmt.Sprintf("Copying to team repo: %s", relPath))
+		if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", relPath, err)
+		}
+		changed = append(changed, relPath)
+	}
+	result.Changed = append(result.Changed, changed...)
+
+	if err := repo.AddAll(); err != nil {
+		return err
+	}
+	if has, err := repo.HasChanges(); err != nil {
+		return err
+	} else if has {
+		if err := repo.Commit(syncMessage()); err != nil {
+			return err
+		}
+	} else if ahead, _, err := repo.AheadBehind(); err != nil || ahead == 0 {
+		return nil
+	}
+
+	e.log.Info("Pushing to team repo...")
+	err := repo.Push()
+	if errors.Is(err, gitpkg.ErrRejected) {
+		e.log.Warn("Team repo push rejected: a teammate pushed first. Rebasing and retrying...")
+		if err = repo.Rebase(false); err == nil {
+			err = repo.Push()
+		}
+	}
+	if err != nil {
+		e.log.Warn(fmt.Sprintf("Team repo push failed: %v", err))
+		e.log.Warn("Team changes are committed locally and will be pushed by the next push.")
+		return nil
+	}
+	e.log.Success("Pushed to the team repo.")
+	return nil
+}
@@ -0,0 +1,236 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// pullTxn stages restored files in a temp tree and swaps them into place
+// only once every file has been decrypted, so a failed pull never leaves
+// ~/.claude half-written. If a swap fails, the files already replaced are
+// put back.
+type pullTxn struct {
+	dir       string // Staging tree inside the sync dir
+	n         int
+	staged    []stagedFile
+	tasks     []func() error // Fill staged files; run by prepare
+	applied   []appliedFile
+	backups   []string // Conflict backups made for this pull
+	backupDir string
+	stamp     string // Folder in backups/files for this pull's conflict backups
+	keep      bool   // Rollback failed: originals are still in dir
+}
+
+type stagedFile struct {
+	tmp     string // "" removes dest
+	dest    string
+	relPath string
+	mode    os.FileMode // From the manifest; 0 keeps the mode of the file it replaces
+}
+
+type appliedFile struct {
+	dest string
+	orig string // Where the replaced file was moved, "" if dest was new
+}
+
+func newPullTxn(syncDir, backupDir string) (*pullTxn, error) {
+	if err := sync.EnsureDir(syncDir); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(syncDir, "pull-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	return &pullTxn{dir: dir, backupDir: backupDir, stamp: sync.Timestamp()}, nil
+}
+
+// next returns an unused file name in the staging tree
+func (t *pullTxn) next() string {
+	t.n++
+	return filepath.Join(t.dir, strconv.Itoa(t.n))
+}
+
+// writeFile stages data for dest
+func (t *pullTxn) writeFile(dest, relPath string, data []byte, perm, mode os.FileMode) error {
+	tmp := t.next()
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	t.staged = append(t.staged, stagedFile{tmp: tmp, dest: dest, relPath: relPath, mode: mode})
+	return nil
+}
+
+// copyFile stages a copy of src for dest. The copy is made by prepare.
+func (t *pullTxn) copyFile(src, dest, relPath string, mode os.FileMode) {
+	t.stage(dest, relPath, mode, func(tmp string) error {
+		if err := sync.CopyFile(src, tmp); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", relPath, err)
+		}
+		return nil
+	})
+}
+
+// decryptFile stages the decrypted contents of src for dest. Decryption
+// happens in prepare.
+func (t *pullTxn) decryptFile(identity *age.X25519Identity, src, dest, relPath string, mode os.FileMode) {
+	t.stage(dest, relPath, mode, func(tmp string) error {
+		if err := crypto.DecryptFile(identity, src, tmp); err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", relPath, err)
+		}
+		return nil
+	})
+}
+
+// removeFile stages the removal of dest
+func (t *pullTxn) removeFile(dest, relPath string) {
+	t.staged = append(t.staged, stagedFile{dest: dest, relPath: relPath})
+}
+
+func (t *pullTxn) stage(dest, relPath string, mode os.FileMode, write func(tmp string) error) {
+	tmp := t.next()
+	t.staged = append(t.staged, stagedFile{tmp: tmp, dest: dest, relPath: relPath, mode: mode})
+	t.tasks = append(t.tasks, func() error { return write(tmp) })
+}
+
+// prepare fills the staged files using up to jobs goroutines
+func (t *pullTxn) prepare(jobs int) error {
+	err := runJobs(jobs, t.tasks)
+	t.tasks = nil
+	return err
+}
+
+// backupFile copies dest into backups/files, removed again on rollback
+func (t *pullTxn) backupFile(dest, relPath string) (string, error) {
+	backupPath, err := sync.BackupFile(dest, t.backupDir, t.stamp, relPath)
+	if backupPath != "" {
+		t.backups = append(t.backups, backupPath)
+	}
+	return backupPath, err
+}
+
+// commit moves every staged file into place, rolling back on the first
+// error. Files identical to what is already there are left alone. Returns
+// the files that were replaced or created.
+func (t *pullTxn) commit() ([]string, error) {
+	var changed []string
+	for _, s := range t.staged {
+		if identical(s) {
+			continue
+		}
+		if err := t.swap(s); err != nil {
+			err = fmt.Errorf("failed to write %s: %w", s.dest, err)
+			if rbErr := t.rollback(); rbErr != nil {
+				return nil, fmt.Errorf("%w; rollback failed: %v (original files are in %s)", err, rbErr, t.dir)
+			}
+			return nil, fmt.Errorf("%w; no files were changed", err)
+		}
+		changed = append(changed, s.relPath)
+	}
+	return changed, nil
+}
+
+// identical reports whether a staged file has the same content and mode as
+// the file it would replace
+func identical(s stagedFile) bool {
+	if s.tmp == "" {
+		return !sync.FileExists(s.dest)
+	}
+	if s.mode != 0 && sync.FileMode(s.dest) != s.mode {
+		return false
+	}
+	staged, err := sync.FileChecksum(s.tmp)
+	if err != nil {
+		return false
+	}
+	current, err := sync.FileChecksum(s.dest)
+	return err == nil && staged == current
+}
+
+// swap replaces dest with its staged copy, or removes it, keeping the
+// original for rollback
+func (t *pullTxn) swap(s stagedFile) error {
+	if s.tmp == "" {
+		a := appliedFile{dest: s.dest, orig: t.next()}
+		if err := moveFile(s.dest, a.orig); err != nil {
+			return err
+		}
+		t.applied = append(t.applied, a)
+		return nil
+	}
+	if err := sync.EnsureDir(filepath.Dir(s.dest)); err != nil {
+		return err
+	}
+	mode := s.mode
+	if mode == 0 {
+		mode = sync.FileMode(s.dest)
+	}
+	if err := sync.SetMode(s.tmp, mode); err != nil {
+		return err
+	}
+
+	a := appliedFile{dest: s.dest}
+	if sync.FileExists(s.dest) {
+		a.orig = t.next()
+		if err := moveFile(s.dest, a.orig); err != nil {
+			return err
+		}
+	}
+	t.applied = append(t.applied, a)
+	return moveFile(s.tmp, s.dest)
+}
+
+// rollback restores the files replaced so far and removes conflict backups
+func (t *pullTxn) rollback() error {
+	var errs []error
+	for i := len(t.applied) - 1; i >= 0; i-- {
+		a := t.applied[i]
+		if err := os.Remove(a.dest); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		if a.orig != "" {
+			if err := moveFile(a.orig, a.dest); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	t.applied = nil
+
+	if len(t.backups) > 0 {
+		os.RemoveAll(filepath.Join(t.backupDir, sync.FileBackupsDir, t.stamp))
+	}
+	t.backups = nil
+
+	if len(errs) > 0 {
+		t.keep = true
+	}
+	return errors.Join(errs...)
+}
+
+// cleanup removes the staging tree
+func (t *pullTxn) cleanup() {
+	if !t.keep {
+		os.RemoveAll(t.dir)
+	}
+}
+
+// moveFile renames src to dst, copying when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := sync.CopyFile(src, dst); err != nil {
+		return err
+	}
+	if err := sync.SetMode(dst, sync.FileMode(src)); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,99 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPullTxn(t *testing.T) {
+	tests := []struct {
+		name    string
+		stage   func(t *testing.T, txn *pullTxn, claudeDir string)
+		wantErr string
+		want    map[string]string // Contents of ~/.claude after the pull, "" for no file
+	}{
+		{
+			name: "applied",
+			stage: func(t *testing.T, txn *pullTxn, claudeDir string) {
+				writeStagedFile(t, txn, claudeDir, "CLAUDE.md", "new")
+				writeStagedFile(t, txn, claudeDir, "commands/deploy.md", "deploy")
+				txn.removeFile(filepath.Join(claudeDir, "settings.json"), "settings.json")
+			},
+			want: map[string]string{"CLAUDE.md": "new", "commands/deploy.md": "deploy", "settings.json": ""},
+		},
+		{
+			name: "failed swap",
+			stage: func(t *testing.T, txn *pullTxn, claudeDir string) {
+				writeStagedFile(t, txn, claudeDir, "CLAUDE.md", "new")
+				writeStagedFile(t, txn, claudeDir, "commands/deploy.md", "deploy")
+				txn.removeFile(filepath.Join(claudeDir, "settings.json"), "settings.json")
+				// A file stands where the directory should be
+				writeStagedFile(t, txn, claudeDir, "blocker/file.md", "blocked")
+			},
+			wantErr: "no files were changed",
+			want:    map[string]string{"CLAUDE.md": "old", "commands/deploy.md": "", "settings.json": "{}", "blocker": "file"},
+		},
+		{
+			name: "partial pull",
+			stage: func(t *testing.T, txn *pullTxn, claudeDir string) {
+				writeStagedFile(t, txn, claudeDir, "CLAUDE.md", "new")
+				txn.copyFile(filepath.Join(claudeDir, "missing.md"), filepath.Join(claudeDir, "agents/a.md"), "agents/a.md", 0)
+			},
+			wantErr: "failed to copy agents/a.md",
+			want:    map[string]string{"CLAUDE.md": "old", "agents/a.md": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			syncDir, claudeDir := filepath.Join(dir, "sync"), filepath.Join(dir, "claude")
+			writeTestFile(t, filepath.Join(claudeDir, "CLAUDE.md"), "old")
+			writeTestFile(t, filepath.Join(claudeDir, "settings.json"), "{}")
+			writeTestFile(t, filepath.Join(claudeDir, "blocker"), "file")
+
+			txn, err := newPullTxn(syncDir, filepath.Join(dir, "backups"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.stage(t, txn, claudeDir)
+			// As applyFiles runs it
+			err = txn.prepare(2)
+			if err == nil {
+				_, err = txn.commit()
+			} else {
+				txn.rollback()
+			}
+			txn.cleanup()
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("pull: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("pull = %v, want an error containing %q", err, tt.wantErr)
+			}
+			for path, want := range tt.want {
+				data, err := os.ReadFile(filepath.Join(claudeDir, filepath.FromSlash(path)))
+				if want == "" && err == nil {
+					t.Errorf("%s exists after the pull", path)
+				}
+				if want != "" && string(data) != want {
+					t.Errorf("%s = %q, want %q", path, data, want)
+				}
+			}
+			entries, _ := os.ReadDir(syncDir)
+			for _, entry := range entries {
+				t.Errorf("staging dir %s was left in the sync dir", entry.Name())
+			}
+		})
+	}
+}
+
+// writeStagedFile stages content for relPath in claudeDir
+func writeStagedFile(t *testing.T, txn *pullTxn, claudeDir, relPath, content string) {
+	t.Helper()
+	if err := txn.writeFile(filepath.Join(claudeDir, filepath.FromSlash(relPath)), relPath, []byte(content), 0644, 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+)
+
+// settingsSchema gives the JSON type of the settings.json keys Claude Code
+// fails to start on when they have the wrong type. Other keys aren't checked.
+var settingsSchema = map[string]string{
+	"permissions":           "object",
+	"env":                   "object",
+	"hooks":                 "object",
+	"statusLine":            "object",
+	"enabledPlugins":        "object",
+	"model":                 "string",
+	"apiKeyHelper":          "string",
+	"outputStyle":           "string",
+	"includeCoAuthoredBy":   "boolean",
+	"alwaysThinkingEnabled": "boolean",
+	"cleanupPeriodDays":     "number",
+}
+
+// permissionsSchema does the same for the permissions object
+var permissionsSchema = map[string]string{
+	"allow":                 "array",
+	"deny":                  "array",
+	"ask":                   "array",
+	"additionalDirectories": "array",
+	"defaultMode":           "string",
+}
+
+// claudeJSONSchema does the same for ~/.claude.json
+var claudeJSONSchema = map[string]string{
+	"mcpServers": "object",
+	"projects":   "object",
+}
+
+// validateStaged checks the files a pull is about to install, so a
+// corrupted settings file that would stop Claude Code from starting is
+// never put in place. Every JSON file must parse; with validate: schema,
+// settings.json and claude.json must also have the expected types.
+func (e *Engine) validateStaged(txn *pullTxn) error {
+	if e.cfg.Validate == config.ValidateOff {
+		return nil
+	}
+	for _, s := range txn.staged {
+		claudeJSON := s.dest == e.paths.ClaudeJSON
+		if s.tmp == "" || !claudeJSON && !strings.HasSuffix(strings.ToLower(s.relPath), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(s.tmp)
+		if err != nil {
+			return err
+		}
+		if err := e.validateJSON(s.relPath, claudeJSON, data); err != nil {
+			return fmt.Errorf("refusing to install %s: %w (fix it in the repo or on the machine that pushed it)", s.relPath, err)
+		}
+	}
+	return nil
+}
+
+// validateJSON checks one file's content
+func (e *Engine) validateJSON(relPath string, claudeJSON bool, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("not valid JSON: %v", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("not valid JSON: data after the end of the document")
+	}
+	if e.cfg.Validate != config.ValidateSchema {
+		return nil
+	}
+
+	var schema map[string]string
+	switch base := filepath.Base(relPath); {
+	case claudeJSON:
+		schema = claudeJSONSchema
+	case filepath.Dir(relPath) == "." && (base == "settings.json" || base == "settings.local.json"):
+		schema = settingsSchema
+	default:
+		return nil
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected a JSON object, found %s", jsonType(doc))
+	}
+	if err := checkTypes(obj, schema, ""); err != nil {
+		return err
+	}
+	if perms, ok := obj["permissions"].(map[string]interface{}); ok && !claudeJSON {
+		return checkTypes(perms, permissionsSchema, "permissions.")
+	}
+	return nil
+}
+
+// checkTypes compares the keys of obj listed in schema with their expected
+// types, reporting the first mismatch in key order
+func checkTypes(obj map[string]interface{}, schema map[string]string, prefix string) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := obj[key]
+		if !ok {
+			continue
+		}
+		if got := jsonType(value); got != schema[key] {
+			return fmt.Errorf("%s%s should be a JSON %s, found %s", prefix, key, schema[key], got)
+		}
+	}
+	return nil
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,139 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// A machine variant such as settings.workmac.json holds one machine's copy
+// of a file. On that machine it is restored as settings.json, in place of the
+// base file and any platform variant, and push writes settings.json back to
+// it. Other machines leave it alone.
+
+// machineTag returns the tag this machine's variants carry
+func (e *Engine) machineTag() string {
+	return sync.MachineTag(config.Hostname())
+}
+
+// machineTags returns the tags of every machine recorded in the repo, this
+// one included
+func (e *Engine) machineTags() []string {
+	tags := []string{e.machineTag()}
+	machines, _ := e.machines()
+	for _, m := range machines {
+		tags = append(tags, sync.MachineTag(m.Hostname))
+	}
+	return tags
+}
+
+// pushPath returns where relPath goes in the repo (without .age): this
+// machine's variant of it if the repo has one, otherwise relPath itself
+func (e *Engine) pushPath(relPath string) string {
+	variant := sync.GetMachineVariantName(relPath, e.machineTag())
+	if variant != relPath && e.repoHas(variant) {
+		return variant
+	}
+	return relPath
+}
+
+// repoHas reports whether the repo holds relPath, plain or encrypted
+func (e *Engine) repoHas(relPath string) bool {
+	path := filepath.Join(e.paths.RepoDir, relPath)
+	return sync.FileExists(path) || sync.FileExists(path+".age")
+}
+
+// machineNote marks a file pushed to this machine's variant in the log
+func machineNote(relPath, repoPath string) string {
+	if repoPath != relPath {
+		return fmt.Sprintf(" (as %s)", filepath.ToSlash(repoPath))
+	}
+	return ""
+}
+
+// MachineVariants lists the files this machine keeps its own copy of,
+// relative to ~/.claude
+func (e *Engine) MachineVariants() ([]string, error) {
+	files, err := e.repoFiles()
+	if err != nil {
+		return nil, err
+	}
+	var variants []string
+	for _, f := range files {
+		repoPath := strings.TrimSuffix(sync.RelPath(e.paths.RepoDir, f.src), ".age")
+		if _, _, ok := sync.SplitMachineVariant(repoPath, []string{e.machineTag()}); ok {
+			variants = append(variants, filepath.ToSlash(f.relPath))
+		}
+	}
+	return variants, nil
+}
+
+// AddMachineVariant starts a variant of each file for this machine, as a
+// copy of the repo's shared one. From the next push this machine's copy
+// goes to the variant and the shared file no longer changes with it.
+func (e *Engine) AddMachineVariant(files []string) ([]string, error) {
+	l, err := e.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Unlock()
+
+	var added []string
+	for _, file := range files {
+		relPath := filepath.FromSlash(cleanSyncPath(file))
+		if relPath == "claude.json" || isExtraPath(relPath) || sync.IsPlatformVariant(relPath) {
+			return added, fmt.Errorf("%s can't have a machine variant", file)
+		}
+		variant := sync.GetMachineVariantName(relPath, e.machineTag())
+		if variant == relPath {
+			return added, fmt.Errorf("%s has no extension to put the machine name before", file)
+		}
+		if e.repoHas(variant) {
+			continue
+		}
+
+		src, dest := filepath.Join(e.paths.RepoDir, relPath), filepath.Join(e.paths.RepoDir, variant)
+		if !sync.FileExists(src) {
+			src, dest = src+".age", dest+".age"
+		}
+		if !sync.FileExists(src) {
+			return added, fmt.Errorf("%s is not in the repo; push it first", file)
+		}
+		if err := sync.CopyFile(src, dest); err != nil {
+			return added, err
+		}
+		added = append(added, filepath.ToSlash(variant))
+	}
+	return added, nil
+}
+
+// RemoveMachineVariant deletes this machine's variant of each file and
+// restores the shared file in its place, saving the local copy to
+// backups/files first
+func (e *Engine) RemoveMachineVariant(files []string) (*PullResult, error) {
+	var removed []string
+	for _, file := range files {
+		relPath := filepath.FromSlash(cleanSyncPath(file))
+		variant := sync.GetMachineVariantName(relPath, e.machineTag())
+		if variant == relPath || !e.repoHas(variant) {
+			return nil, fmt.Errorf("%s has no variant for this machine", file)
+		}
+		path := filepath.Join(e.paths.RepoDir, variant)
+		for _, p := range []string{path, path + ".age"} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		if e.repoHas(relPath) {
+			removed = append(removed, relPath)
+		}
+	}
+	if len(removed) == 0 {
+		return &PullResult{}, nil
+	}
+	return e.Get(removed)
+}
@@ -0,0 +1,509 @@
+package syncer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/felixisaac/claude-code-sync/internal/config"
+	"github.com/felixisaac/claude-code-sync/internal/crypto"
+	"github.com/felixisaac/claude-code-sync/internal/sync"
+)
+
+// WSLSide is the other side's Claude dir on a machine running WSL: the
+// Windows one seen from inside WSL, or the WSL one seen from Windows
+type WSLSide struct {
+	Dir      string // As this side reaches it, e.g. /mnt/c/Users/me/.claude
+	Native   string // As the other side writes it, e.g. C:\Users\me\.claude
+	Home     string // The other side's home dir as it writes it, if Dir is in it
+	Platform string // The other side's platform, sync.PlatformWindows or sync.PlatformUnix
+}
+
+// wslMirrorFile in the sync dir holds the checksums of the files as of the
+// last mirror, to tell which side changed a file since
+const wslMirrorFile = "wsl-mirror.json"
+
+// InWSL reports whether this is Linux running under WSL
+func InWSL() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// DetectWSL looks for Claude Code on the other side of WSL: a Windows
+// user's ~/.claude from inside WSL, or a running distro's from Windows.
+// Returns nil if there is none, or several with none named like this user.
+func DetectWSL() *WSLSide {
+	var dirs []string
+	switch {
+	case InWSL():
+		dirs = userClaudeDirs("/mnt/c/Users")
+	case runtime.GOOS == "windows":
+		// Only running distros: reaching into a stopped one would boot it
+		out, err := exec.Command("wsl.exe", "--list", "--running", "--quiet").Output()
+		if err != nil {
+			return nil
+		}
+		// wsl.exe writes UTF-16; distro names are ASCII
+		for _, distro := range strings.Fields(string(bytes.ReplaceAll(out, []byte{0}, nil))) {
+			dirs = append(dirs, userClaudeDirs(`\\wsl.localhost\`+distro+`\home`)...)
+		}
+	}
+
+	dir := ""
+	for _, d := range dirs {
+		if strings.EqualFold(filepath.Base(filepath.Dir(d)), currentUser()) || len(dirs) == 1 {
+			dir = d
+			break
+		}
+	}
+	if dir == "" {
+		return nil
+	}
+	side, err := wslSideAt(dir)
+	if err != nil {
+		return nil
+	}
+	return side
+}
+
+// userClaudeDirs returns the .claude dirs in the user folders under root
+func userClaudeDirs(root string) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range entries {
+		switch entry.Name() {
+		case "Public", "Default", "Default User", "All Users":
+			continue
+		}
+		dir := filepath.Join(root, entry.Name(), ".claude")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func currentUser() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// wslSideAt describes the Claude dir at dir, which must be on a Windows
+// drive mounted in WSL (/mnt/c/...) or in a distro seen from Windows
+// (\\wsl.localhost\Ubuntu\... or \\wsl$\Ubuntu\...)
+func wslSideAt(dir string) (*WSLSide, error) {
+	side := &WSLSide{Dir: dir}
+	slashed := strings.ReplaceAll(dir, `\`, "/")
+	lower := strings.ToLower(slashed)
+	switch {
+	case len(slashed) >= 6 && strings.HasPrefix(slashed, "/mnt/") && (len(slashed) == 6 || slashed[6] == '/'):
+		side.Native = strings.ToUpper(slashed[5:6]) + `:\` + strings.ReplaceAll(strings.TrimPrefix(slashed[6:], "/"), "/", `\`)
+		side.Platform = sync.PlatformWindows
+	case strings.HasPrefix(lower, "//wsl.localhost/") || strings.HasPrefix(lower, "//wsl$/"):
+		rest := slashed[strings.Index(slashed[2:], "/")+3:]
+		if i := strings.Index(rest, "/"); i >= 0 {
+			side.Native = rest[i:]
+		} else {
+			side.Native = "/"
+		}
+		side.Platform = sync.PlatformUnix
+	default:
+		return nil, fmt.Errorf("%s is neither a Windows drive seen from WSL (/mnt/c/...) nor a WSL path seen from Windows (\\\\wsl.localhost\\...)", dir)
+	}
+	if i := strings.LastIndexAny(side.Native, `\/`); i > 0 && side.Native[i+1:] == ".claude" {
+		side.Home = side.Native[:i]
+	}
+	return side, nil
+}
+
+// WSLSide returns the other side to sync as set up in config.yaml: wsl.path,
+// or the one DetectWSL finds
+func (e *Engine) WSLSide() (*WSLSide, error) {
+	var side *WSLSide
+	if e.cfg.WSL.Path != "" {
+		var err error
+		if side, err = wslSideAt(filepath.Clean(config.ExpandHome(e.cfg.WSL.Path))); err != nil {
+			return nil, err
+		}
+	} else if side = DetectWSL(); side == nil {
+		return nil, errors.New("no Claude dir found on the other side of WSL; set wsl.path")
+	}
+	if info, err := os.Stat(side.Dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not reachable", side.Dir)
+	}
+	return side, nil
+}
+
+// wslValues returns the other side's values for the placeholders enabled in
+// config.yaml. The hostname is shared; the username is its home folder's.
+func (e *Engine) wslValues(side *WSLSide) sync.PathValues {
+	v := e.pathValues()
+	v.ClaudeDir = side.Native
+	v.Home, v.User = "", ""
+	if side.Home != "" {
+		if e.cfg.Placeholders.HomeEnabled() {
+			v.Home = side.Home
+		}
+		if e.cfg.Placeholders.User {
+			v.User = side.Home[strings.LastIndexAny(side.Home, `\/`)+1:]
+		}
+	}
+	return v
+}
+
+// wslFiles lists the files under a Claude dir that sync, by relative path
+func (e *Engine) wslFiles(root string) (map[string]string, error) {
+	walked, err := sync.WalkFiles(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	files := map[string]string{}
+	for _, file := range walked {
+		relPath := sync.RelPath(root, file)
+		if file == e.paths.ClaudeJSON || relPath == ".claude.json" || e.cfg.ShouldExclude(relPath) {
+			continue
+		}
+		files[relPath] = file
+	}
+	return files, nil
+}
+
+// readWSL reads a file with the placeholders for the side it is on, as it
+// would be pushed from there. nil for no file.
+func (e *Engine) readWSL(path, relPath string, v sync.PathValues) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ok, jsonFile := e.placeholderFile(relPath); ok {
+		data = sync.NormalizePlaceholders(data, v, jsonFile)
+	}
+	return data, nil
+}
+
+// writeWSL writes data read by readWSL to dest with the placeholders
+// expanded for the side dest is on
+func (e *Engine) writeWSL(dest, relPath string, data []byte, v sync.PathValues, perm os.FileMode) error {
+	if ok, jsonFile := e.placeholderFile(relPath); ok {
+		data = sync.ExpandPlaceholders(data, v, jsonFile)
+	}
+	if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, perm)
+}
+
+// wslSideFor returns the other side if wsl.mode is mode, or nil. A side
+// that can't be reached is reported without failing the sync.
+func (e *Engine) wslSideFor(mode string) *WSLSide {
+	if e.cfg.WSL.Mode != mode {
+		return nil
+	}
+	side, err := e.WSLSide()
+	if err != nil {
+		e.log.Warn(fmt.Sprintf("Skipping the other side of WSL: %v", err))
+		return nil
+	}
+	return side
+}
+
+// mirrorWSL keeps the other side's Claude dir the same as this one, paths
+// aside. Each file changed on one side since the last mirror is copied to
+// the other, or removed from it if it was removed. When both changed, this
+// side wins and the other's copy is saved to backups/files first.
+func (e *Engine) mirrorWSL(side *WSLSide) error {
+	statePath := filepath.Join(e.paths.SyncDir, wslMirrorFile)
+	last := map[string]string{}
+	if err := readJSONIfExists(statePath, &last); err != nil {
+		return err
+	}
+	here, err := e.wslFiles(e.paths.ClaudeDir)
+	if err != nil {
+		return err
+	}
+	there, err := e.wslFiles(side.Dir)
+	if err != nil {
+		return err
+	}
+	hereValues, thereValues := e.pathValues(), e.wslValues(side)
+
+	all := map[string]bool{}
+	for relPath := range here {
+		all[relPath] = true
+	}
+	for relPath := range there {
+		all[relPath] = true
+	}
+
+	stamp := sync.Timestamp()
+	mirrored := map[string]string{}
+	for _, relPath := range sortedKeys(all) {
+		ours, err := e.readWSL(here[relPath], relPath, hereValues)
+		if err != nil {
+			return err
+		}
+		theirs, err := e.readWSL(there[relPath], relPath, thereValues)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relPath)
+		prev := last[key]
+		oursChanged := ours == nil || sync.Checksum(ours) != prev
+		theirsChanged := theirs == nil || sync.Checksum(theirs) != prev
+
+		switch {
+		case ours != nil && theirs != nil && bytes.Equal(ours, theirs):
+			mirrored[key] = sync.Checksum(ours)
+		case ours == nil && prev != "" && !theirsChanged:
+			e.log.Info(fmt.Sprintf("Removing from %s: %s", side.Dir, relPath))
+			if err := os.Remove(there[relPath]); err != nil {
+				return err
+			}
+		case theirs == nil && prev != "" && !oursChanged:
+			e.log.Info(fmt.Sprintf("Removing (removed in %s): %s", side.Dir, relPath))
+			if err := os.Remove(here[relPath]); err != nil {
+				return err
+			}
+		case ours == nil || (theirsChanged && !oursChanged):
+			e.log.Info(fmt.Sprintf("Copying from %s: %s", side.Dir, relPath))
+			if err := e.writeWSL(filepath.Join(e.paths.ClaudeDir, relPath), relPath, theirs, hereValues, fileMode(there[relPath])); err != nil {
+				return err
+			}
+			mirrored[key] = sync.Checksum(theirs)
+		default:
+			if theirs != nil && theirsChanged {
+				// Changed on both sides since the last mirror
+				if _, err := sync.BackupFile(there[relPath], e.paths.BackupDir, stamp, filepath.Join("wsl", relPath)); err != nil {
+					return err
+				}
+				e.log.Warn(fmt.Sprintf("Changed on both sides, keeping this one: %s", relPath))
+			}
+			e.log.Info(fmt.Sprintf("Copying to %s: %s", side.Dir, relPath))
+			if err := e.writeWSL(filepath.Join(side.Dir, relPath), relPath, ours, thereValues, fileMode(here[relPath])); err != nil {
+				return err
+			}
+			mirrored[key] = sync.Checksum(ours)
+		}
+	}
+
+	data, err := marshalJSON(mirrored)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0600)
+}
+
+// fileMode returns the permissions of the file at path, or 0644
+func fileMode(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0644
+}
+
+// pushWSLVariants pushes each file on the other side that differs from what
+// this side pushed as a variant for the other side's platform, e.g.
+// commands/deploy.windows.md. A variant left from before that matches this
+// side's file again is removed.
+func (e *Engine) pushWSLVariants(side *WSLSide, identity *age.X25519Identity, pubKey string, opts PushOptions, result *PushResult) error {
+	there, err := e.wslFiles(side.Dir)
+	if err != nil {
+		return err
+	}
+	values := e.wslValues(side)
+
+	for _, relPath := range sortedKeys(there) {
+		if sync.IsPlatformVariant(relPath) || e.cfg.IsTeamFile(relPath) {
+			continue
+		}
+		variant := sync.GetPlatformVariantName(relPath, side.Platform)
+		if !sync.IsPlatformVariant(variant) {
+			e.debug(fmt.Sprintf("No variant name for %s (no extension)", relPath))
+			continue
+		}
+
+		data, err := e.readWSL(there[relPath], relPath, values)
+		if err != nil {
+			return err
+		}
+		if paths := e.redactPaths(relPath); len(paths) > 0 {
+			data, _ = redactJSON(data, paths)
+		}
+		if e.cfg.ShouldCanonicalize(relPath) {
+			data = canonicalJSON(data)
+		}
+
+		encrypt := e.cfg.ShouldEncrypt(relPath)
+		repoPath, action, verb := variant, ActionCopy, "Copying"
+		if encrypt {
+			repoPath, action, verb = variant+".age", ActionEncrypt, "Encrypting"
+		}
+		dest := filepath.Join(e.paths.RepoDir, repoPath)
+
+		if e.pushedAs(identity, relPath, data, side.Platform) {
+			// This side's file does for both
+			if sync.FileExists(dest) && !opts.DryRun {
+				e.log.Info(fmt.Sprintf("Removing: %s (same as %s)", variant, relPath))
+				if err := os.Remove(dest); err != nil {
+					return err
+				}
+				result.Changed = append(result.Changed, variant)
+			}
+			continue
+		}
+
+		if opts.DryRun {
+			e.log.Info(fmt.Sprintf("  [%s] %s", action, variant))
+		} else if !repoHolds(identity, dest, data, encrypt) {
+			e.log.Info(fmt.Sprintf("%s: %s (from %s)", verb, variant, side.Dir))
+			if encrypt {
+				if data, err = crypto.EncryptTo(e.recipients(pubKey, relPath), data); err != nil {
+					return fmt.Errorf("failed to encrypt %s: %w", variant, err)
+				}
+			}
+			if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+				return err
+			}
+			if err := os.WriteFile(dest, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", variant, err)
+			}
+			result.Changed = append(result.Changed, variant)
+		}
+		result.recordMode(repoPath, there[relPath])
+		result.Files = append(result.Files, FileAction{Path: variant, Action: action})
+	}
+	return nil
+}
+
+// pushedAs reports whether the repo's copy of relPath, as this side pushed
+// it, is data once rendered for platform
+func (e *Engine) pushedAs(identity *age.X25519Identity, relPath string, data []byte, platform string) bool {
+	src := filepath.Join(e.paths.RepoDir, relPath)
+	content, err := os.ReadFile(src)
+	if err != nil {
+		if content, err = os.ReadFile(src + ".age"); err != nil {
+			return false
+		}
+		if content, err = crypto.Decrypt(identity, content); err != nil {
+			return false
+		}
+	}
+	return bytes.Equal(sync.RenderTemplate(content, platform), data) || jsonEqual(content, data)
+}
+
+// repoHolds reports whether the repo file at dest already holds data
+func repoHolds(identity *age.X25519Identity, dest string, data []byte, encrypted bool) bool {
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		return false
+	}
+	if encrypted {
+		if content, err = crypto.Decrypt(identity, content); err != nil {
+			return false
+		}
+	}
+	return bytes.Equal(content, data)
+}
+
+// restoreWSLVariants restores the repo's files on the other side too, each
+// from the variant for its platform if there is one. Files that differ
+// there are saved to backups/files first, or kept with StrategyOurs.
+func (e *Engine) restoreWSLVariants(side *WSLSide, identity *age.X25519Identity, strategy Strategy) error {
+	files, err := e.repoFiles()
+	if err != nil {
+		return err
+	}
+	sources := map[string]repoFile{}
+	for _, f := range files {
+		// claude.json and extra_paths are this side's alone
+		if f.mcpServers != "" || f.dest == e.paths.ClaudeJSON || isExtraPath(f.relPath) || sync.IsPlatformVariant(f.relPath) {
+			continue
+		}
+		sources[f.relPath] = f
+	}
+
+	// This side skips the other platform's variants; the other side uses
+	// them in place of the file they vary
+	walked, err := sync.WalkFiles(e.paths.RepoDir)
+	if err != nil {
+		return fmt.Errorf("failed to walk repo: %w", err)
+	}
+	modes := e.manifestModes()
+	for _, file := range walked {
+		repoPath := sync.RelPath(e.paths.RepoDir, file)
+		relPath := strings.TrimSuffix(repoPath, ".age")
+		if strings.HasPrefix(relPath, ".git") || sync.GetPlatformSuffix(relPath) != side.Platform || e.cfg.ShouldExclude(relPath) {
+			continue
+		}
+		base := sync.GetBaseName(relPath)
+		sources[base] = repoFile{relPath: base, src: file, encrypted: strings.HasSuffix(repoPath, ".age"), mode: modes[filepath.ToSlash(repoPath)]}
+	}
+
+	values := e.wslValues(side)
+	stamp := sync.Timestamp()
+	for _, relPath := range sortedKeys(sources) {
+		f := sources[relPath]
+		content, err := os.ReadFile(f.src)
+		if err != nil {
+			return err
+		}
+		perm := os.FileMode(0644)
+		if f.encrypted {
+			if content, err = crypto.Decrypt(identity, content); err != nil {
+				return fmt.Errorf("failed to decrypt %s: %w", f.relPath, err)
+			}
+			perm = 0600
+		} else {
+			content = sync.RenderTemplate(content, side.Platform)
+		}
+		if f.mode != 0 {
+			perm = f.mode
+		}
+		if ok, jsonFile := e.placeholderFile(relPath); ok {
+			content = sync.ExpandPlaceholders(content, values, jsonFile)
+		}
+
+		dest := filepath.Join(side.Dir, relPath)
+		local, err := os.ReadFile(dest)
+		if err == nil {
+			if paths := e.redactPaths(relPath); len(paths) > 0 {
+				content = keepRedacted(content, local, paths)
+			}
+			if bytes.Equal(content, local) || jsonEqual(content, local) || strategy == StrategyOurs {
+				continue
+			}
+			if _, err := sync.BackupFile(dest, e.paths.BackupDir, stamp, filepath.Join("wsl", relPath)); err != nil {
+				return err
+			}
+			e.log.Warn(fmt.Sprintf("Conflict: backing up %s in %s", relPath, side.Dir))
+		}
+		e.log.Info(fmt.Sprintf("Restoring in %s: %s", side.Dir, relPath))
+		if err := sync.EnsureDir(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, content, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+	}
+	return nil
+}